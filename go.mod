@@ -3,8 +3,13 @@ module github.com/mattermost/mattermost-plugin-todo
 go 1.13
 
 require (
-	github.com/mattermost/mattermost-server/v5 v5.20.0
+	github.com/DATA-DOG/go-sqlmock v1.4.1
+	github.com/go-sql-driver/mysql v1.5.0
+	github.com/lib/pq v1.4.0
+	github.com/mattermost/go-i18n v1.11.0
+	github.com/mattermost/mattermost-server/v5 v5.24.0
 	github.com/mholt/archiver/v3 v3.3.0
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.5.1
+	google.golang.org/appengine v1.6.4 // indirect
 )
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionPath is the prefix of the paths the buttons of the messages about received todos post to
+	ActionPath = "/action"
+
+	// ActionAccept moves the received todo to the list of the user
+	ActionAccept = "accept"
+	// ActionDecline removes the received todo and tells its sender
+	ActionDecline = "decline"
+	// ActionComplete completes the received todo without accepting it first
+	ActionComplete = "complete"
+
+	actionContextIssueID = "issue_id"
+)
+
+// receivedTodoAttachment returns the attachment of the message about the received todo issueID, with the buttons
+// to accept, decline or complete it in the language of userID
+func (p *Plugin) receivedTodoAttachment(userID, issueID, text string) *model.SlackAttachment {
+	action := func(id string, name *message) *model.PostAction {
+		return &model.PostAction{
+			Id:   id,
+			Name: p.localize(userID, name, nil),
+			Type: model.POST_ACTION_TYPE_BUTTON,
+			Integration: &model.PostActionIntegration{
+				URL:     fmt.Sprintf("/plugins/%s%s/%s", manifest.Id, ActionPath, id),
+				Context: map[string]interface{}{actionContextIssueID: issueID},
+			},
+		}
+	}
+
+	return &model.SlackAttachment{
+		Text: text,
+		Actions: []*model.PostAction{
+			action(ActionAccept, msgButtonAccept),
+			action(ActionDecline, msgButtonDecline),
+			action(ActionComplete, msgButtonComplete),
+		},
+	}
+}
+
+// resolveReceivedTodoPost removes the buttons from the message about a received todo and adds the outcome of the
+// action taken on it
+func resolveReceivedTodoPost(post *model.Post, outcome string) *model.Post {
+	attachments := post.Attachments()
+	for _, attachment := range attachments {
+		attachment.Actions = nil
+		attachment.Footer = outcome
+	}
+	model.ParseSlackAttachment(post, attachments)
+	return post
+}
+
+// doReceivedTodoAction accepts, declines or completes the received todo issueID of userID, notifies its sender, and
+// returns the outcome to show on the message
+func (p *Plugin) doReceivedTodoAction(userID, issueID, action string) (string, error) {
+	switch action {
+	case ActionAccept:
+		todoMessage, sender, err := p.listManager.AcceptIssue(userID, issueID)
+		if err != nil {
+			return "", err
+		}
+		p.notifyAccept(userID, sender, issueID, todoMessage)
+		return p.localize(userID, msgActionAccepted, nil), nil
+	case ActionDecline:
+		todoMessage, sender, err := p.listManager.DeclineIssue(userID, issueID, "")
+		if err != nil {
+			return "", err
+		}
+		p.notifyDecline(userID, sender, todoMessage, "")
+		return p.localize(userID, msgActionDeclined, nil), nil
+	case ActionComplete:
+		issue, err := p.listManager.CompleteIssue(userID, issueID)
+		if err != nil {
+			return "", err
+		}
+		p.notifyCompletion(userID, issue)
+		return p.localize(userID, msgActionCompleted, nil), nil
+	}
+	return "", errors.Errorf("%s is not a valid action", action)
+}
+
+// serveAction handles the buttons of the messages about received todos, and updates the message in place
+func (p *Plugin) serveAction(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	request := model.PostActionIntegrationRequestFromJson(r.Body)
+	if request == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a post action"))
+		return
+	}
+	if request.UserId != userID {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	issueID, _ := request.Context[actionContextIssueID].(string)
+	action := strings.TrimPrefix(r.URL.Path, ActionPath+"/")
+	outcome, err := p.doReceivedTodoAction(userID, issueID, action)
+	if err != nil {
+		p.writeAPIResponse(w, http.StatusOK, &model.PostActionIntegrationResponse{
+			EphemeralText: p.localize(userID, msgActionFailed, map[string]interface{}{"Error": p.localizeError(userID, err)}),
+		})
+		return
+	}
+	p.sendRefreshEvent(userID)
+
+	response := &model.PostActionIntegrationResponse{}
+	post, appErr := p.API.GetPost(request.PostId)
+	if appErr != nil {
+		p.API.LogError("Unable to get the post of the action err=" + appErr.Error())
+	} else if post.UserId == p.BotUserID {
+		response.Update = resolveReceivedTodoPost(post, outcome)
+	}
+	p.writeAPIResponse(w, http.StatusOK, response)
+}
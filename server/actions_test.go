@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceivedTodoAttachment(t *testing.T) {
+	p := &Plugin{}
+	attachment := p.receivedTodoAttachment("user1", "issue1", "todo")
+
+	assert.Equal(t, "todo", attachment.Text)
+	require.Len(t, attachment.Actions, 3)
+	for i, id := range []string{ActionAccept, ActionDecline, ActionComplete} {
+		action := attachment.Actions[i]
+		assert.Equal(t, id, action.Id)
+		assert.Equal(t, "/plugins/"+manifest.Id+ActionPath+"/"+id, action.Integration.URL)
+		assert.Equal(t, "issue1", action.Integration.Context[actionContextIssueID])
+	}
+	assert.Equal(t, "Done", attachment.Actions[2].Name)
+}
+
+func TestResolveReceivedTodoPost(t *testing.T) {
+	p := &Plugin{}
+	post := &model.Post{Message: "You have received a new Todo"}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{p.receivedTodoAttachment("user1", "issue1", "todo")})
+
+	post = resolveReceivedTodoPost(post, "You accepted this Todo.")
+	require.Len(t, post.Attachments(), 1)
+	assert.Empty(t, post.Attachments()[0].Actions)
+	assert.Equal(t, "todo", post.Attachments()[0].Text)
+	assert.Equal(t, "You accepted this Todo.", post.Attachments()[0].Footer)
+}
+
+func TestServeAction(t *testing.T) {
+	p := &Plugin{}
+	p.SetAPI(&plugintest.API{})
+
+	t.Run("no user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, httptest.NewRequest(http.MethodPost, ActionPath+"/"+ActionAccept, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("other user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, ActionPath+"/"+ActionAccept, strings.NewReader(`{"user_id": "user2", "context": {"issue_id": "issue1"}}`))
+		r.Header.Set("Mattermost-User-ID", "user1")
+		p.ServeHTTP(nil, w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
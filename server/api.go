@@ -0,0 +1,580 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// APIv2Prefix is the path prefix of the public REST API
+	APIv2Prefix = "/api/v2"
+	// MaxMessageLength is the maximum length in characters of a todo message
+	MaxMessageLength = model.POST_MESSAGE_MAX_RUNES_V2
+)
+
+// serveAPIv2 routes the requests to the public REST API. Requests are authenticated by the Mattermost server,
+// so both webapp sessions and personal access tokens are accepted, or by a token of the plugin in TokenHeader, which
+// is limited to its scopes.
+func (p *Plugin) serveAPIv2(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if secret := r.Header.Get(TokenHeader); userID == "" && secret != "" {
+		if userID = p.authenticateAPIv2Token(w, r, secret); userID == "" {
+			return
+		}
+	}
+	if userID == "" {
+		p.handleErrorWithCode(w, http.StatusUnauthorized, "Not authorized", errors.New("a session, personal access token or plugin token is required"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, APIv2Prefix), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "todos" && r.Method == http.MethodGet:
+		p.handleAPIv2List(w, r, userID)
+	case path == "todos" && r.Method == http.MethodPost:
+		p.handleAPIv2Add(w, r, userID)
+	case path == "send" && r.Method == http.MethodPost:
+		p.handleAPIv2Send(w, r, userID)
+	case path == "todos/bulk" && r.Method == http.MethodPost:
+		p.handleAPIv2Bulk(w, r, userID)
+	case len(parts) == 3 && parts[0] == "todos" && parts[2] == "complete" && r.Method == http.MethodPost:
+		p.handleAPIv2Complete(w, r, userID, parts[1])
+	case len(parts) == 3 && parts[0] == "todos" && parts[2] == "start" && r.Method == http.MethodPost:
+		p.handleAPIv2Start(w, r, userID, parts[1])
+	case len(parts) == 3 && parts[0] == "todos" && parts[2] == "restore" && r.Method == http.MethodPost:
+		p.handleAPIv2Restore(w, r, userID, parts[1])
+	case len(parts) == 2 && parts[0] == "todos" && r.Method == http.MethodDelete:
+		p.handleAPIv2Delete(w, r, userID, parts[1])
+	case len(parts) == 3 && parts[0] == "todos" && parts[2] == "notes":
+		p.serveAPIv2Notes(w, r, userID, parts[1])
+	case len(parts) >= 3 && parts[0] == "todos" && parts[2] == "subtasks":
+		p.serveAPIv2Subtasks(w, r, userID, parts[1], parts[3:])
+	case len(parts) >= 3 && parts[0] == "channels" && parts[2] == "todos":
+		p.serveAPIv2Channel(w, r, userID, parts[1], parts[3:])
+	case path == "export" && r.Method == http.MethodGet:
+		p.handleAPIv2Export(w, r, userID)
+	case path == "import" && r.Method == http.MethodPost:
+		p.handleAPIv2Import(w, r, userID)
+	case path == "audit" && r.Method == http.MethodGet:
+		p.handleAPIv2Audit(w, r, userID)
+	default:
+		p.handleErrorWithCode(w, http.StatusNotFound, "Not found", errors.Errorf("%s %s is not part of the API", r.Method, r.URL.Path))
+	}
+}
+
+// apiV2Scope returns the scope a token needs for the request: sending todos needs send, reading needs read, and
+// anything else needs write
+func apiV2Scope(r *http.Request) string {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, APIv2Prefix), "/")
+	switch {
+	case path == "send":
+		return TokenScopeSend
+	case r.Method == http.MethodGet:
+		return TokenScopeRead
+	}
+	return TokenScopeWrite
+}
+
+// authenticateAPIv2Token returns the owner of the token secret if it has the scope of the request. Otherwise, it
+// writes the error and returns an empty string.
+func (p *Plugin) authenticateAPIv2Token(w http.ResponseWriter, r *http.Request, secret string) string {
+	token, owner, err := p.authenticateToken(secret)
+	if err != nil {
+		p.API.LogError("Unable to get token err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get token", err)
+		return ""
+	}
+	if token == nil {
+		p.handleErrorWithCode(w, http.StatusUnauthorized, "Not authorized", errors.New("the token is not valid or its owner is not active"))
+		return ""
+	}
+
+	if scope := apiV2Scope(r); !token.hasScope(scope) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.Errorf("the token does not have the %s scope", scope))
+		return ""
+	}
+
+	return owner.Id
+}
+
+type apiV2AddRequest struct {
+	Message string `json:"message"`
+	PostID  string `json:"post_id"`
+	Due     string `json:"due"`
+}
+
+type apiV2SendRequest struct {
+	User    string `json:"user"`
+	Message string `json:"message"`
+	PostID  string `json:"post_id"`
+	Due     string `json:"due"`
+}
+
+func (p *Plugin) handleAPIv2List(w http.ResponseWriter, r *http.Request, userID string) {
+	listID := MyListKey
+	if listName := r.URL.Query().Get("list"); listName != "" {
+		var ok bool
+		listID, ok = parseListName(listName)
+		if !ok {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid list", errors.Errorf("%s is not one of my, in or out", listName))
+			return
+		}
+	}
+
+	status, filter := "", false
+	switch value := r.URL.Query().Get("status"); value {
+	case "":
+	case "open":
+		status, filter = IssueStatusOpen, true
+	case IssueStatusInProgress, IssueStatusDeclined:
+		status, filter = value, true
+	default:
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid status", errors.Errorf("%s is not one of open, in_progress or declined", value))
+		return
+	}
+
+	ownerID := p.getListOwnerForRequest(w, r, userID, listID)
+	if ownerID == "" {
+		return
+	}
+
+	issues, err := p.getIssueListForRequest(w, r, ownerID, listID)
+	if err != nil {
+		p.API.LogError("Unable to get issues for user err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issues for user", err)
+		return
+	}
+	if issues == nil {
+		return
+	}
+
+	if filter {
+		issues = filterIssuesByStatus(issues, status)
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, issues)
+}
+
+func (p *Plugin) handleAPIv2Add(w http.ResponseWriter, r *http.Request, userID string) {
+	var addRequest *apiV2AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&addRequest); err != nil || addRequest == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+		return
+	}
+
+	message, dueAt, err := extractRequestDueDate(addRequest.Message, addRequest.Due, time.Now().In(p.getUserLocation(userID)))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid due date", err)
+		return
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+		return
+	}
+
+	if addRequest.PostID != "" && !p.canReadPost(userID, addRequest.PostID) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Invalid post", errors.New("you do not have access to the post"))
+		return
+	}
+
+	if err = p.checkTodoLimit(userID, 1); err != nil {
+		p.handleLimitError(w, "Todo limit reached", err)
+		return
+	}
+
+	issue, err := p.listManager.AddIssue(userID, message, addRequest.PostID, dueAt)
+	if err != nil {
+		p.refundRate(userID, rateActionAdd, 1)
+		p.API.LogError("Unable to add issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+
+	senderName := p.listManager.GetUserName(userID)
+	replyMessage := p.localizeServer(msgReplyAttached, map[string]interface{}{"User": senderName})
+	p.postReplyIfNeeded(addRequest.PostID, replyMessage, message)
+
+	p.writeAPIResponse(w, http.StatusCreated, issue)
+}
+
+func (p *Plugin) handleAPIv2Send(w http.ResponseWriter, r *http.Request, userID string) {
+	if !p.API.HasPermissionTo(userID, model.PERMISSION_CREATE_DIRECT_CHANNEL) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("you do not have permission to send todos"))
+		return
+	}
+
+	var sendRequest *apiV2SendRequest
+	if err := json.NewDecoder(r.Body).Decode(&sendRequest); err != nil || sendRequest == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+		return
+	}
+
+	message, dueAt, err := extractRequestDueDate(sendRequest.Message, sendRequest.Due, time.Now().In(p.getUserLocation(userID)))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid due date", err)
+		return
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+		return
+	}
+
+	if sendRequest.PostID != "" && !p.canReadPost(userID, sendRequest.PostID) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Invalid post", errors.New("you do not have access to the post"))
+		return
+	}
+
+	receiver, appErr := p.API.GetUserByUsername(strings.TrimPrefix(sendRequest.User, "@"))
+	if appErr != nil {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", appErr)
+		return
+	}
+
+	if receiver.Id == userID {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("use the add endpoint to add todos to your own list"))
+		return
+	}
+
+	if receiver.DeleteAt != 0 || receiver.IsBot {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("todos can only be sent to active users"))
+		return
+	}
+
+	if err = p.checkSendAllowed(userID, receiver, 1); err != nil {
+		p.handleLimitError(w, "Not allowed", err)
+		return
+	}
+
+	issueID, err := p.listManager.SendIssue(userID, receiver.Id, message, sendRequest.PostID, dueAt)
+	if err != nil {
+		p.refundRate(userID, rateActionSend, 1)
+		p.API.LogError("Unable to send issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.notifySend(userID, receiver.Id, message, issueID, dueAt)
+
+	senderName := p.listManager.GetUserName(userID)
+	replyMessage := p.localizeServer(msgReplySent, map[string]interface{}{"User": senderName, "Receiver": receiver.Username})
+	p.postReplyIfNeeded(sendRequest.PostID, replyMessage, message)
+
+	p.writeAPIResponse(w, http.StatusCreated, map[string]interface{}{"status": "OK", "due_at": dueAt})
+}
+
+func (p *Plugin) handleAPIv2Complete(w http.ResponseWriter, r *http.Request, userID, issueID string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	issue, err := p.listManager.CompleteIssue(userID, issueID)
+	if err == errIssueNotFound {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find todo", err)
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to complete issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to complete issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.notifyCompletion(userID, issue)
+
+	p.writeAPIResponse(w, http.StatusOK, issue)
+}
+
+func (p *Plugin) handleAPIv2Start(w http.ResponseWriter, r *http.Request, userID, issueID string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	issue, err := p.listManager.StartIssue(userID, issueID)
+	if err == errIssueNotFound {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find todo", err)
+		return
+	}
+	if err == errIssueAlreadyStarted {
+		p.handleErrorWithCode(w, http.StatusConflict, "Unable to start issue", err)
+		return
+	}
+	if err == errInvalidStart {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to start issue", err)
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to start issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to start issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.notifyStart(userID, issue)
+
+	p.writeAPIResponse(w, http.StatusOK, issue)
+}
+
+func (p *Plugin) handleAPIv2Restore(w http.ResponseWriter, r *http.Request, userID, issueID string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	if err := p.checkTodoLimit(userID, 1); err != nil {
+		p.handleLimitError(w, "Todo limit reached", err)
+		return
+	}
+
+	issue, err := p.listManager.RestoreIssue(userID, issueID)
+	if err != nil {
+		p.refundRate(userID, rateActionAdd, 1)
+	}
+	if err == errIssueNotFound {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find completed todo", err)
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to restore issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to restore issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+
+	p.writeAPIResponse(w, http.StatusOK, issue)
+}
+
+func (p *Plugin) handleAPIv2Delete(w http.ResponseWriter, r *http.Request, userID, issueID string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	issue, isSender, err := p.listManager.RemoveIssue(userID, issueID)
+	if err == errIssueNotFound {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find todo", err)
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to remove issue, err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to remove issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.notifyRemoval(userID, issue, isSender)
+
+	p.writeAPIResponse(w, http.StatusOK, issue)
+}
+
+// serveAPIv2Channel routes the requests to the shared list of channelID, under /channels/{channel_id}/todos
+func (p *Plugin) serveAPIv2Channel(w http.ResponseWriter, r *http.Request, userID, channelID string, parts []string) {
+	if p.getConfiguration().DisableChannelLists {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errChannelListsDisabled)
+		return
+	}
+
+	if !model.IsValidId(channelID) || !p.isChannelMember(channelID, userID) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("you must be a member of the channel to use its todo list"))
+		return
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		p.handleAPIv2ChannelList(w, r, channelID)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		p.handleAPIv2ChannelAdd(w, r, userID, channelID)
+	case len(parts) == 2 && parts[1] == "claim" && r.Method == http.MethodPost:
+		p.handleAPIv2ChannelClaim(w, r, userID, channelID, parts[0])
+	default:
+		p.handleErrorWithCode(w, http.StatusNotFound, "Not found", errors.Errorf("%s %s is not part of the API", r.Method, r.URL.Path))
+	}
+}
+
+func (p *Plugin) handleAPIv2ChannelList(w http.ResponseWriter, r *http.Request, channelID string) {
+	issues, err := p.listManager.GetChannelIssueList(channelID)
+	if err != nil {
+		p.API.LogError("Unable to get issues for channel err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issues for channel", err)
+		return
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, issues)
+}
+
+func (p *Plugin) handleAPIv2ChannelAdd(w http.ResponseWriter, r *http.Request, userID, channelID string) {
+	var addRequest *apiV2AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&addRequest); err != nil || addRequest == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+		return
+	}
+
+	message, dueAt, err := extractRequestDueDate(addRequest.Message, addRequest.Due, time.Now().In(p.getUserLocation(userID)))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid due date", err)
+		return
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+		return
+	}
+
+	if addRequest.PostID != "" && !p.canReadPost(userID, addRequest.PostID) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Invalid post", errors.New("you do not have access to the post"))
+		return
+	}
+
+	if err = p.checkRate(userID, rateActionAdd, 1); err != nil {
+		p.handleLimitError(w, "Not allowed", err)
+		return
+	}
+
+	issue, err := p.listManager.AddChannelIssue(channelID, userID, message, addRequest.PostID, dueAt)
+	if err != nil {
+		p.refundRate(userID, rateActionAdd, 1)
+		p.API.LogError("Unable to add issue to channel err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue to channel", err)
+		return
+	}
+
+	p.sendChannelRefreshEvent(channelID)
+
+	p.writeAPIResponse(w, http.StatusCreated, issue)
+}
+
+func (p *Plugin) handleAPIv2ChannelClaim(w http.ResponseWriter, r *http.Request, userID, channelID, issueID string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	if err := p.checkTodoLimit(userID, 1); err != nil {
+		p.handleLimitError(w, "Todo limit reached", err)
+		return
+	}
+
+	issue, err := p.listManager.ClaimChannelIssue(channelID, userID, issueID)
+	if err != nil {
+		p.refundRate(userID, rateActionAdd, 1)
+	}
+	if err == errIssueNotFound {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find todo", errors.New("the todo is not on the channel list, someone may have claimed it first"))
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to claim issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to claim issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.sendChannelRefreshEvent(channelID)
+	p.notifyClaim(channelID, userID, issue)
+
+	p.writeAPIResponse(w, http.StatusOK, issue)
+}
+
+func (p *Plugin) handleAPIv2Export(w http.ResponseWriter, r *http.Request, userID string) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatJSON
+	}
+	if format != ExportFormatJSON && format != ExportFormatCSV {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid format", errors.Errorf("%s is not one of csv or json", format))
+		return
+	}
+
+	location := p.getUserLocation(userID)
+	issues, err := p.exportIssues(userID, location)
+	if err != nil {
+		p.API.LogError("Unable to export issues err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to export issues", err)
+		return
+	}
+
+	b, contentType, err := encodeExport(issues, format)
+	if err != nil {
+		p.API.LogError("Unable to encode export err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to encode export", err)
+		return
+	}
+
+	fileName := exportFileName(p.listManager.GetUserName(userID), format, time.Now().In(location))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+	_, _ = w.Write(b)
+}
+
+func (p *Plugin) handleAPIv2Import(w http.ResponseWriter, r *http.Request, userID string) {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, MaxImportFileSize+1))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to read the file", err)
+		return
+	}
+
+	result, err := p.importIssues(userID, data, r.URL.Query().Get("dry_run") == "true")
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to import the file", err)
+		return
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, result)
+}
+
+// canReadPost checks whether userID can see the post a todo is going to be attached to
+func (p *Plugin) canReadPost(userID, postID string) bool {
+	if !model.IsValidId(postID) {
+		return false
+	}
+
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		return false
+	}
+
+	return p.API.HasPermissionToChannel(userID, post.ChannelId, model.PERMISSION_READ_CHANNEL)
+}
+
+func (p *Plugin) writeAPIResponse(w http.ResponseWriter, code int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		p.API.LogError("Unable to marshal response err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to marshal response", err)
+		return
+	}
+
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
+
+func validateMessage(message string) error {
+	if strings.TrimSpace(message) == "" {
+		return newLocalizedError(msgErrMessageEmpty, nil)
+	}
+
+	if utf8.RuneCountInString(message) > MaxMessageLength {
+		return newLocalizedError(msgErrMessageTooLong, map[string]interface{}{"Max": MaxMessageLength})
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AuditSegmentSize is the number of audit entries stored under one key, before a new key is started
+	AuditSegmentSize = 100
+	// AuditCommandEntries is the number of most recent audit entries shown by the audit command
+	AuditCommandEntries = 50
+	// AuditDefaultDays is how many days of audit entries are shown when no start is given
+	AuditDefaultDays = 7
+)
+
+// AuditEntry records an action on a todo by ActorID, with the todo as it was right after it. TargetUserID is the
+// other user of a sent todo, and ChannelID the channel of a todo on a shared channel list.
+type AuditEntry struct {
+	Action       string `json:"action"`
+	ActorID      string `json:"actor_id"`
+	TargetUserID string `json:"target_user_id,omitempty"`
+	ChannelID    string `json:"channel_id,omitempty"`
+	CreateAt     int64  `json:"create_at"`
+	Issue        *Issue `json:"issue"`
+}
+
+// recordAudit appends the event to the audit log of its user, and of the other user of the todo if any
+func (p *Plugin) recordAudit(event *IssueEvent) {
+	entry := &AuditEntry{
+		Action:       event.Type,
+		ActorID:      event.UserID,
+		TargetUserID: event.ForeignUserID,
+		ChannelID:    event.ChannelID,
+		CreateAt:     event.CreateAt,
+		Issue:        event.Issue,
+	}
+
+	if err := p.appendAuditEntry(event.UserID, entry); err != nil {
+		p.API.LogError("cannot record audit entry, Err=", err.Error())
+	}
+	if event.ForeignUserID == "" || event.ForeignUserID == event.UserID {
+		return
+	}
+	if err := p.appendAuditEntry(event.ForeignUserID, entry); err != nil {
+		p.API.LogError("cannot record audit entry, Err=", err.Error())
+	}
+}
+
+// parseAuditSince parses the start of the audit entries to show, either a date like 2020-03-15 in location or a
+// number of days before now like 30d
+func parseAuditSince(value string, now time.Time, location *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err == nil && days > 0 {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+
+	since, err := time.ParseInLocation(dayLayout, value, location)
+	if err != nil {
+		return time.Time{}, newLocalizedError(msgErrInvalidAuditStart, map[string]interface{}{"Value": value})
+	}
+	return since, nil
+}
+
+// auditEntriesToString renders the audit entries of userName, the most recent ones last, with the times in location
+func (p *Plugin) auditEntriesToString(T translateFunc, userName string, entries []*AuditEntry, since time.Time, location *time.Location) string {
+	data := map[string]interface{}{"User": userName, "Since": since.In(location).Format(dayLayout)}
+	if len(entries) == 0 {
+		return T(msgAuditNone, data)
+	}
+
+	str := T(msgAuditTitle, data) + "\n\n"
+	if len(entries) > AuditCommandEntries {
+		data["Count"], data["Total"] = AuditCommandEntries, len(entries)
+		str = T(msgAuditTitleLast, data) + "\n\n"
+		entries = entries[len(entries)-AuditCommandEntries:]
+	}
+
+	for _, entry := range entries {
+		str += fmt.Sprintf("* %s: @%s %s", fromMillis(entry.CreateAt).In(location).Format("2006-01-02 15:04"), p.listManager.GetUserName(entry.ActorID), entry.Action)
+		if entry.TargetUserID != "" {
+			str += " (" + T(msgAuditWith, map[string]interface{}{"User": p.listManager.GetUserName(entry.TargetUserID)}) + ")"
+		}
+		if entry.Issue != nil {
+			str += ": " + entry.Issue.Message
+		}
+		str += "\n"
+	}
+	return str
+}
+
+func (p *Plugin) runAuditCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return nil, true, newLocalizedError(msgErrAuditNotAllowed, nil)
+	}
+
+	if len(args) == 0 || len(args) > 2 {
+		return nil, true, newLocalizedError(msgErrAuditUserMissing, nil)
+	}
+
+	user, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[0], "@"))
+	if appErr != nil {
+		return nil, true, newLocalizedError(msgErrInvalidUser, map[string]interface{}{"Value": args[0]})
+	}
+
+	now := time.Now()
+	location := p.getUserLocation(extra.UserId)
+	since := now.AddDate(0, 0, -AuditDefaultDays)
+	if len(args) == 2 {
+		var err error
+		if since, err = parseAuditSince(args[1], now, location); err != nil {
+			return nil, true, err
+		}
+	}
+
+	entries, err := p.getAuditEntries(user.Id, toMillis(since))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.auditEntriesToString(p.translator(extra.UserId), user.Username, entries, since, location)), false, nil
+}
+
+// handleAPIv2Audit returns the audit entries of the user in the user_id query parameter, since the time in
+// milliseconds of the since parameter, for system admins
+func (p *Plugin) handleAPIv2Audit(w http.ResponseWriter, r *http.Request, userID string) {
+	if !p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("only system admins can export the audit log"))
+		return
+	}
+
+	auditUserID := r.URL.Query().Get("user_id")
+	if !model.IsValidId(auditUserID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("user_id must be the ID of a user"))
+		return
+	}
+
+	var since int64
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = strconv.ParseInt(value, 10, 64); err != nil || since < 0 {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid since", errors.New("since must be a time in milliseconds"))
+			return
+		}
+	}
+
+	entries, err := p.getAuditEntries(auditUserID, since)
+	if err != nil {
+		p.API.LogError("Unable to get audit entries err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get audit entries", err)
+		return
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, entries)
+}
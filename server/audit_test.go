@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuditSince(t *testing.T) {
+	now := time.Date(2020, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	since, err := parseAuditSince("30d", now, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, time.February, 14, 10, 0, 0, 0, time.UTC), since)
+
+	since, err = parseAuditSince("2020-03-01", now, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC), since)
+
+	_, err = parseAuditSince("0d", now, time.UTC)
+	assert.Error(t, err)
+	_, err = parseAuditSince("last week", now, time.UTC)
+	assert.Error(t, err)
+}
+
+func TestAppendAuditEntryStartsNewSegment(t *testing.T) {
+	full := make([]*AuditEntry, AuditSegmentSize)
+	for i := range full {
+		full[i] = &AuditEntry{Action: IssueEventCreated, CreateAt: int64(i)}
+	}
+	fullJSON, _ := json.Marshal(full)
+
+	api := &plugintest.API{}
+	api.On("KVGet", auditKey("user1")).Return(nil, nil).Once()
+	api.On("KVGet", auditSegmentKey("user1", 0)).Return(fullJSON, nil)
+	api.On("KVCompareAndSet", auditKey("user1"), []byte(nil), []byte("1")).Return(true, nil).Once()
+	api.On("KVGet", auditKey("user1")).Return([]byte("1"), nil)
+	api.On("KVGet", auditSegmentKey("user1", 1)).Return(nil, nil)
+	api.On("KVCompareAndSet", auditSegmentKey("user1", 1), []byte(nil), mock.Anything).Return(true, nil).Once()
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	require.NoError(t, p.appendAuditEntry("user1", &AuditEntry{Action: IssueEventSent, CreateAt: 200}))
+	api.AssertExpectations(t)
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	older, _ := json.Marshal([]*AuditEntry{{Action: IssueEventCreated, CreateAt: 10}, {Action: IssueEventEdited, CreateAt: 20}})
+	last, _ := json.Marshal([]*AuditEntry{{Action: IssueEventCompleted, CreateAt: 30}})
+
+	api := &plugintest.API{}
+	api.On("KVGet", auditKey("user1")).Return([]byte("1"), nil)
+	api.On("KVGet", auditSegmentKey("user1", 0)).Return(older, nil)
+	api.On("KVGet", auditSegmentKey("user1", 1)).Return(last, nil)
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	entries, err := p.getAuditEntries("user1", 15)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, IssueEventEdited, entries[0].Action)
+	assert.Equal(t, IssueEventCompleted, entries[1].Action)
+}
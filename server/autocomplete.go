@@ -0,0 +1,404 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// AutocompleteIssuesPath is the path of the dynamic list of issue numbers used by the command autocomplete
+	AutocompleteIssuesPath = "/autocomplete/issues"
+	// AutocompleteUsersPath is the path of the dynamic list of usernames used by the command autocomplete
+	AutocompleteUsersPath = "/autocomplete/users"
+
+	// AutocompleteMaxSuggestions is the maximum number of suggestions of a dynamic list
+	AutocompleteMaxSuggestions = 20
+	// AutocompleteMaxMessageLength is the number of characters of the todo message shown next to its number
+	AutocompleteMaxMessageLength = 50
+)
+
+// getAutocompleteData returns the autocomplete of the todo command, with its help texts translated by T
+func getAutocompleteData(T translateFunc) *model.AutocompleteData {
+	issuesURL := strings.TrimPrefix(AutocompleteIssuesPath, "/")
+	usersURL := strings.TrimPrefix(AutocompleteUsersPath, "/")
+
+	listItems := []model.AutocompleteListItem{
+		{Item: "my", HelpText: T(msgAutocompleteListMy, nil)},
+		{Item: "in", HelpText: T(msgAutocompleteListIn, nil)},
+		{Item: "out", HelpText: T(msgAutocompleteListOut, nil)},
+		{Item: "done", HelpText: T(msgAutocompleteListDone, nil)},
+	}
+
+	todo := model.NewAutocompleteData("todo", "[command]", T(msgAutocompleteTodo, nil))
+
+	add := model.NewAutocompleteData("add", "[message]", T(msgAutocompleteAdd, nil))
+	add.AddTextArgument(T(msgAutocompleteAddMessage, nil), "[message] [--file link]", "")
+	todo.AddCommand(add)
+
+	list := model.NewAutocompleteData("list", "[listName] [page|--in-progress]", T(msgAutocompleteList, nil))
+	list.AddStaticListArgument(T(msgAutocompleteListName, nil), false, listItems)
+	list.AddTextArgument(T(msgAutocompleteListPage, nil), "[page|--in-progress]", "")
+	todo.AddCommand(list)
+
+	share := model.NewAutocompleteData("share", "[user]", T(msgAutocompleteShare, nil))
+	share.AddDynamicListArgument(T(msgAutocompleteShareUser, nil), usersURL, false)
+	todo.AddCommand(share)
+
+	unshare := model.NewAutocompleteData("unshare", "[user]", T(msgAutocompleteUnshare, nil))
+	unshare.AddDynamicListArgument(T(msgAutocompleteUnshareUser, nil), usersURL, true)
+	todo.AddCommand(unshare)
+
+	search := model.NewAutocompleteData("search", "[query]", T(msgAutocompleteSearch, nil))
+	search.AddTextArgument(T(msgAutocompleteSearchQuery, nil), "[query]", "")
+	todo.AddCommand(search)
+
+	todo.AddCommand(model.NewAutocompleteData("pop", "", T(msgAutocompletePop, nil)))
+
+	start := model.NewAutocompleteData("start", "[number]", T(msgAutocompleteStart, nil))
+	start.AddDynamicListArgument(T(msgAutocompleteNumberOrFirst, nil), issuesURL, false)
+	todo.AddCommand(start)
+
+	done := model.NewAutocompleteData("done", "[number]", T(msgAutocompleteDone, nil))
+	done.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	todo.AddCommand(done)
+
+	restore := model.NewAutocompleteData("restore", "[number]", T(msgAutocompleteRestore, nil))
+	restore.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	todo.AddCommand(restore)
+
+	move := model.NewAutocompleteData("move", "[from] [to]", T(msgAutocompleteMove, nil))
+	move.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	move.AddTextArgument(T(msgAutocompleteMoveTo, nil), "[to]", "")
+	todo.AddCommand(move)
+
+	edit := model.NewAutocompleteData("edit", "[listName] [number] [message]", T(msgAutocompleteEdit, nil))
+	edit.AddStaticListArgument(T(msgAutocompleteTodoList, nil), false, listItems[:3])
+	edit.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	edit.AddTextArgument(T(msgAutocompleteEditMessage, nil), "[message]", "")
+	todo.AddCommand(edit)
+
+	attach := model.NewAutocompleteData("attach", "[listName] [number] [post link]", T(msgAutocompleteAttach, nil))
+	attach.AddStaticListArgument(T(msgAutocompleteTodoList, nil), false, listItems[:3])
+	attach.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	attach.AddTextArgument(T(msgAutocompleteAttachLink, nil), "[post link]", "")
+	todo.AddCommand(attach)
+
+	rm := model.NewAutocompleteData("rm", "[listName] [number]", T(msgAutocompleteRemove, nil))
+	rm.AddStaticListArgument(T(msgAutocompleteTodoList, nil), false, listItems)
+	rm.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	todo.AddCommand(rm)
+
+	note := model.NewAutocompleteData("note", "[listName] [number] [text]", T(msgAutocompleteNote, nil))
+	note.AddStaticListArgument(T(msgAutocompleteTodoList, nil), false, listItems[:3])
+	note.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	note.AddTextArgument(T(msgAutocompleteNoteText, nil), "[text]", "")
+	todo.AddCommand(note)
+
+	sub := model.NewAutocompleteData("sub", "[add|check|uncheck|rm]", T(msgAutocompleteSub, nil))
+	subAdd := model.NewAutocompleteData("add", "[number] [message]", T(msgAutocompleteSubAdd, nil))
+	subAdd.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	subAdd.AddTextArgument(T(msgAutocompleteSubAddMessage, nil), "[message]", "")
+	sub.AddCommand(subAdd)
+	for _, action := range []struct{ name, helpText string }{
+		{"check", T(msgAutocompleteSubCheck, nil)},
+		{"uncheck", T(msgAutocompleteSubUncheck, nil)},
+		{"rm", T(msgAutocompleteSubRemove, nil)},
+	} {
+		subAction := model.NewAutocompleteData(action.name, "[number.item]", action.helpText)
+		subAction.AddTextArgument(T(msgAutocompleteSubItem, nil), "[number.item]", "")
+		sub.AddCommand(subAction)
+	}
+	todo.AddCommand(sub)
+
+	send := model.NewAutocompleteData("send", "[user] [message]", T(msgAutocompleteSend, nil))
+	send.AddDynamicListArgument(T(msgAutocompleteSendUser, nil), usersURL, true)
+	send.AddTextArgument(T(msgAutocompleteSendMessage, nil), "[message]", "")
+	todo.AddCommand(send)
+
+	scheduled := model.NewAutocompleteData("scheduled", "[list|cancel]", T(msgAutocompleteScheduled, nil))
+	scheduled.AddCommand(model.NewAutocompleteData("list", "", T(msgAutocompleteScheduledList, nil)))
+	scheduledCancel := model.NewAutocompleteData("cancel", "[number]", T(msgAutocompleteScheduledCancel, nil))
+	scheduledCancel.AddTextArgument(T(msgAutocompleteScheduledNumber, nil), "[number]", "")
+	scheduled.AddCommand(scheduledCancel)
+	todo.AddCommand(scheduled)
+
+	channel := model.NewAutocompleteData("channel", "[add|list|claim]", T(msgAutocompleteChannel, nil))
+	channelAdd := model.NewAutocompleteData("add", "[message]", T(msgAutocompleteChannelAdd, nil))
+	channelAdd.AddTextArgument(T(msgAutocompleteChannelAddMessage, nil), "[message]", "")
+	channel.AddCommand(channelAdd)
+	channel.AddCommand(model.NewAutocompleteData("list", "", T(msgAutocompleteChannelList, nil)))
+	channelClaim := model.NewAutocompleteData("claim", "[number]", T(msgAutocompleteChannelClaim, nil))
+	channelClaim.AddTextArgument(T(msgAutocompleteNumber, nil), "[number]", "")
+	channel.AddCommand(channelClaim)
+	todo.AddCommand(channel)
+
+	accept := model.NewAutocompleteData("accept", "[number] [--message message] [--due date]", T(msgAutocompleteAccept, nil))
+	accept.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	accept.AddTextArgument(T(msgAutocompleteAcceptProposal, nil), "[--message message] [--due date]", "")
+	todo.AddCommand(accept)
+
+	approve := model.NewAutocompleteData("approve", "[number]", T(msgAutocompleteApprove, nil))
+	approve.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	todo.AddCommand(approve)
+
+	reject := model.NewAutocompleteData("reject", "[number]", T(msgAutocompleteReject, nil))
+	reject.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	todo.AddCommand(reject)
+
+	decline := model.NewAutocompleteData("decline", "[number] [reason]", T(msgAutocompleteDecline, nil))
+	decline.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	decline.AddTextArgument(T(msgAutocompleteDeclineReason, nil), "[reason]", "")
+	todo.AddCommand(decline)
+
+	forward := model.NewAutocompleteData("forward", "[listName] [number] [user] [note]", T(msgAutocompleteForward, nil))
+	forward.AddStaticListArgument(T(msgAutocompleteReceivedList, nil), false, listItems[:2])
+	forward.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	forward.AddDynamicListArgument(T(msgAutocompleteForwardUser, nil), usersURL, true)
+	forward.AddTextArgument(T(msgAutocompleteForwardNote, nil), "[note]", "")
+	todo.AddCommand(forward)
+
+	nudge := model.NewAutocompleteData("nudge", "[number]", T(msgAutocompleteNudge, nil))
+	nudge.AddDynamicListArgument(T(msgAutocompleteNumberOrFirst, nil), issuesURL, false)
+	todo.AddCommand(nudge)
+
+	watch := model.NewAutocompleteData("watch", "[number]", T(msgAutocompleteWatch, nil))
+	watch.AddDynamicListArgument(T(msgAutocompleteWatchNumber, nil), issuesURL, false)
+	todo.AddCommand(watch)
+
+	unwatch := model.NewAutocompleteData("unwatch", "[number]", T(msgAutocompleteUnwatch, nil))
+	unwatch.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	todo.AddCommand(unwatch)
+
+	stats := model.NewAutocompleteData("stats", "[week|month]", T(msgAutocompleteStats, nil))
+	stats.AddStaticListArgument(T(msgAutocompleteStatsPeriod, nil), false, []model.AutocompleteListItem{
+		{Item: StatsPeriodWeek, HelpText: T(msgAutocompleteStatsWeek, nil)},
+		{Item: StatsPeriodMonth, HelpText: T(msgAutocompleteStatsMonth, nil)},
+	})
+	todo.AddCommand(stats)
+
+	template := model.NewAutocompleteData("template", "[save|apply|list|rm]", T(msgAutocompleteTemplate, nil))
+	templateSave := model.NewAutocompleteData("save", "[--team] [name] [todos]", T(msgAutocompleteTemplateSave, nil))
+	templateSave.AddTextArgument(T(msgAutocompleteTemplateSaveArgs, nil), "[name] [todos]", "")
+	template.AddCommand(templateSave)
+	templateApply := model.NewAutocompleteData("apply", "[name] [user]", T(msgAutocompleteTemplateApply, nil))
+	templateApply.AddTextArgument(T(msgAutocompleteTemplateName, nil), "[name]", "")
+	templateApply.AddDynamicListArgument(T(msgAutocompleteTemplateApplyUser, nil), usersURL, false)
+	template.AddCommand(templateApply)
+	template.AddCommand(model.NewAutocompleteData("list", "", T(msgAutocompleteTemplateList, nil)))
+	templateRemove := model.NewAutocompleteData("rm", "[--team] [name]", T(msgAutocompleteTemplateRemove, nil))
+	templateRemove.AddTextArgument(T(msgAutocompleteTemplateName, nil), "[name]", "")
+	template.AddCommand(templateRemove)
+	todo.AddCommand(template)
+
+	todo.AddCommand(model.NewAutocompleteData("undo", "", T(msgAutocompleteUndo, nil)))
+
+	digest := model.NewAutocompleteData("digest", "[on|off] [time]", T(msgAutocompleteDigest, nil))
+	digest.AddStaticListArgument(T(msgAutocompleteDigestToggle, nil), false, []model.AutocompleteListItem{
+		{Item: "on", Hint: "[HH:MM]", HelpText: T(msgAutocompleteDigestOn, nil)},
+		{Item: "off", HelpText: T(msgAutocompleteDigestOff, nil)},
+	})
+	todo.AddCommand(digest)
+
+	settings := model.NewAutocompleteData("settings", "[setting] [value]", T(msgAutocompleteSettings, nil))
+	settingsDigest := model.NewAutocompleteData("digest", "[on|off] [time]", T(msgAutocompleteSettingsDigest, nil))
+	settingsDigest.AddStaticListArgument(T(msgAutocompleteDigestToggle, nil), true, []model.AutocompleteListItem{
+		{Item: "on", Hint: "[HH:MM]", HelpText: T(msgAutocompleteDigestOn, nil)},
+		{Item: "off", HelpText: T(msgAutocompleteDigestOff, nil)},
+	})
+	settings.AddCommand(settingsDigest)
+	settingsNotifications := model.NewAutocompleteData("notifications", "[on|off]", T(msgAutocompleteSettingsNotifications, nil))
+	settingsNotifications.AddStaticListArgument(T(msgAutocompleteNotificationsToggle, nil), true, []model.AutocompleteListItem{
+		{Item: "on", HelpText: T(msgAutocompleteNotificationsOn, nil)},
+		{Item: "off", HelpText: T(msgAutocompleteNotificationsOff, nil)},
+	})
+	settings.AddCommand(settingsNotifications)
+	settingsList := model.NewAutocompleteData("list", "[my|in|out|done]", T(msgAutocompleteSettingsList, nil))
+	settingsList.AddStaticListArgument(T(msgAutocompleteSettingsListName, nil), true, listItems)
+	settings.AddCommand(settingsList)
+	settingsReminder := model.NewAutocompleteData("reminder", "[lead time|off]", T(msgAutocompleteSettingsReminder, nil))
+	settingsReminder.AddTextArgument(T(msgAutocompleteSettingsReminderLead, nil), "[lead time|off]", "")
+	settings.AddCommand(settingsReminder)
+	settingsReport := model.NewAutocompleteData("report", "[on|off]", T(msgAutocompleteSettingsReport, nil))
+	settingsReport.AddStaticListArgument(T(msgAutocompleteReportToggle, nil), true, []model.AutocompleteListItem{
+		{Item: "on", HelpText: T(msgAutocompleteReportOn, nil)},
+		{Item: "off", HelpText: T(msgAutocompleteReportOff, nil)},
+	})
+	settings.AddCommand(settingsReport)
+	settingsEmails := model.NewAutocompleteData("emails", "[on|off]", T(msgAutocompleteSettingsEmails, nil))
+	settingsEmails.AddStaticListArgument(T(msgAutocompleteEmailsToggle, nil), true, []model.AutocompleteListItem{
+		{Item: "on", HelpText: T(msgAutocompleteEmailsOn, nil)},
+		{Item: "off", HelpText: T(msgAutocompleteEmailsOff, nil)},
+	})
+	settings.AddCommand(settingsEmails)
+	settingsWatch := model.NewAutocompleteData("watch", "[all|selected]", T(msgAutocompleteSettingsWatch, nil))
+	settingsWatch.AddStaticListArgument(T(msgAutocompleteWatchMode, nil), true, []model.AutocompleteListItem{
+		{Item: "all", HelpText: T(msgAutocompleteWatchAll, nil)},
+		{Item: "selected", HelpText: T(msgAutocompleteWatchSelected, nil)},
+	})
+	settings.AddCommand(settingsWatch)
+	todo.AddCommand(settings)
+
+	calendar := model.NewAutocompleteData("calendar", "[off]", T(msgAutocompleteCalendar, nil))
+	calendar.AddStaticListArgument(T(msgAutocompleteCalendarOff, nil), false, []model.AutocompleteListItem{
+		{Item: "off", HelpText: T(msgAutocompleteCalendarOff, nil)},
+	})
+	todo.AddCommand(calendar)
+
+	jira := model.NewAutocompleteData("jira", "[link|unlink]", T(msgAutocompleteJira, nil))
+	jiraLink := model.NewAutocompleteData("link", "[issue key] [number]", T(msgAutocompleteJiraLink, nil))
+	jiraLink.AddTextArgument(T(msgAutocompleteJiraKey, nil), "[issue key]", "")
+	jiraLink.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	jira.AddCommand(jiraLink)
+	jiraUnlink := model.NewAutocompleteData("unlink", "[number]", T(msgAutocompleteJiraUnlink, nil))
+	jiraUnlink.AddDynamicListArgument(T(msgAutocompleteNumber, nil), issuesURL, true)
+	jira.AddCommand(jiraUnlink)
+	todo.AddCommand(jira)
+
+	token := model.NewAutocompleteData("token", "[create|list|revoke]", T(msgAutocompleteToken, nil))
+	tokenCreate := model.NewAutocompleteData("create", "[name] [scopes]", T(msgAutocompleteTokenCreate, nil))
+	tokenCreate.AddTextArgument(T(msgAutocompleteTokenCreateArgs, nil), "[name] [scopes]", "")
+	token.AddCommand(tokenCreate)
+	token.AddCommand(model.NewAutocompleteData("list", "", T(msgAutocompleteTokenList, nil)))
+	tokenRevoke := model.NewAutocompleteData("revoke", "[id]", T(msgAutocompleteTokenRevoke, nil))
+	tokenRevoke.AddTextArgument(T(msgAutocompleteTokenID, nil), "[id]", "")
+	token.AddCommand(tokenRevoke)
+	todo.AddCommand(token)
+
+	export := model.NewAutocompleteData("export", "[csv|json]", T(msgAutocompleteExport, nil))
+	export.AddStaticListArgument(T(msgAutocompleteExportFormat, nil), false, []model.AutocompleteListItem{
+		{Item: ExportFormatCSV},
+		{Item: ExportFormatJSON},
+	})
+	todo.AddCommand(export)
+
+	importCommand := model.NewAutocompleteData("import", "[post link] [--dry-run]", T(msgAutocompleteImport, nil))
+	importCommand.AddTextArgument(T(msgAutocompleteImportLink, nil), "[post link] [--dry-run]", "")
+	todo.AddCommand(importCommand)
+
+	purge := model.NewAutocompleteData("purge", "", T(msgAutocompletePurge, nil))
+	purge.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	todo.AddCommand(purge)
+
+	audit := model.NewAutocompleteData("audit", "[user] [since]", T(msgAutocompleteAudit, nil))
+	audit.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	audit.AddDynamicListArgument(T(msgAutocompleteAuditUser, nil), usersURL, true)
+	audit.AddTextArgument(T(msgAutocompleteAuditSince, nil), "[since]", "")
+	todo.AddCommand(audit)
+
+	migrate := model.NewAutocompleteData("migrate", "", T(msgAutocompleteMigrate, nil))
+	migrate.RoleID = model.SYSTEM_ADMIN_ROLE_ID
+	todo.AddCommand(migrate)
+
+	todo.AddCommand(model.NewAutocompleteData("help", "", T(msgAutocompleteHelp, nil)))
+
+	return todo
+}
+
+// handleAutocompleteIssues suggests the numbers of the issues of the list used by the command being typed
+func (p *Plugin) handleAutocompleteIssues(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	listID := autocompleteListID(r.URL.Query().Get("parsed"))
+	issues, _, err := p.listManager.GetIssueListPage(userID, listID, 0, AutocompleteMaxSuggestions)
+	if err != nil {
+		p.API.LogError("Unable to get issues for autocomplete err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issues for user", err)
+		return
+	}
+
+	items := []model.AutocompleteListItem{}
+	for i, issue := range issues {
+		items = append(items, model.AutocompleteListItem{
+			Item:     strconv.Itoa(i + 1),
+			HelpText: truncateMessage(issue.Message, AutocompleteMaxMessageLength),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(model.AutocompleteStaticListItemsToJSON(items))
+}
+
+// handleAutocompleteUsers suggests the active users matching the username being typed
+func (p *Plugin) handleAutocompleteUsers(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	term := strings.TrimSpace(strings.TrimPrefix(query.Get("user_input"), query.Get("parsed")))
+	prefix := ""
+	if strings.HasPrefix(term, "@") {
+		prefix = "@"
+	}
+
+	items := []model.AutocompleteListItem{}
+	users, appErr := p.API.SearchUsers(&model.UserSearch{
+		Term:  strings.TrimPrefix(term, "@"),
+		Limit: AutocompleteMaxSuggestions,
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to search users for autocomplete err=" + appErr.Error())
+	}
+	for _, user := range users {
+		if user.IsBot || user.DeleteAt != 0 {
+			continue
+		}
+		items = append(items, model.AutocompleteListItem{
+			Item:     prefix + user.Username,
+			HelpText: user.GetDisplayName(model.SHOW_FULLNAME),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(model.AutocompleteStaticListItemsToJSON(items))
+}
+
+// autocompleteListID finds the list whose issue numbers are suggested from the parsed part of the command,
+// like "todo rm out "
+func autocompleteListID(parsed string) string {
+	words := strings.Fields(strings.TrimPrefix(parsed, "/"))
+	if len(words) < 2 {
+		return MyListKey
+	}
+
+	switch words[1] {
+	case "accept", "decline":
+		return InListKey
+	case "forward":
+		if len(words) > 2 && words[2] == "my" {
+			return MyListKey
+		}
+		return InListKey
+	case "restore":
+		return DoneListKey
+	case "approve", "reject", "nudge", "watch", "unwatch":
+		return OutListKey
+	case "rm", "edit", "note", "attach":
+		if len(words) > 2 {
+			if listID, ok := parseListName(words[2]); ok {
+				return listID
+			}
+		}
+	}
+
+	return MyListKey
+}
+
+// truncateMessage shortens message to maxLength characters, adding an ellipsis if needed
+func truncateMessage(message string, maxLength int) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if utf8.RuneCountInString(message) <= maxLength {
+		return message
+	}
+	return string([]rune(message)[:maxLength-1]) + "…"
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAutocompleteData(t *testing.T) {
+	assert.NoError(t, getAutocompleteData(renderEnglish).IsValid())
+}
+
+func TestAutocompleteListID(t *testing.T) {
+	assert.Equal(t, MyListKey, autocompleteListID("todo done "))
+	assert.Equal(t, InListKey, autocompleteListID("/todo accept "))
+	assert.Equal(t, DoneListKey, autocompleteListID("todo restore "))
+	assert.Equal(t, OutListKey, autocompleteListID("todo rm out "))
+	assert.Equal(t, MyListKey, autocompleteListID("todo edit "))
+	assert.Equal(t, InListKey, autocompleteListID("todo forward "))
+	assert.Equal(t, MyListKey, autocompleteListID("todo forward my "))
+	assert.Equal(t, OutListKey, autocompleteListID("todo approve "))
+}
+
+func TestTruncateMessage(t *testing.T) {
+	assert.Equal(t, "short message", truncateMessage("short\nmessage", 20))
+	assert.Equal(t, "a longer…", truncateMessage("a longer message", 9))
+}
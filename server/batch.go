@@ -0,0 +1,165 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// EventBatchWindow is how long the websocket events of a user are held, so the events of a bulk operation are
+	// published together
+	EventBatchWindow = 500 * time.Millisecond
+	// MaxBatchedChanges is the most list changes published for a batch. Larger batches are replaced by a single
+	// refresh event, so the clients fetch their lists once.
+	MaxBatchedChanges = 20
+)
+
+// eventBatch holds the websocket events of a user waiting to be published
+type eventBatch struct {
+	refresh bool
+	changes []*ListChange
+}
+
+// eventBatcher coalesces the websocket events of each user published within EventBatchWindow
+type eventBatcher struct {
+	window  time.Duration
+	refresh func(userID string)
+	change  func(change *ListChange)
+
+	lock    sync.Mutex
+	batches map[string]*eventBatch
+	stopped bool
+}
+
+// newEventBatcher creates an eventBatcher that publishes the refresh events with refresh and the list changes with
+// change
+func newEventBatcher(window time.Duration, refresh func(userID string), change func(change *ListChange)) *eventBatcher {
+	return &eventBatcher{
+		window:  window,
+		refresh: refresh,
+		change:  change,
+		batches: map[string]*eventBatch{},
+	}
+}
+
+// AddRefresh queues a refresh event for userID
+func (b *eventBatcher) AddRefresh(userID string) {
+	b.add(userID, func(batch *eventBatch) {
+		batch.refresh = true
+	})
+}
+
+// AddChange queues a change of a list of its user
+func (b *eventBatcher) AddChange(change *ListChange) {
+	b.add(change.UserID, func(batch *eventBatch) {
+		batch.changes = append(batch.changes, change)
+	})
+}
+
+func (b *eventBatcher) add(userID string, update func(batch *eventBatch)) {
+	b.lock.Lock()
+	if b.stopped {
+		b.lock.Unlock()
+		batch := &eventBatch{}
+		update(batch)
+		b.publish(userID, batch)
+		return
+	}
+
+	batch, ok := b.batches[userID]
+	if !ok {
+		batch = &eventBatch{}
+		b.batches[userID] = batch
+		time.AfterFunc(b.window, func() { b.Flush(userID) })
+	}
+	update(batch)
+	b.lock.Unlock()
+}
+
+// Flush publishes the events of userID waiting in the batch
+func (b *eventBatcher) Flush(userID string) {
+	b.lock.Lock()
+	batch, ok := b.batches[userID]
+	delete(b.batches, userID)
+	b.lock.Unlock()
+
+	if ok {
+		b.publish(userID, batch)
+	}
+}
+
+// Stop publishes every waiting event, and publishes the next events right away
+func (b *eventBatcher) Stop() {
+	b.lock.Lock()
+	batches := b.batches
+	b.batches = map[string]*eventBatch{}
+	b.stopped = true
+	b.lock.Unlock()
+
+	for userID, batch := range batches {
+		b.publish(userID, batch)
+	}
+}
+
+func (b *eventBatcher) publish(userID string, batch *eventBatch) {
+	changes := compactChanges(batch.changes)
+	if batch.refresh || len(changes) > MaxBatchedChanges {
+		b.refresh(userID)
+		return
+	}
+
+	for _, change := range changes {
+		b.change(change)
+	}
+}
+
+// compactChanges merges the changes of a batch into the fewest changes with the same result. Only the last change of
+// each issue of a list is kept, except that an issue added and then updated is published as added, and the lists
+// are reordered once, at the end.
+func compactChanges(changes []*ListChange) []*ListChange {
+	type issueKey struct {
+		listID  string
+		issueID string
+	}
+
+	compacted := []*ListChange{}
+	positions := map[issueKey]int{}
+	reorders := []*ListChange{}
+	reorderPositions := map[string]int{}
+
+	for _, change := range changes {
+		if change.Type == ListChangeReordered {
+			if i, ok := reorderPositions[change.ListID]; ok {
+				reorders[i] = change
+				continue
+			}
+			reorderPositions[change.ListID] = len(reorders)
+			reorders = append(reorders, change)
+			continue
+		}
+
+		key := issueKey{change.ListID, change.IssueID}
+		i, ok := positions[key]
+		switch {
+		case ok && change.Type == ListChangeUpdated:
+			// The update replaces the issue of the previous change, keeping it an addition if it was one
+			updated := *compacted[i]
+			updated.Issue = change.Issue
+			compacted[i] = &updated
+			continue
+		case ok:
+			// An added or removed issue is published after the other changes, keeping the order of the list
+			compacted[i] = nil
+		}
+		positions[key] = len(compacted)
+		compacted = append(compacted, change)
+	}
+
+	result := []*ListChange{}
+	for _, change := range compacted {
+		if change != nil {
+			result = append(result, change)
+		}
+	}
+	return append(result, reorders...)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactChanges(t *testing.T) {
+	issueA := &ExtendedIssue{Issue: Issue{ID: "a", Message: "first"}}
+	issueA2 := &ExtendedIssue{Issue: Issue{ID: "a", Message: "second"}}
+	issueB := &ExtendedIssue{Issue: Issue{ID: "b"}}
+
+	t.Run("update of an added issue", func(t *testing.T) {
+		changes := compactChanges([]*ListChange{
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "a", Issue: issueA},
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "b", Issue: issueB},
+			{Type: ListChangeUpdated, ListID: MyListKey, IssueID: "a", Issue: issueA2},
+		})
+		assert.Equal(t, []*ListChange{
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "a", Issue: issueA2},
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "b", Issue: issueB},
+		}, changes)
+	})
+
+	t.Run("last change wins", func(t *testing.T) {
+		changes := compactChanges([]*ListChange{
+			{Type: ListChangeRemoved, ListID: MyListKey, IssueID: "a"},
+			{Type: ListChangeAdded, ListID: DoneListKey, IssueID: "a", Issue: issueA},
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "b", Issue: issueB},
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "a", Issue: issueA},
+			{Type: ListChangeRemoved, ListID: DoneListKey, IssueID: "a"},
+		})
+		assert.Equal(t, []*ListChange{
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "b", Issue: issueB},
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "a", Issue: issueA},
+			{Type: ListChangeRemoved, ListID: DoneListKey, IssueID: "a"},
+		}, changes)
+	})
+
+	t.Run("reorders last", func(t *testing.T) {
+		changes := compactChanges([]*ListChange{
+			{Type: ListChangeReordered, ListID: DoneListKey, Order: []string{"a"}},
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "b", Issue: issueB},
+			{Type: ListChangeReordered, ListID: DoneListKey, Order: []string{"b", "a"}},
+		})
+		assert.Equal(t, []*ListChange{
+			{Type: ListChangeAdded, ListID: MyListKey, IssueID: "b", Issue: issueB},
+			{Type: ListChangeReordered, ListID: DoneListKey, Order: []string{"b", "a"}},
+		}, changes)
+	})
+}
+
+func TestEventBatcher(t *testing.T) {
+	refreshed := []string{}
+	published := []*ListChange{}
+	batcher := newEventBatcher(time.Hour, func(userID string) {
+		refreshed = append(refreshed, userID)
+	}, func(change *ListChange) {
+		published = append(published, change)
+	})
+
+	batcher.AddChange(&ListChange{Type: ListChangeRemoved, UserID: "user1", IssueID: "a"})
+	batcher.AddChange(&ListChange{Type: ListChangeRemoved, UserID: "user1", IssueID: "a"})
+	for i := 0; i <= MaxBatchedChanges; i++ {
+		batcher.AddChange(&ListChange{Type: ListChangeRemoved, UserID: "user2", IssueID: string(rune('a' + i))})
+	}
+	assert.Empty(t, published)
+
+	batcher.Flush("user1")
+	assert.Equal(t, []*ListChange{{Type: ListChangeRemoved, UserID: "user1", IssueID: "a"}}, published)
+	assert.Empty(t, refreshed)
+
+	batcher.Stop()
+	assert.Equal(t, []string{"user2"}, refreshed)
+	assert.Len(t, published, 1)
+
+	batcher.AddChange(&ListChange{Type: ListChangeRemoved, UserID: "user1", IssueID: "b"})
+	assert.Len(t, published, 2)
+}
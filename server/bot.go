@@ -18,16 +18,40 @@ func (p *Plugin) PostBotDM(userID string, message string) error {
 		return fmt.Errorf("could not get direct channel for bot and user_id=%s", userID)
 	}
 
+	return p.createBotDM(userID, &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channel.Id,
+		Message:   message,
+	})
+}
+
+// PostBotDMWithFile uploads the file to the DM between the bot and userID, and posts it with the message as the bot user.
+func (p *Plugin) PostBotDMWithFile(userID, message, fileName string, data []byte) error {
+	channel, appError := p.API.GetDirectChannel(userID, p.BotUserID)
+	if appError != nil {
+		return appError
+	}
+	if channel == nil {
+		return fmt.Errorf("could not get direct channel for bot and user_id=%s", userID)
+	}
+
+	fileInfo, appError := p.API.UploadFile(data, channel.Id, fileName)
+	if appError != nil {
+		return appError
+	}
+
 	_, appError = p.API.CreatePost(&model.Post{
 		UserId:    p.BotUserID,
 		ChannelId: channel.Id,
 		Message:   message,
+		FileIds:   model.StringArray{fileInfo.Id},
 	})
 
 	return appError
 }
 
-// PostBotCustomDM posts a DM as the cloud bot user using custom post with action buttons.
+// PostBotCustomDM posts a DM as the cloud bot user about the received todo issueID, with the buttons to accept,
+// decline or complete it.
 func (p *Plugin) PostBotCustomDM(userID string, message string, todo string, issueID string) error {
 	channel, appError := p.API.GetDirectChannel(userID, p.BotUserID)
 	if appError != nil {
@@ -37,20 +61,44 @@ func (p *Plugin) PostBotCustomDM(userID string, message string, todo string, iss
 		return fmt.Errorf("could not get direct channel for bot and user_id=%s", userID)
 	}
 
-	_, appError = p.API.CreatePost(&model.Post{
+	post := &model.Post{
 		UserId:    p.BotUserID,
 		ChannelId: channel.Id,
-		Message:   message + ": " + todo,
-		Type:      "custom_todo",
+		Message:   message,
 		Props: map[string]interface{}{
-			"type":    "custom_todo",
 			"message": message,
 			"todo":    todo,
 			"issueId": issueID,
 		},
-	})
+	}
 
-	return appError
+	// The files of the todo are linked, as the files of another post cannot be attached to this one
+	text := todo
+	if issue, err := p.listManager.GetIssue(userID, issueID); err == nil && len(issue.Files) > 0 {
+		setFileURLs(p.siteURL(), issue.Files)
+		for _, file := range issue.Files {
+			text += "\n* " + fileLink(file)
+		}
+		post.AddProp("files", issue.Files)
+	}
+
+	attachment := p.receivedTodoAttachment(userID, issueID, text)
+	attachment.Fallback = message + ": " + todo
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{attachment})
+
+	return p.createBotDM(userID, post)
+}
+
+// createBotDM posts the DM to userID, or queues it until the user turns Do Not Disturb off
+func (p *Plugin) createBotDM(userID string, post *model.Post) error {
+	if p.isDoNotDisturb(userID) {
+		return p.deferNotification(userID, post)
+	}
+
+	if _, appError := p.API.CreatePost(post); appError != nil {
+		return appError
+	}
+	return nil
 }
 
 // ReplyPostBot post a message and a todo in the same thread as the post postID
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// MaxBulkIssues is the maximum number of todos changed by a single bulk operation
+const MaxBulkIssues = 100
+
+// BulkResult is a todo changed by a bulk operation
+type BulkResult struct {
+	Issue    *ExtendedIssue `json:"issue"`
+	IsSender bool           `json:"is_sender"`
+}
+
+type apiV2BulkRequest struct {
+	Action string   `json:"action"`
+	IDs    []string `json:"ids"`
+}
+
+// apiV2BulkError is the response of a bulk request that failed after changing some of the todos
+type apiV2BulkError struct {
+	Error   string        `json:"error"`
+	Details string        `json:"details"`
+	Changed []*BulkResult `json:"changed"`
+}
+
+// parsePositions parses a list of todo numbers and ranges, like 1-3,5, into the sorted numbers without duplicates
+func parsePositions(arg string) ([]int, error) {
+	seen := map[int]bool{}
+	positions := []int{}
+	for _, part := range strings.Split(arg, ",") {
+		part = strings.TrimSpace(part)
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+
+		first, err := parsePosition(from)
+		if err != nil {
+			return nil, newLocalizedError(msgErrInvalidRange, map[string]interface{}{"Value": part})
+		}
+		last, err := parsePosition(to)
+		if err != nil || last < first {
+			return nil, newLocalizedError(msgErrInvalidRange, map[string]interface{}{"Value": part})
+		}
+		if last-first >= MaxBulkIssues {
+			return nil, newLocalizedError(msgErrTooManyBulk, map[string]interface{}{"Max": MaxBulkIssues})
+		}
+
+		for position := first; position <= last; position++ {
+			if !seen[position] {
+				seen[position] = true
+				positions = append(positions, position)
+			}
+		}
+		if len(positions) > MaxBulkIssues {
+			return nil, newLocalizedError(msgErrTooManyBulk, map[string]interface{}{"Max": MaxBulkIssues})
+		}
+	}
+
+	sort.Ints(positions)
+	return positions, nil
+}
+
+// isBulkArg checks whether the argument of a command is a list of todo numbers or ranges, rather than a single number
+func isBulkArg(arg string) bool {
+	return strings.ContainsAny(arg, ",-")
+}
+
+// issueIDsByPosition finds the todos at the positions of the list of userID, before any of them is changed
+func (p *Plugin) issueIDsByPosition(userID, listID string, positions []int) ([]string, error) {
+	issues, err := p.listManager.GetIssueList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, position := range positions {
+		if position > len(issues) {
+			return nil, newLocalizedError(msgErrNoTodoNumber, map[string]interface{}{"Number": position})
+		}
+		ids = append(ids, issues[position-1].ID)
+	}
+
+	return ids, nil
+}
+
+// bulkIssues completes or removes the todos of userID, refreshing the lists and notifying every other user involved once
+func (p *Plugin) bulkIssues(userID, action string, issueIDs []string) ([]*BulkResult, error) {
+	results, err := p.listManager.BulkIssues(userID, action, issueIDs)
+	if len(results) > 0 {
+		p.sendRefreshEvent(userID)
+		p.notifyBulk(userID, action, results)
+	}
+
+	return results, err
+}
+
+// notifyBulk sends every foreign user of the changed todos a single message with all the todos of theirs that changed
+func (p *Plugin) notifyBulk(userID, action string, results []*BulkResult) {
+	userName := p.listManager.GetUserName(userID)
+
+	replyMsg, msg := msgReplyCompleted, msgNotifyBulkCompleted
+	if action == BulkActionRemove {
+		replyMsg, msg = msgReplyRemoved, msgNotifyBulkRemoved
+	}
+	replyMessage := p.localizeServer(replyMsg, map[string]interface{}{"User": userName})
+
+	foreignUserIDs := []string{}
+	messages := map[string][]string{}
+	for _, result := range results {
+		issue := result.Issue
+		p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
+
+		if issue.ForeignUser == "" {
+			continue
+		}
+
+		relation := msgNotifyBulkSent
+		if action == BulkActionRemove && !result.IsSender {
+			relation = msgNotifyBulkReceived
+		} else if action == BulkActionRemove {
+			relation = msgNotifyBulkDeclined
+		}
+
+		if _, ok := messages[issue.ForeignUserID]; !ok {
+			foreignUserIDs = append(foreignUserIDs, issue.ForeignUserID)
+		}
+		line := p.localize(issue.ForeignUserID, relation, map[string]interface{}{"Todo": issue.Message})
+		messages[issue.ForeignUserID] = append(messages[issue.ForeignUserID], line)
+	}
+
+	for _, foreignUserID := range foreignUserIDs {
+		lines := messages[foreignUserID]
+		message := p.localize(foreignUserID, msg, map[string]interface{}{
+			"User":  userName,
+			"Count": len(lines),
+			"Todos": strings.Join(lines, "\n"),
+		})
+		p.sendRefreshEvent(foreignUserID)
+		p.PostBotDM(foreignUserID, message)
+	}
+}
+
+// runBulkCommand completes or removes the todos at the numbers and ranges in arg of the list of the user
+func (p *Plugin) runBulkCommand(action, listID, arg string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	positions, err := parsePositions(arg)
+	if err != nil {
+		return nil, true, err
+	}
+
+	issueIDs, err := p.issueIDsByPosition(extra.UserId, listID, positions)
+	if err != nil {
+		return nil, true, err
+	}
+
+	results, err := p.bulkIssues(extra.UserId, action, issueIDs)
+	if err != nil && len(results) == 0 {
+		return nil, err == errIssueAlreadyCompleted, err
+	}
+
+	numbers := []string{}
+	for _, position := range positions[:len(results)] {
+		numbers = append(numbers, strconv.Itoa(position))
+	}
+
+	response := msgResponseBulkCompleted
+	if action == BulkActionRemove {
+		response = msgResponseBulkRemoved
+	}
+	T := p.translator(extra.UserId)
+	responseMessage := T(response, map[string]interface{}{"Numbers": strings.Join(numbers, ", ")})
+	if err != nil {
+		p.API.LogError("Unable to change every issue err=" + err.Error())
+		responseMessage += " " + T(msgResponseBulkPartial, nil)
+	}
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, listID, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, listID)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) handleAPIv2Bulk(w http.ResponseWriter, r *http.Request, userID string) {
+	var bulkRequest *apiV2BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&bulkRequest); err != nil || bulkRequest == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+		return
+	}
+
+	if bulkRequest.Action != BulkActionComplete && bulkRequest.Action != BulkActionRemove {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid action", errors.Errorf("%s is not one of complete or remove", bulkRequest.Action))
+		return
+	}
+
+	if len(bulkRequest.IDs) == 0 || len(bulkRequest.IDs) > MaxBulkIssues {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo ids", errors.Errorf("between 1 and %d ids are required", MaxBulkIssues))
+		return
+	}
+
+	for _, issueID := range bulkRequest.IDs {
+		if !model.IsValidId(issueID) {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+			return
+		}
+	}
+
+	results, err := p.bulkIssues(userID, bulkRequest.Action, bulkRequest.IDs)
+	if err != nil && len(results) > 0 {
+		// The todos changed before the error stay changed, and can be undone together
+		p.API.LogError("Unable to change every issue err=" + err.Error())
+		p.writeAPIResponse(w, http.StatusInternalServerError, &apiV2BulkError{
+			Error:   "Unable to change every todo",
+			Details: err.Error(),
+			Changed: results,
+		})
+		return
+	}
+	if err == errIssueNotFound {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Todo not found", errors.New("at least one of the todos does not exist, so none was changed"))
+		return
+	}
+	if err == errIssueAlreadyCompleted {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Todo already completed", errors.New("at least one of the todos is already completed, so none was changed"))
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to change issues err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to change issues", err)
+		return
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, results)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePositions(t *testing.T) {
+	positions, err := parsePositions("1-3,5")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 5}, positions)
+
+	positions, err = parsePositions("7,2,2-3")
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 7}, positions)
+
+	positions, err = parsePositions("4")
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, positions)
+
+	for _, arg := range []string{"", "0", "3-1", "1-", "-2", "a,b", "1,,2", "1-200"} {
+		_, err = parsePositions(arg)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestIsBulkArg(t *testing.T) {
+	assert.True(t, isBulkArg("1-3"))
+	assert.True(t, isBulkArg("1,5"))
+	assert.False(t, isBulkArg("2"))
+}
+
+// failingListStore is a ListStore that cannot remove the references to failIssueID
+type failingListStore struct {
+	ListStore
+	failIssueID string
+}
+
+func (s *failingListStore) RemoveReference(userID, issueID, listID string) error {
+	if issueID == s.failIssueID {
+		return errors.New("unable to store list")
+	}
+	return s.ListStore.RemoveReference(userID, issueID, listID)
+}
+
+// listMessages returns the messages of the todos on listID of userID, in order
+func listMessages(t *testing.T, l *listManager, userID, listID string) []string {
+	issues, err := l.GetIssueList(userID, listID)
+	require.NoError(t, err)
+
+	messages := []string{}
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	return messages
+}
+
+func TestBulkIssuesUndo(t *testing.T) {
+	l := NewListManager(newMemoryKV())
+	ids := []string{}
+	for _, message := range []string{"one", "two", "three"} {
+		issue, err := l.AddIssue("user1", message, "", 0)
+		require.NoError(t, err)
+		ids = append(ids, issue.ID)
+	}
+
+	results, err := l.BulkIssues("user1", BulkActionComplete, []string{ids[0], ids[2]})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, []string{"two"}, listMessages(t, l, "user1", MyListKey))
+
+	entry, err := l.UndoLastAction("user1")
+	require.NoError(t, err)
+	assert.Equal(t, JournalActionBulk, entry.Action)
+	assert.Len(t, entry.Entries, 2)
+	assert.Equal(t, []string{"one", "two", "three"}, listMessages(t, l, "user1", MyListKey))
+	assert.Empty(t, listMessages(t, l, "user1", DoneListKey))
+
+	_, err = l.UndoLastAction("user1")
+	assert.Equal(t, errNothingToUndo, err)
+}
+
+func TestBulkIssuesPartialFailure(t *testing.T) {
+	kv := newMemoryKV()
+	l := NewListManager(kv)
+	ids := []string{}
+	for _, message := range []string{"one", "two", "three"} {
+		issue, err := l.AddIssue("user1", message, "", 0)
+		require.NoError(t, err)
+		ids = append(ids, issue.ID)
+	}
+	l.setStore(&failingListStore{ListStore: NewListStore(kv), failIssueID: ids[1]})
+
+	// The todos changed before the error are returned, and journaled to be undone together
+	results, err := l.BulkIssues("user1", BulkActionRemove, ids)
+	require.Error(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "one", results[0].Issue.Message)
+	assert.Equal(t, []string{"two", "three"}, listMessages(t, l, "user1", MyListKey))
+
+	entry, err := l.UndoLastAction("user1")
+	require.NoError(t, err)
+	assert.Equal(t, JournalActionBulk, entry.Action)
+	assert.Equal(t, []string{"one", "two", "three"}, listMessages(t, l, "user1", MyListKey))
+}
@@ -0,0 +1,135 @@
+package main
+
+const (
+	// ListChangeAdded is published when an issue is added to a list
+	ListChangeAdded = "issue_added"
+	// ListChangeUpdated is published when an issue of a list changes
+	ListChangeUpdated = "issue_updated"
+	// ListChangeRemoved is published when an issue leaves a list, because it was completed, removed or moved
+	ListChangeRemoved = "issue_removed"
+	// ListChangeReordered is published when the issues of a list change order
+	ListChangeReordered = "issue_reordered"
+)
+
+// ListChange is a change of the list ListID of UserID, so the clients can update the list without fetching it
+// again. Issue is set when an issue is added or updated, and Order, the issue IDs of the list, when it is reordered.
+type ListChange struct {
+	Type    string
+	UserID  string
+	ListID  string
+	IssueID string
+	Issue   *ExtendedIssue
+	Order   []string
+}
+
+// ListChangeHandler is called by the list manager after every change of a list
+type ListChangeHandler func(change *ListChange)
+
+// observedListStore is a ListStore that lets its list manager publish the changes of the issue references
+type observedListStore struct {
+	ListStore
+	manager *listManager
+}
+
+func (s *observedListStore) AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error {
+	if err := s.ListStore.AddReference(userID, issueID, listID, foreignUserID, foreignIssueID); err != nil {
+		return err
+	}
+
+	s.manager.publishAdded(userID, listID, &IssueRef{IssueID: issueID, ForeignUserID: foreignUserID, ForeignIssueID: foreignIssueID})
+	return nil
+}
+
+func (s *observedListStore) RemoveReference(userID, issueID, listID string) error {
+	if err := s.ListStore.RemoveReference(userID, issueID, listID); err != nil {
+		return err
+	}
+
+	s.manager.publishChange(&ListChange{Type: ListChangeRemoved, UserID: userID, ListID: listID, IssueID: issueID})
+	return nil
+}
+
+func (s *observedListStore) PopReference(userID, listID string) (*IssueRef, error) {
+	ir, err := s.ListStore.PopReference(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.manager.publishChange(&ListChange{Type: ListChangeRemoved, UserID: userID, ListID: listID, IssueID: ir.IssueID})
+	return ir, nil
+}
+
+func (s *observedListStore) BumpReference(userID, issueID, listID string) error {
+	if err := s.ListStore.BumpReference(userID, issueID, listID); err != nil {
+		return err
+	}
+
+	s.manager.publishReordered(userID, listID)
+	return nil
+}
+
+func (s *observedListStore) MoveReference(userID, issueID, listID string, position int) error {
+	if err := s.ListStore.MoveReference(userID, issueID, listID, position); err != nil {
+		return err
+	}
+
+	s.manager.publishReordered(userID, listID)
+	return nil
+}
+
+// publishChange calls the change handler, if set. The shared lists of the channels have their own refresh event.
+func (l *listManager) publishChange(change *ListChange) {
+	if l.changes == nil || change.ListID == ChannelListKey {
+		return
+	}
+
+	l.changes(change)
+}
+
+// publishAdded publishes the issue of ir, just added to listID of userID
+func (l *listManager) publishAdded(userID, listID string, ir *IssueRef) {
+	if l.changes == nil || listID == ChannelListKey {
+		return
+	}
+
+	issue, err := l.store.GetIssue(ir.IssueID)
+	if err != nil || issue == nil {
+		l.api.LogWarn("cannot get added issue to publish", "issueID", ir.IssueID)
+		return
+	}
+
+	l.publishChange(&ListChange{Type: ListChangeAdded, UserID: userID, ListID: listID, IssueID: issue.ID, Issue: l.extendIssueInfo(issue, ir)})
+}
+
+// publishUpdated publishes issue, just changed, to the list of userID it is on
+func (l *listManager) publishUpdated(userID string, issue *Issue) {
+	if l.changes == nil || issue == nil {
+		return
+	}
+
+	listID, ir, _ := l.store.GetIssueListAndReference(userID, issue.ID)
+	if ir == nil {
+		return
+	}
+
+	l.publishChange(&ListChange{Type: ListChangeUpdated, UserID: userID, ListID: listID, IssueID: issue.ID, Issue: l.extendIssueInfo(issue, ir)})
+}
+
+// publishReordered publishes the new order of listID of userID
+func (l *listManager) publishReordered(userID, listID string) {
+	if l.changes == nil || listID == ChannelListKey {
+		return
+	}
+
+	irs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		l.api.LogWarn("cannot get reordered list to publish", "err", err.Error())
+		return
+	}
+
+	order := make([]string, 0, len(irs))
+	for _, ir := range irs {
+		order = append(order, ir.IssueID)
+	}
+	l.publishChange(&ListChange{Type: ListChangeReordered, UserID: userID, ListID: listID, Order: order})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPublishListChange(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewString("https://chat.example.com")}})
+	api.On("PublishWebSocketEvent", mock.Anything, mock.Anything, mock.Anything).Return()
+	p := &Plugin{}
+	p.SetAPI(api)
+	broadcast := &model.WebsocketBroadcast{UserId: "user1"}
+
+	p.publishListChange(&ListChange{Type: ListChangeReordered, UserID: "user1", ListID: OutListKey, Order: []string{"a", "b"}})
+	api.AssertCalled(t, "PublishWebSocketEvent", ListChangeReordered, map[string]interface{}{"list": "out", "issue_id": "", "order": "a,b"}, broadcast)
+
+	p.publishListChange(&ListChange{Type: ListChangeAdded, UserID: "user1", ListID: MyListKey, IssueID: "a", Issue: &ExtendedIssue{Issue: Issue{ID: "a", Message: "todo"}}})
+	api.AssertCalled(t, "PublishWebSocketEvent", ListChangeAdded, map[string]interface{}{
+		"list":     "my",
+		"issue_id": "a",
+		"issue":    `{"id":"a","message":"todo","create_at":0,"post_id":"","user":"","user_id":"","list":"","position":0}`,
+	}, broadcast)
+
+	p.sendRefreshEvent("user1")
+	api.AssertNotCalled(t, "PublishWebSocketEvent", WSEventRefresh, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChannelSendBatchSize is how many members of a channel receive a todo sent to the channel before pausing
+	ChannelSendBatchSize = 50
+	// ChannelSendBatchDelay is the pause between the batches of a todo sent to a channel, so that sending to a large
+	// channel does not overwhelm the server with list updates and bot DMs
+	ChannelSendBatchDelay = time.Second
+
+	channelMembersPerPage = 200
+)
+
+// getChannelRecipients returns the active members of channelID other than senderID, who receive a todo sent to
+// the channel
+func (p *Plugin) getChannelRecipients(channelID, senderID string) ([]*model.User, error) {
+	recipients := []*model.User{}
+	for page := 0; ; page++ {
+		users, appErr := p.API.GetUsersInChannel(channelID, model.CHANNEL_SORT_BY_USERNAME, page, channelMembersPerPage)
+		if appErr != nil {
+			return nil, errors.New(appErr.Error())
+		}
+
+		for _, user := range users {
+			if user.Id != senderID && user.DeleteAt == 0 && !user.IsBot {
+				recipients = append(recipients, user)
+			}
+		}
+
+		if len(users) < channelMembersPerPage {
+			return recipients, nil
+		}
+	}
+}
+
+// sendToChannel sends a todo on behalf of senderID to each of the recipients, members of channel, pausing for delay
+// after every ChannelSendBatchSize of them. The sender gets a DM once they all received it, listing the members it
+// could not be sent to.
+func (p *Plugin) sendToChannel(senderID string, channel *model.Channel, recipients []*model.User, message string, dueAt int64, delay time.Duration) {
+	sent := 0
+	failures := []string{}
+	for i, receiver := range recipients {
+		if i > 0 && i%ChannelSendBatchSize == 0 {
+			p.sendRefreshEvent(senderID)
+			time.Sleep(delay)
+		}
+
+		if err := p.checkSendPolicy(senderID, receiver, 1); err != nil {
+			failures = append(failures, "* "+p.localize(senderID, msgNotifyChannelFailure, map[string]interface{}{"User": receiver.Username, "Error": p.localizeError(senderID, err)}))
+			continue
+		}
+
+		receiverIssueID, err := p.listManager.SendIssue(senderID, receiver.Id, message, "", dueAt)
+		if err != nil {
+			p.API.LogError("cannot send issue to channel member, Err=", err.Error())
+			failures = append(failures, "* "+p.localize(senderID, msgNotifyChannelUnknownFailure, map[string]interface{}{"User": receiver.Username}))
+			continue
+		}
+
+		sent++
+		p.notifySend(senderID, receiver.Id, message, receiverIssueID, dueAt)
+	}
+
+	p.sendRefreshEvent(senderID)
+	p.refundRate(senderID, rateActionSend, len(recipients)-sent)
+
+	summary := p.localize(senderID, msgNotifyChannelSent, map[string]interface{}{
+		"Count":   sent,
+		"Channel": channel.Name,
+		"Todo":    message,
+	})
+	if len(failures) > 0 {
+		summary += "\n\n" + p.localize(senderID, msgNotifyChannelFailed, map[string]interface{}{"Failures": strings.Join(failures, "\n")})
+	}
+
+	if err := p.PostBotDM(senderID, summary); err != nil {
+		p.API.LogError("cannot post channel send summary, Err=", err.Error())
+	}
+}
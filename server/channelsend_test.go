@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChannelRecipients(t *testing.T) {
+	firstPage := []*model.User{{Id: "sender"}, {Id: "bot", IsBot: true}, {Id: "deactivated", DeleteAt: 1}}
+	for i := len(firstPage); i < channelMembersPerPage; i++ {
+		firstPage = append(firstPage, &model.User{Id: fmt.Sprintf("user%d", i)})
+	}
+
+	api := &plugintest.API{}
+	api.On("GetUsersInChannel", "channel1", model.CHANNEL_SORT_BY_USERNAME, 0, channelMembersPerPage).Return(firstPage, nil)
+	api.On("GetUsersInChannel", "channel1", model.CHANNEL_SORT_BY_USERNAME, 1, channelMembersPerPage).Return([]*model.User{{Id: "last"}}, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	recipients, err := p.getChannelRecipients("channel1", "sender")
+	require.NoError(t, err)
+	assert.Len(t, recipients, channelMembersPerPage-3+1)
+	assert.Equal(t, "user3", recipients[0].Id)
+	assert.Equal(t, "last", recipients[len(recipients)-1].Id)
+}
+
+func TestSendToChannelReportsFailures(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("PublishWebSocketEvent", WSEventRefresh, mock.Anything, mock.Anything).Return()
+	api.On("GetDirectChannel", "sender", "bot").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("GetUserStatus", "sender").Return(&model.Status{Status: model.STATUS_ONLINE}, nil)
+	var summary string
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(nil, nil).Run(func(args mock.Arguments) {
+		summary = args.Get(0).(*model.Post).Message
+	})
+
+	p := &Plugin{BotUserID: "bot"}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{SendPolicy: SendPolicyDisabled})
+
+	recipients := []*model.User{{Id: "user1", Username: "alice"}, {Id: "user2", Username: "bob"}}
+	p.sendToChannel("sender", &model.Channel{Name: "town-square"}, recipients, "Fill out the survey", 0, 0)
+
+	assert.Contains(t, summary, "Your Todo was sent to 0 members of ~town-square: Fill out the survey")
+	assert.Contains(t, summary, "* @alice: "+errSendDisabled.Error())
+	assert.Contains(t, summary, "* @bob: "+errSendDisabled.Error())
+}
@@ -1,52 +1,36 @@
 package main
 
 import (
-	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 )
 
-func getHelp() string {
-	return `Available Commands:
+// ListPageSize is the number of issues shown at once by the commands
+const ListPageSize = 20
 
-add [message]
-	Adds a Todo.
+// messageFlagRegexp matches the --message flag, with the message either quoted or as the rest of the arguments
+var messageFlagRegexp = regexp.MustCompile(`\s*--message\s+(?:"([^"]*)"|'([^']*)'|(.*))`)
 
-	example: /todo add Don't forget to be awesome
-
-list
-	Lists your Todo issues.
-
-list [listName]
-	List your issues in certain list
-
-	example: /todo list in
-	example: /todo list out
-	example (same as /todo list): /todo list my
-
-pop
-	Removes the Todo issue at the top of the list.
-
-send [user] [message]
-	Sends some user a Todo
-
-	example: /todo send @awesomePerson Don't forget to be awesome
-
-help
-	Display usage.
-`
+// getHelp returns the usage of the commands in the language of userID
+func (p *Plugin) getHelp(userID string) string {
+	return p.localize(userID, msgHelp, map[string]interface{}{"PageSize": ListPageSize})
 }
 
-func getCommand() *model.Command {
+func getCommand(T translateFunc) *model.Command {
 	return &model.Command{
 		Trigger:          "todo",
 		DisplayName:      "Todo Bot",
-		Description:      "Interact with your Todo list.",
+		Description:      T(msgCommandDescription, nil),
 		AutoComplete:     true,
-		AutoCompleteDesc: "Available commands: add, list, pop",
+		AutoCompleteDesc: T(msgCommandAutocompleteDesc, nil),
 		AutoCompleteHint: "[command]",
+		AutocompleteData: getAutocompleteData(T),
 	}
 }
 
@@ -66,10 +50,11 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	restOfArgs := []string{}
 
 	var handler func([]string, *model.CommandArgs) (*model.CommandResponse, bool, error)
+	command := "list"
 	if lengthOfArgs == 1 {
 		handler = p.runListCommand
 	} else {
-		command := stringArgs[1]
+		command = stringArgs[1]
 		if lengthOfArgs > 2 {
 			restOfArgs = stringArgs[2:]
 		}
@@ -78,29 +63,111 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 			handler = p.runAddCommand
 		case "list":
 			handler = p.runListCommand
+		case "search":
+			handler = p.runSearchCommand
 		case "pop":
 			handler = p.runPopCommand
+		case "done":
+			handler = p.runDoneCommand
+		case "restore":
+			handler = p.runRestoreCommand
+		case "move":
+			handler = p.runMoveCommand
+		case "edit":
+			handler = p.runEditCommand
+		case "rm":
+			handler = p.runRemoveCommand
+		case "note":
+			handler = p.runNoteCommand
+		case "sub":
+			handler = p.runSubtaskCommand
 		case "send":
 			handler = p.runSendCommand
+		case "scheduled":
+			handler = p.runScheduledCommand
+		case "channel":
+			handler = p.runChannelCommand
+		case "accept":
+			handler = p.runAcceptCommand
+		case "decline":
+			handler = p.runDeclineCommand
+		case "forward":
+			handler = p.runForwardCommand
+		case "watch":
+			handler = p.runWatchCommand
+		case "unwatch":
+			handler = p.runUnwatchCommand
+		case "nudge":
+			handler = p.runNudgeCommand
+		case "start":
+			handler = p.runStartCommand
+		case "attach":
+			handler = p.runAttachCommand
+		case "approve":
+			handler = func(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+				return p.runResolveProposalCommand(args, extra, true)
+			}
+		case "reject":
+			handler = func(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+				return p.runResolveProposalCommand(args, extra, false)
+			}
+		case "stats":
+			handler = p.runStatsCommand
+		case "template":
+			handler = p.runTemplateCommand
+		case "undo":
+			handler = p.runUndoCommand
+		case "digest":
+			handler = p.runDigestCommand
+		case "settings":
+			handler = p.runSettingsCommand
+		case "calendar":
+			handler = p.runCalendarCommand
+		case "jira":
+			handler = p.runJiraCommand
+		case "token":
+			handler = p.runTokenCommand
+		case "export":
+			handler = p.runExportCommand
+		case "import":
+			handler = p.runImportCommand
+		case "purge":
+			handler = p.runPurgeCommand
+		case "audit":
+			handler = p.runAuditCommand
+		case "migrate":
+			handler = p.runMigrateCommand
+		case "share":
+			handler = p.runShareCommand
+		case "unshare":
+			handler = p.runUnshareCommand
 		default:
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getHelp()), nil
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.getHelp(args.UserId)), nil
 		}
 	}
+	start := time.Now()
 	resp, isUserError, err := handler(restOfArgs, args)
+	p.metrics.observeCommand(command, start, isUserError, err)
 	if err != nil {
 		if isUserError {
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, fmt.Sprintf("__Error: %s__\n\nRun `/todo help` for usage instructions.", err.Error())), nil
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.localize(args.UserId, msgCommandError, map[string]interface{}{"Error": p.localizeError(args.UserId, err)})), nil
 		}
 		p.API.LogError(err.Error())
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "An unknown error occurred. Please talk to your system administrator for help."), nil
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.localize(args.UserId, msgCommandUnknownError, nil)), nil
 	}
 
 	return resp, nil
 }
 
 func (p *Plugin) runSendCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
 	if len(args) < 2 {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "You must specify a user and a message.\n"+getHelp()), false, nil
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageSend, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	if args[0][0] == '~' {
+		return p.runSendChannelCommand(args, extra)
 	}
 
 	userName := args[0]
@@ -109,90 +176,271 @@ func (p *Plugin) runSendCommand(args []string, extra *model.CommandArgs) (*model
 	}
 	receiver, appErr := p.API.GetUserByUsername(userName)
 	if appErr != nil {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Please, provide a valid user.\n"+getHelp()), false, nil
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageInvalidUser, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
 	}
 
+	text, deliverAt, scheduled := extractAtFlag(strings.Join(args[1:], " "))
+
 	if receiver.Id == extra.UserId {
+		if scheduled {
+			return nil, true, newLocalizedError(msgErrScheduleSelf, nil)
+		}
 		return p.runAddCommand(args[1:], extra)
 	}
 
-	message := strings.Join(args[1:], " ")
+	location := p.getUserLocation(extra.UserId)
+	message, dueAt, err := extractDueDate(text, time.Now().In(location))
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkSendAllowed(extra.UserId, receiver, 1); err != nil {
+		return nil, true, err
+	}
+
+	if scheduled {
+		send, err := p.scheduleSend(extra.UserId, receiver, message, deliverAt, dueAt)
+		if err != nil {
+			p.refundRate(extra.UserId, rateActionSend, 1)
+			return nil, true, err
+		}
+		responseMessage := T(msgResponseScheduled, map[string]interface{}{"User": userName, "Date": formatDueDate(send.DeliverAt, location)}) + dueDateConfirmation(T, dueAt, location)
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
 
-	receiverIssueID, err := p.listManager.SendIssue(extra.UserId, receiver.Id, message, "")
+	receiverIssueID, err := p.listManager.SendIssue(extra.UserId, receiver.Id, message, "", dueAt)
 	if err != nil {
+		p.refundRate(extra.UserId, rateActionSend, 1)
 		return nil, false, err
 	}
 
 	p.sendRefreshEvent(extra.UserId)
-	p.sendRefreshEvent(receiver.Id)
+	p.notifySend(extra.UserId, receiver.Id, message, receiverIssueID, dueAt)
+
+	responseMessage := T(msgResponseSent, map[string]interface{}{"User": userName}) + dueDateConfirmation(T, dueAt, location)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+// runSendChannelCommand sends a todo to every member of the channel in args[0]. Members receive it in the background,
+// and the sender is told once it is done.
+func (p *Plugin) runSendChannelCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	channelName := args[0][1:]
+	channel, appErr := p.API.GetChannelByName(extra.TeamId, channelName, false)
+	if appErr != nil || !p.API.HasPermissionToChannel(extra.UserId, channel.Id, model.PERMISSION_READ_CHANNEL) {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageInvalidChannel, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	if p.getConfiguration().SendPolicy == SendPolicyDisabled {
+		return nil, true, errSendDisabled
+	}
+
+	if _, _, scheduled := extractAtFlag(strings.Join(args[1:], " ")); scheduled {
+		return nil, true, newLocalizedError(msgErrScheduleChannel, nil)
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	message, dueAt, err := extractDueDate(strings.Join(args[1:], " "), time.Now().In(location))
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		return nil, true, err
+	}
+
+	recipients, err := p.getChannelRecipients(channel.Id, extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(recipients) == 0 {
+		return nil, true, newLocalizedError(msgErrChannelNoMembers, map[string]interface{}{"Channel": channelName})
+	}
 
-	responseMessage := fmt.Sprintf("Todo sent to @%s.", userName)
+	if err = p.checkRate(extra.UserId, rateActionSend, len(recipients)); err != nil {
+		return nil, true, err
+	}
 
-	senderName := p.listManager.GetUserName(extra.UserId)
+	go p.sendToChannel(extra.UserId, channel, recipients, message, dueAt, ChannelSendBatchDelay)
 
-	receiverMessage := fmt.Sprintf("You have received a new Todo from @%s", senderName)
+	responseMessage := T(msgResponseSendingChannel, map[string]interface{}{"Count": len(recipients), "Channel": channelName}) + dueDateConfirmation(T, dueAt, location)
 
-	p.PostBotCustomDM(receiver.Id, receiverMessage, message, receiverIssueID)
 	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
 }
 
 func (p *Plugin) runAddCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
-	message := strings.Join(args, " ")
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 && extra.TriggerId != "" {
+		if err := p.openAddDialog(extra.UserId, extra.TriggerId); err != nil {
+			return nil, false, err
+		}
+		return &model.CommandResponse{}, false, nil
+	}
+
+	references, args, err := parseFileFlags(args)
+	if err != nil {
+		return nil, true, err
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	message, dueAt, err := extractDueDate(strings.Join(args, " "), time.Now().In(location))
+	if err != nil {
+		return nil, true, err
+	}
 
 	if message == "" {
-		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, "Please add a task."), false, nil
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageAdd, nil)), false, nil
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		return nil, true, err
+	}
+
+	files, err := p.getAttachments(extra.UserId, references)
+	if err != nil {
+		return nil, true, err
+	}
+
+	link, rest, isGitHub := parseGitHubURL(message)
+	if isGitHub {
+		if err = p.fetchGitHubLink(link); err != nil {
+			return nil, true, newLocalizedError(msgErrGitHubFetch, map[string]interface{}{"Kind": link.kind(), "Error": err})
+		}
+	}
+
+	if err = p.checkTodoLimit(extra.UserId, 1); err != nil {
+		return nil, true, err
 	}
 
-	if err := p.listManager.AddIssue(extra.UserId, message, ""); err != nil {
+	var issue *Issue
+	if isGitHub {
+		issue, err = p.addGitHubIssue(extra.UserId, link, rest, dueAt)
+	} else {
+		issue, err = p.listManager.AddIssue(extra.UserId, message, "", dueAt)
+	}
+	if err != nil {
+		p.refundRate(extra.UserId, rateActionAdd, 1)
 		return nil, false, err
 	}
 
+	if len(files) > 0 {
+		if _, err = p.listManager.AttachFiles(extra.UserId, issue.ID, files); err != nil {
+			return nil, false, err
+		}
+	}
+
 	p.sendRefreshEvent(extra.UserId)
 
-	responseMessage := "Added Todo."
+	responseMessage := T(msgResponseAdded, nil) + dueDateConfirmation(T, dueAt, location)
 
-	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
 	if err != nil {
 		p.API.LogError(err.Error())
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
 	}
+	p.setLinks(issues)
 
-	responseMessage += "Todo List:\n\n"
-	responseMessage += issuesListToString(issues)
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, location)
 
 	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
 }
 
 func (p *Plugin) runListCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
-	listID := MyListKey
-	responseMessage := "Todo List:\n\n"
+	T := p.translator(extra.UserId)
+
+	inProgress := false
+	for i, arg := range args {
+		if arg == "--in-progress" {
+			inProgress = true
+			args = append(args[:i:i], args[i+1:]...)
+			break
+		}
+	}
+
+	if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+		return p.runListUserCommand(args, extra)
+	}
 
+	listID := p.defaultListID(extra.UserId)
 	if len(args) > 0 {
-		switch args[0] {
-		case "my":
-		case "in":
-			listID = InListKey
-			responseMessage = "Received Todo list:\n\n"
-		case "out":
-			listID = OutListKey
-			responseMessage = "Sent Todo list:\n\n"
-		default:
-			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, getHelp()), true, nil
+		if _, err := strconv.Atoi(args[0]); err != nil {
+			var ok bool
+			listID, ok = parseListName(args[0])
+			if !ok {
+				return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.getHelp(extra.UserId)), true, nil
+			}
+			args = args[1:]
+		}
+	}
+
+	responseMessage := listTitle(T, listID)
+
+	if inProgress {
+		issues, err := p.listManager.GetIssueList(extra.UserId, listID)
+		if err != nil {
+			return nil, false, err
+		}
+		p.sendRefreshEvent(extra.UserId)
+		p.setLinks(issues)
+
+		responseMessage += inProgressToString(T, issues, p.getUserLocation(extra.UserId))
+
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	page := 0
+	if len(args) > 0 {
+		pageNumber, err := parsePosition(args[0])
+		if err != nil {
+			return nil, true, newLocalizedError(msgErrInvalidPage, map[string]interface{}{"Value": args[0]})
 		}
+		page = pageNumber - 1
 	}
 
-	issues, err := p.listManager.GetIssueList(extra.UserId, listID)
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, listID, page, ListPageSize)
 	if err != nil {
 		return nil, false, err
 	}
 	p.sendRefreshEvent(extra.UserId)
 
-	responseMessage += issuesListToString(issues)
+	if total > 0 && len(issues) == 0 {
+		return nil, true, newLocalizedError(msgErrNoPage, map[string]interface{}{"Page": page + 1})
+	}
+	p.setLinks(issues)
+
+	responseMessage += issuesPageToString(T, issues, page, ListPageSize, total, p.getUserLocation(extra.UserId))
 
 	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
 }
 
+func (p *Plugin) runSearchCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageSearch, nil)), false, nil
+	}
+
+	results, err := p.listManager.SearchIssues(extra.UserId, query)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, searchResultsToString(T, query, results, p.getUserLocation(extra.UserId))), false, nil
+}
+
 func (p *Plugin) runPopCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
 	issue, err := p.listManager.PopIssue(extra.UserId)
 	if err != nil {
 		return nil, false, err
@@ -201,26 +449,1111 @@ func (p *Plugin) runPopCommand(args []string, extra *model.CommandArgs) (*model.
 	userName := p.listManager.GetUserName(extra.UserId)
 
 	if issue.ForeignUser != "" {
-		message := fmt.Sprintf("@%s popped a Todo you sent: %s", userName, issue.Message)
-		p.sendRefreshEvent(issue.ForeignUser)
-		p.PostBotDM(issue.ForeignUser, message)
+		message := p.localize(issue.ForeignUserID, msgNotifyPopped, map[string]interface{}{"User": userName, "Todo": issue.Message})
+		p.sendRefreshEvent(issue.ForeignUserID)
+		p.PostBotDM(issue.ForeignUserID, message)
 	}
 
 	p.sendRefreshEvent(extra.UserId)
 
-	responseMessage := "Removed top Todo."
+	responseMessage := T(msgResponsePopped, nil)
 
-	replyMessage := fmt.Sprintf("@%s popped a todo attached to this thread", userName)
+	replyMessage := p.localizeServer(msgReplyPopped, map[string]interface{}{"User": userName})
 	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
 
-	issues, err := p.listManager.GetIssueList(extra.UserId, MyListKey)
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runDoneCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageDone, nil)), false, nil
+	}
+
+	if isBulkArg(args[0]) {
+		return p.runBulkCommand(BulkActionComplete, MyListKey, args[0], extra)
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	issue, err := p.listManager.CompleteIssue(extra.UserId, target.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyCompletion(extra.UserId, issue)
+
+	responseMessage := T(msgResponseCompleted, map[string]interface{}{"Number": position})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runRestoreCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageRestore, nil)), false, nil
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, DoneListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkTodoLimit(extra.UserId, 1); err != nil {
+		return nil, true, err
+	}
+
+	if _, err = p.listManager.RestoreIssue(extra.UserId, target.ID); err != nil {
+		p.refundRate(extra.UserId, rateActionAdd, 1)
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+
+	responseMessage := T(msgResponseRestored, map[string]interface{}{"Number": position})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runRemoveCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) > 0 && isBulkArg(args[len(args)-1]) {
+		listID := MyListKey
+		if len(args) == 2 {
+			var ok bool
+			if listID, ok = parseListName(args[0]); !ok {
+				return nil, true, newLocalizedError(msgErrInvalidList, map[string]interface{}{"Value": args[0]})
+			}
+		} else if len(args) > 2 {
+			return nil, true, newLocalizedError(msgErrUnexpectedAfterNumbers, map[string]interface{}{"Args": strings.Join(args[2:], " ")})
+		}
+		return p.runBulkCommand(BulkActionRemove, listID, args[len(args)-1], extra)
+	}
+
+	listID, position, err := parseListAndPosition(args)
+	if err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, listID, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	issue, isSender, err := p.listManager.RemoveIssue(extra.UserId, target.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyRemoval(extra.UserId, issue, isSender)
+
+	responseMessage := T(msgResponseRemoved, map[string]interface{}{"Number": position})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, listID, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, listID)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runMoveCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) != 2 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageMove, nil)), false, nil
+	}
+
+	from, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	to, err := parsePosition(args[1])
+	if err != nil {
+		return nil, true, err
+	}
+
+	issue, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, from)
+	if err != nil {
+		return nil, true, err
+	}
+
+	// An empty page only loads the size of the list
+	_, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	if to > total {
+		return nil, true, newLocalizedError(msgErrNoTodoNumber, map[string]interface{}{"Number": to})
+	}
+
+	if err = p.listManager.MoveIssue(extra.UserId, MyListKey, issue.ID, to); err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+
+	responseMessage := T(msgResponseMoved, map[string]interface{}{"From": from, "To": to})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runEditCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	listID, position, rest, err := splitListAndPosition(args)
+	if err != nil {
+		return nil, true, err
+	}
+
+	message := strings.Join(rest, " ")
+	if message == "" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageEdit, nil)), false, nil
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, listID, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	oldMessage, foreignUserID, isSender, err := p.listManager.EditIssue(extra.UserId, target.ID, message)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyEdit(extra.UserId, foreignUserID, target.ID, isSender, oldMessage, message)
+
+	responseMessage := T(msgResponseEdited, map[string]interface{}{"Number": position})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, listID, 0, ListPageSize)
 	if err != nil {
 		p.API.LogError(err.Error())
 		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
 	}
 
-	responseMessage += "Todo List:\n\n"
-	responseMessage += issuesListToString(issues)
+	responseMessage += listTitle(T, listID)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
 
 	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
 }
+
+func (p *Plugin) runChannelCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageChannel, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	if p.getConfiguration().DisableChannelLists {
+		return nil, true, errChannelListsDisabled
+	}
+
+	if !p.isChannelMember(extra.ChannelId, extra.UserId) {
+		return nil, true, newLocalizedError(msgErrNotChannelMember, nil)
+	}
+
+	switch args[0] {
+	case "add":
+		return p.runChannelAddCommand(args[1:], extra)
+	case "list":
+		return p.runChannelListCommand(args[1:], extra)
+	case "claim":
+		return p.runChannelClaimCommand(args[1:], extra)
+	}
+
+	return nil, true, newLocalizedError(msgErrInvalidChannelOption, map[string]interface{}{"Value": args[0]})
+}
+
+func (p *Plugin) runChannelAddCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	location := p.getUserLocation(extra.UserId)
+	message, dueAt, err := extractDueDate(strings.Join(args, " "), time.Now().In(location))
+	if err != nil {
+		return nil, true, err
+	}
+
+	if message == "" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageAdd, nil)), false, nil
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkRate(extra.UserId, rateActionAdd, 1); err != nil {
+		return nil, true, err
+	}
+
+	if _, err = p.listManager.AddChannelIssue(extra.ChannelId, extra.UserId, message, "", dueAt); err != nil {
+		p.refundRate(extra.UserId, rateActionAdd, 1)
+		return nil, false, err
+	}
+
+	p.sendChannelRefreshEvent(extra.ChannelId)
+
+	responseMessage := T(msgResponseChannelAdded, nil) + dueDateConfirmation(T, dueAt, location)
+	return p.channelListResponse(T, responseMessage, extra.ChannelId, location), false, nil
+}
+
+func (p *Plugin) runChannelListCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	return p.channelListResponse(p.translator(extra.UserId), "", extra.ChannelId, p.getUserLocation(extra.UserId)), false, nil
+}
+
+func (p *Plugin) runChannelClaimCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageClaim, nil)), false, nil
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.ChannelId, ChannelListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkTodoLimit(extra.UserId, 1); err != nil {
+		return nil, true, err
+	}
+
+	issue, err := p.listManager.ClaimChannelIssue(extra.ChannelId, extra.UserId, target.ID)
+	if err != nil {
+		p.refundRate(extra.UserId, rateActionAdd, 1)
+	}
+	if err == errIssueNotFound {
+		return nil, true, newLocalizedError(msgErrClaimedFirst, nil)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.sendChannelRefreshEvent(extra.ChannelId)
+	p.notifyClaim(extra.ChannelId, extra.UserId, issue)
+
+	responseMessage := T(msgResponseClaimed, map[string]interface{}{"Number": position})
+	return p.channelListResponse(T, responseMessage, extra.ChannelId, p.getUserLocation(extra.UserId)), false, nil
+}
+
+// channelListResponse responds with the message followed by the shared list of channelID, translated with T
+func (p *Plugin) channelListResponse(T translateFunc, responseMessage, channelID string, location *time.Location) *model.CommandResponse {
+	issues, err := p.listManager.GetChannelIssueList(channelID)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage)
+	}
+
+	responseMessage += T(msgListTitleChannel, nil) + "\n\n"
+	responseMessage += issuesListToString(T, issues, location)
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage)
+}
+
+func (p *Plugin) runAcceptCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) < 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageAccept, nil)), false, nil
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	proposal, err := parseProposal(strings.Join(args[1:], " "), time.Now().In(location))
+	if err != nil {
+		return nil, true, err
+	}
+	if proposal != nil && proposal.Message != "" {
+		if err = p.checkMessage(proposal.Message); err != nil {
+			return nil, true, err
+		}
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, InListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	todoMessage, sender, err := p.listManager.AcceptIssue(extra.UserId, target.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	responseMessage := T(msgResponseAccepted, map[string]interface{}{"Number": position})
+
+	if proposal == nil {
+		p.notifyAccept(extra.UserId, sender, target.ID, todoMessage)
+	} else {
+		proposal.UserID = extra.UserId
+		proposal.CreateAt = model.GetMillis()
+
+		issue, err := p.listManager.ProposeChange(extra.UserId, target.ID, proposal)
+		if err != nil {
+			p.API.LogError("Unable to propose change err=" + err.Error())
+			responseMessage += " " + T(msgResponseProposalFailed, nil)
+		} else {
+			p.notifyProposal(extra.UserId, issue, proposal)
+			responseMessage += " " + T(msgResponseProposalSent, nil)
+		}
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runAttachCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	listID, position, rest, err := splitListAndPosition(args)
+	if err != nil {
+		return nil, true, err
+	}
+
+	postID := extra.RootId
+	if len(rest) > 0 {
+		postID = rest[0][strings.LastIndex(rest[0], "/")+1:]
+	}
+	if postID == "" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageAttach, nil)), false, nil
+	}
+
+	if !p.canReadPost(extra.UserId, postID) {
+		return nil, true, newLocalizedError(msgErrPostNotFound, map[string]interface{}{"Post": postID})
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, listID, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	issue, err := p.listManager.AttachIssue(extra.UserId, target.ID, postID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	if issue.ForeignUserID != "" {
+		p.sendRefreshEvent(issue.ForeignUserID)
+	}
+
+	replyMessage := p.localizeServer(msgReplyAttached, map[string]interface{}{"User": p.listManager.GetUserName(extra.UserId)})
+	p.postReplyIfNeeded(postID, replyMessage, issue.Message)
+
+	responseMessage := T(msgResponseAttached, map[string]interface{}{"Number": position})
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runStartCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	position := 1
+	if len(args) > 0 {
+		var err error
+		if position, err = parsePosition(args[0]); err != nil {
+			return nil, true, err
+		}
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	issue, err := p.listManager.StartIssue(extra.UserId, target.ID)
+	if err == errIssueAlreadyStarted || err == errInvalidStart {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyStart(extra.UserId, issue)
+
+	responseMessage := T(msgResponseStarted, map[string]interface{}{"Number": position})
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+// runResolveProposalCommand approves or rejects the change proposed by the receiver of the todo at the position of
+// the sent list
+func (p *Plugin) runResolveProposalCommand(args []string, extra *model.CommandArgs, approve bool) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageResolveProposal, nil)), false, nil
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, OutListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	issue, _, err := p.listManager.ResolveProposal(extra.UserId, target.ID, approve)
+	if err == errNoProposal {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+
+	msg, response := msgNotifyProposalRejected, msgResponseProposalRejected
+	if approve {
+		msg, response = msgNotifyProposalApproved, msgResponseProposalApproved
+	}
+	responseMessage := T(response, map[string]interface{}{"Number": position})
+
+	userName := p.listManager.GetUserName(extra.UserId)
+	p.sendRefreshEvent(issue.ForeignUserID)
+	p.PostBotDM(issue.ForeignUserID, p.localize(issue.ForeignUserID, msg, map[string]interface{}{"User": userName, "Todo": issue.Message}))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runForwardCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	listID := InListKey
+	if len(args) > 0 {
+		if id, ok := parseListName(args[0]); ok {
+			listID = id
+			args = args[1:]
+		}
+	}
+
+	if len(args) < 2 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageForward, nil)), false, nil
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	receiver, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[1], "@"))
+	if appErr != nil {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageInvalidUser, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	if receiver.DeleteAt != 0 || receiver.IsBot {
+		return nil, true, newLocalizedError(msgErrForwardInactive, nil)
+	}
+
+	note := strings.Join(args[2:], " ")
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, listID, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if err = p.checkSendAllowed(extra.UserId, receiver, 1); err != nil {
+		return nil, true, err
+	}
+
+	todoMessage, sender, err := p.listManager.ForwardIssue(extra.UserId, target.ID, receiver.Id, note)
+	if err != nil {
+		p.refundRate(extra.UserId, rateActionSend, 1)
+	}
+	if err == errIssueNotReceived || err == errInvalidForward {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyForward(extra.UserId, sender, receiver.Id, target.ID, todoMessage, note)
+
+	responseMessage := T(msgResponseForwarded, map[string]interface{}{"Number": position, "User": receiver.Username})
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runNudgeCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	position := 1
+	if len(args) > 0 {
+		var err error
+		if position, err = parsePosition(args[0]); err != nil {
+			return nil, true, err
+		}
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, OutListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if target.ForeignUserID == "" {
+		return nil, true, newLocalizedError(msgErrNudgeNotSent, nil)
+	}
+
+	if isDeclined(&target.Issue) {
+		return nil, true, newLocalizedError(msgErrNudgeDeclined, map[string]interface{}{"User": target.ForeignUser})
+	}
+
+	ok, err := p.claimNudge(target.ID, NudgeCooldown)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, true, newLocalizedError(msgErrAlreadyNudged, map[string]interface{}{"User": target.ForeignUser, "Hours": int(NudgeCooldown / time.Hour)})
+	}
+
+	if err = p.notifyNudge(extra.UserId, target.ForeignUserID, target.Message, target.CreateAt); err != nil {
+		return nil, false, err
+	}
+
+	responseMessage := T(msgResponseNudged, map[string]interface{}{"User": target.ForeignUser, "Number": position})
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runDeclineCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) < 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageDecline, nil)), false, nil
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	reason := strings.Join(args[1:], " ")
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, InListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	todoMessage, sender, err := p.listManager.DeclineIssue(extra.UserId, target.ID, reason)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyDecline(extra.UserId, sender, todoMessage, reason)
+
+	responseMessage := T(msgResponseDeclined, map[string]interface{}{"Number": position})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, InListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, InListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+// listTitles are the titles of the lists in the command responses, by list key
+var listTitles = map[string]*message{
+	MyListKey:   msgListTitleMy,
+	InListKey:   msgListTitleIn,
+	OutListKey:  msgListTitleOut,
+	DoneListKey: msgListTitleDone,
+}
+
+// listTitle returns the title of the list listID, followed by the blank line before its issues
+func listTitle(T translateFunc, listID string) string {
+	return T(listTitles[listID], nil) + "\n\n"
+}
+
+// parseListName translates a list name used in commands into its list key
+func parseListName(name string) (string, bool) {
+	switch name {
+	case "my":
+		return MyListKey, true
+	case "in":
+		return InListKey, true
+	case "out":
+		return OutListKey, true
+	case "done":
+		return DoneListKey, true
+	}
+	return "", false
+}
+
+// parseProposal parses the --message and --due flags of the accept command into the change proposed to the sender,
+// or returns nil if there are none. now must be in the user's timezone.
+func parseProposal(text string, now time.Time) (*Proposal, error) {
+	text, dueAt, err := extractDueDate(text, now)
+	if err != nil {
+		return nil, err
+	}
+
+	message := ""
+	if match := messageFlagRegexp.FindStringSubmatchIndex(text); match != nil {
+		for i := 2; i < len(match); i += 2 {
+			if match[i] >= 0 {
+				message = strings.TrimSpace(text[match[i]:match[i+1]])
+				break
+			}
+		}
+		text = text[:match[0]] + text[match[1]:]
+	}
+
+	if strings.TrimSpace(text) != "" {
+		return nil, newLocalizedError(msgErrUnexpectedProposalArgs, map[string]interface{}{"Args": strings.TrimSpace(text)})
+	}
+
+	if message == "" && dueAt == 0 {
+		return nil, nil
+	}
+	return &Proposal{Message: message, DueAt: dueAt}, nil
+}
+
+// parsePosition parses a 1-based list position as shown by issuesListToString
+func parsePosition(arg string) (int, error) {
+	position, err := strconv.Atoi(arg)
+	if err != nil || position < 1 {
+		return 0, newLocalizedError(msgErrInvalidTodoNumber, map[string]interface{}{"Value": arg})
+	}
+	return position, nil
+}
+
+// parseListAndPosition parses arguments in the form "[listName] [number]", where the list defaults to the user's own list
+func parseListAndPosition(args []string) (string, int, error) {
+	listID, position, rest, err := splitListAndPosition(args)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(rest) > 0 {
+		return "", 0, newLocalizedError(msgErrUnexpectedAfterNumber, map[string]interface{}{"Args": strings.Join(rest, " ")})
+	}
+
+	return listID, position, nil
+}
+
+// splitListAndPosition parses arguments starting with "[listName] [number]", where the list defaults to the user's own list,
+// and returns the remaining arguments
+func splitListAndPosition(args []string) (string, int, []string, error) {
+	if len(args) == 0 {
+		return "", 0, nil, newLocalizedError(msgErrTodoNumberMissing, nil)
+	}
+
+	listID := MyListKey
+	if _, err := strconv.Atoi(args[0]); err != nil {
+		var ok bool
+		listID, ok = parseListName(args[0])
+		if !ok {
+			return "", 0, nil, newLocalizedError(msgErrInvalidList, map[string]interface{}{"Value": args[0]})
+		}
+		args = args[1:]
+		if len(args) == 0 {
+			return "", 0, nil, newLocalizedError(msgErrTodoNumberMissing, nil)
+		}
+	}
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	return listID, position, args[1:], nil
+}
+
+func (p *Plugin) runUndoCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	entry, err := p.listManager.UndoLastAction(extra.UserId)
+	if err == errNothingToUndo || err == errUndoConflict {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	p.notifyUndo(extra.UserId, entry)
+
+	if entry.Action == JournalActionBulk {
+		responseMessage := p.localize(extra.UserId, msgResponseUndoBulk, map[string]interface{}{"Count": len(entry.Entries)})
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	var response *message
+	switch entry.Action {
+	case JournalActionComplete:
+		response = msgResponseUndoComplete
+	case JournalActionRemove:
+		response = msgResponseUndoRemove
+	case JournalActionSend:
+		response = msgResponseUndoSend
+	}
+
+	responseMessage := p.localize(extra.UserId, response, map[string]interface{}{"Todo": entry.Issue.Message})
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runDigestCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	settings, err := p.getDigestSettings(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(args) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, digestSettingsToString(T, settings)), false, nil
+	}
+
+	switch args[0] {
+	case "on":
+		if len(args) > 1 {
+			settings.Hour, settings.Minute, err = parseDigestTime(args[1])
+			if err != nil {
+				return nil, true, err
+			}
+		}
+		settings.Enabled = true
+	case "off":
+		settings.Enabled = false
+	default:
+		return nil, true, newLocalizedError(msgErrInvalidDigestOption, map[string]interface{}{"Value": args[0]})
+	}
+
+	if err := p.saveDigestSettings(extra.UserId, settings); err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, digestSettingsToString(T, settings)), false, nil
+}
+
+func (p *Plugin) runCalendarCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) > 0 {
+		if args[0] != "off" {
+			return nil, true, newLocalizedError(msgErrInvalidCalendarOption, map[string]interface{}{"Value": args[0]})
+		}
+
+		if err := p.saveCalendarToken(extra.UserId, ""); err != nil {
+			return nil, false, err
+		}
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseCalendarDisabled, nil)), false, nil
+	}
+
+	token, err := p.createCalendarToken(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	responseMessage := T(msgResponseCalendar, map[string]interface{}{"URL": p.calendarURL(token)})
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runJiraCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageJira, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	config := p.getConfiguration()
+	if config.JiraURL == "" {
+		return nil, true, newLocalizedError(msgErrJiraNotConfigured, nil)
+	}
+
+	switch args[0] {
+	case "link":
+		if len(args) != 3 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageJiraLink, nil)), false, nil
+		}
+
+		key := strings.ToUpper(args[1])
+		if !isValidJiraIssueKey(key) {
+			return nil, true, newLocalizedError(msgErrInvalidJiraKey, map[string]interface{}{"Value": args[1]})
+		}
+
+		position, err := parsePosition(args[2])
+		if err != nil {
+			return nil, true, err
+		}
+
+		issue, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, position)
+		if err != nil {
+			return nil, true, err
+		}
+
+		if err = p.addJiraLink(key, &JiraLink{UserID: extra.UserId, IssueID: issue.ID}); err != nil {
+			return nil, false, err
+		}
+
+		response := msgResponseJiraLinked
+		if config.JiraUsername != "" && config.JiraAPIToken != "" {
+			response = msgResponseJiraLinkedResolve
+		}
+		responseMessage := T(response, map[string]interface{}{"Number": position, "Link": jiraMessage(config.JiraURL, key, ""), "Key": key})
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	case "unlink":
+		if len(args) != 2 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageTodoNumber, nil)), false, nil
+		}
+
+		position, err := parsePosition(args[1])
+		if err != nil {
+			return nil, true, err
+		}
+
+		issue, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, position)
+		if err != nil {
+			return nil, true, err
+		}
+
+		key, err := p.getJiraKey(issue.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		if key == "" {
+			return nil, true, newLocalizedError(msgErrNotJiraLinked, map[string]interface{}{"Number": position})
+		}
+
+		if err = p.removeJiraLink(key, issue.ID); err != nil {
+			return nil, false, err
+		}
+
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseJiraUnlinked, map[string]interface{}{"Number": position, "Key": key})), false, nil
+	}
+
+	return nil, true, newLocalizedError(msgErrInvalidJiraOption, map[string]interface{}{"Value": args[0]})
+}
+
+func (p *Plugin) runTokenCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageToken, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	switch args[0] {
+	case "create":
+		args = args[1:]
+		scopes := defaultTokenScopes
+		if len(args) > 0 {
+			if parsed, ok := parseTokenScopes(args[len(args)-1]); ok {
+				scopes = parsed
+				args = args[:len(args)-1]
+			}
+		}
+
+		name := strings.TrimSpace(strings.Join(args, " "))
+		if utf8.RuneCountInString(name) > MaxHookNameLength {
+			return nil, true, newLocalizedError(msgErrNameTooLong, map[string]interface{}{"Max": MaxHookNameLength})
+		}
+
+		token, secret, err := p.createHookToken(extra.UserId, name, scopes)
+		if err == errTooManyHookTokens {
+			return nil, true, err
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		responseMessage := T(msgResponseTokenCreated, map[string]interface{}{"ID": token.ID, "Scopes": strings.Join(scopes, ", ")})
+		if token.hasScope(TokenScopeWrite) || token.hasScope(TokenScopeSend) {
+			responseMessage += " " + T(msgResponseTokenHook, map[string]interface{}{"URL": p.hookURL(secret)})
+		}
+		responseMessage += " " + T(msgResponseTokenAPI, map[string]interface{}{"Header": TokenHeader, "Secret": secret, "URL": p.pluginURL(APIv2Prefix + "/todos")})
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	case "list":
+		tokens, _, err := p.getHookTokens(extra.UserId)
+		if err != nil {
+			return nil, false, err
+		}
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, hookTokensToString(T, tokens, p.getUserLocation(extra.UserId))), false, nil
+	case "revoke":
+		if len(args) != 2 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageTokenRevoke, nil)), false, nil
+		}
+
+		token, err := p.removeHookToken(extra.UserId, strings.Trim(args[1], "`"))
+		if err == errHookTokenNotFound {
+			return nil, true, newLocalizedError(msgErrHookTokenNotFound, map[string]interface{}{"ID": args[1]})
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseTokenRevoked, map[string]interface{}{"ID": token.ID})), false, nil
+	}
+
+	return nil, true, newLocalizedError(msgErrInvalidTokenOption, map[string]interface{}{"Value": args[0]})
+}
+
+func (p *Plugin) runExportCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	format := ExportFormatCSV
+	if len(args) > 0 {
+		format = strings.ToLower(args[0])
+	}
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		return nil, true, newLocalizedError(msgErrInvalidFormat, map[string]interface{}{"Value": args[0]})
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	issues, err := p.exportIssues(extra.UserId, location)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, _, err := encodeExport(issues, format)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fileName := exportFileName(p.listManager.GetUserName(extra.UserId), format, time.Now().In(location))
+	message := T(msgExportMessage, map[string]interface{}{"Count": len(issues)})
+	if err := p.PostBotDMWithFile(extra.UserId, message, fileName, data); err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseExportSent, nil)), false, nil
+}
+
+func (p *Plugin) runImportCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	dryRun := false
+	postID := ""
+	for _, arg := range args {
+		switch {
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "":
+		case postID == "":
+			postID = arg[strings.LastIndex(arg, "/")+1:]
+		default:
+			return nil, true, newLocalizedError(msgErrInvalidOption, map[string]interface{}{"Value": arg})
+		}
+	}
+	if postID == "" {
+		return nil, true, newLocalizedError(msgErrImportPostMissing, nil)
+	}
+
+	if !p.canReadPost(extra.UserId, postID) {
+		return nil, true, newLocalizedError(msgErrPostNotFound, map[string]interface{}{"Post": postID})
+	}
+	post, appErr := p.API.GetPost(postID)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+	if len(post.FileIds) == 0 {
+		return nil, true, newLocalizedError(msgErrPostWithoutFile, nil)
+	}
+
+	fileInfo, appErr := p.API.GetFileInfo(post.FileIds[0])
+	if appErr != nil {
+		return nil, false, appErr
+	}
+	if fileInfo.Size > MaxImportFileSize {
+		return nil, true, newLocalizedError(msgErrFileTooLarge, map[string]interface{}{"Max": MaxImportFileSize / 1024 / 1024})
+	}
+
+	data, appErr := p.API.GetFile(fileInfo.Id)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+
+	result, err := p.importIssues(extra.UserId, data, dryRun)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, importResultToString(T, result)), false, nil
+}
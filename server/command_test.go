@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListAndPosition(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args             []string
+		expectedListID   string
+		expectedPosition int
+		expectError      bool
+	}{
+		"only number":     {args: []string{"3"}, expectedListID: MyListKey, expectedPosition: 3},
+		"list and number": {args: []string{"out", "1"}, expectedListID: OutListKey, expectedPosition: 1},
+		"no args":         {args: []string{}, expectError: true},
+		"invalid list":    {args: []string{"foo", "1"}, expectError: true},
+		"zero":            {args: []string{"0"}, expectError: true},
+		"not a number":    {args: []string{"first"}, expectError: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			listID, position, err := parseListAndPosition(tc.args)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedListID, listID)
+			assert.Equal(t, tc.expectedPosition, position)
+		})
+	}
+}
+
+func TestSplitListAndPosition(t *testing.T) {
+	listID, position, rest, err := splitListAndPosition([]string{"in", "2", "new", "text"})
+	assert.NoError(t, err)
+	assert.Equal(t, InListKey, listID)
+	assert.Equal(t, 2, position)
+	assert.Equal(t, []string{"new", "text"}, rest)
+
+	listID, position, rest, err = splitListAndPosition([]string{"4", "in", "the", "morning"})
+	assert.NoError(t, err)
+	assert.Equal(t, MyListKey, listID)
+	assert.Equal(t, 4, position)
+	assert.Equal(t, []string{"in", "the", "morning"}, rest)
+
+	_, _, _, err = splitListAndPosition([]string{"out"})
+	assert.Error(t, err)
+}
+
+func TestParseProposal(t *testing.T) {
+	now := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	proposal, err := parseProposal("", now)
+	require.NoError(t, err)
+	assert.Nil(t, proposal)
+
+	proposal, err = parseProposal(`--message "Will do by Friday instead"`, now)
+	require.NoError(t, err)
+	assert.Equal(t, &Proposal{Message: "Will do by Friday instead"}, proposal)
+
+	proposal, err = parseProposal(`--message 'Review the draft' --due friday`, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Review the draft", proposal.Message)
+	assert.Equal(t, toMillis(time.Date(2020, 6, 5, DefaultDueHour, 0, 0, 0, time.UTC)), proposal.DueAt)
+
+	proposal, err = parseProposal("--due tomorrow", now)
+	require.NoError(t, err)
+	assert.Empty(t, proposal.Message)
+	assert.NotZero(t, proposal.DueAt)
+
+	_, err = parseProposal("please", now)
+	assert.Error(t, err)
+}
+
+// moveListManager is a ListManager with a list of size todos that records the moves
+type moveListManager struct {
+	ListManager
+	size  int
+	moves []int
+}
+
+func (m *moveListManager) GetIssueByPosition(userID, listID string, position int) (*ExtendedIssue, error) {
+	if position > m.size {
+		return nil, newLocalizedError(msgErrNoTodoNumber, map[string]interface{}{"Number": position})
+	}
+	return &ExtendedIssue{Issue: Issue{ID: "issue"}}, nil
+}
+
+func (m *moveListManager) GetIssueListPage(userID, listID string, page, perPage int) ([]*ExtendedIssue, int, error) {
+	return []*ExtendedIssue{}, m.size, nil
+}
+
+func (m *moveListManager) MoveIssue(userID, listID, issueID string, position int) error {
+	m.moves = append(m.moves, position)
+	return nil
+}
+
+func TestRunMoveCommand(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1"}, nil)
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	listManager := &moveListManager{size: 3}
+	p.listManager = listManager
+	extra := &model.CommandArgs{UserId: "user1"}
+
+	_, isUserError, err := p.runMoveCommand([]string{"3", "1"}, extra)
+	require.NoError(t, err)
+	assert.False(t, isUserError)
+	assert.Equal(t, []int{1}, listManager.moves)
+
+	_, isUserError, err = p.runMoveCommand([]string{"1", "4"}, extra)
+	require.Error(t, err)
+	assert.True(t, isUserError)
+	assert.Equal(t, "there is no todo number 4 in the list", err.Error())
+	assert.Equal(t, []int{1}, listManager.moves)
+}
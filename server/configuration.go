@@ -1,7 +1,9 @@
 package main
 
 import (
+	"net/url"
 	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -18,6 +20,53 @@ import (
 // If you add non-reference types to your configuration struct, be sure to rewrite Clone as a deep
 // copy appropriate for your types.
 type configuration struct {
+	// MaxTodosPerUser is the maximum number of todos on the list and received list of a user, 0 for no limit
+	MaxTodosPerUser int
+	// MaxMessageLength is the maximum length in characters of a todo message, 0 for the default limit
+	MaxMessageLength int
+	// SendPolicy is who users may send todos to, one of the SendPolicy constants
+	SendPolicy string
+	// DisableChannelLists turns off the shared todo lists of the channels
+	DisableChannelLists bool
+	// MaxAddsPerMinute is how many todos a user can add to their own list in a minute, 0 for no limit
+	MaxAddsPerMinute int
+	// MaxSendsPerHour is how many todos a user can send in an hour, 0 for no limit
+	MaxSendsPerHour int
+	// AllowedPlugins are the comma separated IDs of the plugins that can add and send todos on behalf of users
+	AllowedPlugins string
+	// EscalationDays is how many days a received todo waits to be accepted before it is escalated, 0 for never
+	EscalationDays int
+	// RetentionDays is how many days completed todos are kept before they are deleted, 0 to keep them forever
+	RetentionDays int
+	// PurgeDeactivatedUsers deletes the todos and every other record of the users once they are deactivated
+	PurgeDeactivatedUsers bool
+	// EmailInactiveMinutes is how long users must be inactive to get emails about received and overdue todos, 0 for never
+	EmailInactiveMinutes int
+	// StorageEngine is where the todos are stored, one of the StorageEngine constants. It is read on activation.
+	StorageEngine string
+	// LegacyRefreshEvents sends the refresh event after every change, for the clients without the granular events
+	LegacyRefreshEvents bool
+
+	// WebhookURLs are the comma separated URLs that receive the todo lifecycle events
+	WebhookURLs string
+	// WebhookSecret is the secret used to sign the webhook payloads
+	WebhookSecret string
+
+	// JiraURL is the base URL of the Jira instance
+	JiraURL string
+	// JiraWebhookSecret is the secret Jira must send in the secret query parameter of its webhook
+	JiraWebhookSecret string
+	// JiraUsername is the user that transitions the Jira issues when their todos are completed
+	JiraUsername string
+	// JiraAPIToken is the API token or password of JiraUsername
+	JiraAPIToken string
+	// JiraDoneTransition is the name of the transition that resolves a Jira issue
+	JiraDoneTransition string
+
+	// GitHubToken is the personal access token used to read the linked GitHub issues
+	GitHubToken string
+	// GitHubCompleteClosed completes the todos of the linked GitHub issues when they are closed
+	GitHubCompleteClosed bool
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
@@ -28,9 +77,90 @@ func (c *configuration) Clone() *configuration {
 }
 
 func (c *configuration) IsValid() error {
+	if c.MaxTodosPerUser < 0 {
+		return errors.New("the maximum number of Todos per user cannot be negative")
+	}
+
+	if c.MaxAddsPerMinute < 0 || c.MaxSendsPerHour < 0 {
+		return errors.New("the rate limits cannot be negative")
+	}
+
+	if c.RetentionDays < 0 {
+		return errors.New("the days to keep completed Todos cannot be negative")
+	}
+
+	if c.EmailInactiveMinutes < 0 {
+		return errors.New("the minutes of inactivity before emailing users cannot be negative")
+	}
+
+	if c.EscalationDays < 0 {
+		return errors.New("the days before escalating received Todos cannot be negative")
+	}
+
+	if c.MaxMessageLength < 0 || c.MaxMessageLength > MaxMessageLength {
+		return errors.Errorf("the maximum message length must be between 0 and %d", MaxMessageLength)
+	}
+
+	switch c.SendPolicy {
+	case "", SendPolicyEveryone, SendPolicyTeam, SendPolicyDisabled:
+	default:
+		return errors.Errorf("%s is not a valid send policy", c.SendPolicy)
+	}
+
+	switch c.StorageEngine {
+	case "", StorageEngineKV, StorageEngineSQL:
+	default:
+		return errors.Errorf("%s is not a valid storage engine", c.StorageEngine)
+	}
+
+	for _, webhookURL := range c.getWebhookURLs() {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("%s is not a valid webhook URL", webhookURL)
+		}
+	}
+
+	if c.JiraURL != "" {
+		u, err := url.Parse(c.JiraURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("%s is not a valid Jira URL", c.JiraURL)
+		}
+	}
+
 	return nil
 }
 
+// getWebhookURLs returns the webhook URLs, ignoring the empty ones
+func (c *configuration) getWebhookURLs() []string {
+	urls := []string{}
+	for _, webhookURL := range strings.Split(c.WebhookURLs, ",") {
+		if webhookURL = strings.TrimSpace(webhookURL); webhookURL != "" {
+			urls = append(urls, webhookURL)
+		}
+	}
+	return urls
+}
+
+// retentionEnabled checks whether the system admin turned on any of the retention settings
+func (c *configuration) retentionEnabled() bool {
+	return c.RetentionDays > 0 || c.PurgeDeactivatedUsers
+}
+
+// useSQLStore checks whether the todos are stored in the SQL tables instead of the KV store
+func (c *configuration) useSQLStore() bool {
+	return c.StorageEngine == StorageEngineSQL
+}
+
+// isPluginAllowed checks whether the plugin pluginID can add and send todos on behalf of users
+func (c *configuration) isPluginAllowed(pluginID string) bool {
+	for _, allowed := range strings.Split(c.AllowedPlugins, ",") {
+		if strings.TrimSpace(allowed) == pluginID {
+			return pluginID != ""
+		}
+	}
+	return false
+}
+
 // getConfiguration retrieves the active configuration under lock, making it safe to use
 // concurrently. The active configuration may change underneath the client of this method, but
 // the struct returned by this API call is considered immutable.
@@ -81,6 +211,10 @@ func (p *Plugin) OnConfigurationChange() error {
 		return errors.Wrap(err, "failed to load plugin configuration")
 	}
 
+	if err := configuration.IsValid(); err != nil {
+		return err
+	}
+
 	p.setConfiguration(configuration)
 
 	return nil
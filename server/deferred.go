@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// MaxDeferredNotifications is the maximum number of notifications queued for a user, older ones are dropped first
+const MaxDeferredNotifications = 100
+
+// DeferredNotification is a bot DM that was not posted because its recipient was in Do Not Disturb
+type DeferredNotification struct {
+	Post     *model.Post `json:"post"`
+	CreateAt int64       `json:"create_at"`
+}
+
+// isDoNotDisturb checks whether userID set their status to Do Not Disturb
+func (p *Plugin) isDoNotDisturb(userID string) bool {
+	status, appErr := p.API.GetUserStatus(userID)
+	if appErr != nil {
+		return false
+	}
+	return status.Status == model.STATUS_DND
+}
+
+// deferNotification queues the post until userID turns Do Not Disturb off
+func (p *Plugin) deferNotification(userID string, post *model.Post) error {
+	return p.addDeferredNotification(userID, &DeferredNotification{
+		Post:     post,
+		CreateAt: model.GetMillis(),
+	})
+}
+
+// runDeferredNotificationsJob delivers the queued notifications of the users that are no longer in Do Not Disturb
+func (p *Plugin) runDeferredNotificationsJob(now time.Time) {
+	userIDs, _, err := p.getUserSet(StoreDeferredUsersKey)
+	if err != nil {
+		p.API.LogError("cannot get users with deferred notifications, Err=", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		if p.isDoNotDisturb(userID) {
+			continue
+		}
+
+		notifications, err := p.takeDeferredNotifications(userID)
+		if err != nil {
+			p.API.LogError("cannot get deferred notifications, Err=", err.Error())
+			continue
+		}
+
+		for _, notification := range notifications {
+			if _, appErr := p.API.CreatePost(notification.Post); appErr != nil {
+				p.API.LogError("cannot post deferred notification, Err=", appErr.Error())
+			}
+		}
+	}
+}
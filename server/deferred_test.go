@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBotDMDefersDuringDoNotDisturb(t *testing.T) {
+	post := &model.Post{UserId: "bot", ChannelId: "channel", Message: "You have received a new Todo"}
+
+	api := &plugintest.API{}
+	api.On("GetUserStatus", "user").Return(&model.Status{UserId: "user", Status: model.STATUS_DND}, nil)
+	api.On("KVGet", deferredKey("user")).Return(nil, nil)
+	api.On("KVGet", StoreDeferredUsersKey).Return(nil, nil)
+	api.On("KVCompareAndSet", deferredKey("user"), []byte(nil), mock.Anything).Return(true, nil)
+	api.On("KVCompareAndSet", StoreDeferredUsersKey, []byte(nil), []byte(`["user"]`)).Return(true, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	require.NoError(t, p.createBotDM("user", post))
+	api.AssertNotCalled(t, "CreatePost", mock.Anything)
+
+	var queued []*DeferredNotification
+	stored := api.Calls[2].Arguments.Get(2).([]byte)
+	require.NoError(t, json.Unmarshal(stored, &queued))
+	require.Len(t, queued, 1)
+	assert.Equal(t, post.Message, queued[0].Post.Message)
+}
+
+func TestRunDeferredNotificationsJob(t *testing.T) {
+	notifications, err := json.Marshal([]*DeferredNotification{
+		{Post: &model.Post{UserId: "bot", ChannelId: "channel", Message: "first"}},
+		{Post: &model.Post{UserId: "bot", ChannelId: "channel", Message: "second"}},
+	})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	api.On("KVGet", StoreDeferredUsersKey).Return([]byte(`["busy","user"]`), nil)
+	api.On("GetUserStatus", "busy").Return(&model.Status{UserId: "busy", Status: model.STATUS_DND}, nil)
+	api.On("GetUserStatus", "user").Return(&model.Status{UserId: "user", Status: model.STATUS_ONLINE}, nil)
+	api.On("KVCompareAndSet", StoreDeferredUsersKey, []byte(`["busy","user"]`), []byte(`["busy"]`)).Return(true, nil)
+	api.On("KVGet", deferredKey("user")).Return(notifications, nil)
+	api.On("KVCompareAndSet", deferredKey("user"), notifications, []byte("[]")).Return(true, nil)
+	api.On("CreatePost", mock.AnythingOfType("*model.Post")).Return(&model.Post{}, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	p.runDeferredNotificationsJob(time.Now())
+
+	api.AssertNumberOfCalls(t, "CreatePost", 2)
+	assert.Equal(t, "first", api.Calls[len(api.Calls)-2].Arguments.Get(0).(*model.Post).Message)
+	assert.Equal(t, "second", api.Calls[len(api.Calls)-1].Arguments.Get(0).(*model.Post).Message)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DialogPath is the path the webapp gets the add dialog from, for the post given in the post_id parameter
+	DialogPath = "/dialog"
+	// DialogSubmitPath is the path the add dialog is submitted to
+	DialogSubmitPath = "/dialog/submit"
+
+	dialogFieldMessage  = "message"
+	dialogFieldDue      = "due"
+	dialogFieldPriority = "priority"
+	dialogFieldAssignee = "assignee"
+	dialogPriorityNone  = "normal"
+)
+
+// addDialog returns the dialog to add a todo, or send it with an assignee, created from the post postID if it is set.
+// The dialog is translated with T.
+func (p *Plugin) addDialog(T translateFunc, postID, message string) *model.OpenDialogRequest {
+	return &model.OpenDialogRequest{
+		URL: fmt.Sprintf("/plugins/%s%s", manifest.Id, DialogSubmitPath),
+		Dialog: model.Dialog{
+			CallbackId:  "add",
+			Title:       T(msgDialogTitle, nil),
+			SubmitLabel: T(msgDialogSubmit, nil),
+			State:       postID,
+			Elements: []model.DialogElement{{
+				DisplayName: T(msgDialogMessage, nil),
+				Name:        dialogFieldMessage,
+				Type:        "textarea",
+				Default:     message,
+			}, {
+				DisplayName: T(msgDialogDue, nil),
+				Name:        dialogFieldDue,
+				Type:        "text",
+				Placeholder: "tomorrow 5pm",
+				HelpText:    T(msgDialogDueHelp, nil),
+				Optional:    true,
+			}, {
+				DisplayName: T(msgDialogPriority, nil),
+				Name:        dialogFieldPriority,
+				Type:        "select",
+				Default:     dialogPriorityNone,
+				Options: []*model.PostActionOptions{
+					{Text: T(msgDialogPriorityHigh, nil), Value: IssuePriorityHigh},
+					{Text: T(msgDialogPriorityNormal, nil), Value: dialogPriorityNone},
+					{Text: T(msgDialogPriorityLow, nil), Value: IssuePriorityLow},
+				},
+			}, {
+				DisplayName: T(msgDialogAssignee, nil),
+				Name:        dialogFieldAssignee,
+				Type:        "select",
+				DataSource:  "users",
+				HelpText:    T(msgDialogAssigneeHelp, nil),
+				Optional:    true,
+			}},
+		},
+	}
+}
+
+// openAddDialog opens the add dialog for the command of userID with triggerID
+func (p *Plugin) openAddDialog(userID, triggerID string) error {
+	request := p.addDialog(p.translator(userID), "", "")
+	request.TriggerId = triggerID
+	if appErr := p.API.OpenInteractiveDialog(*request); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+	return nil
+}
+
+// handleDialog returns the add dialog for the post in the post_id parameter, which the webapp opens itself
+func (p *Plugin) handleDialog(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	postID := r.URL.Query().Get("post_id")
+	message := ""
+	if postID != "" {
+		if !p.canReadPost(userID, postID) {
+			p.handleErrorWithCode(w, http.StatusForbidden, "Invalid post", errors.New("you do not have access to the post"))
+			return
+		}
+		if post, appErr := p.API.GetPost(postID); appErr == nil {
+			message = post.Message
+		}
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, p.addDialog(p.translator(userID), postID, message))
+}
+
+// handleDialogSubmit adds the todo of a submitted add dialog to the list of the user, or sends it to the assignee
+func (p *Plugin) handleDialogSubmit(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request *model.SubmitDialogRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a submitted dialog"))
+		return
+	}
+	if request.Cancelled {
+		return
+	}
+
+	response := p.submitAddDialog(userID, request)
+	p.writeAPIResponse(w, http.StatusOK, response)
+}
+
+func (p *Plugin) submitAddDialog(userID string, request *model.SubmitDialogRequest) *model.SubmitDialogResponse {
+	field := func(name string) string {
+		value, _ := request.Submission[name].(string)
+		return value
+	}
+	T := p.translator(userID)
+	fieldError := func(name string, err error) *model.SubmitDialogResponse {
+		return &model.SubmitDialogResponse{Errors: map[string]string{name: p.localizeError(userID, err)}}
+	}
+
+	location := p.getUserLocation(userID)
+	message, dueAt, err := extractRequestDueDate(field(dialogFieldMessage), field(dialogFieldDue), time.Now().In(location))
+	if err != nil {
+		return fieldError(dialogFieldDue, err)
+	}
+	if err = p.checkMessage(message); err != nil {
+		return fieldError(dialogFieldMessage, err)
+	}
+
+	priority := field(dialogFieldPriority)
+	if priority == dialogPriorityNone {
+		priority = ""
+	}
+	if !isValidPriority(priority) {
+		return fieldError(dialogFieldPriority, newLocalizedError(msgErrInvalidPriority, map[string]interface{}{"Value": priority}))
+	}
+
+	postID := request.State
+	if postID != "" && !p.canReadPost(userID, postID) {
+		return &model.SubmitDialogResponse{Error: T(msgDialogNoPostAccess, nil)}
+	}
+
+	senderName := p.listManager.GetUserName(userID)
+	assigneeID := field(dialogFieldAssignee)
+	var ownerID, issueID, confirmation string
+	if assigneeID == "" || assigneeID == userID {
+		if err = p.checkTodoLimit(userID, 1); err != nil {
+			return &model.SubmitDialogResponse{Error: p.localizeError(userID, err)}
+		}
+
+		issue, err := p.listManager.AddIssue(userID, message, postID, dueAt)
+		if err != nil {
+			p.refundRate(userID, rateActionAdd, 1)
+			p.API.LogError("Unable to add issue err=" + err.Error())
+			return &model.SubmitDialogResponse{Error: T(msgDialogAddFailed, nil)}
+		}
+		ownerID, issueID = userID, issue.ID
+		confirmation = T(msgResponseAdded, nil)
+
+		replyMessage := p.localizeServer(msgReplyAttached, map[string]interface{}{"User": senderName})
+		p.postReplyIfNeeded(postID, replyMessage, message)
+	} else {
+		if !p.API.HasPermissionTo(userID, model.PERMISSION_CREATE_DIRECT_CHANNEL) {
+			return fieldError(dialogFieldAssignee, newLocalizedError(msgErrNoSendPermission, nil))
+		}
+
+		receiver, appErr := p.API.GetUser(assigneeID)
+		if appErr != nil || receiver.DeleteAt != 0 || receiver.IsBot {
+			return fieldError(dialogFieldAssignee, newLocalizedError(msgErrInactiveReceiver, nil))
+		}
+
+		if err = p.checkSendAllowed(userID, receiver, 1); err != nil {
+			return fieldError(dialogFieldAssignee, err)
+		}
+
+		issueID, err = p.listManager.SendIssue(userID, receiver.Id, message, postID, dueAt)
+		if err != nil {
+			p.refundRate(userID, rateActionSend, 1)
+			p.API.LogError("Unable to send issue err=" + err.Error())
+			return &model.SubmitDialogResponse{Error: T(msgDialogSendFailed, nil)}
+		}
+		ownerID = receiver.Id
+		confirmation = T(msgResponseSent, map[string]interface{}{"User": receiver.Username})
+
+		p.notifySend(userID, receiver.Id, message, issueID, dueAt)
+		replyMessage := p.localizeServer(msgReplySent, map[string]interface{}{"User": senderName, "Receiver": receiver.Username})
+		p.postReplyIfNeeded(postID, replyMessage, message)
+	}
+
+	if priority != "" {
+		if _, err = p.listManager.SetIssuePriority(ownerID, issueID, priority); err != nil {
+			p.API.LogError("Unable to set priority err=" + err.Error())
+		}
+	}
+
+	p.sendRefreshEvent(userID)
+	if request.ChannelId != "" {
+		p.API.SendEphemeralPost(userID, &model.Post{
+			UserId:    p.BotUserID,
+			ChannelId: request.ChannelId,
+			Message:   confirmation + dueDateConfirmation(T, dueAt, location),
+		})
+	}
+
+	return &model.SubmitDialogResponse{}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddDialog(t *testing.T) {
+	p := &Plugin{}
+	request := p.addDialog(renderEnglish, "post_id", "Review the draft")
+	assert.Equal(t, "/plugins/"+manifest.Id+DialogSubmitPath, request.URL)
+	assert.Equal(t, "post_id", request.Dialog.State)
+	require.Len(t, request.Dialog.Elements, 4)
+	assert.Equal(t, "Review the draft", request.Dialog.Elements[0].Default)
+	assert.Equal(t, "users", request.Dialog.Elements[3].DataSource)
+}
+
+func TestSubmitAddDialogValidation(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1"}, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	t.Run("invalid due date", func(t *testing.T) {
+		response := p.submitAddDialog("user1", &model.SubmitDialogRequest{Submission: map[string]interface{}{
+			dialogFieldMessage: "Review the draft",
+			dialogFieldDue:     "someday maybe",
+		}})
+		assert.Contains(t, response.Errors, dialogFieldDue)
+	})
+
+	t.Run("invalid priority", func(t *testing.T) {
+		response := p.submitAddDialog("user1", &model.SubmitDialogRequest{Submission: map[string]interface{}{
+			dialogFieldMessage:  "Review the draft",
+			dialogFieldPriority: "urgent",
+		}})
+		assert.Contains(t, response.Errors, dialogFieldPriority)
+	})
+
+	t.Run("empty message", func(t *testing.T) {
+		response := p.submitAddDialog("user1", &model.SubmitDialogRequest{Submission: map[string]interface{}{}})
+		assert.Contains(t, response.Errors, dialogFieldMessage)
+	})
+}
+
+type priorityListManager struct {
+	ListManager
+	priorities map[string]string
+}
+
+func (m *priorityListManager) SendIssue(senderID, receiverID, message, postID string, dueAt int64) (string, error) {
+	return "receiver_issue", nil
+}
+
+func (m *priorityListManager) GetUserName(userID string) string {
+	return userID
+}
+
+func (m *priorityListManager) SetIssuePriority(userID, issueID, priority string) (*ExtendedIssue, error) {
+	m.priorities[userID+"/"+issueID] = priority
+	return &ExtendedIssue{}, nil
+}
+
+func TestSubmitAddDialogSendPriority(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1"}, nil)
+	api.On("GetUser", "user2").Return(&model.User{Id: "user2", Username: "bob"}, nil)
+	api.On("HasPermissionTo", "user1", model.PERMISSION_CREATE_DIRECT_CHANNEL).Return(true)
+	api.On("KVGet", settingsKey("user2")).Return([]byte(`{"mute_send_notifications":true}`), nil)
+	listManager := &priorityListManager{priorities: map[string]string{}}
+	p := &Plugin{listManager: listManager}
+	p.SetAPI(api)
+
+	response := p.submitAddDialog("user1", &model.SubmitDialogRequest{Submission: map[string]interface{}{
+		dialogFieldMessage:  "Review the draft",
+		dialogFieldAssignee: "user2",
+		dialogFieldPriority: IssuePriorityHigh,
+	}})
+	assert.Empty(t, response.Error)
+	assert.Equal(t, map[string]string{"user2/receiver_issue": IssuePriorityHigh}, listManager.priorities)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDigestHour is the local hour the digest is sent at unless the user chooses otherwise
+const DefaultDigestHour = 9
+
+// runDigestJob sends the digest to every subscribed user whose digest time has passed today in their timezone
+func (p *Plugin) runDigestJob(now time.Time) {
+	userIDs, _, err := p.getDigestUsers()
+	if err != nil {
+		p.API.LogError("cannot get digest users, Err=", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		settings, err := p.getDigestSettings(userID)
+		if err != nil {
+			p.API.LogError("cannot get digest settings, Err=", err.Error())
+			continue
+		}
+
+		localNow := now.In(p.getUserLocation(userID))
+		if !settings.Enabled || !isDigestDue(settings, localNow) {
+			continue
+		}
+
+		if err := p.sendDigest(userID, localNow); err != nil {
+			p.API.LogError("cannot send digest, Err=", err.Error())
+			continue
+		}
+
+		settings.LastSentAt = toMillis(now)
+		if err := p.saveDigestSettings(userID, settings); err != nil {
+			p.API.LogError("cannot save digest settings, Err=", err.Error())
+		}
+	}
+}
+
+// isDigestDue checks whether the digest time of today has passed in localNow and the digest was not sent since
+func isDigestDue(settings *DigestSettings, localNow time.Time) bool {
+	scheduled := time.Date(localNow.Year(), localNow.Month(), localNow.Day(), settings.Hour, settings.Minute, 0, 0, localNow.Location())
+	if localNow.Before(scheduled) {
+		return false
+	}
+
+	lastSent := fromMillis(settings.LastSentAt)
+	return lastSent.Before(scheduled)
+}
+
+// sendDigest sends the digest to userID, with the overdue todos at localNow, which must be in the user's timezone
+func (p *Plugin) sendDigest(userID string, localNow time.Time) error {
+	myIssues, err := p.listManager.GetIssueList(userID, MyListKey)
+	if err != nil {
+		return err
+	}
+
+	inIssues, err := p.listManager.GetIssueList(userID, InListKey)
+	if err != nil {
+		return err
+	}
+
+	if len(myIssues) == 0 && len(inIssues) == 0 {
+		return nil
+	}
+
+	return p.PostBotDM(userID, p.digestToString(userID, myIssues, inIssues, localNow))
+}
+
+// digestToString formats the digest of userID, in their language
+func (p *Plugin) digestToString(userID string, myIssues, inIssues []*ExtendedIssue, localNow time.Time) string {
+	location := localNow.Location()
+	T := p.translator(userID)
+	str := T(msgDigestTitle, nil) + "\n\n"
+
+	overdueIssues := []*ExtendedIssue{}
+	for _, issue := range append(append([]*ExtendedIssue{}, myIssues...), inIssues...) {
+		if issue.DueAt != 0 && issue.DueAt < toMillis(localNow) {
+			overdueIssues = append(overdueIssues, issue)
+		}
+	}
+
+	if len(overdueIssues) > 0 {
+		str += T(msgDigestOverdue, nil) + issuesListToString(T, overdueIssues, location) + "\n"
+	}
+
+	str += T(msgDigestMyList, nil) + issuesListToString(T, myIssues, location)
+
+	if len(inIssues) > 0 {
+		str += "\n" + T(msgDigestInList, nil) + issuesListToString(T, inIssues, location)
+	}
+
+	return str
+}
+
+// getUserLocation returns the Mattermost timezone of userID, or UTC if it cannot be found
+func (p *Plugin) getUserLocation(userID string) *time.Location {
+	user, appErr := p.getUser(userID)
+	if appErr != nil {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(user.GetPreferredTimezone())
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// parseDigestTime parses a time of the day in the HH:MM format
+func parseDigestTime(value string) (int, int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, newLocalizedError(msgErrInvalidDigestTime, map[string]interface{}{"Value": value})
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+func digestSettingsToString(T translateFunc, settings *DigestSettings) string {
+	if !settings.Enabled {
+		return T(msgDigestOff, nil)
+	}
+	return T(msgDigestOn, map[string]interface{}{"Time": fmt.Sprintf("%02d:%02d", settings.Hour, settings.Minute)})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDigestDue(t *testing.T) {
+	location := time.FixedZone("test", 2*60*60)
+	toMillis := func(t time.Time) int64 {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+
+	settings := &DigestSettings{Enabled: true, Hour: 9, Minute: 30}
+
+	before := time.Date(2020, 3, 10, 9, 29, 0, 0, location)
+	assert.False(t, isDigestDue(settings, before))
+
+	after := time.Date(2020, 3, 10, 9, 31, 0, 0, location)
+	assert.True(t, isDigestDue(settings, after))
+
+	settings.LastSentAt = toMillis(time.Date(2020, 3, 10, 9, 30, 0, 0, location))
+	assert.False(t, isDigestDue(settings, after))
+
+	nextDay := time.Date(2020, 3, 11, 10, 0, 0, 0, location)
+	assert.True(t, isDigestDue(settings, nextDay))
+}
+
+func TestParseDigestTime(t *testing.T) {
+	hour, minute, err := parseDigestTime("08:05")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, hour)
+	assert.Equal(t, 5, minute)
+
+	_, _, err = parseDigestTime("25:00")
+	assert.Error(t, err)
+}
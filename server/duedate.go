@@ -0,0 +1,314 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDueHour is the local hour a todo is due at when only the day is given
+const DefaultDueHour = 17
+
+// dueFlagRegexp matches the --due flag, with the date either quoted or as the rest of the message
+var dueFlagRegexp = regexp.MustCompile(`\s*--due\s+(?:"([^"]*)"|'([^']*)'|(.*))`)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// extractDueDate looks for a due date in message, either in a --due flag or after a trailing "by", and returns
+// the message without it and the due date in milliseconds, or 0 if there is none. now must be in the user's timezone.
+// An invalid --due flag is an error, while a "by" that is not followed by a date is considered part of the message.
+func extractDueDate(message string, now time.Time) (string, int64, error) {
+	if match := dueFlagRegexp.FindStringSubmatchIndex(message); match != nil {
+		phrase := ""
+		for i := 2; i < len(match); i += 2 {
+			if match[i] >= 0 {
+				phrase = message[match[i]:match[i+1]]
+				break
+			}
+		}
+
+		due, err := parseDueDate(phrase, now)
+		if err != nil {
+			return "", 0, err
+		}
+
+		rest := strings.TrimSpace(strings.TrimSpace(message[:match[0]]) + " " + strings.TrimSpace(message[match[1]:]))
+		return rest, toMillis(due), nil
+	}
+
+	lower := strings.ToLower(message)
+	for index := strings.LastIndex(lower, " by "); index >= 0; index = strings.LastIndex(lower[:index], " by ") {
+		due, err := parseDueDate(message[index+len(" by "):], now)
+		if err != nil {
+			continue
+		}
+		return strings.TrimSpace(message[:index]), toMillis(due), nil
+	}
+
+	return message, 0, nil
+}
+
+// parseDueDate parses phrases like "tomorrow 5pm", "next friday", "in 3 days", "march 15 at 10:30" or "2020-03-15",
+// relative to now and in its location
+func parseDueDate(phrase string, now time.Time) (time.Time, error) {
+	words := strings.Fields(strings.ToLower(strings.TrimSpace(phrase)))
+	if len(words) == 0 {
+		return time.Time{}, newLocalizedError(msgErrDueDateEmpty, nil)
+	}
+
+	if due, ok := parseRelativeTime(words, now); ok {
+		return due, nil
+	}
+
+	day, rest, hasDay := parseDay(words, now)
+
+	hour, minute := DefaultDueHour, 0
+	hasTime := false
+	if len(rest) > 0 {
+		if rest[0] == "at" {
+			rest = rest[1:]
+		}
+
+		var ok bool
+		hour, minute, ok = parseTimeOfDay(strings.Join(rest, ""))
+		if !ok {
+			return time.Time{}, newLocalizedError(msgErrInvalidDueDate, map[string]interface{}{"Value": phrase})
+		}
+		hasTime = true
+	}
+
+	if !hasDay && !hasTime {
+		return time.Time{}, newLocalizedError(msgErrInvalidDueDate, map[string]interface{}{"Value": phrase})
+	}
+
+	if !hasDay {
+		day = now
+	}
+
+	due := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location())
+	if !hasDay && due.Before(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+
+	return due, nil
+}
+
+// parseRelativeTime parses "in N minutes/hours/days/weeks/months"
+func parseRelativeTime(words []string, now time.Time) (time.Time, bool) {
+	if len(words) != 3 || words[0] != "in" {
+		return time.Time{}, false
+	}
+
+	n, err := strconv.Atoi(words[1])
+	if err != nil && (words[1] == "a" || words[1] == "an") {
+		n, err = 1, nil
+	}
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+
+	switch strings.TrimSuffix(words[2], "s") {
+	case "minute", "min":
+		return now.Add(time.Duration(n) * time.Minute), true
+	case "hour":
+		return now.Add(time.Duration(n) * time.Hour), true
+	case "day":
+		return atDefaultHour(now.AddDate(0, 0, n)), true
+	case "week":
+		return atDefaultHour(now.AddDate(0, 0, 7*n)), true
+	case "month":
+		return atDefaultHour(now.AddDate(0, n, 0)), true
+	}
+
+	return time.Time{}, false
+}
+
+// parseDay parses the day at the beginning of words, and returns the remaining words
+func parseDay(words []string, now time.Time) (time.Time, []string, bool) {
+	first := words[0]
+	switch first {
+	case "today", "tonight":
+		return now, words[1:], true
+	case "tomorrow", "tmr":
+		return now.AddDate(0, 0, 1), words[1:], true
+	case "on", "this":
+		if len(words) > 1 {
+			if day, ok := nextWeekday(words[1], now); ok {
+				return day, words[2:], true
+			}
+		}
+	case "next":
+		if len(words) > 1 {
+			switch words[1] {
+			case "week":
+				return now.AddDate(0, 0, 7), words[2:], true
+			case "month":
+				return now.AddDate(0, 1, 0), words[2:], true
+			}
+			if day, ok := nextWeekday(words[1], now); ok {
+				return day, words[2:], true
+			}
+		}
+	}
+
+	if day, ok := nextWeekday(first, now); ok {
+		return day, words[1:], true
+	}
+
+	if day, err := time.ParseInLocation("2006-01-02", first, now.Location()); err == nil {
+		return day, words[1:], true
+	}
+
+	if parts := strings.Split(first, "/"); len(parts) == 2 {
+		month, monthErr := strconv.Atoi(parts[0])
+		dayOfMonth, dayErr := strconv.Atoi(parts[1])
+		if monthErr == nil && dayErr == nil && month >= 1 && month <= 12 {
+			if day, ok := nextDate(time.Month(month), dayOfMonth, now); ok {
+				return day, words[1:], true
+			}
+		}
+	}
+
+	if len(words) > 1 {
+		if month, ok := months[first]; ok {
+			if dayOfMonth, err := strconv.Atoi(strings.TrimRight(words[1], "stndrh,")); err == nil {
+				return parseYear(month, dayOfMonth, words[2:], now)
+			}
+		}
+		if month, ok := months[strings.TrimSuffix(words[1], ",")]; ok {
+			if dayOfMonth, err := strconv.Atoi(strings.TrimRight(first, "stndrh")); err == nil {
+				return parseYear(month, dayOfMonth, words[2:], now)
+			}
+		}
+	}
+
+	return time.Time{}, words, false
+}
+
+// parseYear completes a month and day with the year, if given in the first of the remaining words,
+// or with the year that makes the date upcoming
+func parseYear(month time.Month, dayOfMonth int, rest []string, now time.Time) (time.Time, []string, bool) {
+	if len(rest) > 0 && len(rest[0]) == 4 {
+		if year, err := strconv.Atoi(rest[0]); err == nil {
+			day := time.Date(year, month, dayOfMonth, 0, 0, 0, 0, now.Location())
+			if day.Day() != dayOfMonth {
+				return time.Time{}, rest, false
+			}
+			return day, rest[1:], true
+		}
+	}
+
+	day, ok := nextDate(month, dayOfMonth, now)
+	return day, rest, ok
+}
+
+// nextDate returns the first occurrence of month and day starting today
+func nextDate(month time.Month, dayOfMonth int, now time.Time) (time.Time, bool) {
+	day := time.Date(now.Year(), month, dayOfMonth, 0, 0, 0, 0, now.Location())
+	if day.Day() != dayOfMonth {
+		return time.Time{}, false
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if day.Before(today) {
+		day = day.AddDate(1, 0, 0)
+	}
+	return day, true
+}
+
+// nextWeekday returns the first day after today that falls on the weekday name
+func nextWeekday(name string, now time.Time) (time.Time, bool) {
+	weekday, ok := weekdays[name]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	days := (int(weekday) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return now.AddDate(0, 0, days), true
+}
+
+// parseTimeOfDay parses times like "5pm", "5:30pm", "17:30", "17" or "noon"
+func parseTimeOfDay(value string) (int, int, bool) {
+	switch value {
+	case "noon", "midday":
+		return 12, 0, true
+	case "midnight":
+		return 23, 59, true
+	case "eod":
+		return DefaultDueHour, 0, true
+	}
+
+	for _, layout := range []string{"3pm", "3:04pm", "15:04", "15"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Hour(), t.Minute(), true
+		}
+	}
+
+	return 0, 0, false
+}
+
+func atDefaultHour(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), DefaultDueHour, 0, 0, 0, day.Location())
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond))
+}
+
+// formatDueDate formats a due date in milliseconds for the user's location
+func formatDueDate(dueAt int64, location *time.Location) string {
+	return fromMillis(dueAt).In(location).Format("Monday, January 2, 2006 at 15:04")
+}
+
+// extractRequestDueDate gets the due date of a todo from the due field of a request if given, or from the message otherwise
+func extractRequestDueDate(message, due string, now time.Time) (string, int64, error) {
+	if due == "" {
+		return extractDueDate(message, now)
+	}
+
+	dueDate, err := parseDueDate(due, now)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return message, toMillis(dueDate), nil
+}
+
+// dueDateConfirmation echoes the due date back to the user, if there is one
+func dueDateConfirmation(T translateFunc, dueAt int64, location *time.Location) string {
+	if dueAt == 0 {
+		return ""
+	}
+	return " " + T(msgDueConfirmation, map[string]interface{}{"Date": formatDueDate(dueAt, location)})
+}
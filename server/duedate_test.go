@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDueDate(t *testing.T) {
+	location := time.FixedZone("test", -5*60*60)
+	// Wednesday
+	now := time.Date(2020, 3, 11, 10, 0, 0, 0, location)
+
+	for phrase, expected := range map[string]time.Time{
+		"tomorrow":           time.Date(2020, 3, 12, DefaultDueHour, 0, 0, 0, location),
+		"tomorrow 5pm":       time.Date(2020, 3, 12, 17, 0, 0, 0, location),
+		"Tomorrow at 9:30am": time.Date(2020, 3, 12, 9, 30, 0, 0, location),
+		"today 18:00":        time.Date(2020, 3, 11, 18, 0, 0, 0, location),
+		"friday":             time.Date(2020, 3, 13, DefaultDueHour, 0, 0, 0, location),
+		"next friday":        time.Date(2020, 3, 13, DefaultDueHour, 0, 0, 0, location),
+		"wednesday":          time.Date(2020, 3, 18, DefaultDueHour, 0, 0, 0, location),
+		"next week":          time.Date(2020, 3, 18, DefaultDueHour, 0, 0, 0, location),
+		"in 3 days":          time.Date(2020, 3, 14, DefaultDueHour, 0, 0, 0, location),
+		"in 2 hours":         time.Date(2020, 3, 11, 12, 0, 0, 0, location),
+		"march 15":           time.Date(2020, 3, 15, DefaultDueHour, 0, 0, 0, location),
+		"15 march at noon":   time.Date(2020, 3, 15, 12, 0, 0, 0, location),
+		"jan 2":              time.Date(2021, 1, 2, DefaultDueHour, 0, 0, 0, location),
+		"2020-04-01 8am":     time.Date(2020, 4, 1, 8, 0, 0, 0, location),
+		"4/1":                time.Date(2020, 4, 1, DefaultDueHour, 0, 0, 0, location),
+		"9am":                time.Date(2020, 3, 12, 9, 0, 0, 0, location),
+		"3pm":                time.Date(2020, 3, 11, 15, 0, 0, 0, location),
+	} {
+		t.Run(phrase, func(t *testing.T) {
+			due, err := parseDueDate(phrase, now)
+			require.NoError(t, err)
+			assert.True(t, expected.Equal(due), "expected %s, got %s", expected, due)
+		})
+	}
+
+	for _, phrase := range []string{"", "someday", "the fire", "february 30", "tomorrow morning-ish"} {
+		_, err := parseDueDate(phrase, now)
+		assert.Error(t, err, phrase)
+	}
+}
+
+func TestExtractDueDate(t *testing.T) {
+	location := time.UTC
+	now := time.Date(2020, 3, 11, 10, 0, 0, 0, location)
+
+	message, dueAt, err := extractDueDate("Pay invoice by tomorrow 5pm", now)
+	require.NoError(t, err)
+	assert.Equal(t, "Pay invoice", message)
+	assert.Equal(t, toMillis(time.Date(2020, 3, 12, 17, 0, 0, 0, location)), dueAt)
+
+	message, dueAt, err = extractDueDate(`Prepare the demo --due "next friday" for the team`, now)
+	require.NoError(t, err)
+	assert.Equal(t, "Prepare the demo for the team", message)
+	assert.Equal(t, toMillis(time.Date(2020, 3, 13, DefaultDueHour, 0, 0, 0, location)), dueAt)
+
+	message, dueAt, err = extractDueDate("Stand by me", now)
+	require.NoError(t, err)
+	assert.Equal(t, "Stand by me", message)
+	assert.Equal(t, int64(0), dueAt)
+
+	_, _, err = extractDueDate("Something --due whenever", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// recordDueUser tracks the users that got a todo with a due date, so the overdue job only looks at their lists
+func (p *Plugin) recordDueUser(event *IssueEvent) {
+	if event.Issue == nil || event.Issue.DueAt == 0 {
+		return
+	}
+
+	userID := event.UserID
+	switch event.Type {
+	case IssueEventCreated:
+	case IssueEventSent, IssueEventForwarded:
+		userID = event.ForeignUserID
+	default:
+		return
+	}
+
+	if err := p.updateUserSet(StoreOverdueUsersKey, userID, true); err != nil {
+		p.API.LogError("cannot record user for overdue emails, Err=", err.Error())
+	}
+}
+
+// isAway checks whether userID has not been active in Mattermost for the inactivity period set by the system admin
+func (p *Plugin) isAway(userID string, now time.Time) bool {
+	minutes := p.getConfiguration().EmailInactiveMinutes
+	if minutes <= 0 {
+		return false
+	}
+
+	status, appErr := p.API.GetUserStatus(userID)
+	if appErr != nil {
+		p.API.LogWarn("Unable to get the status of the user", "err", appErr.Error())
+		return false
+	}
+
+	return now.Sub(fromMillis(status.LastActivityAt)) >= time.Duration(minutes)*time.Minute
+}
+
+// emailIfAway emails userID about the todos, in addition to the bot message, if they are away and did not turn the
+// emails off
+func (p *Plugin) emailIfAway(userID, subject, intro string, todos []string) {
+	if !p.isAway(userID, time.Now()) || !p.wantsEmails(userID) {
+		return
+	}
+
+	if err := p.sendEmail(userID, subject, renderEmail(intro, todos, p.siteURL())); err != nil {
+		p.API.LogError("cannot send email, Err=", err.Error())
+	}
+}
+
+// sendEmail sends an email to the address of userID, if the server is set up to send email notifications
+func (p *Plugin) sendEmail(userID, subject, htmlBody string) error {
+	config := p.API.GetConfig()
+	if config == nil || config.EmailSettings.SendEmailNotifications == nil || !*config.EmailSettings.SendEmailNotifications {
+		return nil
+	}
+
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return errors.New(appErr.Error())
+	}
+	if user.Email == "" || user.DeleteAt != 0 {
+		return nil
+	}
+
+	if appErr := p.API.SendMail(user.Email, subject, htmlBody); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+	return nil
+}
+
+// renderEmail renders the HTML body of an email with the intro, the list of todos and a link to Mattermost
+func renderEmail(intro string, todos []string, siteURL string) string {
+	var b strings.Builder
+	b.WriteString("<p>" + html.EscapeString(intro) + "</p>")
+	if len(todos) > 0 {
+		b.WriteString("<ul>")
+		for _, todo := range todos {
+			b.WriteString("<li>" + html.EscapeString(todo) + "</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	if siteURL != "" {
+		b.WriteString(`<p><a href="` + html.EscapeString(siteURL) + `">` + html.EscapeString(siteURL) + "</a></p>")
+	}
+	return b.String()
+}
+
+// newlyOverdueIssues returns the issues whose due date passed after from and until the given time, both in milliseconds
+func newlyOverdueIssues(issues []*ExtendedIssue, from, until int64) []*ExtendedIssue {
+	overdue := []*ExtendedIssue{}
+	for _, issue := range issues {
+		if issue.DueAt > from && issue.DueAt <= until {
+			overdue = append(overdue, issue)
+		}
+	}
+	return overdue
+}
+
+// hasUpcomingDueDate checks whether any of the issues is due after the given time in milliseconds
+func hasUpcomingDueDate(issues []*ExtendedIssue, after int64) bool {
+	for _, issue := range issues {
+		if issue.DueAt > after {
+			return true
+		}
+	}
+	return false
+}
+
+// runOverdueEmailJob emails the users that are away about the todos of their list and received list that became
+// overdue since the last run
+func (p *Plugin) runOverdueEmailJob(now time.Time) {
+	if p.getConfiguration().EmailInactiveMinutes <= 0 {
+		return
+	}
+
+	userIDs, _, err := p.getUserSet(StoreOverdueUsersKey)
+	if err != nil {
+		p.API.LogError("cannot get users for overdue emails, Err=", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		// The user is removed first, so a todo with a due date added meanwhile adds them back
+		if err := p.updateUserSet(StoreOverdueUsersKey, userID, false); err != nil {
+			p.API.LogError("cannot update users for overdue emails, Err=", err.Error())
+			continue
+		}
+
+		settings, err := p.getUserSettings(userID)
+		if err != nil {
+			p.API.LogError("cannot get user settings, Err=", err.Error())
+			p.keepOverdueUser(userID)
+			continue
+		}
+
+		// The todos already overdue when the user is first checked are in the digest, not in an email
+		from := settings.OverdueCheckedAt
+		if from == 0 {
+			from = toMillis(now)
+		}
+
+		issues, err := p.listManager.GetDueIssues(userID, []string{MyListKey, InListKey}, from, math.MaxInt64)
+		if err != nil {
+			p.API.LogError("cannot get issues for overdue emails, Err=", err.Error())
+			p.keepOverdueUser(userID)
+			continue
+		}
+		if hasUpcomingDueDate(issues, toMillis(now)) {
+			p.keepOverdueUser(userID)
+		}
+
+		p.emailOverdueIssues(userID, newlyOverdueIssues(issues, from, toMillis(now)))
+
+		settings.OverdueCheckedAt = toMillis(now)
+		if err := p.saveUserSettings(userID, settings); err != nil {
+			p.API.LogError("cannot save user settings, Err=", err.Error())
+		}
+	}
+}
+
+// keepOverdueUser adds userID back to the users the overdue job looks at
+func (p *Plugin) keepOverdueUser(userID string) {
+	if err := p.updateUserSet(StoreOverdueUsersKey, userID, true); err != nil {
+		p.API.LogError("cannot update users for overdue emails, Err=", err.Error())
+	}
+}
+
+// emailOverdueIssues emails userID the issues that just became overdue, if they are away
+func (p *Plugin) emailOverdueIssues(userID string, overdue []*ExtendedIssue) {
+	if len(overdue) == 0 {
+		return
+	}
+
+	todos := []string{}
+	for _, issue := range overdue {
+		todos = append(todos, issue.Message)
+	}
+	p.emailIfAway(userID, p.localize(userID, msgEmailOverdueSubject, nil), p.localize(userID, msgEmailOverdue, nil), todos)
+}
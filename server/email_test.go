@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderEmail(t *testing.T) {
+	body := renderEmail("These Todos are now overdue:", []string{"Review <b>PR</b>", "Ship it"}, "https://example.com")
+	assert.Equal(t, `<p>These Todos are now overdue:</p><ul><li>Review &lt;b&gt;PR&lt;/b&gt;</li><li>Ship it</li></ul><p><a href="https://example.com">https://example.com</a></p>`, body)
+
+	assert.Equal(t, "<p>Hello</p>", renderEmail("Hello", nil, ""))
+}
+
+func TestNewlyOverdueIssues(t *testing.T) {
+	before := &ExtendedIssue{Issue: Issue{ID: "before", DueAt: 100}}
+	during := &ExtendedIssue{Issue: Issue{ID: "during", DueAt: 200}}
+	after := &ExtendedIssue{Issue: Issue{ID: "after", DueAt: 400}}
+	noDueDate := &ExtendedIssue{Issue: Issue{ID: "no_due_date"}}
+	issues := []*ExtendedIssue{before, during, after, noDueDate}
+
+	assert.Equal(t, []*ExtendedIssue{during}, newlyOverdueIssues(issues, 100, 300))
+	assert.True(t, hasUpcomingDueDate(issues, 300))
+	assert.False(t, hasUpcomingDueDate(issues, 400))
+}
+
+func TestIsAway(t *testing.T) {
+	now := time.Now()
+	api := &plugintest.API{}
+	api.On("GetUserStatus", "away").Return(&model.Status{LastActivityAt: toMillis(now.Add(-time.Hour))}, nil)
+	api.On("GetUserStatus", "active").Return(&model.Status{LastActivityAt: toMillis(now.Add(-time.Minute))}, nil)
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	assert.False(t, p.isAway("away", now))
+
+	p.setConfiguration(&configuration{EmailInactiveMinutes: 30})
+	assert.True(t, p.isAway("away", now))
+	assert.False(t, p.isAway("active", now))
+}
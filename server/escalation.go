@@ -0,0 +1,139 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// recordReceiver records since when the received todos of the receiver are waiting, so the escalation job only
+// looks at the received lists with a todo that may be stalled
+func (p *Plugin) recordReceiver(event *IssueEvent) {
+	if event.Type != IssueEventSent && event.Type != IssueEventForwarded {
+		return
+	}
+
+	if err := p.recordEscalationDue(event.ForeignUserID, model.GetMillis()); err != nil {
+		p.API.LogError("cannot record receiver for escalations, Err=", err.Error())
+	}
+}
+
+// receivedAt returns when issue landed in the received list of its current receiver
+func receivedAt(issue *Issue) int64 {
+	if len(issue.Forwards) > 0 {
+		return issue.Forwards[len(issue.Forwards)-1].CreateAt
+	}
+	return issue.CreateAt
+}
+
+// stalledIssues returns the received issues waiting since before the given time, in milliseconds, and not escalated
+// since they were received
+func stalledIssues(issues []*ExtendedIssue, before int64) []*ExtendedIssue {
+	stalled := []*ExtendedIssue{}
+	for _, issue := range issues {
+		received := receivedAt(&issue.Issue)
+		if received < before && issue.EscalatedAt < received {
+			stalled = append(stalled, issue)
+		}
+	}
+	return stalled
+}
+
+// oldestWaiting returns when the oldest of the received issues that were not escalated since they were received,
+// leaving out the escalated ones, was received, and false if there is none
+func oldestWaiting(issues, escalated []*ExtendedIssue) (int64, bool) {
+	skip := map[string]bool{}
+	for _, issue := range escalated {
+		skip[issue.ID] = true
+	}
+
+	oldest, found := int64(0), false
+	for _, issue := range issues {
+		received := receivedAt(&issue.Issue)
+		if skip[issue.ID] || issue.EscalatedAt >= received {
+			continue
+		}
+		if !found || received < oldest {
+			oldest, found = received, true
+		}
+	}
+	return oldest, found
+}
+
+// runEscalationJob reminds the users of the todos waiting in their received list for longer than EscalationDays, and
+// tells the senders that they are stalled. Only the received lists with a todo waiting for that long are loaded.
+func (p *Plugin) runEscalationJob(now time.Time) {
+	days := p.getConfiguration().EscalationDays
+	if days <= 0 {
+		return
+	}
+
+	due, _, err := p.getEscalationDue()
+	if err != nil {
+		p.API.LogError("cannot get users for escalations, Err=", err.Error())
+		return
+	}
+
+	before := toMillis(now.AddDate(0, 0, -days))
+	for userID, since := range due {
+		if since >= before {
+			continue
+		}
+
+		// The user is forgotten first, so a todo received meanwhile records them again
+		if err := p.forgetEscalationDue(userID); err != nil {
+			p.API.LogError("cannot update users for escalations, Err=", err.Error())
+			continue
+		}
+
+		issues, err := p.listManager.GetIssueList(userID, InListKey)
+		if err != nil {
+			p.API.LogError("cannot get received issues for escalation, Err=", err.Error())
+			if err := p.recordEscalationDue(userID, since); err != nil {
+				p.API.LogError("cannot update users for escalations, Err=", err.Error())
+			}
+			continue
+		}
+
+		escalated := p.escalateIssues(userID, stalledIssues(issues, before), days)
+		if next, ok := oldestWaiting(issues, escalated); ok {
+			if err := p.recordEscalationDue(userID, next); err != nil {
+				p.API.LogError("cannot update users for escalations, Err=", err.Error())
+			}
+		}
+	}
+}
+
+// escalateIssues reminds userID of the stalled issues of their received list, notifies the sender of each one, and
+// returns the issues escalated
+func (p *Plugin) escalateIssues(userID string, stalled []*ExtendedIssue, days int) []*ExtendedIssue {
+	escalated := []*ExtendedIssue{}
+	for _, issue := range stalled {
+		if _, err := p.listManager.EscalateIssue(userID, issue.ID); err != nil {
+			p.API.LogError("cannot escalate issue, Err=", err.Error())
+			continue
+		}
+		escalated = append(escalated, issue)
+
+		T := p.translator(issue.ForeignUserID)
+		message := T(msgNotifyStalled, map[string]interface{}{
+			"User": p.listManager.GetUserName(userID),
+			"Todo": issue.Message,
+			"Age":  formatAge(T, time.Since(fromMillis(receivedAt(&issue.Issue)))),
+		})
+		if err := p.PostBotDM(issue.ForeignUserID, message); err != nil {
+			p.API.LogError("cannot notify stalled issue, Err=", err.Error())
+		}
+	}
+
+	if len(escalated) == 0 {
+		return escalated
+	}
+
+	T := p.translator(userID)
+	message := T(msgReminderStalled, map[string]interface{}{"Days": days})
+	if err := p.PostBotDM(userID, message+issuesListToString(T, escalated, p.getUserLocation(userID))); err != nil {
+		p.API.LogError("cannot send escalation reminder, Err=", err.Error())
+	}
+	return escalated
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStalledIssues(t *testing.T) {
+	waiting := &ExtendedIssue{Issue: Issue{ID: "waiting", CreateAt: 100}}
+	recent := &ExtendedIssue{Issue: Issue{ID: "recent", CreateAt: 300}}
+	escalated := &ExtendedIssue{Issue: Issue{ID: "escalated", CreateAt: 100, EscalatedAt: 150}}
+	forwarded := &ExtendedIssue{Issue: Issue{ID: "forwarded", CreateAt: 50, EscalatedAt: 150, Forwards: []*Forward{{CreateAt: 180}}}}
+	forwardedRecently := &ExtendedIssue{Issue: Issue{ID: "forwarded_recently", CreateAt: 50, Forwards: []*Forward{{CreateAt: 250}}}}
+
+	stalled := stalledIssues([]*ExtendedIssue{waiting, recent, escalated, forwarded, forwardedRecently}, 200)
+	assert.Equal(t, []*ExtendedIssue{waiting, forwarded}, stalled)
+}
+
+func TestOldestWaiting(t *testing.T) {
+	waiting := &ExtendedIssue{Issue: Issue{ID: "waiting", CreateAt: 200}}
+	older := &ExtendedIssue{Issue: Issue{ID: "older", CreateAt: 100}}
+	escalated := &ExtendedIssue{Issue: Issue{ID: "escalated", CreateAt: 50, EscalatedAt: 150}}
+
+	oldest, ok := oldestWaiting([]*ExtendedIssue{waiting, older, escalated}, nil)
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), oldest)
+
+	oldest, ok = oldestWaiting([]*ExtendedIssue{waiting, older, escalated}, []*ExtendedIssue{older})
+	assert.True(t, ok)
+	assert.Equal(t, int64(200), oldest)
+
+	_, ok = oldestWaiting([]*ExtendedIssue{escalated}, nil)
+	assert.False(t, ok)
+}
+
+func TestRunEscalationJobSkipsRecentTodos(t *testing.T) {
+	now := time.Date(2020, 6, 10, 9, 0, 0, 0, time.UTC)
+	api := &plugintest.API{}
+	api.On("KVGet", StoreEscalationDueKey).Return([]byte(`{"user1":`+strconv.FormatInt(toMillis(now.AddDate(0, 0, -1)), 10)+`}`), nil)
+
+	// The list manager has no received lists, so loading one would panic
+	p := &Plugin{listManager: &issueListManager{}}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{EscalationDays: 3})
+
+	p.runEscalationJob(now)
+	api.AssertExpectations(t)
+}
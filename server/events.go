@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// IssueEventCreated is dispatched when a todo is added to a list
+	IssueEventCreated = "created"
+	// IssueEventSent is dispatched when a todo is sent to another user
+	IssueEventSent = "sent"
+	// IssueEventAccepted is dispatched when a received todo is accepted
+	IssueEventAccepted = "accepted"
+	// IssueEventDeclined is dispatched when a received todo is declined
+	IssueEventDeclined = "declined"
+	// IssueEventCompleted is dispatched when a todo is completed or popped
+	IssueEventCompleted = "completed"
+	// IssueEventDeleted is dispatched when a todo is removed
+	IssueEventDeleted = "deleted"
+	// IssueEventForwarded is dispatched when a received todo is handed off to another user
+	IssueEventForwarded = "forwarded"
+	// IssueEventStarted is dispatched when a todo is marked in progress
+	IssueEventStarted = "started"
+	// IssueEventEdited is dispatched when the message of a todo changes
+	IssueEventEdited = "edited"
+)
+
+// IssueEvent is a change in the lifecycle of a todo, done by UserID. ForeignUserID is the other user of a sent todo,
+// and ChannelID the channel of a todo on a shared channel list.
+type IssueEvent struct {
+	Type          string
+	UserID        string
+	ForeignUserID string
+	ChannelID     string
+	Issue         *Issue
+	CreateAt      int64
+}
+
+// IssueEventHandler is called by the list manager after every change in the lifecycle of a todo
+type IssueEventHandler func(event *IssueEvent)
+
+// dispatch calls the event handlers of the list manager with a new event. Events without an issue are not dispatched.
+func (l *listManager) dispatch(eventType, userID, foreignUserID string, issue *Issue) {
+	l.dispatchEvent(&IssueEvent{
+		Type:          eventType,
+		UserID:        userID,
+		ForeignUserID: foreignUserID,
+		Issue:         issue,
+	})
+}
+
+// dispatchChannel calls the event handlers with a new event on the shared list of channelID
+func (l *listManager) dispatchChannel(eventType, userID, channelID string, issue *Issue) {
+	l.dispatchEvent(&IssueEvent{
+		Type:      eventType,
+		UserID:    userID,
+		ChannelID: channelID,
+		Issue:     issue,
+	})
+}
+
+func (l *listManager) dispatchEvent(event *IssueEvent) {
+	if event.Issue == nil {
+		return
+	}
+
+	event.CreateAt = model.GetMillis()
+	for _, handler := range l.eventHandlers {
+		handler(event)
+	}
+}
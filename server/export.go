@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// ExportFormatCSV exports the todos as a CSV file with a header row
+	ExportFormatCSV = "csv"
+	// ExportFormatJSON exports the todos as a JSON array
+	ExportFormatJSON = "json"
+
+	// ExportStateOpen is the state of a todo on the user's own list
+	ExportStateOpen = "open"
+	// ExportStatePending is the state of a sent todo that was not accepted yet
+	ExportStatePending = "pending"
+	// ExportStateAccepted is the state of a sent todo the receiver accepted
+	ExportStateAccepted = "accepted"
+	// ExportStateDeclined is the state of a sent todo the receiver declined
+	ExportStateDeclined = "declined"
+	// ExportStateInProgress is the state of an open or accepted todo someone started working on
+	ExportStateInProgress = "in_progress"
+	// ExportStateDone is the state of a completed todo
+	ExportStateDone = "done"
+)
+
+// exportedIssue is a todo with the metadata needed to understand it outside of the plugin
+type exportedIssue struct {
+	ID            string `json:"id"`
+	List          string `json:"list"`
+	Message       string `json:"message"`
+	CreatedAt     string `json:"created_at"`
+	Sender        string `json:"sender"`
+	Receiver      string `json:"receiver"`
+	State         string `json:"state"`
+	DueAt         string `json:"due_at,omitempty"`
+	CompletedAt   string `json:"completed_at,omitempty"`
+	PostID        string `json:"post_id,omitempty"`
+	DeclineReason string `json:"decline_reason,omitempty"`
+}
+
+// exportIssues gets every todo of userID, with the times in location
+func (p *Plugin) exportIssues(userID string, location *time.Location) ([]*exportedIssue, error) {
+	userName := p.listManager.GetUserName(userID)
+
+	exported := []*exportedIssue{}
+	for _, listID := range []string{MyListKey, InListKey, OutListKey, DoneListKey} {
+		issues, err := p.listManager.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			exported = append(exported, newExportedIssue(issue, listID, userName, location))
+		}
+	}
+
+	return exported, nil
+}
+
+func newExportedIssue(issue *ExtendedIssue, listID, userName string, location *time.Location) *exportedIssue {
+	exported := &exportedIssue{
+		ID:            issue.ID,
+		List:          listKeyToName(listID),
+		Message:       issue.Message,
+		CreatedAt:     fromMillis(issue.CreateAt).In(location).Format(time.RFC3339),
+		PostID:        issue.PostID,
+		DeclineReason: issue.DeclineReason,
+	}
+
+	if issue.DueAt != 0 {
+		exported.DueAt = fromMillis(issue.DueAt).In(location).Format(time.RFC3339)
+	}
+	if issue.CompleteAt != 0 {
+		exported.CompletedAt = fromMillis(issue.CompleteAt).In(location).Format(time.RFC3339)
+	}
+
+	switch listID {
+	case OutListKey:
+		exported.Sender = userName
+		exported.Receiver = issue.ForeignUser
+		switch {
+		case issue.Status == IssueStatusDeclined:
+			exported.State = ExportStateDeclined
+		case issue.ForeignList == "in":
+			exported.State = ExportStatePending
+		case issue.Status == IssueStatusInProgress:
+			exported.State = ExportStateInProgress
+		default:
+			exported.State = ExportStateAccepted
+		}
+	case InListKey:
+		exported.Sender = issue.ForeignUser
+		exported.Receiver = userName
+		exported.State = ExportStatePending
+	case DoneListKey:
+		exported.Sender = issue.ForeignUser
+		if exported.Sender == "" {
+			exported.Sender = userName
+		}
+		exported.Receiver = userName
+		exported.State = ExportStateDone
+	default:
+		exported.Sender = issue.ForeignUser
+		if exported.Sender == "" {
+			exported.Sender = userName
+		}
+		exported.Receiver = userName
+		exported.State = ExportStateOpen
+		if issue.Status == IssueStatusInProgress {
+			exported.State = ExportStateInProgress
+		}
+	}
+
+	return exported
+}
+
+// encodeExport encodes the exported todos in format, and returns the content type of the result
+func encodeExport(issues []*exportedIssue, format string) ([]byte, string, error) {
+	switch format {
+	case ExportFormatJSON:
+		b, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+		return b, "application/json", nil
+	case ExportFormatCSV:
+		buf := &bytes.Buffer{}
+		writer := csv.NewWriter(buf)
+		_ = writer.Write([]string{"id", "list", "message", "created_at", "sender", "receiver", "state", "due_at", "completed_at", "post_id", "decline_reason"})
+		for _, issue := range issues {
+			_ = writer.Write([]string{
+				issue.ID,
+				issue.List,
+				issue.Message,
+				issue.CreatedAt,
+				issue.Sender,
+				issue.Receiver,
+				issue.State,
+				issue.DueAt,
+				issue.CompletedAt,
+				issue.PostID,
+				issue.DeclineReason,
+			})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "text/csv", nil
+	}
+
+	return nil, "", newLocalizedError(msgErrInvalidFormat, map[string]interface{}{"Value": format})
+}
+
+// exportFileName returns the name of the export file of userName created at now
+func exportFileName(userName, format string, now time.Time) string {
+	return fmt.Sprintf("todo-%s-%s.%s", userName, now.Format("2006-01-02-150405"), format)
+}
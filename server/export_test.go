@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExportedIssue(t *testing.T) {
+	issue := &ExtendedIssue{
+		Issue:       Issue{ID: "id", Message: "message", CreateAt: 1583830800000},
+		ForeignUser: "receiver",
+		ForeignList: "in",
+	}
+
+	exported := newExportedIssue(issue, OutListKey, "sender", time.UTC)
+	assert.Equal(t, "out", exported.List)
+	assert.Equal(t, "sender", exported.Sender)
+	assert.Equal(t, "receiver", exported.Receiver)
+	assert.Equal(t, ExportStatePending, exported.State)
+	assert.Equal(t, "2020-03-10T09:00:00Z", exported.CreatedAt)
+	assert.Empty(t, exported.DueAt)
+
+	issue.ForeignList = ""
+	assert.Equal(t, ExportStateAccepted, newExportedIssue(issue, OutListKey, "sender", time.UTC).State)
+
+	issue.Status = IssueStatusInProgress
+	assert.Equal(t, ExportStateInProgress, newExportedIssue(issue, OutListKey, "sender", time.UTC).State)
+
+	issue.Status = IssueStatusDeclined
+	assert.Equal(t, ExportStateDeclined, newExportedIssue(issue, OutListKey, "sender", time.UTC).State)
+
+	own := newExportedIssue(&ExtendedIssue{Issue: Issue{ID: "id"}}, MyListKey, "user", time.UTC)
+	assert.Equal(t, "user", own.Sender)
+	assert.Equal(t, "user", own.Receiver)
+	assert.Equal(t, ExportStateOpen, own.State)
+}
+
+func TestEncodeExport(t *testing.T) {
+	issues := []*exportedIssue{{ID: "id", List: "my", Message: "with, comma", State: ExportStateOpen}}
+
+	b, contentType, err := encodeExport(issues, ExportFormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", contentType)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `id,my,"with, comma",,,,open,,,,`, lines[1])
+
+	b, contentType, err = encodeExport(issues, ExportFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", contentType)
+	assert.Contains(t, string(b), `"message": "with, comma"`)
+
+	_, _, err = encodeExport(issues, "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+)
+
+// MaxAttachments is the maximum number of files attached to a todo
+const MaxAttachments = 10
+
+// errTooManyFiles is returned when attaching more than MaxAttachments files to a todo
+var errTooManyFiles = newLocalizedError(msgErrTooManyFiles, map[string]interface{}{"Max": MaxAttachments})
+
+// Attachment is a file uploaded to Mattermost and attached to a todo. The file stays with the todo once completed.
+type Attachment struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// URL is the download link of the file, set when the todo is shown
+	URL string `json:"url,omitempty"`
+}
+
+// postAttachments returns the files of postID, so a todo created from the post keeps them
+func postAttachments(api plugin.API, postID string) []*Attachment {
+	if postID == "" {
+		return nil
+	}
+
+	post, appErr := api.GetPost(postID)
+	if appErr != nil {
+		api.LogWarn("Unable to get the files of the post", "err", appErr.Error())
+		return nil
+	}
+
+	files := []*Attachment{}
+	for _, fileID := range post.FileIds {
+		if len(files) == MaxAttachments {
+			break
+		}
+
+		fileInfo, appErr := api.GetFileInfo(fileID)
+		if appErr != nil {
+			api.LogWarn("Unable to get the file of the post", "err", appErr.Error())
+			continue
+		}
+		files = append(files, &Attachment{ID: fileInfo.Id, Name: fileInfo.Name})
+	}
+	return files
+}
+
+// parseFileFlags removes the --file flags from args, and returns their values and the remaining arguments
+func parseFileFlags(args []string) ([]string, []string, error) {
+	references := []string{}
+	rest := []string{}
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--file" {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		if i+1 == len(args) {
+			return nil, nil, newLocalizedError(msgErrFileFlag, nil)
+		}
+		i++
+		references = append(references, args[i])
+	}
+	return references, rest, nil
+}
+
+// fileReferenceID returns the ID of the file of a reference, which is a file ID or a link to a file like
+// https://example.com/files/{id}/public or https://example.com/api/v4/files/{id}
+func fileReferenceID(reference string) string {
+	if i := strings.LastIndex(reference, "/files/"); i != -1 {
+		reference = reference[i+len("/files/"):]
+	}
+	if i := strings.IndexAny(reference, "/?"); i != -1 {
+		reference = reference[:i]
+	}
+	return reference
+}
+
+// getAttachments returns the files of the references that userID uploaded or can see in a channel
+func (p *Plugin) getAttachments(userID string, references []string) ([]*Attachment, error) {
+	if len(references) > MaxAttachments {
+		return nil, errTooManyFiles
+	}
+
+	files := []*Attachment{}
+	for _, reference := range references {
+		fileID := fileReferenceID(reference)
+		if !model.IsValidId(fileID) {
+			return nil, newLocalizedError(msgErrNotFileLink, map[string]interface{}{"Reference": reference})
+		}
+
+		fileInfo, appErr := p.API.GetFileInfo(fileID)
+		if appErr != nil || (fileInfo.CreatorId != userID && (fileInfo.PostId == "" || !p.canReadPost(userID, fileInfo.PostId))) {
+			return nil, newLocalizedError(msgErrFileNotFound, map[string]interface{}{"Reference": reference})
+		}
+		files = append(files, &Attachment{ID: fileInfo.Id, Name: fileInfo.Name})
+	}
+	return files, nil
+}
+
+// setFileURLs sets the download link of the files, using siteURL
+func setFileURLs(siteURL string, files []*Attachment) {
+	for _, file := range files {
+		file.URL = fmt.Sprintf("%s/api/v4/files/%s?download=1", siteURL, file.ID)
+	}
+}
+
+// fileLink renders the file as a markdown link, or only its name if it has no download link
+func fileLink(file *Attachment) string {
+	if file.URL == "" {
+		return file.Name
+	}
+	return fmt.Sprintf("[%s](%s)", file.Name, file.URL)
+}
+
+// addAttachments appends files to the files of issue, skipping the ones it already has
+func addAttachments(issue *Issue, files []*Attachment) error {
+	for _, file := range files {
+		found := false
+		for _, existing := range issue.Files {
+			found = found || existing.ID == file.ID
+		}
+		if found {
+			continue
+		}
+
+		if len(issue.Files) == MaxAttachments {
+			return errTooManyFiles
+		}
+		issue.Files = append(issue.Files, &Attachment{ID: file.ID, Name: file.Name})
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileFlags(t *testing.T) {
+	references, rest, err := parseFileFlags([]string{"Review", "--file", "abc", "the", "contract", "--file", "def"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc", "def"}, references)
+	assert.Equal(t, []string{"Review", "the", "contract"}, rest)
+
+	_, _, err = parseFileFlags([]string{"Review", "--file"})
+	assert.Error(t, err)
+}
+
+func TestFileReferenceID(t *testing.T) {
+	id := "kq8ruc1nbtgz7xyjpucwhge4gh"
+	assert.Equal(t, id, fileReferenceID(id))
+	assert.Equal(t, id, fileReferenceID("https://example.com/files/"+id+"/public?h=hash"))
+	assert.Equal(t, id, fileReferenceID("https://example.com/api/v4/files/"+id+"?download=1"))
+}
+
+func TestAddAttachments(t *testing.T) {
+	issue := &Issue{Files: []*Attachment{{ID: "a", Name: "a.pdf"}}}
+	require.NoError(t, addAttachments(issue, []*Attachment{{ID: "a", Name: "a.pdf"}, {ID: "b", Name: "b.png", URL: "link"}}))
+	assert.Equal(t, []*Attachment{{ID: "a", Name: "a.pdf"}, {ID: "b", Name: "b.png"}}, issue.Files)
+
+	files := []*Attachment{}
+	for i := 0; i < MaxAttachments; i++ {
+		files = append(files, &Attachment{ID: string(rune('c' + i))})
+	}
+	assert.Equal(t, errTooManyFiles, addAttachments(issue, files))
+}
+
+func TestFileLink(t *testing.T) {
+	file := &Attachment{ID: "a", Name: "a.pdf"}
+	assert.Equal(t, "a.pdf", fileLink(file))
+
+	setFileURLs("https://example.com", []*Attachment{file})
+	assert.Equal(t, "[a.pdf](https://example.com/api/v4/files/a?download=1)", fileLink(file))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// GitHubAPIURL is the base URL of the GitHub REST API
+	GitHubAPIURL = "https://api.github.com"
+	// GitHubTimeout is the timeout of the requests to the GitHub REST API
+	GitHubTimeout = 10 * time.Second
+	// GitHubRefreshInterval is how often the linked GitHub issues are refreshed
+	GitHubRefreshInterval = 15 * time.Minute
+
+	// GitHubStateOpen is the state of an open issue or pull request
+	GitHubStateOpen = "open"
+	// GitHubStateClosed is the state of a closed issue or pull request
+	GitHubStateClosed = "closed"
+	// GitHubStateMerged is the state of a merged pull request
+	GitHubStateMerged = "merged"
+)
+
+var gitHubURLRegexp = regexp.MustCompile(`^https?://github\.com/([\w.-]+)/([\w.-]+)/(issues|pull)/(\d+)(?:[/?#]\S*)?(?:\s+|$)`)
+
+// GitHubLink is the GitHub issue or pull request a todo is about, as it was when it was last refreshed
+type GitHubLink struct {
+	Owner       string `json:"owner"`
+	Repo        string `json:"repo"`
+	Number      int    `json:"number"`
+	PullRequest bool   `json:"pull_request,omitempty"`
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	URL         string `json:"url"`
+	RefreshedAt int64  `json:"refreshed_at"`
+}
+
+// GitHubLinkRef is a todo whose GitHub issue is refreshed periodically
+type GitHubLinkRef struct {
+	UserID  string `json:"user_id"`
+	IssueID string `json:"issue_id"`
+}
+
+type gitHubIssue struct {
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	HTMLURL     string `json:"html_url"`
+	PullRequest *struct {
+		MergedAt *string `json:"merged_at"`
+	} `json:"pull_request"`
+}
+
+// parseGitHubURL finds the GitHub issue or pull request URL at the start of message, returning the rest of the message
+func parseGitHubURL(message string) (*GitHubLink, string, bool) {
+	match := gitHubURLRegexp.FindStringSubmatch(message)
+	if match == nil {
+		return nil, message, false
+	}
+
+	number, err := strconv.Atoi(match[4])
+	if err != nil || number <= 0 {
+		return nil, message, false
+	}
+
+	link := &GitHubLink{
+		Owner:       match[1],
+		Repo:        match[2],
+		Number:      number,
+		PullRequest: match[3] == "pull",
+	}
+	return link, strings.TrimSpace(message[len(match[0]):]), true
+}
+
+// Reference returns the short reference of the issue, like org/repo#42
+func (l *GitHubLink) Reference() string {
+	return fmt.Sprintf("%s/%s#%d", l.Owner, l.Repo, l.Number)
+}
+
+// IsClosed checks whether the issue is closed or the pull request closed or merged
+func (l *GitHubLink) IsClosed() bool {
+	return l.State == GitHubStateClosed || l.State == GitHubStateMerged
+}
+
+func (l *GitHubLink) kind() string {
+	if l.PullRequest {
+		return "pull request"
+	}
+	return "issue"
+}
+
+// gitHubMessage returns the message of a todo about link, using the title of the issue unless the user wrote one
+func gitHubMessage(link *GitHubLink, message string) string {
+	if message == "" {
+		message = link.Title
+	}
+	return fmt.Sprintf("[%s](%s) %s", link.Reference(), link.URL, message)
+}
+
+// fetchGitHubLink gets the title and state of the issue or pull request of link from GitHub
+func fetchGitHubLink(client *http.Client, baseURL, token string, link *GitHubLink) error {
+	requestURL := fmt.Sprintf("%s/repos/%s/%s/issues/%d", strings.TrimSuffix(baseURL, "/"), link.Owner, link.Repo, link.Number)
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return newLocalizedError(msgErrGitHubNotFound, map[string]interface{}{"Reference": link.Reference()})
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return errors.Errorf("GitHub returned status code %d for %s", resp.StatusCode, link.Reference())
+	}
+
+	var issue gitHubIssue
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return err
+	}
+
+	link.Title = issue.Title
+	link.State = issue.State
+	link.URL = issue.HTMLURL
+	link.PullRequest = issue.PullRequest != nil
+	if issue.PullRequest != nil && issue.PullRequest.MergedAt != nil {
+		link.State = GitHubStateMerged
+	}
+	link.RefreshedAt = model.GetMillis()
+
+	return nil
+}
+
+func (p *Plugin) fetchGitHubLink(link *GitHubLink) error {
+	client := &http.Client{Timeout: GitHubTimeout}
+	return fetchGitHubLink(client, GitHubAPIURL, p.getConfiguration().GitHubToken, link)
+}
+
+// addGitHubIssue adds a todo about the GitHub issue or pull request of link to the list of userID, and starts
+// refreshing it
+func (p *Plugin) addGitHubIssue(userID string, link *GitHubLink, message string, dueAt int64) (*Issue, error) {
+	issue, err := p.listManager.AddIssue(userID, gitHubMessage(link, message), "", dueAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if issue, err = p.listManager.SetIssueGitHubLink(userID, issue.ID, link); err != nil {
+		return nil, err
+	}
+
+	if err = p.updateGitHubLinkRefs(&GitHubLinkRef{UserID: userID, IssueID: issue.ID}, true); err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+// runGitHubRefreshJob refreshes the GitHub issues that were not refreshed for GitHubRefreshInterval, completing the
+// todos of closed issues if the plugin is configured to
+func (p *Plugin) runGitHubRefreshJob(now time.Time) {
+	refs, _, err := p.getGitHubLinkRefs()
+	if err != nil {
+		p.API.LogError("cannot get GitHub links, Err=", err.Error())
+		return
+	}
+
+	config := p.getConfiguration()
+	for _, ref := range refs {
+		issue, err := p.listManager.GetIssue(ref.UserID, ref.IssueID)
+		if err == errIssueNotFound || (err == nil && (issue.GitHub == nil || issue.CompleteAt != 0)) {
+			p.forgetGitHubLink(ref)
+			continue
+		}
+		if err != nil {
+			p.API.LogError("cannot get GitHub linked issue, Err=", err.Error())
+			continue
+		}
+
+		link := issue.GitHub
+		if now.Sub(fromMillis(link.RefreshedAt)) < GitHubRefreshInterval {
+			continue
+		}
+
+		wasClosed := link.IsClosed()
+		if err = p.fetchGitHubLink(link); err != nil {
+			p.API.LogWarn("Unable to refresh GitHub issue", "issue", link.Reference(), "err", err.Error())
+			continue
+		}
+
+		if _, err = p.listManager.SetIssueGitHubLink(ref.UserID, ref.IssueID, link); err != nil {
+			p.API.LogError("cannot save GitHub link, Err=", err.Error())
+			continue
+		}
+
+		if !link.IsClosed() || wasClosed || !config.GitHubCompleteClosed {
+			continue
+		}
+
+		if _, err = p.listManager.CompleteIssue(ref.UserID, ref.IssueID); err != nil {
+			p.API.LogError("cannot complete GitHub linked issue, Err=", err.Error())
+			continue
+		}
+
+		p.forgetGitHubLink(ref)
+		p.sendRefreshEvent(ref.UserID)
+		p.PostBotDM(ref.UserID, p.localize(ref.UserID, msgNotifyGitHubClosed, map[string]interface{}{
+			"Kind":  link.kind(),
+			"Issue": link.Reference(),
+			"URL":   link.URL,
+			"State": link.State,
+			"Todo":  issue.Message,
+		}))
+	}
+}
+
+func (p *Plugin) forgetGitHubLink(ref *GitHubLinkRef) {
+	if err := p.updateGitHubLinkRefs(ref, false); err != nil {
+		p.API.LogError("cannot remove GitHub link, Err=", err.Error())
+	}
+}
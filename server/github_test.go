@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitHubURL(t *testing.T) {
+	link, rest, ok := parseGitHubURL("https://github.com/mattermost/mattermost-server/issues/42")
+	require.True(t, ok)
+	assert.Equal(t, "mattermost", link.Owner)
+	assert.Equal(t, "mattermost-server", link.Repo)
+	assert.Equal(t, 42, link.Number)
+	assert.False(t, link.PullRequest)
+	assert.Equal(t, "", rest)
+	assert.Equal(t, "mattermost/mattermost-server#42", link.Reference())
+
+	link, rest, ok = parseGitHubURL("https://github.com/org/repo.js/pull/7/files Review the tests")
+	require.True(t, ok)
+	assert.Equal(t, "repo.js", link.Repo)
+	assert.True(t, link.PullRequest)
+	assert.Equal(t, "Review the tests", rest)
+
+	_, _, ok = parseGitHubURL("Read https://github.com/org/repo/issues/42")
+	assert.False(t, ok)
+	_, _, ok = parseGitHubURL("https://github.com/org/repo/issues/42abc")
+	assert.False(t, ok)
+	_, _, ok = parseGitHubURL("https://github.com/org/repo")
+	assert.False(t, ok)
+}
+
+func TestGitHubMessage(t *testing.T) {
+	link := &GitHubLink{Owner: "org", Repo: "repo", Number: 42, Title: "Crash on start", URL: "https://github.com/org/repo/issues/42"}
+	assert.Equal(t, "[org/repo#42](https://github.com/org/repo/issues/42) Crash on start", gitHubMessage(link, ""))
+	assert.Equal(t, "[org/repo#42](https://github.com/org/repo/issues/42) Reproduce it", gitHubMessage(link, "Reproduce it"))
+}
+
+func TestFetchGitHubLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/org/repo/issues/1":
+			_, _ = w.Write([]byte(`{"title": "Crash on start", "state": "open", "html_url": "https://github.com/org/repo/issues/1"}`))
+		case "/repos/org/repo/issues/2":
+			_, _ = w.Write([]byte(`{"title": "Fix the crash", "state": "closed", "html_url": "https://github.com/org/repo/pull/2", "pull_request": {"merged_at": "2020-03-10T09:00:00Z"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	link := &GitHubLink{Owner: "org", Repo: "repo", Number: 1}
+	require.NoError(t, fetchGitHubLink(server.Client(), server.URL, "secret", link))
+	assert.Equal(t, "Crash on start", link.Title)
+	assert.Equal(t, GitHubStateOpen, link.State)
+	assert.False(t, link.IsClosed())
+	assert.NotZero(t, link.RefreshedAt)
+
+	link = &GitHubLink{Owner: "org", Repo: "repo", Number: 2}
+	require.NoError(t, fetchGitHubLink(server.Client(), server.URL, "secret", link))
+	assert.True(t, link.PullRequest)
+	assert.Equal(t, GitHubStateMerged, link.State)
+	assert.True(t, link.IsClosed())
+
+	assert.Error(t, fetchGitHubLink(server.Client(), server.URL, "secret", &GitHubLink{Owner: "org", Repo: "repo", Number: 3}))
+}
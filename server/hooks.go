@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HooksPath is the path prefix of the incoming webhooks, followed by the token
+	HooksPath = "/api/v1/hooks"
+	// MaxHookTokens is the maximum number of incoming webhook tokens of a user
+	MaxHookTokens = 10
+	// MaxHookNameLength is the maximum length in characters of the name of an incoming webhook token
+	MaxHookNameLength = 64
+	// MaxHookBodySize is the maximum size in bytes of the body of an incoming webhook request
+	MaxHookBodySize = 1024 * 1024
+	// TokenHeader is the header of the REST API requests authenticated with a token of the plugin
+	TokenHeader = "X-Todo-Token"
+
+	// TokenScopeRead allows reading the lists of the owner of a token through the REST API
+	TokenScopeRead = "read"
+	// TokenScopeWrite allows changing the lists of the owner of a token, and adding todos through the incoming webhook
+	TokenScopeWrite = "write"
+	// TokenScopeSend allows sending todos to other users on behalf of the owner of a token
+	TokenScopeSend = "send"
+)
+
+// tokenScopes are the valid scopes of a token, in the order they are shown
+var tokenScopes = []string{TokenScopeRead, TokenScopeWrite, TokenScopeSend}
+
+// defaultTokenScopes are the scopes of the tokens created without scopes, and of the tokens created before there
+// were scopes, which could only be used by the incoming webhook
+var defaultTokenScopes = []string{TokenScopeWrite, TokenScopeSend}
+
+var errHookTokenNotFound = newLocalizedError(msgErrTokenNotFound, nil)
+
+var errTooManyHookTokens = newLocalizedError(msgErrTooManyTokens, map[string]interface{}{"Max": MaxHookTokens})
+
+// HookToken lets external systems manage the lists of its owner through an incoming webhook or the REST API, as far
+// as its scopes allow. Only the hash of the token is stored, so the token itself is shown once when it is created.
+type HookToken struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	UserID   string   `json:"user_id"`
+	Hash     string   `json:"hash"`
+	CreateAt int64    `json:"create_at"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// getScopes returns the scopes of the token, which are the default ones if it has none
+func (t *HookToken) getScopes() []string {
+	if len(t.Scopes) == 0 {
+		return defaultTokenScopes
+	}
+	return t.Scopes
+}
+
+// hasScope checks whether the token can be used for scope
+func (t *HookToken) hasScope(scope string) bool {
+	for _, s := range t.getScopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type hookRequest struct {
+	Message string `json:"message"`
+	Due     string `json:"due"`
+	SendTo  string `json:"send_to"`
+}
+
+// hashToken returns the hash under which a secret token, like a webhook or calendar token, is stored
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// parseTokenScopes parses a comma separated list of scopes, like read,write. It returns false if any of them is not
+// a valid scope.
+func parseTokenScopes(arg string) ([]string, bool) {
+	found := map[string]bool{}
+	for _, scope := range strings.Split(strings.ToLower(arg), ",") {
+		valid := false
+		for _, s := range tokenScopes {
+			valid = valid || s == scope
+		}
+		if !valid {
+			return nil, false
+		}
+		found[scope] = true
+	}
+
+	scopes := []string{}
+	for _, scope := range tokenScopes {
+		if found[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, true
+}
+
+// createHookToken creates a token for userID with the given scopes, returning it along with the secret used in the
+// webhook URL and the REST API requests
+func (p *Plugin) createHookToken(userID, name string, scopes []string) (*HookToken, string, error) {
+	secret := model.NewId() + model.NewId()
+	token := &HookToken{
+		ID:       model.NewId(),
+		Name:     name,
+		UserID:   userID,
+		Hash:     hashToken(secret),
+		CreateAt: model.GetMillis(),
+		Scopes:   scopes,
+	}
+
+	if err := p.addHookToken(token); err != nil {
+		return nil, "", err
+	}
+
+	return token, secret, nil
+}
+
+// hookURL returns the URL external systems post the todos to
+func (p *Plugin) hookURL(secret string) string {
+	return p.pluginURL(HooksPath + "/" + secret)
+}
+
+// authenticateToken returns the token of secret and its owner, or nil if the token is not valid or its owner is not
+// active
+func (p *Plugin) authenticateToken(secret string) (*HookToken, *model.User, error) {
+	if secret == "" {
+		return nil, nil, nil
+	}
+
+	token, err := p.getHookToken(hashToken(secret))
+	if err != nil || token == nil {
+		return nil, nil, err
+	}
+
+	owner, appErr := p.API.GetUser(token.UserID)
+	if appErr != nil || owner.DeleteAt != 0 {
+		return nil, nil, nil
+	}
+
+	return token, owner, nil
+}
+
+// serveHook adds the todo in the body of the request to the list of the owner of the token in the path, or sends it
+// to the user in send_to. Requests are not authenticated by Mattermost, so the token is all that identifies the owner.
+func (p *Plugin) serveHook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		p.handleErrorWithCode(w, http.StatusMethodNotAllowed, "Method not allowed", errors.New("incoming webhooks only accept POST requests"))
+		return
+	}
+
+	secret := strings.Trim(strings.TrimPrefix(r.URL.Path, HooksPath), "/")
+	token, owner, err := p.authenticateToken(secret)
+	if err != nil {
+		p.API.LogError("Unable to get hook token err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get token", err)
+		return
+	}
+	if token == nil {
+		p.handleErrorWithCode(w, http.StatusUnauthorized, "Not authorized", errors.New("the token is not valid or its owner is not active"))
+		return
+	}
+
+	var request *hookRequest
+	if err = json.NewDecoder(io.LimitReader(r.Body, MaxHookBodySize)).Decode(&request); err != nil || request == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+		return
+	}
+
+	message, dueAt, err := extractRequestDueDate(request.Message, request.Due, time.Now().In(p.getUserLocation(owner.Id)))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid due date", err)
+		return
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+		return
+	}
+
+	sendTo := strings.TrimPrefix(request.SendTo, "@")
+	if sendTo == "" || sendTo == owner.Username {
+		if !token.hasScope(TokenScopeWrite) {
+			p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("the token does not have the write scope"))
+			return
+		}
+
+		if err = p.checkTodoLimit(owner.Id, 1); err != nil {
+			p.handleLimitError(w, "Todo limit reached", err)
+			return
+		}
+
+		issue, err := p.listManager.AddIssue(owner.Id, message, "", dueAt)
+		if err != nil {
+			p.refundRate(owner.Id, rateActionAdd, 1)
+			p.API.LogError("Unable to add issue err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
+			return
+		}
+
+		p.sendRefreshEvent(owner.Id)
+		p.writeAPIResponse(w, http.StatusCreated, issue)
+		return
+	}
+
+	if !token.hasScope(TokenScopeSend) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("the token does not have the send scope"))
+		return
+	}
+
+	if !p.API.HasPermissionTo(owner.Id, model.PERMISSION_CREATE_DIRECT_CHANNEL) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("the owner of the token does not have permission to send todos"))
+		return
+	}
+
+	receiver, appErr := p.API.GetUserByUsername(sendTo)
+	if appErr != nil {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", appErr)
+		return
+	}
+
+	if receiver.DeleteAt != 0 || receiver.IsBot {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("todos can only be sent to active users"))
+		return
+	}
+
+	if err = p.checkSendAllowed(owner.Id, receiver, 1); err != nil {
+		p.handleLimitError(w, "Not allowed", err)
+		return
+	}
+
+	issueID, err := p.listManager.SendIssue(owner.Id, receiver.Id, message, "", dueAt)
+	if err != nil {
+		p.refundRate(owner.Id, rateActionSend, 1)
+		p.API.LogError("Unable to send issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(owner.Id)
+	p.notifySend(owner.Id, receiver.Id, message, issueID, dueAt)
+
+	p.writeAPIResponse(w, http.StatusCreated, map[string]interface{}{"status": "OK", "due_at": dueAt})
+}
+
+func hookTokensToString(T translateFunc, tokens []*HookToken, location *time.Location) string {
+	if len(tokens) == 0 {
+		return T(msgTokensNone, nil)
+	}
+
+	str := T(msgTokensTitle, nil) + "\n\n"
+	for _, token := range tokens {
+		name := token.Name
+		if name == "" {
+			name = T(msgTokensUnnamed, nil)
+		}
+		str += "* " + T(msgTokensToken, map[string]interface{}{
+			"Name":   name,
+			"ID":     token.ID,
+			"Date":   fromMillis(token.CreateAt).In(location).Format("January 2, 2006 at 15:04"),
+			"Scopes": strings.Join(token.getScopes(), ", "),
+		}) + "\n"
+	}
+
+	return str
+}
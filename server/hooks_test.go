@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashToken(t *testing.T) {
+	assert.Len(t, hashToken("secret"), 64)
+	assert.Equal(t, hashToken("secret"), hashToken("secret"))
+	assert.NotEqual(t, hashToken("secret"), hashToken("other"))
+}
+
+func TestHookTokensToString(t *testing.T) {
+	assert.Contains(t, hookTokensToString(renderEnglish, []*HookToken{}, time.UTC), "You have no tokens")
+
+	str := hookTokensToString(renderEnglish, []*HookToken{
+		{ID: "id1", Name: "monitoring", CreateAt: 1583830800000},
+		{ID: "id2", CreateAt: 1583830800000},
+	}, time.UTC)
+	assert.Contains(t, str, "* **monitoring** `id1`, created on March 10, 2020 at 09:00")
+	assert.Contains(t, str, "* **Unnamed** `id2`")
+}
+
+func TestServeHook(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("KVGet", mock.AnythingOfType("string")).Return(nil, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	t.Run("only POST", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, httptest.NewRequest(http.MethodGet, HooksPath+"/secret", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, httptest.NewRequest(http.MethodPost, HooksPath+"/secret", strings.NewReader(`{"message": "todo"}`)))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		api.AssertCalled(t, "KVGet", hookTokenKey(hashToken("secret")))
+	})
+}
+
+func TestParseTokenScopes(t *testing.T) {
+	scopes, ok := parseTokenScopes("send,READ,send")
+	assert.True(t, ok)
+	assert.Equal(t, []string{TokenScopeRead, TokenScopeSend}, scopes)
+
+	_, ok = parseTokenScopes("monitoring")
+	assert.False(t, ok)
+	_, ok = parseTokenScopes("read,admin")
+	assert.False(t, ok)
+}
+
+func TestHookTokenHasScope(t *testing.T) {
+	legacy := &HookToken{}
+	assert.True(t, legacy.hasScope(TokenScopeWrite))
+	assert.True(t, legacy.hasScope(TokenScopeSend))
+	assert.False(t, legacy.hasScope(TokenScopeRead))
+
+	readOnly := &HookToken{Scopes: []string{TokenScopeRead}}
+	assert.True(t, readOnly.hasScope(TokenScopeRead))
+	assert.False(t, readOnly.hasScope(TokenScopeWrite))
+}
+
+func TestAPIv2Scope(t *testing.T) {
+	assert.Equal(t, TokenScopeRead, apiV2Scope(httptest.NewRequest(http.MethodGet, APIv2Prefix+"/todos", nil)))
+	assert.Equal(t, TokenScopeWrite, apiV2Scope(httptest.NewRequest(http.MethodPost, APIv2Prefix+"/todos", nil)))
+	assert.Equal(t, TokenScopeWrite, apiV2Scope(httptest.NewRequest(http.MethodDelete, APIv2Prefix+"/todos/abc", nil)))
+	assert.Equal(t, TokenScopeSend, apiV2Scope(httptest.NewRequest(http.MethodPost, APIv2Prefix+"/send", nil)))
+}
+
+func TestServeAPIv2Token(t *testing.T) {
+	token := &HookToken{ID: "id", UserID: "user_id", Scopes: []string{TokenScopeRead}}
+	tokenBytes, err := json.Marshal(token)
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	api.On("KVGet", hookTokenKey(hashToken("secret"))).Return(tokenBytes, nil)
+	api.On("KVGet", mock.AnythingOfType("string")).Return(nil, nil)
+	api.On("GetUser", "user_id").Return(&model.User{Id: "user_id"}, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	t.Run("unknown token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, APIv2Prefix+"/todos", nil)
+		r.Header.Set(TokenHeader, "other")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, APIv2Prefix+"/todos", strings.NewReader(`{"message": "todo"}`))
+		r.Header.Set(TokenHeader, "secret")
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mattermost/go-i18n/i18n/bundle"
+	"github.com/pkg/errors"
+)
+
+// I18nDir is the directory of the translation files, relative to the bundle of the plugin
+const I18nDir = "assets/i18n"
+
+// message is a server-generated text shown to users. Text is the English translation, a Go template filled with the
+// data given when it is localized. The other translations are loaded from I18nDir, with the ID as key.
+type message struct {
+	ID   string
+	Text string
+	tmpl *template.Template
+}
+
+// allMessages are the messages that can be translated, by ID
+var allMessages = map[string]*message{}
+
+// newMessage registers a translatable message, with its English text
+func newMessage(id, text string) *message {
+	if _, ok := allMessages[id]; ok {
+		panic("duplicated message id " + id)
+	}
+
+	m := &message{ID: id, Text: text, tmpl: template.Must(template.New(id).Parse(text))}
+	allMessages[id] = m
+	return m
+}
+
+// render fills the English text of the message with data
+func (m *message) render(data map[string]interface{}) string {
+	var b strings.Builder
+	if err := m.tmpl.Execute(&b, data); err != nil {
+		return m.Text
+	}
+	return b.String()
+}
+
+// loadTranslations loads the translation files in dir, named after their locale like es.json
+func loadTranslations(dir string) (*bundle.Bundle, error) {
+	b := bundle.New()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = b.ParseTranslationFileBytes(file, data); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse translation file %s", filepath.Base(file))
+		}
+	}
+
+	return b, nil
+}
+
+// translate returns msg in locale filled with data, or in English if there is no translation for it
+func (p *Plugin) translate(locale string, msg *message, data map[string]interface{}) string {
+	if p.translations != nil && locale != "" && locale != "en" {
+		if tfunc, err := p.translations.Tfunc(locale); err == nil {
+			if text := tfunc(msg.ID, data); text != msg.ID {
+				return text
+			}
+		}
+	}
+
+	return msg.render(data)
+}
+
+// localize returns msg in the language of userID filled with data
+func (p *Plugin) localize(userID string, msg *message, data map[string]interface{}) string {
+	return p.translate(p.getUserLocale(userID), msg, data)
+}
+
+// translateFunc returns a message filled with data in the language it was made for, see translator
+type translateFunc func(msg *message, data map[string]interface{}) string
+
+// renderEnglish is the translateFunc rendering the messages in English
+func renderEnglish(msg *message, data map[string]interface{}) string {
+	return msg.render(data)
+}
+
+// translator returns the translateFunc for the language of userID, looked up once for the messages built together
+func (p *Plugin) translator(userID string) translateFunc {
+	locale := p.getUserLocale(userID)
+	return func(msg *message, data map[string]interface{}) string {
+		return p.translate(locale, msg, data)
+	}
+}
+
+// localizable is an error the user can fix. It is shown in the language of the user by the commands, and in English
+// by the API and in the logs.
+type localizable interface {
+	error
+	localize(T translateFunc) string
+}
+
+// localizedError is a localizable error with a message filled with data
+type localizedError struct {
+	msg  *message
+	data map[string]interface{}
+}
+
+// newLocalizedError returns a localizedError with msg filled with data
+func newLocalizedError(msg *message, data map[string]interface{}) error {
+	return &localizedError{msg: msg, data: data}
+}
+
+// localize renders the error with T, translating the values of data that are localizable themselves, such as a
+// wrapped localizedError
+func (e *localizedError) localize(T translateFunc) string {
+	data := make(map[string]interface{}, len(e.data))
+	for key, value := range e.data {
+		if err, ok := value.(error); ok {
+			value = errors.Cause(err)
+		}
+		if localized, ok := value.(localizable); ok {
+			value = localized.localize(T)
+		}
+		data[key] = value
+	}
+	return T(e.msg, data)
+}
+
+func (e *localizedError) Error() string {
+	return e.localize(renderEnglish)
+}
+
+// localizeError returns err in the language of userID if it is localizable, and as it is otherwise
+func (p *Plugin) localizeError(userID string, err error) string {
+	if localized, ok := errors.Cause(err).(localizable); ok {
+		return localized.localize(p.translator(userID))
+	}
+	return err.Error()
+}
+
+// localizeServer returns msg in the default language of the server, for posts seen by many users
+func (p *Plugin) localizeServer(msg *message, data map[string]interface{}) string {
+	if p.translations == nil {
+		return msg.render(data)
+	}
+
+	locale := ""
+	if config := p.API.GetConfig(); config != nil && config.LocalizationSettings.DefaultServerLocale != nil {
+		locale = *config.LocalizationSettings.DefaultServerLocale
+	}
+	return p.translate(locale, msg, data)
+}
+
+// getUserLocale returns the language chosen by userID in Mattermost
+func (p *Plugin) getUserLocale(userID string) string {
+	if p.translations == nil {
+		return ""
+	}
+
+	user, appErr := p.getUser(userID)
+	if appErr != nil {
+		return ""
+	}
+	return user.Locale
+}
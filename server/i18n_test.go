@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"text/template"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnglishTranslationsMatchMessages(t *testing.T) {
+	data, err := ioutil.ReadFile("../assets/i18n/en.json")
+	require.NoError(t, err)
+
+	var translations []struct {
+		ID          string `json:"id"`
+		Translation string `json:"translation"`
+	}
+	require.NoError(t, json.Unmarshal(data, &translations))
+
+	texts := map[string]string{}
+	for _, translation := range translations {
+		texts[translation.ID] = translation.Translation
+	}
+
+	require.Len(t, texts, len(allMessages))
+	for id, msg := range allMessages {
+		assert.Equal(t, msg.Text, texts[id], id)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	translations, err := loadTranslations("../assets/i18n")
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1", Locale: "es"}, nil)
+	api.On("GetUser", "user2").Return(&model.User{Id: "user2", Locale: "fr"}, nil)
+
+	p := &Plugin{translations: translations}
+	p.SetAPI(api)
+
+	data := map[string]interface{}{"User": "alice", "Todo": "Write docs"}
+	assert.Equal(t, "@alice aceptó un Todo que enviaste: Write docs", p.localize("user1", msgNotifyAccepted, data))
+
+	// Languages without translations fall back to English
+	assert.Equal(t, "@alice accepted a Todo you sent: Write docs", p.localize("user2", msgNotifyAccepted, data))
+
+	assert.Contains(t, p.localize("user1", msgHelp, map[string]interface{}{"PageSize": ListPageSize}), "Comandos disponibles:")
+
+	// Messages without translations fall back to English
+	untranslated := &message{ID: "test.untranslated", Text: "Hello @{{.User}}", tmpl: template.Must(template.New("test.untranslated").Parse("Hello @{{.User}}"))}
+	assert.Equal(t, "Hello @alice", p.localize("user1", untranslated, data))
+
+	// Optional parts of a message are left out without data
+	assert.Equal(t, "@alice forwarded you a Todo from @bob", p.localize("user2", msgNotifyForwarded, map[string]interface{}{"User": "alice", "Sender": "bob"}))
+	assert.Equal(t, "@alice forwarded you a Todo from @bob (\"Thanks\")", p.localize("user2", msgNotifyForwarded, map[string]interface{}{"User": "alice", "Sender": "bob", "Note": "Thanks"}))
+
+	// Without translations, messages are in English
+	p.translations = nil
+	assert.Equal(t, "@alice accepted a Todo you sent: Write docs", p.localize("user1", msgNotifyAccepted, data))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CalendarPath is the path prefix of the calendar feeds, followed by the feed token and .ics
+	CalendarPath = "/calendar"
+	// CalendarEventDuration is the length of the event of a due todo in the calendar
+	CalendarEventDuration = 30 * time.Minute
+
+	icalTimeFormat   = "20060102T150405Z"
+	icalMaxLineBytes = 75
+)
+
+// calendarEvent is a due todo as shown in the calendar feed
+type calendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	CreatedAt   time.Time
+}
+
+// calendarEvents returns the events of the todos with a due date on the list and the received list of userID
+func (p *Plugin) calendarEvents(userID string) ([]*calendarEvent, error) {
+	T := p.translator(userID)
+	events := []*calendarEvent{}
+	for _, listID := range []string{MyListKey, InListKey} {
+		issues, err := p.listManager.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if issue.DueAt == 0 {
+				continue
+			}
+
+			description := T(msgCalendarMyList, nil)
+			if listID == InListKey {
+				description = T(msgCalendarReceived, map[string]interface{}{"User": issue.ForeignUser})
+			}
+
+			events = append(events, &calendarEvent{
+				UID:         issue.ID + "@" + manifest.Id,
+				Summary:     issue.Message,
+				Description: description,
+				Start:       fromMillis(issue.DueAt),
+				CreatedAt:   fromMillis(issue.CreateAt),
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// encodeICalendar serializes the events as an iCalendar (RFC 5545) file
+func encodeICalendar(name string, events []*calendarEvent, now time.Time) []byte {
+	var b bytes.Buffer
+	writeICalLine(&b, "BEGIN:VCALENDAR")
+	writeICalLine(&b, "VERSION:2.0")
+	writeICalLine(&b, "PRODID:-//Mattermost//"+manifest.Name+"//EN")
+	writeICalLine(&b, "CALSCALE:GREGORIAN")
+	writeICalLine(&b, "METHOD:PUBLISH")
+	writeICalLine(&b, "X-WR-CALNAME:"+escapeICalText(name))
+
+	for _, event := range events {
+		writeICalLine(&b, "BEGIN:VEVENT")
+		writeICalLine(&b, "UID:"+event.UID)
+		writeICalLine(&b, "DTSTAMP:"+now.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "CREATED:"+event.CreatedAt.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTSTART:"+event.Start.UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "DTEND:"+event.Start.Add(CalendarEventDuration).UTC().Format(icalTimeFormat))
+		writeICalLine(&b, "SUMMARY:"+escapeICalText(event.Summary))
+		if event.Description != "" {
+			writeICalLine(&b, "DESCRIPTION:"+escapeICalText(event.Description))
+		}
+		writeICalLine(&b, "TRANSP:TRANSPARENT")
+		writeICalLine(&b, "END:VEVENT")
+	}
+
+	writeICalLine(&b, "END:VCALENDAR")
+	return b.Bytes()
+}
+
+// escapeICalText escapes the characters with a meaning in iCalendar text values
+func escapeICalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(text)
+}
+
+// writeICalLine writes a content line, folding it into lines of at most 75 bytes without splitting characters
+func writeICalLine(b *bytes.Buffer, line string) {
+	limit := icalMaxLineBytes
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = icalMaxLineBytes - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+// calendarURL returns the URL of the calendar feed with the token
+func (p *Plugin) calendarURL(token string) string {
+	return p.pluginURL(CalendarPath + "/" + token + ".ics")
+}
+
+// createCalendarToken creates a new feed token for userID, replacing the previous one
+func (p *Plugin) createCalendarToken(userID string) (string, error) {
+	token := model.NewId() + model.NewId()
+	if err := p.saveCalendarToken(userID, hashToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// serveCalendar serves the calendar feed of the owner of the token in the path. Calendar applications cannot log in
+// to Mattermost, so the token is all that identifies the owner.
+func (p *Plugin) serveCalendar(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		p.handleErrorWithCode(w, http.StatusMethodNotAllowed, "Method not allowed", errors.New("calendar feeds are read only"))
+		return
+	}
+
+	token := strings.TrimSuffix(strings.Trim(strings.TrimPrefix(r.URL.Path, CalendarPath), "/"), ".ics")
+	userID, err := p.getCalendarTokenOwner(hashToken(token))
+	if err != nil {
+		p.API.LogError("Unable to get calendar token err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get token", err)
+		return
+	}
+	if token == "" || userID == "" {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Not found", errors.New("the calendar does not exist"))
+		return
+	}
+
+	// The feeds of deactivated users are gone, even if their URL leaked
+	owner, appErr := p.API.GetUser(userID)
+	if appErr != nil || owner.DeleteAt != 0 {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Not found", errors.New("the calendar does not exist"))
+		return
+	}
+
+	events, err := p.calendarEvents(userID)
+	if err != nil {
+		p.API.LogError("Unable to get calendar events err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issues for user", err)
+		return
+	}
+
+	name := p.localize(userID, msgCalendarName, map[string]interface{}{"User": p.listManager.GetUserName(userID)})
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(encodeICalendar(name, events, time.Now()))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeICalText(t *testing.T) {
+	assert.Equal(t, `Buy milk\, eggs\; bread \\ butter\nand jam`, escapeICalText("Buy milk, eggs; bread \\ butter\nand jam"))
+}
+
+func TestWriteICalLine(t *testing.T) {
+	var b bytes.Buffer
+	writeICalLine(&b, "SUMMARY:short")
+	assert.Equal(t, "SUMMARY:short\r\n", b.String())
+
+	b.Reset()
+	line := "SUMMARY:" + strings.Repeat("é", 100)
+	writeICalLine(&b, line)
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.True(t, len(lines) > 1)
+	for i, l := range lines {
+		assert.True(t, len(l) <= 75)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(l, " "))
+		}
+	}
+
+	unfolded := strings.Replace(strings.TrimSuffix(b.String(), "\r\n"), "\r\n ", "", -1)
+	assert.Equal(t, line, unfolded)
+}
+
+func TestEncodeICalendar(t *testing.T) {
+	now := time.Date(2020, 3, 10, 9, 0, 0, 0, time.UTC)
+	events := []*calendarEvent{{
+		UID:         "id@" + manifest.Id,
+		Summary:     "Pay invoice, today",
+		Description: "On your Todo list",
+		Start:       time.Date(2020, 3, 11, 17, 0, 0, 0, time.FixedZone("CET", 3600)),
+		CreatedAt:   now,
+	}}
+
+	ics := string(encodeICalendar("Todos of @alice", events, now))
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "X-WR-CALNAME:Todos of @alice\r\n")
+	assert.Contains(t, ics, "UID:id@"+manifest.Id+"\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20200310T090000Z\r\n")
+	assert.Contains(t, ics, "DTSTART:20200311T160000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20200311T163000Z\r\n")
+	assert.Contains(t, ics, "SUMMARY:Pay invoice\\, today\r\n")
+}
+
+func TestServeCalendarDeactivatedUser(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("KVGet", calendarTokenKey(hashToken("secret"))).Return([]byte("user1"), nil)
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1", DeleteAt: 1}, nil)
+
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	w := httptest.NewRecorder()
+	p.serveCalendar(w, httptest.NewRequest(http.MethodGet, CalendarPath+"/secret.ics", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+type calendarListManager struct {
+	ListManager
+	lists map[string][]*ExtendedIssue
+}
+
+func (m *calendarListManager) GetIssueList(userID, listID string) ([]*ExtendedIssue, error) {
+	return m.lists[listID], nil
+}
+
+func TestCalendarEventsLocalized(t *testing.T) {
+	translations, err := loadTranslations("../assets/i18n")
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1", Locale: "es"}, nil)
+
+	p := &Plugin{translations: translations}
+	p.SetAPI(api)
+	p.listManager = &calendarListManager{lists: map[string][]*ExtendedIssue{
+		MyListKey: {{Issue: Issue{ID: "issue1", Message: "Pay invoice", DueAt: 1583942400000}}},
+		InListKey: {
+			{Issue: Issue{ID: "issue2", Message: "Review PR", DueAt: 1583942400000}, ForeignUser: "alice"},
+			{Issue: Issue{ID: "issue3", Message: "Read docs"}, ForeignUser: "bob"},
+		},
+	}}
+
+	events, err := p.calendarEvents("user1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "En tu lista de Todos", events[0].Description)
+	assert.Equal(t, "Recibido de @alice, aún no aceptado", events[1].Description)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxImportFileSize is the maximum size in bytes of a file that can be imported
+	MaxImportFileSize = 5 * 1024 * 1024
+	// MaxImportIssues is the maximum number of todos created by a single import
+	MaxImportIssues = 1000
+	// MaxImportSkipsShown is the maximum number of skipped entries listed by the import command
+	MaxImportSkipsShown = 20
+)
+
+// importItem is a todo read from an import file, numbered by its position in the file
+type importItem struct {
+	Item    int    `json:"item"`
+	Message string `json:"message"`
+	DueAt   int64  `json:"due_at,omitempty"`
+}
+
+// importSkip is an entry of an import file that was not imported, and why
+type importSkip struct {
+	Item    int    `json:"item"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+
+	reason error
+}
+
+// newImportSkip returns the entry item of an import file with message skipped because of reason
+func newImportSkip(item int, message string, reason error) *importSkip {
+	return &importSkip{Item: item, Message: message, Reason: reason.Error(), reason: reason}
+}
+
+// localizedReason returns why the entry was skipped translated with T
+func (s *importSkip) localizedReason(T translateFunc) string {
+	if localized, ok := s.reason.(localizable); ok {
+		return localized.localize(T)
+	}
+	return s.Reason
+}
+
+// importResult summarizes an import
+type importResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Created []*importItem `json:"created"`
+	Skipped []*importSkip `json:"skipped"`
+}
+
+// importIssues adds the todos in data to the list of userID. If dryRun is set, the file is only parsed.
+func (p *Plugin) importIssues(userID string, data []byte, dryRun bool) (*importResult, error) {
+	if len(data) > MaxImportFileSize {
+		return nil, newLocalizedError(msgErrFileTooLarge, map[string]interface{}{"Max": MaxImportFileSize / 1024 / 1024})
+	}
+
+	items, skipped, err := parseImport(data, time.Now().In(p.getUserLocation(userID)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &importResult{
+		DryRun:  dryRun,
+		Created: []*importItem{},
+		Skipped: skipped,
+	}
+
+	room, err := p.todoRoom(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if len(result.Created) >= MaxImportIssues {
+			result.Skipped = append(result.Skipped, newImportSkip(item.Item, item.Message, newLocalizedError(msgImportTooMany, map[string]interface{}{"Max": MaxImportIssues})))
+			continue
+		}
+		if room >= 0 && len(result.Created) >= room {
+			result.Skipped = append(result.Skipped, newImportSkip(item.Item, item.Message, newLocalizedError(msgImportListFull, nil)))
+			continue
+		}
+		if err := p.checkMessage(item.Message); err != nil {
+			result.Skipped = append(result.Skipped, newImportSkip(item.Item, item.Message, err))
+			continue
+		}
+
+		if !dryRun {
+			if _, err := p.listManager.AddIssue(userID, item.Message, "", item.DueAt); err != nil {
+				p.API.LogError("Unable to import issue err=" + err.Error())
+				result.Skipped = append(result.Skipped, newImportSkip(item.Item, item.Message, newLocalizedError(msgImportNotSaved, nil)))
+				continue
+			}
+		}
+		result.Created = append(result.Created, item)
+	}
+
+	if !dryRun && len(result.Created) > 0 {
+		p.sendRefreshEvent(userID)
+	}
+
+	return result, nil
+}
+
+// parseImport reads the todos from a file in any of the supported formats: the CSV and JSON exports of this plugin,
+// the Todoist CSV export, the Wunderlist backup and any CSV file with a message, content, title or task column.
+// Relative due dates are understood from now.
+func parseImport(data []byte, now time.Time) ([]*importItem, []*importSkip, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil, newLocalizedError(msgErrImportEmpty, nil)
+	}
+
+	switch trimmed[0] {
+	case '[':
+		return parseJSONImport(trimmed, now)
+	case '{':
+		return parseWunderlistImport(trimmed, now)
+	}
+	return parseCSVImport(trimmed, now)
+}
+
+func parseJSONImport(data []byte, now time.Time) ([]*importItem, []*importSkip, error) {
+	var issues []*exportedIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, nil, newLocalizedError(msgErrImportInvalidExport, map[string]interface{}{"Error": err.Error()})
+	}
+
+	importer := newImporter(now)
+	for _, issue := range issues {
+		if issue == nil {
+			continue
+		}
+		importer.add(issue.Message, issue.DueAt, issue.List == "out", issue.State == ExportStateDeclined || issue.State == ExportStateDone)
+	}
+
+	return importer.items, importer.skipped, nil
+}
+
+type wunderlistBackup struct {
+	Data struct {
+		Tasks []struct {
+			Title     string `json:"title"`
+			Completed bool   `json:"completed"`
+			DueDate   string `json:"due_date"`
+		} `json:"tasks"`
+	} `json:"data"`
+}
+
+func parseWunderlistImport(data []byte, now time.Time) ([]*importItem, []*importSkip, error) {
+	var backup wunderlistBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, nil, newLocalizedError(msgErrImportInvalidWunderlist, map[string]interface{}{"Error": err.Error()})
+	}
+
+	importer := newImporter(now)
+	for _, task := range backup.Data.Tasks {
+		importer.add(task.Title, task.DueDate, false, task.Completed)
+	}
+
+	return importer.items, importer.skipped, nil
+}
+
+func parseCSVImport(data []byte, now time.Time) ([]*importItem, []*importSkip, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, newLocalizedError(msgErrImportInvalidCSV, map[string]interface{}{"Error": err.Error()})
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+
+	messageColumn := findColumn(columns, "message", "content", "title", "task")
+	if messageColumn < 0 {
+		return nil, nil, newLocalizedError(msgErrImportNoMessageColumn, nil)
+	}
+	dueColumn := findColumn(columns, "due_at", "due_date", "due", "date")
+	typeColumn := findColumn(columns, "type")
+	listColumn := findColumn(columns, "list")
+	stateColumn := findColumn(columns, "state", "status", "completed")
+
+	importer := newImporter(now)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, newLocalizedError(msgErrImportInvalidCSV, map[string]interface{}{"Error": err.Error()})
+		}
+
+		// Todoist exports sections and notes along with the tasks
+		if typeColumn >= 0 && !strings.EqualFold(field(record, typeColumn), "task") {
+			continue
+		}
+
+		state := strings.ToLower(field(record, stateColumn))
+		done := state == ExportStateDeclined || state == "completed" || state == "done" || state == "true"
+		importer.add(field(record, messageColumn), field(record, dueColumn), field(record, listColumn) == "out", done)
+	}
+
+	return importer.items, importer.skipped, nil
+}
+
+// importer collects the items of an import file, numbering them and deciding which ones are skipped
+type importer struct {
+	now     time.Time
+	items   []*importItem
+	skipped []*importSkip
+	count   int
+}
+
+func newImporter(now time.Time) *importer {
+	return &importer{
+		now:     now,
+		items:   []*importItem{},
+		skipped: []*importSkip{},
+	}
+}
+
+func (i *importer) add(message, due string, sent, done bool) {
+	i.count++
+	message = strings.TrimSpace(message)
+
+	var reason error
+	switch {
+	case sent:
+		reason = newLocalizedError(msgImportSent, nil)
+	case done:
+		reason = newLocalizedError(msgImportDone, nil)
+	default:
+		reason = validateMessage(message)
+	}
+	if reason != nil {
+		i.skipped = append(i.skipped, newImportSkip(i.count, message, reason))
+		return
+	}
+
+	item := &importItem{Item: i.count, Message: message}
+	if dueAt, ok := parseImportDueDate(due, i.now); ok {
+		item.DueAt = dueAt
+	}
+	i.items = append(i.items, item)
+}
+
+// parseImportDueDate parses the due dates used by the export formats, falling back to the phrases understood by
+// /todo add. Due dates that cannot be understood, like recurring Todoist dates, are ignored.
+func parseImportDueDate(due string, now time.Time) (int64, bool) {
+	due = strings.TrimSpace(due)
+	if due == "" {
+		return 0, false
+	}
+
+	if t, err := time.Parse(time.RFC3339, due); err == nil {
+		return toMillis(t), true
+	}
+	if t, err := time.ParseInLocation("2006-01-02", due, now.Location()); err == nil {
+		return toMillis(atDefaultHour(t)), true
+	}
+	if t, err := parseDueDate(due, now); err == nil {
+		return toMillis(t), true
+	}
+
+	return 0, false
+}
+
+func findColumn(columns map[string]int, names ...string) int {
+	for _, name := range names {
+		if i, ok := columns[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func field(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+func importResultToString(T translateFunc, result *importResult) string {
+	str := T(msgImportSummary, map[string]interface{}{"DryRun": result.DryRun, "Created": len(result.Created), "Skipped": len(result.Skipped)}) + "\n"
+
+	if len(result.Skipped) > 0 {
+		str += "\n" + T(msgImportSkipped, nil) + "\n"
+		for i, skip := range result.Skipped {
+			if i == MaxImportSkipsShown {
+				str += "* " + T(msgImportMoreSkips, map[string]interface{}{"Count": len(result.Skipped) - i}) + "\n"
+				break
+			}
+			str += "* " + T(msgImportSkip, map[string]interface{}{"Item": skip.Item, "Reason": skip.localizedReason(T), "Message": skip.Message}) + "\n"
+		}
+	}
+
+	return str
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImport(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("todoist", func(t *testing.T) {
+		data := "TYPE,CONTENT,PRIORITY,INDENT,AUTHOR,RESPONSIBLE,DATE,DATE_LANG,TIMEZONE\n" +
+			"section,Work,,,,,,,\n" +
+			"task,Write the report,4,1,Me,,2020-03-12,en,UTC\n" +
+			"task,Water the plants,1,1,Me,,every day,en,UTC\n" +
+			"task,,1,1,Me,,,en,UTC\n"
+
+		items, skipped, err := parseImport([]byte(data), now)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, "Write the report", items[0].Message)
+		assert.Equal(t, toMillis(time.Date(2020, 3, 12, DefaultDueHour, 0, 0, 0, time.UTC)), items[0].DueAt)
+		assert.Equal(t, int64(0), items[1].DueAt)
+		require.Len(t, skipped, 1)
+		assert.Equal(t, 3, skipped[0].Item)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		data := `[
+			{"id": "1", "list": "my", "message": "Mine", "state": "open", "due_at": "2020-03-11T10:00:00Z"},
+			{"id": "2", "list": "out", "message": "Sent", "state": "pending"}
+		]`
+
+		items, skipped, err := parseImport([]byte(data), now)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, toMillis(time.Date(2020, 3, 11, 10, 0, 0, 0, time.UTC)), items[0].DueAt)
+		require.Len(t, skipped, 1)
+		assert.Equal(t, "Sent", skipped[0].Message)
+	})
+
+	t.Run("wunderlist", func(t *testing.T) {
+		data := `{"data": {"tasks": [{"title": "Buy milk"}, {"title": "Old", "completed": true}]}}`
+
+		items, skipped, err := parseImport([]byte(data), now)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "Buy milk", items[0].Message)
+		assert.Len(t, skipped, 1)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := parseImport([]byte("name,priority\nfoo,1\n"), now)
+		assert.Error(t, err)
+
+		_, _, err = parseImport([]byte("  "), now)
+		assert.Error(t, err)
+	})
+}
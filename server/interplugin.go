@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// PluginAPIPrefix is the path prefix of the API for other plugins, which they call with PluginHTTP
+const PluginAPIPrefix = "/plugin/v1"
+
+// PluginAddRequest is the body of a request of another plugin to add a todo to the list of UserID, or to send it
+// on behalf of UserID to the user SendTo
+type PluginAddRequest struct {
+	UserID  string `json:"user_id"`
+	SendTo  string `json:"send_to,omitempty"`
+	Message string `json:"message"`
+	PostID  string `json:"post_id,omitempty"`
+	DueAt   int64  `json:"due_at,omitempty"`
+}
+
+// PluginAddResponse is the response to a PluginAddRequest, with the ID of the todo added or sent
+type PluginAddResponse struct {
+	ID string `json:"id"`
+}
+
+// servePluginAPI routes the requests of other plugins. The server sets the ID of the calling plugin, but not the
+// user, so the user of the request is only trusted if the system admin allowed the plugin.
+func (p *Plugin) servePluginAPI(w http.ResponseWriter, r *http.Request, pluginID string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !p.getConfiguration().isPluginAllowed(pluginID) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.Errorf("plugin %s is not allowed to add todos", pluginID))
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, PluginAPIPrefix)
+	switch {
+	case path == "/todos" && r.Method == http.MethodPost:
+		p.handlePluginAdd(w, r, pluginID)
+	default:
+		p.handleErrorWithCode(w, http.StatusNotFound, "Not found", errors.Errorf("%s %s is not part of the plugin API", r.Method, r.URL.Path))
+	}
+}
+
+func (p *Plugin) handlePluginAdd(w http.ResponseWriter, r *http.Request, pluginID string) {
+	var request *PluginAddRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, MaxHookBodySize)).Decode(&request); err != nil || request == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+		return
+	}
+
+	if !model.IsValidId(request.UserID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("user_id must be a valid user id"))
+		return
+	}
+
+	user, appErr := p.API.GetUser(request.UserID)
+	if appErr != nil {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", appErr)
+		return
+	}
+
+	if user.DeleteAt != 0 || user.IsBot {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("todos can only be added for active users"))
+		return
+	}
+
+	if err := p.checkMessage(request.Message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+		return
+	}
+
+	if request.DueAt < 0 {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid due date", errors.New("due_at must be a time in milliseconds"))
+		return
+	}
+
+	if request.PostID != "" && !p.canReadPost(user.Id, request.PostID) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Invalid post", errors.New("the user does not have access to the post"))
+		return
+	}
+
+	if request.SendTo == "" || request.SendTo == user.Id {
+		if err := p.checkTodoLimit(user.Id, 1); err != nil {
+			p.handleLimitError(w, "Todo limit reached", err)
+			return
+		}
+
+		issue, err := p.listManager.AddIssue(user.Id, request.Message, request.PostID, request.DueAt)
+		if err != nil {
+			p.refundRate(user.Id, rateActionAdd, 1)
+			p.API.LogError("Unable to add issue err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
+			return
+		}
+
+		p.API.LogDebug("Todo added by plugin", "plugin_id", pluginID, "user_id", user.Id, "issue_id", issue.ID)
+		p.sendRefreshEvent(user.Id)
+
+		replyMessage := p.localizeServer(msgReplyAttached, map[string]interface{}{"User": user.Username})
+		p.postReplyIfNeeded(request.PostID, replyMessage, request.Message)
+
+		p.writeAPIResponse(w, http.StatusCreated, &PluginAddResponse{ID: issue.ID})
+		return
+	}
+
+	if !p.API.HasPermissionTo(user.Id, model.PERMISSION_CREATE_DIRECT_CHANNEL) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("the user does not have permission to send todos"))
+		return
+	}
+
+	if !model.IsValidId(request.SendTo) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("send_to must be a valid user id"))
+		return
+	}
+
+	receiver, appErr := p.API.GetUser(request.SendTo)
+	if appErr != nil {
+		p.handleErrorWithCode(w, http.StatusNotFound, "Unable to find user", appErr)
+		return
+	}
+
+	if receiver.DeleteAt != 0 || receiver.IsBot {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("todos can only be sent to active users"))
+		return
+	}
+
+	if err := p.checkSendAllowed(user.Id, receiver, 1); err != nil {
+		p.handleLimitError(w, "Not allowed", err)
+		return
+	}
+
+	issueID, err := p.listManager.SendIssue(user.Id, receiver.Id, request.Message, request.PostID, request.DueAt)
+	if err != nil {
+		p.refundRate(user.Id, rateActionSend, 1)
+		p.API.LogError("Unable to send issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send issue", err)
+		return
+	}
+
+	p.API.LogDebug("Todo sent by plugin", "plugin_id", pluginID, "user_id", user.Id, "issue_id", issueID)
+	p.sendRefreshEvent(user.Id)
+	p.notifySend(user.Id, receiver.Id, request.Message, issueID, request.DueAt)
+
+	replyMessage := p.localizeServer(msgReplySent, map[string]interface{}{"User": user.Username, "Receiver": receiver.Username})
+	p.postReplyIfNeeded(request.PostID, replyMessage, request.Message)
+
+	p.writeAPIResponse(w, http.StatusCreated, &PluginAddResponse{ID: issueID})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPluginAllowed(t *testing.T) {
+	c := &configuration{AllowedPlugins: "com.example.github, com.example.incident"}
+	assert.True(t, c.isPluginAllowed("com.example.github"))
+	assert.True(t, c.isPluginAllowed("com.example.incident"))
+	assert.False(t, c.isPluginAllowed("com.example.other"))
+	assert.False(t, c.isPluginAllowed(""))
+	assert.False(t, (&configuration{}).isPluginAllowed(""))
+}
+
+func TestServePluginAPI(t *testing.T) {
+	userID := model.NewId()
+	api := &plugintest.API{}
+	api.On("GetUser", userID).Return(&model.User{Id: userID, IsBot: true}, nil)
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{AllowedPlugins: "com.example.github"})
+
+	serve := func(pluginID, path, body string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Mattermost-User-ID", userID)
+		p.ServeHTTP(&plugin.Context{SourcePluginId: pluginID}, w, r)
+		return w.Code
+	}
+
+	t.Run("plugin not allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve("com.example.other", PluginAPIPrefix+"/todos", `{}`))
+	})
+
+	t.Run("other routes are not served to plugins", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("com.example.github", "/add", `{"message": "todo"}`))
+	})
+
+	t.Run("invalid user", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("com.example.github", PluginAPIPrefix+"/todos", `{"user_id": "nobody", "message": "todo"}`))
+		assert.Equal(t, http.StatusBadRequest, serve("com.example.github", PluginAPIPrefix+"/todos", `{"user_id": "`+userID+`", "message": "todo"}`))
+	})
+
+	t.Run("not served to users", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("", PluginAPIPrefix+"/todos", `{"user_id": "`+userID+`", "message": "todo"}`))
+	})
+}
@@ -2,47 +2,317 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 )
 
+const (
+	// IssueStatusOpen is the status of a todo nobody has acted on yet
+	IssueStatusOpen = ""
+	// IssueStatusDeclined is the status of a sent todo the receiver declined
+	IssueStatusDeclined = "declined"
+	// IssueStatusInProgress is the status of a todo its owner started working on
+	IssueStatusInProgress = "in_progress"
+
+	// IssuePriorityHigh is the priority of an important todo. Todos without a priority have a normal one.
+	IssuePriorityHigh = "high"
+	// IssuePriorityLow is the priority of a todo that can wait
+	IssuePriorityLow = "low"
+
+	// DueBucketOverdue is the section of a list with the todos whose due date passed
+	DueBucketOverdue = "overdue"
+	// DueBucketToday is the section of a list with the todos due later today
+	DueBucketToday = "today"
+	// DueBucketUpcoming is the section of a list with the todos due after today
+	DueBucketUpcoming = "upcoming"
+	// DueBucketNone is the section of a list with the todos without a due date
+	DueBucketNone = "none"
+)
+
+// isValidPriority checks whether priority is one of the priorities of a todo, or empty for a normal priority
+func isValidPriority(priority string) bool {
+	return priority == "" || priority == IssuePriorityHigh || priority == IssuePriorityLow
+}
+
 // Issue represents a Todo issue
 type Issue struct {
-	ID       string `json:"id"`
-	Message  string `json:"message"`
+	ID            string        `json:"id"`
+	Message       string        `json:"message"`
+	CreateAt      int64         `json:"create_at"`
+	PostID        string        `json:"post_id"`
+	DueAt         int64         `json:"due_at,omitempty"`
+	Status        string        `json:"status,omitempty"`
+	DeclineReason string        `json:"decline_reason,omitempty"`
+	CompleteAt    int64         `json:"complete_at,omitempty"`
+	GitHub        *GitHubLink   `json:"github,omitempty"`
+	Subtasks      []*Subtask    `json:"subtasks,omitempty"`
+	Notes         []*Note       `json:"notes,omitempty"`
+	Forwards      []*Forward    `json:"forwards,omitempty"`
+	Proposal      *Proposal     `json:"proposal,omitempty"`
+	Files         []*Attachment `json:"files,omitempty"`
+	Priority      string        `json:"priority,omitempty"`
+	// EscalatedAt is when the receiver was last reminded of the todo waiting in their received list
+	EscalatedAt int64 `json:"escalated_at,omitempty"`
+	// Watchers are the users told about every change the receiver makes to the todo, kept on the copies of both users
+	Watchers []string `json:"watchers,omitempty"`
+}
+
+// Proposal is a change to a sent todo proposed by its receiver when accepting it, waiting for the sender to approve
+// or reject it. It is kept on the copies of both users.
+type Proposal struct {
+	UserID   string `json:"user_id"`
+	Message  string `json:"message,omitempty"`
+	DueAt    int64  `json:"due_at,omitempty"`
 	CreateAt int64  `json:"create_at"`
-	PostID   string `json:"post_id"`
+}
+
+// Forward records a received todo handed off by FromUserID to ToUserID, so the chain of its owners can be followed
+type Forward struct {
+	FromUserID string `json:"from_user_id"`
+	ToUserID   string `json:"to_user_id"`
+	Note       string `json:"note,omitempty"`
+	CreateAt   int64  `json:"create_at"`
 }
 
 // ExtendedIssue extends the information on Issue to be used on the front-end
 type ExtendedIssue struct {
 	Issue
 	ForeignUser     string `json:"user"`
+	ForeignUserID   string `json:"user_id"`
 	ForeignList     string `json:"list"`
 	ForeignPosition int    `json:"position"`
+	DueBucket       string `json:"due_bucket,omitempty"`
+	Permalink       string `json:"permalink,omitempty"`
+}
+
+var dueBucketTitles = map[string]*message{
+	DueBucketOverdue:  msgBucketOverdue,
+	DueBucketToday:    msgBucketToday,
+	DueBucketUpcoming: msgBucketUpcoming,
+	DueBucketNone:     msgBucketNone,
+}
+
+// dueBucket returns the section of a list a todo due at dueAt is shown in, at now in the user's timezone
+func dueBucket(dueAt int64, now time.Time) string {
+	if dueAt == 0 {
+		return DueBucketNone
+	}
+
+	due := fromMillis(dueAt)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	switch {
+	case due.Before(now):
+		return DueBucketOverdue
+	case due.Before(tomorrow):
+		return DueBucketToday
+	default:
+		return DueBucketUpcoming
+	}
+}
+
+// setDueBuckets sets the due bucket of the open issues, at now in the user's timezone
+func setDueBuckets(issues []*ExtendedIssue, now time.Time) {
+	for _, issue := range issues {
+		if issue.CompleteAt == 0 {
+			issue.DueBucket = dueBucket(issue.DueAt, now)
+		}
+	}
 }
 
-func newIssue(message string, postID string) *Issue {
+func newIssue(message string, postID string, dueAt int64) *Issue {
 	return &Issue{
 		ID:       model.NewId(),
 		CreateAt: model.GetMillis(),
 		Message:  message,
 		PostID:   postID,
+		DueAt:    dueAt,
 	}
 }
 
-func issuesListToString(issues []*ExtendedIssue) string {
+func issuesListToString(T translateFunc, issues []*ExtendedIssue, location *time.Location) string {
 	if len(issues) == 0 {
-		return "Nothing to do!"
+		return T(msgListNothingToDo, nil)
+	}
+
+	return "\n\n" + renderIssues(T, issues, 1, location)
+}
+
+// issuesPageToString renders a page of a list, numbering the issues by their position in the whole list
+func issuesPageToString(T translateFunc, issues []*ExtendedIssue, page, perPage, total int, location *time.Location) string {
+	if total == 0 {
+		return T(msgListNothingToDo, nil)
+	}
+
+	str := "\n\n" + renderIssueBuckets(T, issues, page*perPage+1, location, time.Now().In(location))
+
+	pages := (total + perPage - 1) / perPage
+	if pages > 1 {
+		str += "\n" + T(msgListPage, map[string]interface{}{"Page": page + 1, "Pages": pages, "Total": total}) + "\n"
 	}
 
-	str := "\n\n"
+	return str
+}
 
+// renderIssueBuckets renders the open issues like renderIssues, split in sections by due date at now if any of them
+// is due. The issues keep the numbers of their position in the list, starting at firstNumber.
+func renderIssueBuckets(T translateFunc, issues []*ExtendedIssue, firstNumber int, location *time.Location, now time.Time) string {
+	hasDueDate := false
 	for _, issue := range issues {
-		createAt := time.Unix(issue.CreateAt/1000, 0)
-		str += fmt.Sprintf("* %s\n  * (%s)\n", issue.Message, createAt.Format("January 2, 2006 at 15:04"))
+		if issue.CompleteAt != 0 {
+			return renderIssues(T, issues, firstNumber, location)
+		}
+		hasDueDate = hasDueDate || issue.DueAt != 0
+	}
+	if !hasDueDate {
+		return renderIssues(T, issues, firstNumber, location)
+	}
+
+	str := ""
+	bucket := ""
+	for _, i := range dueDateOrder(issues) {
+		if issueBucket := dueBucket(issues[i].DueAt, now); issueBucket != bucket {
+			bucket = issueBucket
+			str += "#### " + T(dueBucketTitles[bucket], nil) + "\n"
+		}
+		str += renderIssues(T, issues[i:i+1], firstNumber+i, location)
 	}
 
 	return str
 }
+
+// dueDateOrder returns the indexes of issues with a due date first, the soonest first, keeping the order of the
+// others and of those due at the same time
+func dueDateOrder(issues []*ExtendedIssue) []int {
+	order := make([]int, len(issues))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := issues[order[i]], issues[order[j]]
+		if a.DueAt == 0 || b.DueAt == 0 {
+			return a.DueAt != 0 && b.DueAt == 0
+		}
+		return a.DueAt < b.DueAt
+	})
+
+	return order
+}
+
+// sortByDueDate orders the open issues like dueDateOrder. The completed ones keep their order.
+func sortByDueDate(issues []*ExtendedIssue) {
+	for _, issue := range issues {
+		if issue.CompleteAt != 0 {
+			return
+		}
+	}
+
+	sorted := make([]*ExtendedIssue, len(issues))
+	for i, index := range dueDateOrder(issues) {
+		sorted[i] = issues[index]
+	}
+	copy(issues, sorted)
+}
+
+// renderIssues renders the issues as a numbered markdown list starting at firstNumber
+func renderIssues(T translateFunc, issues []*ExtendedIssue, firstNumber int, location *time.Location) string {
+	str := ""
+
+	for i, issue := range issues {
+		createAt := time.Unix(issue.CreateAt/1000, 0).In(location)
+		message := issue.Message
+		if done, total := subtaskProgress(&issue.Issue); total > 0 {
+			message += fmt.Sprintf(" (%d/%d)", done, total)
+		}
+		if issue.Status == IssueStatusInProgress && issue.CompleteAt == 0 {
+			message = T(msgIssueInProgress, nil) + " " + message
+		}
+		str += fmt.Sprintf("%d. %s\n  * (%s)\n", firstNumber+i, message, createAt.Format("January 2, 2006 at 15:04"))
+		if issue.DueAt != 0 {
+			str += "  * " + T(msgIssueDue, map[string]interface{}{"Date": formatDueDate(issue.DueAt, location)}) + "\n"
+		}
+		switch issue.Priority {
+		case IssuePriorityHigh:
+			str += "  * " + T(msgIssuePriorityHigh, nil) + "\n"
+		case IssuePriorityLow:
+			str += "  * " + T(msgIssuePriorityLow, nil) + "\n"
+		}
+		if issue.GitHub != nil {
+			str += "  * " + T(msgIssueGitHub, map[string]interface{}{"Kind": issue.GitHub.kind(), "State": issue.GitHub.State}) + "\n"
+		}
+		if issue.Permalink != "" {
+			str += "  * " + T(msgIssueGoToThread, map[string]interface{}{"Link": issue.Permalink}) + "\n"
+		}
+		for _, file := range issue.Files {
+			str += "  * " + T(msgIssueAttachment, map[string]interface{}{"Link": fileLink(file)}) + "\n"
+		}
+		if issue.ForeignList == ChannelListName {
+			str += "  * " + T(msgIssueAddedBy, map[string]interface{}{"User": issue.ForeignUser}) + "\n"
+		}
+		if issue.CompleteAt != 0 {
+			completeAt := fromMillis(issue.CompleteAt).In(location).Format("January 2, 2006 at 15:04")
+			str += "  * " + T(msgIssueCompletedOn, map[string]interface{}{"Date": completeAt}) + "\n"
+		}
+		if issue.Status == IssueStatusDeclined {
+			str += "  * " + T(msgIssueDeclinedBy, map[string]interface{}{"User": issue.ForeignUser})
+			if issue.DeclineReason != "" {
+				str += ": " + issue.DeclineReason
+			}
+			str += "\n"
+		}
+		if issue.Proposal != nil {
+			str += "  * " + T(msgIssueChangeProposed, map[string]interface{}{"Change": proposalToString(T, issue.Proposal, location)}) + "\n"
+		}
+		str += renderNotes(T, issue, location)
+		for j, subtask := range issue.Subtasks {
+			check := " "
+			if subtask.CompleteAt != 0 {
+				check = "x"
+			}
+			str += fmt.Sprintf("  * [%s] %d.%d %s\n", check, firstNumber+i, j+1, subtask.Message)
+		}
+	}
+
+	return str
+}
+
+// inProgressToString renders the issues in progress, numbered by their position in issues like the whole list
+func inProgressToString(T translateFunc, issues []*ExtendedIssue, location *time.Location) string {
+	str := ""
+	for i, issue := range issues {
+		if issue.Status == IssueStatusInProgress {
+			str += renderIssues(T, issues[i:i+1], i+1, location)
+		}
+	}
+
+	if str == "" {
+		return T(msgListNothingInProgress, nil)
+	}
+	return "\n\n" + str
+}
+
+// filterIssuesByStatus returns the issues with the given status
+func filterIssuesByStatus(issues []*ExtendedIssue, status string) []*ExtendedIssue {
+	filtered := []*ExtendedIssue{}
+	for _, issue := range issues {
+		if issue.Status == status {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// proposalToString describes the changes of a proposal
+func proposalToString(T translateFunc, proposal *Proposal, location *time.Location) string {
+	changes := []string{}
+	if proposal.Message != "" {
+		changes = append(changes, proposal.Message)
+	}
+	if proposal.DueAt != 0 {
+		changes = append(changes, T(msgProposalDue, map[string]interface{}{"Date": formatDueDate(proposal.DueAt, location)}))
+	}
+	return strings.Join(changes, ", ")
+}
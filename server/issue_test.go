@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuesPageToString(t *testing.T) {
+	assert.Equal(t, "Nothing to do!", issuesPageToString(renderEnglish, nil, 0, 20, 0, time.UTC))
+
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "third"}},
+		{Issue: Issue{Message: "fourth"}},
+	}
+
+	str := issuesPageToString(renderEnglish, issues, 1, 2, 5, time.UTC)
+	assert.Contains(t, str, "3. third\n")
+	assert.Contains(t, str, "4. fourth\n")
+	assert.Contains(t, str, "Page 2 of 3, 5 Todos in total.")
+
+	str = issuesPageToString(renderEnglish, issues, 0, 20, 2, time.UTC)
+	assert.Contains(t, str, "1. third\n")
+	assert.NotContains(t, str, "Page")
+}
+
+func TestDueBucket(t *testing.T) {
+	now := time.Date(2020, 6, 1, 15, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, DueBucketNone, dueBucket(0, now))
+	assert.Equal(t, DueBucketOverdue, dueBucket(toMillis(now.Add(-time.Minute)), now))
+	assert.Equal(t, DueBucketToday, dueBucket(toMillis(now.Add(time.Hour)), now))
+	assert.Equal(t, DueBucketUpcoming, dueBucket(toMillis(time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC)), now))
+}
+
+func TestRenderIssueBuckets(t *testing.T) {
+	now := time.Date(2020, 6, 1, 15, 0, 0, 0, time.UTC)
+
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "late", DueAt: toMillis(now.Add(-time.Hour))}},
+		{Issue: Issue{Message: "soon", DueAt: toMillis(now.Add(time.Hour))}},
+		{Issue: Issue{Message: "later", DueAt: toMillis(now.Add(48 * time.Hour))}},
+		{Issue: Issue{Message: "whenever"}},
+	}
+
+	str := renderIssueBuckets(renderEnglish, issues, 3, time.UTC, now)
+	assert.Regexp(t, `(?s)#### Overdue\n3\. late.*#### Due today\n4\. soon.*#### Upcoming\n5\. later.*#### No due date\n6\. whenever`, str)
+
+	// The issues keep the number of their position in the list
+	unordered := []*ExtendedIssue{issues[3], issues[2], issues[0]}
+	str = renderIssueBuckets(renderEnglish, unordered, 1, time.UTC, now)
+	assert.Regexp(t, `(?s)#### Overdue\n3\. late.*#### Upcoming\n2\. later.*#### No due date\n1\. whenever`, str)
+
+	// Without due dates, the list is not split
+	assert.NotContains(t, renderIssueBuckets(renderEnglish, issues[3:], 1, time.UTC, now), "####")
+}
+
+func TestSortByDueDate(t *testing.T) {
+	issues := []*ExtendedIssue{
+		{Issue: Issue{ID: "none1"}},
+		{Issue: Issue{ID: "late", DueAt: 200}},
+		{Issue: Issue{ID: "none2"}},
+		{Issue: Issue{ID: "early", DueAt: 100}},
+		{Issue: Issue{ID: "late2", DueAt: 200}},
+	}
+
+	sortByDueDate(issues)
+
+	ids := []string{}
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+	assert.Equal(t, []string{"early", "late", "late2", "none1", "none2"}, ids)
+
+	// The completed list keeps its order
+	completed := []*ExtendedIssue{
+		{Issue: Issue{ID: "first", CompleteAt: 1}},
+		{Issue: Issue{ID: "due", DueAt: 100, CompleteAt: 1}},
+	}
+	sortByDueDate(completed)
+	assert.Equal(t, "first", completed[0].ID)
+}
+
+func TestInProgressToString(t *testing.T) {
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "waiting"}},
+		{Issue: Issue{Message: "working", Status: IssueStatusInProgress}},
+		{Issue: Issue{Message: "finished", Status: IssueStatusInProgress, CompleteAt: 1}},
+	}
+
+	str := inProgressToString(renderEnglish, issues, time.UTC)
+	assert.Contains(t, str, "2. **[In progress]** working")
+	assert.Contains(t, str, "3. finished")
+	assert.NotContains(t, str, "waiting")
+
+	assert.Equal(t, "Nothing in progress!", inProgressToString(renderEnglish, issues[:1], time.UTC))
+	assert.Len(t, filterIssuesByStatus(issues, IssueStatusOpen), 1)
+	assert.Len(t, filterIssuesByStatus(issues, IssueStatusInProgress), 2)
+}
+
+func TestRenderIssuesPermalink(t *testing.T) {
+	issues := []*ExtendedIssue{{Issue: Issue{Message: "attached", PostID: "post1"}}, {Issue: Issue{Message: "alone"}}}
+	p := &Plugin{}
+	api := &plugintest.API{}
+	api.On("GetConfig").Return(&model.Config{ServiceSettings: model.ServiceSettings{SiteURL: model.NewString("https://chat.example.com/")}})
+	p.SetAPI(api)
+
+	p.setLinks(issues)
+	assert.Equal(t, "https://chat.example.com/_redirect/pl/post1", issues[0].Permalink)
+	assert.Empty(t, issues[1].Permalink)
+	assert.Contains(t, renderIssues(renderEnglish, issues, 1, time.UTC), "[Go to thread](https://chat.example.com/_redirect/pl/post1)")
+}
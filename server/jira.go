@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// JiraWebhookPath is the path Jira posts its webhook events to
+	JiraWebhookPath = "/jira/webhook"
+	// JiraTimeout is the timeout of the requests to the Jira REST API
+	JiraTimeout = 10 * time.Second
+	// MaxJiraBodySize is the maximum size in bytes of a Jira webhook payload
+	MaxJiraBodySize = 10 * 1024 * 1024
+	// DefaultJiraDoneTransition is the name of the Jira transition used when a linked todo is completed
+	DefaultJiraDoneTransition = "Done"
+
+	jiraEventIssueCreated  = "jira:issue_created"
+	jiraEventIssueUpdated  = "jira:issue_updated"
+	jiraStatusCategoryDone = "done"
+)
+
+var jiraIssueKeyRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[0-9]+$`)
+
+// JiraLink ties a todo to a Jira issue. Managed links were created by the Jira webhook, so the todo message follows
+// the summary of the Jira issue.
+type JiraLink struct {
+	UserID  string `json:"user_id"`
+	IssueID string `json:"issue_id"`
+	Managed bool   `json:"managed"`
+}
+
+type jiraUser struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary  string    `json:"summary"`
+		Assignee *jiraUser `json:"assignee"`
+		Status   *struct {
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+type jiraWebhook struct {
+	WebhookEvent string     `json:"webhookEvent"`
+	Issue        *jiraIssue `json:"issue"`
+	Changelog    *struct {
+		Items []struct {
+			Field string `json:"field"`
+		} `json:"items"`
+	} `json:"changelog"`
+}
+
+type jiraTransitions struct {
+	Transitions []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		To   struct {
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"to"`
+	} `json:"transitions"`
+}
+
+func (i *jiraIssue) isDone() bool {
+	return i.Fields.Status != nil && i.Fields.Status.StatusCategory.Key == jiraStatusCategoryDone
+}
+
+func (w *jiraWebhook) changed(field string) bool {
+	if w.Changelog == nil {
+		return false
+	}
+	for _, item := range w.Changelog.Items {
+		if item.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidJiraIssueKey(key string) bool {
+	return jiraIssueKeyRegexp.MatchString(key)
+}
+
+// jiraMessage returns the message of the todo of a Jira issue, linking to the issue if the Jira URL is configured
+func jiraMessage(jiraURL, key, summary string) string {
+	if jiraURL == "" {
+		return key + " " + summary
+	}
+	return fmt.Sprintf("[%s](%s/browse/%s) %s", key, strings.TrimSuffix(jiraURL, "/"), key, summary)
+}
+
+// serveJiraWebhook creates a todo when a Jira issue is assigned to a Mattermost user, keeps the message of the todos
+// it created in sync with the summary, and completes the linked todos when the issue is resolved. Jira has to
+// include the webhook secret in the secret query parameter.
+func (p *Plugin) serveJiraWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	config := p.getConfiguration()
+	if config.JiraWebhookSecret == "" {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not enabled", errors.New("the Jira integration is not configured"))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		p.handleErrorWithCode(w, http.StatusMethodNotAllowed, "Method not allowed", errors.New("the Jira webhook only accepts POST requests"))
+		return
+	}
+
+	secret := r.URL.Query().Get("secret")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(config.JiraWebhookSecret)) != 1 {
+		p.handleErrorWithCode(w, http.StatusUnauthorized, "Not authorized", errors.New("the secret is not valid"))
+		return
+	}
+
+	var webhook *jiraWebhook
+	if err := json.NewDecoder(io.LimitReader(r.Body, MaxJiraBodySize)).Decode(&webhook); err != nil || webhook == nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a Jira webhook payload"))
+		return
+	}
+
+	if webhook.Issue != nil && isValidJiraIssueKey(webhook.Issue.Key) &&
+		(webhook.WebhookEvent == jiraEventIssueCreated || webhook.WebhookEvent == jiraEventIssueUpdated) {
+		if err := p.handleJiraIssueEvent(webhook, config.JiraURL); err != nil {
+			p.API.LogError("Unable to handle Jira webhook err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to handle Jira webhook", err)
+			return
+		}
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, map[string]interface{}{"status": "OK"})
+}
+
+func (p *Plugin) handleJiraIssueEvent(webhook *jiraWebhook, jiraURL string) error {
+	jiraIssue := webhook.Issue
+	links, _, err := p.getJiraLinks(jiraIssue.Key)
+	if err != nil {
+		return err
+	}
+
+	if jiraIssue.isDone() {
+		for _, link := range links {
+			p.completeJiraLinkedIssue(jiraIssue.Key, link)
+		}
+		return nil
+	}
+
+	message := jiraMessage(jiraURL, jiraIssue.Key, jiraIssue.Fields.Summary)
+	if err = p.checkMessage(message); err != nil {
+		p.API.LogWarn("Skipping Jira issue with an invalid summary", "key", jiraIssue.Key, "err", err.Error())
+		return nil
+	}
+
+	if webhook.changed("summary") {
+		for _, link := range links {
+			if !link.Managed {
+				continue
+			}
+			if _, _, _, err := p.listManager.EditIssue(link.UserID, link.IssueID, message); err != nil && err != errIssueNotFound {
+				p.API.LogError("Unable to update issue from Jira err=" + err.Error())
+				continue
+			}
+			p.sendRefreshEvent(link.UserID)
+		}
+	}
+
+	if jiraIssue.Fields.Assignee == nil || (webhook.WebhookEvent == jiraEventIssueUpdated && !webhook.changed("assignee")) {
+		return nil
+	}
+
+	userID := p.findJiraUser(jiraIssue.Fields.Assignee)
+	if userID == "" {
+		return nil
+	}
+	for _, link := range links {
+		if link.UserID == userID {
+			return nil
+		}
+	}
+
+	if err = p.checkTodoLimit(userID, 1); err != nil {
+		p.API.LogWarn("Skipping Jira issue assigned to a user that cannot add more todos", "key", jiraIssue.Key, "user_id", userID, "err", err.Error())
+		return nil
+	}
+
+	issue, err := p.listManager.AddIssue(userID, message, "", 0)
+	if err != nil {
+		p.refundRate(userID, rateActionAdd, 1)
+		return err
+	}
+
+	if err := p.addJiraLink(jiraIssue.Key, &JiraLink{UserID: userID, IssueID: issue.ID, Managed: true}); err != nil {
+		return err
+	}
+
+	p.sendRefreshEvent(userID)
+	p.PostBotDM(userID, p.localize(userID, msgNotifyJiraAssigned, map[string]interface{}{"Issue": message}))
+
+	return nil
+}
+
+// completeJiraLinkedIssue completes the todo of a resolved Jira issue. The link is removed first, so completing the
+// todo does not transition the Jira issue again.
+func (p *Plugin) completeJiraLinkedIssue(key string, link *JiraLink) {
+	if err := p.removeJiraLink(key, link.IssueID); err != nil {
+		p.API.LogError("Unable to remove Jira link err=" + err.Error())
+		return
+	}
+
+	issue, err := p.listManager.CompleteIssue(link.UserID, link.IssueID)
+	if err == errIssueNotFound {
+		return
+	}
+	if err != nil {
+		p.API.LogError("Unable to complete issue from Jira err=" + err.Error())
+		return
+	}
+
+	p.sendRefreshEvent(link.UserID)
+	p.PostBotDM(link.UserID, p.localize(link.UserID, msgNotifyJiraResolved, map[string]interface{}{"Issue": key, "Todo": issue.Message}))
+}
+
+// findJiraUser returns the Mattermost user with the email or the username of the Jira user, if any
+func (p *Plugin) findJiraUser(jiraUser *jiraUser) string {
+	if jiraUser.EmailAddress != "" {
+		if user, appErr := p.API.GetUserByEmail(jiraUser.EmailAddress); appErr == nil && user.DeleteAt == 0 && !user.IsBot {
+			return user.Id
+		}
+	}
+	if jiraUser.Name != "" {
+		if user, appErr := p.API.GetUserByUsername(jiraUser.Name); appErr == nil && user.DeleteAt == 0 && !user.IsBot {
+			return user.Id
+		}
+	}
+	return ""
+}
+
+// handleJiraEvents transitions the linked Jira issue when a todo is completed, and forgets the link when a todo
+// is removed
+func (p *Plugin) handleJiraEvents(event *IssueEvent) {
+	if event.Type != IssueEventCompleted && event.Type != IssueEventDeleted {
+		return
+	}
+
+	key, err := p.getJiraKey(event.Issue.ID)
+	if err != nil {
+		p.API.LogError("Unable to get Jira link err=" + err.Error())
+		return
+	}
+	if key == "" {
+		return
+	}
+
+	if event.Type == IssueEventDeleted {
+		if err := p.removeJiraLink(key, event.Issue.ID); err != nil {
+			p.API.LogError("Unable to remove Jira link err=" + err.Error())
+		}
+		return
+	}
+
+	config := p.getConfiguration()
+	if config.JiraURL == "" || config.JiraUsername == "" || config.JiraAPIToken == "" {
+		return
+	}
+
+	go func() {
+		if err := transitionJiraIssue(config, key); err != nil {
+			p.API.LogWarn("Unable to transition Jira issue", "key", key, "err", err.Error())
+		}
+	}()
+}
+
+// transitionJiraIssue resolves the Jira issue with the configured transition, or with the first one to a done status
+func transitionJiraIssue(config *configuration, key string) error {
+	client := &http.Client{Timeout: JiraTimeout}
+	transitionsURL := fmt.Sprintf("%s/rest/api/2/issue/%s/transitions", strings.TrimSuffix(config.JiraURL, "/"), url.PathEscape(key))
+
+	var transitions jiraTransitions
+	if err := doJiraRequest(client, config, http.MethodGet, transitionsURL, nil, &transitions); err != nil {
+		return err
+	}
+
+	transitionName := config.JiraDoneTransition
+	if transitionName == "" {
+		transitionName = DefaultJiraDoneTransition
+	}
+
+	transitionID := ""
+	for _, transition := range transitions.Transitions {
+		if strings.EqualFold(transition.Name, transitionName) {
+			transitionID = transition.ID
+			break
+		}
+		if transitionID == "" && transition.To.StatusCategory.Key == jiraStatusCategoryDone {
+			transitionID = transition.ID
+		}
+	}
+	if transitionID == "" {
+		return errors.Errorf("no transition to a done status is available")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"transition": map[string]string{"id": transitionID}})
+	if err != nil {
+		return err
+	}
+
+	return doJiraRequest(client, config, http.MethodPost, transitionsURL, body, nil)
+}
+
+func doJiraRequest(client *http.Client, config *configuration, method, requestURL string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(config.JiraUsername, config.JiraAPIToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("%s %s returned status code %d", method, requestURL, resp.StatusCode)
+	}
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
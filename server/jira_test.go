@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidJiraIssueKey(t *testing.T) {
+	assert.True(t, isValidJiraIssueKey("PROJ-123"))
+	assert.True(t, isValidJiraIssueKey("A1_B-1"))
+	assert.False(t, isValidJiraIssueKey("proj-123"))
+	assert.False(t, isValidJiraIssueKey("PROJ"))
+	assert.False(t, isValidJiraIssueKey("PROJ-12a"))
+}
+
+func TestJiraMessage(t *testing.T) {
+	assert.Equal(t, "PROJ-1 Fix the login", jiraMessage("", "PROJ-1", "Fix the login"))
+	assert.Equal(t, "[PROJ-1](https://example.atlassian.net/browse/PROJ-1) Fix the login", jiraMessage("https://example.atlassian.net/", "PROJ-1", "Fix the login"))
+}
+
+func TestJiraWebhook(t *testing.T) {
+	var webhook *jiraWebhook
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"webhookEvent": "jira:issue_updated",
+		"issue": {
+			"key": "PROJ-1",
+			"fields": {
+				"summary": "Fix the login",
+				"assignee": {"name": "alice", "emailAddress": "alice@example.com"},
+				"status": {"name": "Closed", "statusCategory": {"key": "done"}}
+			}
+		},
+		"changelog": {"items": [{"field": "assignee"}]}
+	}`), &webhook))
+
+	assert.Equal(t, "alice@example.com", webhook.Issue.Fields.Assignee.EmailAddress)
+	assert.True(t, webhook.Issue.isDone())
+	assert.True(t, webhook.changed("assignee"))
+	assert.False(t, webhook.changed("summary"))
+
+	webhook.Issue.Fields.Status = nil
+	webhook.Changelog = nil
+	assert.False(t, webhook.Issue.isDone())
+	assert.False(t, webhook.changed("assignee"))
+}
+
+func TestTransitionJiraIssue(t *testing.T) {
+	transitioned := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "bot@example.com", username)
+		assert.Equal(t, "token", password)
+		assert.Equal(t, "/rest/api/2/issue/PROJ-1/transitions", r.URL.Path)
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"transitions": [
+				{"id": "11", "name": "Start", "to": {"statusCategory": {"key": "indeterminate"}}},
+				{"id": "21", "name": "Close", "to": {"statusCategory": {"key": "done"}}},
+				{"id": "31", "name": "Resolve", "to": {"statusCategory": {"key": "done"}}}
+			]}`))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		transitioned = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := &configuration{JiraURL: server.URL, JiraUsername: "bot@example.com", JiraAPIToken: "token", JiraDoneTransition: "resolve"}
+	require.NoError(t, transitionJiraIssue(config, "PROJ-1"))
+	assert.JSONEq(t, `{"transition": {"id": "31"}}`, transitioned)
+
+	config.JiraDoneTransition = "Done"
+	require.NoError(t, transitionJiraIssue(config, "PROJ-1"))
+	assert.JSONEq(t, `{"transition": {"id": "21"}}`, transitioned)
+}
+
+// jiraListManager is a ListManager with the todos of a single user that records the added todos
+type jiraListManager struct {
+	ListManager
+	issues []*ExtendedIssue
+	added  []string
+}
+
+func (m *jiraListManager) GetIssueList(userID, listID string) ([]*ExtendedIssue, error) {
+	if listID != MyListKey {
+		return []*ExtendedIssue{}, nil
+	}
+	return m.issues, nil
+}
+
+func (m *jiraListManager) AddIssue(userID, message, postID string, dueAt int64) (*Issue, error) {
+	m.added = append(m.added, message)
+	return &Issue{ID: "issue1", Message: message}, nil
+}
+
+func TestHandleJiraIssueEventLimits(t *testing.T) {
+	webhook := func(summary string) *jiraWebhook {
+		issue := &jiraIssue{Key: "PROJ-1"}
+		issue.Fields.Summary = summary
+		issue.Fields.Assignee = &jiraUser{EmailAddress: "alice@example.com"}
+		return &jiraWebhook{WebhookEvent: jiraEventIssueCreated, Issue: issue}
+	}
+
+	api := &plugintest.API{}
+	api.On("KVGet", jiraLinksKey("PROJ-1")).Return(nil, nil)
+	api.On("GetUserByEmail", "alice@example.com").Return(&model.User{Id: "alice"}, nil)
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	listManager := &jiraListManager{issues: []*ExtendedIssue{{Issue: Issue{ID: "existing"}}}}
+	p := &Plugin{listManager: listManager}
+	p.SetAPI(api)
+
+	t.Run("summary too long", func(t *testing.T) {
+		p.setConfiguration(&configuration{MaxMessageLength: 20})
+		require.NoError(t, p.handleJiraIssueEvent(webhook(strings.Repeat("a", 30)), ""))
+		assert.Empty(t, listManager.added)
+	})
+
+	t.Run("assignee at the todo limit", func(t *testing.T) {
+		p.setConfiguration(&configuration{MaxTodosPerUser: 1})
+		require.NoError(t, p.handleJiraIssueEvent(webhook("Fix the login"), ""))
+		assert.Empty(t, listManager.added)
+	})
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 )
 
@@ -13,12 +15,35 @@ const (
 	InListKey = "_in"
 	// OutListKey is the key used to store the list of sent todos
 	OutListKey = "_out"
+	// DoneListKey is the key used to store the archive of completed todos, the most recent first
+	DoneListKey = "_done"
+	// ChannelListKey is the key used to store the shared list of a channel, stored with the channel ID as the user ID
+	ChannelListKey = "_channel"
+	// ChannelListName is the foreign list of the issues in a shared channel list, whose foreign user added them
+	ChannelListName = "channel"
+
+	// JournalActionComplete is the journal action of completing or popping a todo
+	JournalActionComplete = "complete"
+	// JournalActionRemove is the journal action of removing a todo
+	JournalActionRemove = "remove"
+	// JournalActionSend is the journal action of sending a todo
+	JournalActionSend = "send"
+	// JournalActionBulk is the journal action of completing or removing several todos at once
+	JournalActionBulk = "bulk"
+
+	// BulkActionComplete completes every todo of a bulk operation
+	BulkActionComplete = "complete"
+	// BulkActionRemove removes every todo of a bulk operation
+	BulkActionRemove = "remove"
 )
 
 // ListStore represents the KVStore operations for lists
 type ListStore interface {
 	// Issue related function
 	AddIssue(issue *Issue) error
+	// ModifyIssue applies modify to the stored issue issueID and returns it. It retries if something else changes the
+	// issue at the same time, so modify may be called more than once.
+	ModifyIssue(issueID string, modify func(*Issue) error) (*Issue, error)
 	GetIssue(issueID string) (*Issue, error)
 	RemoveIssue(issueID string) error
 	GetAndRemoveIssue(issueID string) (*Issue, error)
@@ -34,6 +59,8 @@ type ListStore interface {
 	PopReference(userID, listID string) (*IssueRef, error)
 	// BumpReference moves the Issue reference for issueID in listID for userID to the beggining of the list
 	BumpReference(userID, issueID, listID string) error
+	// MoveReference moves the Issue reference for issueID in listID for userID to the 0-based position of the list
+	MoveReference(userID, issueID, listID string, position int) error
 
 	// GetIssueReference gets the IssueRef and position of the issue issueID on user userID's list listID
 	GetIssueReference(userID, issueID, listID string) (*IssueRef, int, error)
@@ -42,45 +69,127 @@ type ListStore interface {
 
 	// GetList returns the list of IssueRef in listID for userID
 	GetList(userID, listID string) ([]*IssueRef, error)
+	// GetUserIDs returns the users with at least one list, leaving out the channels
+	GetUserIDs() ([]string, error)
+
+	// Journal related functions
+
+	// PushJournalEntry records the entry as the last action of userID, forgetting the oldest one if the journal is full
+	PushJournalEntry(userID string, entry *JournalEntry) error
+	// PopJournalEntry removes the last action of userID from the journal and returns it, or nil if there is none
+	PopJournalEntry(userID string) (*JournalEntry, error)
+}
+
+// IssueQueryStore is a ListStore that can look up the todos by date without loading the whole lists. The list manager
+// uses these lookups when its store has them, and goes through the lists otherwise.
+type IssueQueryStore interface {
+	// GetDueIssues returns the issues on the lists listIDs of userID due after from and until the given time, in
+	// milliseconds, the soonest first, with their IssueRef
+	GetDueIssues(userID string, listIDs []string, from, until int64) ([]*IssueRef, []*Issue, error)
+	// GetCompletedIssueIDs returns the issues on the done list of userID completed before the given time in
+	// milliseconds, and the ones referenced there that are gone
+	GetCompletedIssueIDs(userID string, before int64) ([]string, error)
 }
 
+// errIssueNotFound is returned when the issue cannot be found in any of the lists of the user
+var errIssueNotFound = newLocalizedError(msgErrIssueNotFound, nil)
+
+// errIssueAlreadyCompleted is returned when completing a todo of the done list
+var errIssueAlreadyCompleted = newLocalizedError(msgErrIssueAlreadyCompleted, nil)
+
+// errIssueNotReceived is returned when forwarding a todo that was not received from someone else
+var errIssueNotReceived = newLocalizedError(msgErrIssueNotReceived, nil)
+
+// errIssueNotWaiting is returned when escalating a todo that is not waiting in the received list of the user
+var errIssueNotWaiting = newLocalizedError(msgErrIssueNotWaiting, nil)
+
+// errIssueNotSent is returned when watching a todo that the user did not send, or that its receiver declined
+var errIssueNotSent = newLocalizedError(msgErrIssueNotSent, nil)
+
+// errInvalidForward is returned when forwarding a todo back to its sender or to its current receiver
+var errInvalidForward = newLocalizedError(msgErrInvalidForward, nil)
+
+// errNoProposal is returned when approving or rejecting a change to a todo nobody proposed
+var errNoProposal = newLocalizedError(msgErrNoProposal, nil)
+
+// errIssueAlreadyStarted is returned when starting a todo already in progress
+var errIssueAlreadyStarted = newLocalizedError(msgErrIssueAlreadyStarted, nil)
+
+// errInvalidStart is returned when starting a todo that is not on the list of the user, like a received one
+var errInvalidStart = newLocalizedError(msgErrInvalidStart, nil)
+
+// errNothingToUndo is returned when the journal of the user is empty
+var errNothingToUndo = newLocalizedError(msgErrNothingToUndo, nil)
+
+// errUndoConflict is returned when the todo changed after the action, so the action cannot be undone
+var errUndoConflict = newLocalizedError(msgErrUndoConflict, nil)
+
 type listManager struct {
-	store ListStore
-	api   plugin.API
+	store         ListStore
+	queries       IssueQueryStore
+	index         *searchIndex
+	api           plugin.API
+	eventHandlers []IssueEventHandler
+
+	// metrics records the size of the lists when they are loaded, if set
+	metrics *metrics
+
+	// users caches the users looked up for their names, if set
+	users *userCache
+
+	// changes receives every change of the lists, if set
+	changes ListChangeHandler
 }
 
-// NewListManager creates a new listManager
-func NewListManager(api plugin.API) *listManager {
-	return &listManager{
-		store: NewListStore(api),
-		api:   api,
+// NewListManager creates a new listManager that calls the eventHandlers after every change in the lifecycle of a todo
+func NewListManager(api plugin.API, eventHandlers ...IssueEventHandler) *listManager {
+	l := &listManager{
+		index:         newSearchIndex(api),
+		api:           api,
+		eventHandlers: eventHandlers,
 	}
+	l.setStore(NewListStore(api))
+	return l
+}
+
+// setStore makes the manager keep the todos in store
+func (l *listManager) setStore(store ListStore) {
+	l.store = &observedListStore{ListStore: store, manager: l}
+	l.queries, _ = store.(IssueQueryStore)
 }
 
-func (l *listManager) AddIssue(userID, message, postID string) error {
-	issue := newIssue(message, postID)
+func (l *listManager) AddIssue(userID, message, postID string, dueAt int64) (*Issue, error) {
+	issue := newIssue(message, postID, dueAt)
+	issue.Files = postAttachments(l.api, postID)
 
 	if err := l.store.AddIssue(issue); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := l.store.AddReference(userID, issue.ID, MyListKey, "", ""); err != nil {
 		if rollbackError := l.store.RemoveIssue(issue.ID); rollbackError != nil {
 			l.api.LogError("cannot rollback issue after add error, Err=", err.Error())
 		}
-		return err
+		return nil, err
 	}
 
-	return nil
+	l.indexIssue(userID, issue, "")
+	l.dispatch(IssueEventCreated, userID, "", issue)
+
+	return issue, nil
 }
 
-func (l *listManager) SendIssue(senderID, receiverID, message, postID string) (string, error) {
-	senderIssue := newIssue(message, postID)
+func (l *listManager) SendIssue(senderID, receiverID, message, postID string, dueAt int64) (string, error) {
+	files := postAttachments(l.api, postID)
+
+	senderIssue := newIssue(message, postID, dueAt)
+	senderIssue.Files = files
 	if err := l.store.AddIssue(senderIssue); err != nil {
 		return "", err
 	}
 
-	receiverIssue := newIssue(message, postID)
+	receiverIssue := newIssue(message, postID, dueAt)
+	receiverIssue.Files = files
 	if err := l.store.AddIssue(receiverIssue); err != nil {
 		if rollbackError := l.store.RemoveIssue(senderIssue.ID); rollbackError != nil {
 			l.api.LogError("cannot rollback sender issue after send error, Err=", err.Error())
@@ -111,52 +220,225 @@ func (l *listManager) SendIssue(senderID, receiverID, message, postID string) (s
 		return "", err
 	}
 
+	l.indexIssue(senderID, senderIssue, receiverID)
+	l.indexIssue(receiverID, receiverIssue, senderID)
+
+	l.recordAction(senderID, &JournalEntry{
+		Action:         JournalActionSend,
+		Issue:          senderIssue,
+		ListID:         OutListKey,
+		ForeignUserID:  receiverID,
+		ForeignIssueID: receiverIssue.ID,
+	})
+	l.dispatch(IssueEventSent, senderID, receiverID, senderIssue)
+
 	return receiverIssue.ID, nil
 }
 
+func (l *listManager) AddChannelIssue(channelID, userID, message, postID string, dueAt int64) (*Issue, error) {
+	issue := newIssue(message, postID, dueAt)
+
+	if err := l.store.AddIssue(issue); err != nil {
+		return nil, err
+	}
+
+	if err := l.store.AddReference(channelID, issue.ID, ChannelListKey, userID, ""); err != nil {
+		if rollbackError := l.store.RemoveIssue(issue.ID); rollbackError != nil {
+			l.api.LogError("cannot rollback issue after add error, Err=", err.Error())
+		}
+		return nil, err
+	}
+
+	l.dispatchChannel(IssueEventCreated, userID, channelID, issue)
+
+	return issue, nil
+}
+
+func (l *listManager) GetChannelIssueList(channelID string) ([]*ExtendedIssue, error) {
+	irs, err := l.store.GetList(channelID, ChannelListKey)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []*ExtendedIssue{}
+	for _, ir := range irs {
+		issue, err := l.store.GetIssue(ir.IssueID)
+		if err != nil {
+			continue
+		}
+
+		issues = append(issues, &ExtendedIssue{
+			Issue:         *issue,
+			ForeignUser:   l.GetUserName(ir.ForeignUserID),
+			ForeignUserID: ir.ForeignUserID,
+			ForeignList:   ChannelListName,
+		})
+	}
+
+	return issues, nil
+}
+
+func (l *listManager) ClaimChannelIssue(channelID, userID, issueID string) (*Issue, error) {
+	ir, _, _ := l.store.GetIssueReference(channelID, issueID, ChannelListKey)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+
+	issue, err := l.store.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Removing the reference first makes sure only one user can claim the todo
+	if err = l.store.RemoveReference(channelID, issueID, ChannelListKey); err != nil {
+		return nil, errIssueNotFound
+	}
+
+	if err = l.store.AddReference(userID, issueID, MyListKey, "", ""); err != nil {
+		if rollbackError := l.store.AddReference(channelID, issueID, ChannelListKey, ir.ForeignUserID, ""); rollbackError != nil {
+			l.api.LogError("cannot rollback claim operation, Err=", rollbackError.Error())
+		}
+		return nil, err
+	}
+
+	l.indexIssue(userID, issue, "")
+	l.dispatchChannel(IssueEventAccepted, userID, channelID, issue)
+
+	return issue, nil
+}
+
 func (l *listManager) GetIssueList(userID, listID string) ([]*ExtendedIssue, error) {
 	irs, err := l.store.GetList(userID, listID)
 	if err != nil {
 		return nil, err
 	}
+	l.observeListSize(listID, len(irs))
+
+	return l.extendIssueRefs(irs), nil
+}
+
+// GetDueIssues returns the todos on the lists listIDs of userID due after from and until the given time, in
+// milliseconds
+func (l *listManager) GetDueIssues(userID string, listIDs []string, from, until int64) ([]*ExtendedIssue, error) {
+	if l.queries != nil {
+		irs, issues, err := l.queries.GetDueIssues(userID, listIDs, from, until)
+		if err != nil {
+			return nil, err
+		}
+		return l.extendIssues(irs, issues), nil
+	}
+
+	issues := []*ExtendedIssue{}
+	for _, listID := range listIDs {
+		listIssues, err := l.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, dueSoonIssues(listIssues, from, until)...)
+	}
+	return issues, nil
+}
+
+func (l *listManager) GetIssueListPage(userID, listID string, page, perPage int) ([]*ExtendedIssue, int, error) {
+	irs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		return nil, 0, err
+	}
+	l.observeListSize(listID, len(irs))
+
+	total := len(irs)
+	start := page * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+
+	return l.extendIssueRefs(irs[start:end]), total, nil
+}
+
+func (l *listManager) GetIssueByPosition(userID, listID string, position int) (*ExtendedIssue, error) {
+	irs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if position < 1 || position > len(irs) {
+		return nil, newLocalizedError(msgErrNoTodoNumber, map[string]interface{}{"Number": position})
+	}
+
+	ir := irs[position-1]
+	issue, err := l.store.GetIssue(ir.IssueID)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.extendIssueInfo(issue, ir), nil
+}
 
+// extendIssueRefs loads the issues referenced by irs, skipping the ones that cannot be loaded
+func (l *listManager) extendIssueRefs(irs []*IssueRef) []*ExtendedIssue {
 	extendedIssues := []*ExtendedIssue{}
 	for _, ir := range irs {
 		issue, err := l.store.GetIssue(ir.IssueID)
-		if err != nil {
+		if err != nil || issue == nil {
 			continue
 		}
 
-		extendedIssue := l.extendIssueInfo(issue, ir)
-		extendedIssues = append(extendedIssues, extendedIssue)
+		extendedIssues = append(extendedIssues, l.extendIssueInfo(issue, ir))
+	}
+
+	return extendedIssues
+}
+
+// extendIssues extends the issues referenced by irs
+func (l *listManager) extendIssues(irs []*IssueRef, issues []*Issue) []*ExtendedIssue {
+	extendedIssues := []*ExtendedIssue{}
+	for i, ir := range irs {
+		extendedIssues = append(extendedIssues, l.extendIssueInfo(issues[i], ir))
 	}
 
-	return extendedIssues, nil
+	return extendedIssues
 }
 
 func (l *listManager) CompleteIssue(userID, issueID string) (*ExtendedIssue, error) {
-	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	issue, entry, err := l.completeIssue(userID, issueID)
+	if entry != nil {
+		l.recordAction(userID, entry)
+	}
+
+	return issue, err
+}
+
+// completeIssue completes the todo issueID for userID like CompleteIssue, and returns the journal entry to undo it
+func (l *listManager) completeIssue(userID, issueID string) (*ExtendedIssue, *JournalEntry, error) {
+	issueList, ir, n := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return nil, fmt.Errorf("cannot find element")
+		return nil, nil, errIssueNotFound
+	}
+	if issueList == DoneListKey {
+		return nil, nil, errIssueAlreadyCompleted
 	}
 
 	if err := l.store.RemoveReference(userID, issueID, issueList); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	issue, err := l.store.GetAndRemoveIssue(issueID)
-	if err != nil {
-		l.api.LogError("cannot remove issue, Err=", err.Error())
-	}
+	issue := l.archiveIssue(userID, issueID, ir.ForeignUserID)
+	entry := newJournalEntry(JournalActionComplete, issue, issueList, n, ir)
+	l.dispatch(IssueEventCompleted, userID, ir.ForeignUserID, issue)
 
-	if ir.ForeignUserID == "" {
+	if ir.ForeignUserID == "" || isDeclined(issue) {
 		if issue == nil {
-			return &ExtendedIssue{}, nil
+			return &ExtendedIssue{}, entry, nil
 		}
-		return l.extendIssueInfo(issue, ir), nil
+		return &ExtendedIssue{Issue: *issue}, entry, nil
 	}
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	_, foreignPosition, _ := l.store.GetIssueReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	err := l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
 	if err != nil {
 		l.api.LogError("cannot clean foreigner list after complete, Err=", err.Error())
 	}
@@ -165,8 +447,11 @@ func (l *listManager) CompleteIssue(userID, issueID string) (*ExtendedIssue, err
 	if err != nil {
 		l.api.LogError("cannot clean foreigner issue after complete, Err=", err.Error())
 	}
+	l.unindexIssue(ir.ForeignUserID, ir.ForeignIssueID)
 
-	return l.extendIssueInfo(issue, ir), nil
+	entry.setForeignIssue(issue, OutListKey, foreignPosition)
+
+	return l.extendIssueInfo(issue, ir), entry, nil
 }
 
 func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, foreignUserID string, outErr error) {
@@ -196,128 +481,946 @@ func (l *listManager) AcceptIssue(userID, issueID string) (todoMessage string, f
 		return "", "", err
 	}
 
+	l.dispatch(IssueEventAccepted, userID, ir.ForeignUserID, issue)
+
 	return issue.Message, ir.ForeignUserID, nil
 }
 
-func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *ExtendedIssue, isSender bool, outErr error) {
-	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+func (l *listManager) DeclineIssue(userID, issueID, reason string) (todoMessage string, foreignUserID string, outErr error) {
+	ir, _, err := l.store.GetIssueReference(userID, issueID, InListKey)
+	if err != nil {
+		return "", "", err
+	}
 	if ir == nil {
-		return nil, false, fmt.Errorf("cannot find element")
+		return "", "", fmt.Errorf("element reference not found")
 	}
 
-	if err := l.store.RemoveReference(userID, issueID, issueList); err != nil {
-		return nil, false, err
+	if err = l.store.RemoveReference(userID, issueID, InListKey); err != nil {
+		return "", "", err
 	}
 
 	issue, err := l.store.GetAndRemoveIssue(issueID)
 	if err != nil {
-		l.api.LogError("cannot remove issue, Err=", err.Error())
+		l.api.LogError("cannot remove issue after decline, Err=", err.Error())
 	}
+	l.unindexIssue(userID, issueID)
+	l.dispatch(IssueEventDeclined, userID, ir.ForeignUserID, issue)
 
-	if ir.ForeignUserID == "" {
+	foreignIssue, err := l.store.ModifyIssue(ir.ForeignIssueID, func(foreignIssue *Issue) error {
+		foreignIssue.Status = IssueStatusDeclined
+		foreignIssue.DeclineReason = reason
+		return nil
+	})
+	if err != nil {
+		l.api.LogError("cannot update foreigner issue after decline, Err=", err.Error())
 		if issue == nil {
-			return &ExtendedIssue{}, false, nil
+			return "", ir.ForeignUserID, nil
 		}
-		return l.extendIssueInfo(issue, ir), false, nil
+		return issue.Message, ir.ForeignUserID, nil
 	}
+	l.publishUpdated(ir.ForeignUserID, foreignIssue)
 
-	list, _, _ := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
+	return foreignIssue.Message, ir.ForeignUserID, nil
+}
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, list)
-	if err != nil {
-		l.api.LogError("cannot clean foreigner list after remove, Err=", err.Error())
+func (l *listManager) ForwardIssue(userID, issueID, receiverID, note string) (todoMessage string, senderID string, outErr error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return "", "", errIssueNotFound
 	}
-
-	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
-	if err != nil {
-		l.api.LogError("cannot clean foreigner issue after remove, Err=", err.Error())
+	if (issueList != InListKey && issueList != MyListKey) || ir.ForeignUserID == "" || ir.ForeignIssueID == "" {
+		return "", "", errIssueNotReceived
+	}
+	if receiverID == userID || receiverID == ir.ForeignUserID {
+		return "", "", errInvalidForward
 	}
 
-	return l.extendIssueInfo(issue, ir), list == OutListKey, nil
-}
+	forward := &Forward{
+		FromUserID: userID,
+		ToUserID:   receiverID,
+		Note:       note,
+		CreateAt:   model.GetMillis(),
+	}
 
-func (l *listManager) PopIssue(userID string) (*ExtendedIssue, error) {
-	ir, err := l.store.PopReference(userID, MyListKey)
+	_, senderPosition, err := l.store.GetIssueReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 
-	if ir == nil {
-		return &ExtendedIssue{}, nil
+	if err = l.store.AddReference(receiverID, issueID, InListKey, ir.ForeignUserID, ir.ForeignIssueID); err != nil {
+		return "", "", err
 	}
 
-	issue, err := l.store.GetAndRemoveIssue(ir.IssueID)
-	if err != nil {
-		l.api.LogError("cannot remove issue after pop, Err=", err.Error())
+	if err = l.store.RemoveReference(userID, issueID, issueList); err != nil {
+		if rollbackError := l.store.RemoveReference(receiverID, issueID, InListKey); rollbackError != nil {
+			l.api.LogError("cannot rollback forward operation, Err=", rollbackError.Error())
+		}
+		return "", "", err
 	}
 
-	if ir.ForeignUserID == "" {
-		if issue == nil {
-			return &ExtendedIssue{}, nil
-		}
-		return l.extendIssueInfo(issue, ir), nil
+	if err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey); err != nil {
+		l.api.LogError("cannot clean foreigner list after forward, Err=", err.Error())
+	}
+	if err = l.insertReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey, receiverID, issueID, senderPosition); err != nil {
+		l.api.LogError("cannot update foreigner list after forward, Err=", err.Error())
 	}
 
-	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	// The new receiver has not started on the todo yet
+	issue, err := l.store.ModifyIssue(issueID, func(issue *Issue) error {
+		issue.Forwards = append(issue.Forwards, forward)
+		issue.Status = IssueStatusOpen
+		return nil
+	})
 	if err != nil {
-		l.api.LogError("cannot clean foreigner list after pop, Err=", err.Error())
+		l.api.LogError("cannot record forward on issue, Err=", err.Error())
 	}
-	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
+	l.unindexIssue(userID, issueID)
+	if issue != nil {
+		l.indexIssue(receiverID, issue, ir.ForeignUserID)
+		l.publishUpdated(receiverID, issue)
+	}
+
+	foreignIssue, err := l.store.ModifyIssue(ir.ForeignIssueID, func(foreignIssue *Issue) error {
+		foreignIssue.Forwards = append(foreignIssue.Forwards, forward)
+		foreignIssue.Status = IssueStatusOpen
+		return nil
+	})
 	if err != nil {
-		l.api.LogError("cannot clean foreigner issue after pop, Err=", err.Error())
+		l.api.LogError("cannot record forward on foreigner issue, Err=", err.Error())
+	} else {
+		l.indexIssue(ir.ForeignUserID, foreignIssue, receiverID)
+		l.publishUpdated(ir.ForeignUserID, foreignIssue)
 	}
 
-	return l.extendIssueInfo(issue, ir), nil
+	l.dispatch(IssueEventForwarded, userID, receiverID, issue)
+
+	if issue == nil {
+		return "", ir.ForeignUserID, nil
+	}
+	return issue.Message, ir.ForeignUserID, nil
 }
 
-func (l *listManager) BumpIssue(userID, issueID string) (todoMessage string, receiver string, foreignIssueID string, outErr error) {
-	ir, _, err := l.store.GetIssueReference(userID, issueID, OutListKey)
-	if err != nil {
-		return "", "", "", err
+func (l *listManager) ProposeChange(userID, issueID string, proposal *Proposal) (*ExtendedIssue, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
 	}
+	if issueList != MyListKey || ir.ForeignUserID == "" || ir.ForeignIssueID == "" {
+		return nil, newLocalizedError(msgErrInvalidProposal, nil)
+	}
+
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		issue.Proposal = proposal
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Proposal = issue.Proposal
+	})
+}
+
+func (l *listManager) AttachIssue(userID, issueID, postID string) (*ExtendedIssue, error) {
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		issue.PostID = postID
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.PostID = issue.PostID
+	})
+}
+
+func (l *listManager) AttachFiles(userID, issueID string, files []*Attachment) (*ExtendedIssue, error) {
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		return addAttachments(issue, files)
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Files = issue.Files
+	})
+}
+
+func (l *listManager) SetIssuePriority(userID, issueID, priority string) (*ExtendedIssue, error) {
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		issue.Priority = priority
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Priority = issue.Priority
+	})
+}
 
+func (l *listManager) WatchIssue(userID, issueID string, watch bool) (*ExtendedIssue, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return "", "", "", fmt.Errorf("cannot find sender issue")
+		return nil, errIssueNotFound
+	}
+	if issueList != OutListKey || ir.ForeignUserID == "" {
+		return nil, errIssueNotSent
 	}
 
-	err = l.store.BumpReference(ir.ForeignUserID, ir.ForeignIssueID, InListKey)
-	if err != nil {
-		return "", "", "", err
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		if isDeclined(issue) {
+			return errIssueNotSent
+		}
+
+		watchers := []string{}
+		for _, watcher := range issue.Watchers {
+			if watcher != userID {
+				watchers = append(watchers, watcher)
+			}
+		}
+		if watch {
+			watchers = append(watchers, userID)
+		}
+		issue.Watchers = watchers
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Watchers = issue.Watchers
+	})
+}
+
+func (l *listManager) EscalateIssue(userID, issueID string) (*ExtendedIssue, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+	if issueList != InListKey {
+		return nil, errIssueNotWaiting
 	}
 
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		issue.EscalatedAt = model.GetMillis()
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.EscalatedAt = issue.EscalatedAt
+	})
+}
+
+func (l *listManager) StartIssue(userID, issueID string) (*ExtendedIssue, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
 	if ir == nil {
-		return "", "", "", fmt.Errorf("cannot find receiver issue")
+		return nil, errIssueNotFound
+	}
+	if issueList != MyListKey {
+		return nil, errInvalidStart
 	}
 
-	issue, err := l.store.GetIssue(ir.ForeignIssueID)
+	issue, err := l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		if issue.Status == IssueStatusInProgress {
+			return errIssueAlreadyStarted
+		}
+		issue.Status = IssueStatusInProgress
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Status = issue.Status
+	})
 	if err != nil {
-		l.api.LogError("cannot find foreigner issue after bump, Err=", err.Error())
-		return "", "", "", nil
+		return nil, err
 	}
 
-	return issue.Message, ir.ForeignUserID, ir.ForeignIssueID, nil
+	l.dispatch(IssueEventStarted, userID, ir.ForeignUserID, &issue.Issue)
+
+	return issue, nil
 }
 
-func (l *listManager) GetUserName(userID string) string {
-	user, err := l.api.GetUser(userID)
-	if err != nil {
-		return "Someone"
+func (l *listManager) ResolveProposal(userID, issueID string, approve bool) (*ExtendedIssue, *Proposal, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, nil, errIssueNotFound
+	}
+	if issueList != OutListKey {
+		return nil, nil, errNoProposal
 	}
-	return user.Username
-}
 
-func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue {
-	if issue == nil || ir == nil {
+	var proposal *Proposal
+	issue, err := l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		if issue.Proposal == nil {
+			return errNoProposal
+		}
+
+		proposal = issue.Proposal
+		issue.Proposal = nil
+		if approve && proposal.Message != "" {
+			issue.Message = proposal.Message
+		}
+		if approve && proposal.DueAt != 0 {
+			issue.DueAt = proposal.DueAt
+		}
 		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Proposal = nil
+		foreignIssue.Message = issue.Message
+		foreignIssue.DueAt = issue.DueAt
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	feIssue := &ExtendedIssue{
-		Issue: *issue,
+	if approve && proposal.Message != "" {
+		l.indexIssue(userID, &issue.Issue, ir.ForeignUserID)
+		if foreignIssue, err := l.store.GetIssue(ir.ForeignIssueID); err == nil && foreignIssue != nil {
+			l.indexIssue(ir.ForeignUserID, foreignIssue, userID)
+		}
 	}
 
-	if ir == nil || ir.ForeignUserID == "" {
-		return feIssue
-	}
+	return issue, proposal, nil
+}
+
+func (l *listManager) EditIssue(userID, issueID, message string) (oldMessage string, foreignUserID string, isSender bool, outErr error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return "", "", false, errIssueNotFound
+	}
+	if issueList == DoneListKey {
+		return "", "", false, newLocalizedError(msgErrCompletedNotEditable, nil)
+	}
+
+	issue, err := l.store.ModifyIssue(issueID, func(issue *Issue) error {
+		oldMessage = issue.Message
+		issue.Message = message
+		return nil
+	})
+	if err != nil {
+		return "", "", false, err
+	}
+	l.indexIssue(userID, issue, ir.ForeignUserID)
+	l.publishUpdated(userID, issue)
+
+	if ir.ForeignUserID == "" || isDeclined(issue) {
+		l.dispatch(IssueEventEdited, userID, "", issue)
+		return oldMessage, "", false, nil
+	}
+	l.dispatch(IssueEventEdited, userID, ir.ForeignUserID, issue)
+
+	foreignIssue, err := l.store.ModifyIssue(ir.ForeignIssueID, func(foreignIssue *Issue) error {
+		foreignIssue.Message = message
+		return nil
+	})
+	if err != nil {
+		l.api.LogError("cannot update foreigner issue after edit, Err=", err.Error())
+		return oldMessage, ir.ForeignUserID, issueList == OutListKey, nil
+	}
+	l.indexIssue(ir.ForeignUserID, foreignIssue, userID)
+	l.publishUpdated(ir.ForeignUserID, foreignIssue)
+
+	return oldMessage, ir.ForeignUserID, issueList == OutListKey, nil
+}
+
+// updateSharedIssue applies update to the todo issueID of userID, then share copies the changes to the copy of the
+// foreign user of a sent todo, so both see the same todo. Completed todos cannot be changed.
+func (l *listManager) updateSharedIssue(userID, issueID string, update func(*Issue) error, share func(foreignIssue, issue *Issue)) (*ExtendedIssue, error) {
+	issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+	if issueList == DoneListKey {
+		return nil, newLocalizedError(msgErrCompletedNotEditable, nil)
+	}
+
+	issue, err := l.store.ModifyIssue(issueID, update)
+	if err != nil {
+		return nil, err
+	}
+	l.publishUpdated(userID, issue)
+
+	if ir.ForeignUserID != "" && !isDeclined(issue) {
+		foreignIssue, err := l.store.ModifyIssue(ir.ForeignIssueID, func(foreignIssue *Issue) error {
+			share(foreignIssue, issue)
+			return nil
+		})
+		if err != nil {
+			l.api.LogError("cannot update foreigner issue after update, Err=", err.Error())
+		} else {
+			l.publishUpdated(ir.ForeignUserID, foreignIssue)
+		}
+	}
+
+	return l.extendIssueInfo(issue, ir), nil
+}
+
+func (l *listManager) RemoveIssue(userID, issueID string) (outIssue *ExtendedIssue, isSender bool, outErr error) {
+	outIssue, isSender, entry, outErr := l.removeIssue(userID, issueID)
+	if entry != nil {
+		l.recordAction(userID, entry)
+		l.dispatch(IssueEventDeleted, userID, entry.ForeignUserID, entry.Issue)
+	}
+
+	return outIssue, isSender, outErr
+}
+
+// removeIssue removes the todo issueID for userID like RemoveIssue, and returns the journal entry to undo it
+func (l *listManager) removeIssue(userID, issueID string) (*ExtendedIssue, bool, *JournalEntry, error) {
+	issueList, ir, n := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, false, nil, errIssueNotFound
+	}
+
+	if err := l.store.RemoveReference(userID, issueID, issueList); err != nil {
+		return nil, false, nil, err
+	}
+
+	issue, err := l.store.GetAndRemoveIssue(issueID)
+	if err != nil {
+		l.api.LogError("cannot remove issue, Err=", err.Error())
+	}
+	l.unindexIssue(userID, issueID)
+	entry := newJournalEntry(JournalActionRemove, issue, issueList, n, ir)
+
+	// The receiver of a declined todo already removed their copy, and completed todos are not linked anymore
+	if ir.ForeignUserID == "" || isDeclined(issue) || issueList == DoneListKey {
+		if issue == nil {
+			return &ExtendedIssue{}, false, entry, nil
+		}
+		return &ExtendedIssue{Issue: *issue}, false, entry, nil
+	}
+
+	list, _, foreignPosition := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
+
+	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, list)
+	if err != nil {
+		l.api.LogError("cannot clean foreigner list after remove, Err=", err.Error())
+	}
+
+	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
+	if err != nil {
+		l.api.LogError("cannot clean foreigner issue after remove, Err=", err.Error())
+	}
+	l.unindexIssue(ir.ForeignUserID, ir.ForeignIssueID)
+	entry.setForeignIssue(issue, list, foreignPosition)
+
+	return l.extendIssueInfo(issue, ir), list == OutListKey, entry, nil
+}
+
+func (l *listManager) PurgeCompleted(userID string, before int64) (int, error) {
+	issueIDs, err := l.getCompletedIssueIDs(userID, before)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, issueID := range issueIDs {
+		if err := l.purgeIssue(userID, issueID, DoneListKey); err != nil {
+			return i, err
+		}
+	}
+
+	return len(issueIDs), nil
+}
+
+// getCompletedIssueIDs returns the todos on the done list of userID completed before the given time in milliseconds.
+// References to issues that are gone are returned too. Todos completed before the completion time was recorded are as
+// old as their creation.
+func (l *listManager) getCompletedIssueIDs(userID string, before int64) ([]string, error) {
+	if l.queries != nil {
+		return l.queries.GetCompletedIssueIDs(userID, before)
+	}
+
+	irs, err := l.store.GetList(userID, DoneListKey)
+	if err != nil {
+		return nil, err
+	}
+
+	issueIDs := []string{}
+	for _, ir := range irs {
+		issue, err := l.store.GetIssue(ir.IssueID)
+		if err == nil && issue != nil {
+			completeAt := issue.CompleteAt
+			if completeAt == 0 {
+				completeAt = issue.CreateAt
+			}
+			if completeAt >= before {
+				continue
+			}
+		}
+		issueIDs = append(issueIDs, ir.IssueID)
+	}
+
+	return issueIDs, nil
+}
+
+func (l *listManager) PurgeUser(userID string) (int, error) {
+	purged := 0
+	for _, listID := range []string{MyListKey, InListKey, OutListKey, DoneListKey} {
+		irs, err := l.store.GetList(userID, listID)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, ir := range irs {
+			if err := l.purgeIssue(userID, ir.IssueID, listID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (l *listManager) GetUserIDs() ([]string, error) {
+	return l.store.GetUserIDs()
+}
+
+// purgeIssue deletes the todo issueID on listID of userID for good, without touching the copy of the foreign user
+func (l *listManager) purgeIssue(userID, issueID, listID string) error {
+	if err := l.store.RemoveReference(userID, issueID, listID); err != nil {
+		return err
+	}
+
+	if err := l.store.RemoveIssue(issueID); err != nil {
+		l.api.LogError("cannot remove purged issue, Err=", err.Error())
+	}
+	l.unindexIssue(userID, issueID)
+	return nil
+}
+
+func (l *listManager) BulkIssues(userID, action string, issueIDs []string) ([]*BulkResult, error) {
+	if action != BulkActionComplete && action != BulkActionRemove {
+		return nil, fmt.Errorf("%s is not a valid bulk action", action)
+	}
+
+	// Every todo is checked before changing anything, so a missing todo does not leave the lists half updated
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, issueID := range issueIDs {
+		if seen[issueID] {
+			continue
+		}
+		seen[issueID] = true
+
+		issueList, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+		if ir == nil {
+			return nil, errIssueNotFound
+		}
+		if action == BulkActionComplete && issueList == DoneListKey {
+			return nil, errIssueAlreadyCompleted
+		}
+		ids = append(ids, issueID)
+	}
+
+	// The changes are journaled as a single entry, even if a later todo fails, so they are undone together
+	results := []*BulkResult{}
+	bulkEntry := &JournalEntry{Action: JournalActionBulk}
+	defer l.recordAction(userID, bulkEntry)
+
+	for _, issueID := range ids {
+		result := &BulkResult{}
+		var entry *JournalEntry
+		var err error
+		if action == BulkActionComplete {
+			result.Issue, entry, err = l.completeIssue(userID, issueID)
+		} else {
+			result.Issue, result.IsSender, entry, err = l.removeIssue(userID, issueID)
+			if entry != nil {
+				l.dispatch(IssueEventDeleted, userID, entry.ForeignUserID, entry.Issue)
+			}
+		}
+		if entry != nil && entry.Issue != nil {
+			bulkEntry.Entries = append(bulkEntry.Entries, entry)
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (l *listManager) PopIssue(userID string) (*ExtendedIssue, error) {
+	ir, err := l.store.PopReference(userID, MyListKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if ir == nil {
+		return &ExtendedIssue{}, nil
+	}
+
+	issue := l.archiveIssue(userID, ir.IssueID, ir.ForeignUserID)
+	entry := newJournalEntry(JournalActionComplete, issue, MyListKey, 0, ir)
+	l.dispatch(IssueEventCompleted, userID, ir.ForeignUserID, issue)
+
+	if ir.ForeignUserID == "" {
+		l.recordAction(userID, entry)
+		if issue == nil {
+			return &ExtendedIssue{}, nil
+		}
+		return l.extendIssueInfo(issue, ir), nil
+	}
+
+	_, foreignPosition, _ := l.store.GetIssueReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	err = l.store.RemoveReference(ir.ForeignUserID, ir.ForeignIssueID, OutListKey)
+	if err != nil {
+		l.api.LogError("cannot clean foreigner list after pop, Err=", err.Error())
+	}
+	issue, err = l.store.GetAndRemoveIssue(ir.ForeignIssueID)
+	if err != nil {
+		l.api.LogError("cannot clean foreigner issue after pop, Err=", err.Error())
+	}
+	l.unindexIssue(ir.ForeignUserID, ir.ForeignIssueID)
+
+	entry.setForeignIssue(issue, OutListKey, foreignPosition)
+	l.recordAction(userID, entry)
+
+	return l.extendIssueInfo(issue, ir), nil
+}
+
+func (l *listManager) BumpIssue(userID, issueID string) (todoMessage string, receiver string, foreignIssueID string, outErr error) {
+	ir, _, err := l.store.GetIssueReference(userID, issueID, OutListKey)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if ir == nil {
+		return "", "", "", fmt.Errorf("cannot find sender issue")
+	}
+
+	err = l.store.BumpReference(ir.ForeignUserID, ir.ForeignIssueID, InListKey)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if ir == nil {
+		return "", "", "", fmt.Errorf("cannot find receiver issue")
+	}
+
+	issue, err := l.store.GetIssue(ir.ForeignIssueID)
+	if err != nil {
+		l.api.LogError("cannot find foreigner issue after bump, Err=", err.Error())
+		return "", "", "", nil
+	}
+
+	return issue.Message, ir.ForeignUserID, ir.ForeignIssueID, nil
+}
+
+func (l *listManager) GetIssue(userID, issueID string) (*Issue, error) {
+	_, ir, _ := l.store.GetIssueListAndReference(userID, issueID)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+
+	return l.store.GetIssue(issueID)
+}
+
+func (l *listManager) SetIssueGitHubLink(userID, issueID string, link *GitHubLink) (*Issue, error) {
+	if _, err := l.GetIssue(userID, issueID); err != nil {
+		return nil, err
+	}
+
+	issue, err := l.store.ModifyIssue(issueID, func(issue *Issue) error {
+		issue.GitHub = link
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	l.publishUpdated(userID, issue)
+
+	return issue, nil
+}
+
+func (l *listManager) RestoreIssue(userID, issueID string) (*Issue, error) {
+	ir, _, _ := l.store.GetIssueReference(userID, issueID, DoneListKey)
+	if ir == nil {
+		return nil, errIssueNotFound
+	}
+
+	issue, err := l.store.ModifyIssue(issueID, func(issue *Issue) error {
+		issue.CompleteAt = 0
+		issue.Status = IssueStatusOpen
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The foreign copy was removed on completion, so the restored todo is not linked to anyone
+	if err = l.store.AddReference(userID, issueID, MyListKey, "", ""); err != nil {
+		return nil, err
+	}
+
+	if err = l.store.RemoveReference(userID, issueID, DoneListKey); err != nil {
+		if rollbackError := l.store.RemoveReference(userID, issueID, MyListKey); rollbackError != nil {
+			l.api.LogError("cannot rollback restore operation, Err=", rollbackError.Error())
+		}
+		return nil, err
+	}
+
+	l.indexIssue(userID, issue, "")
+
+	return issue, nil
+}
+
+func (l *listManager) UndoLastAction(userID string) (*JournalEntry, error) {
+	entry, err := l.store.PopJournalEntry(userID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || (entry.Issue == nil && len(entry.Entries) == 0) {
+		return nil, errNothingToUndo
+	}
+
+	if entry.Action != JournalActionBulk {
+		if err = l.undoEntry(userID, entry); err != nil {
+			return nil, err
+		}
+		return entry, nil
+	}
+
+	// The todos of a bulk action are restored in the reverse order they changed, so they get their positions back.
+	// The ones that changed since are left as they are.
+	undone := []*JournalEntry{}
+	for i := len(entry.Entries) - 1; i >= 0; i-- {
+		err = l.undoEntry(userID, entry.Entries[i])
+		if err == errUndoConflict {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		undone = append(undone, entry.Entries[i])
+	}
+	if len(undone) == 0 {
+		return nil, errUndoConflict
+	}
+
+	entry.Entries = undone
+	return entry, nil
+}
+
+// undoEntry reverses the action of a single todo recorded in entry
+func (l *listManager) undoEntry(userID string, entry *JournalEntry) error {
+	var err error
+	switch entry.Action {
+	case JournalActionSend:
+		if _, _, _, err = l.removeIssue(userID, entry.Issue.ID); err == errIssueNotFound {
+			return errUndoConflict
+		}
+		return err
+	case JournalActionComplete:
+		if err = l.store.RemoveReference(userID, entry.Issue.ID, DoneListKey); err != nil {
+			return errUndoConflict
+		}
+		entry.Issue.CompleteAt = 0
+	case JournalActionRemove:
+		if _, ir, _ := l.store.GetIssueListAndReference(userID, entry.Issue.ID); ir != nil {
+			return errUndoConflict
+		}
+	default:
+		return fmt.Errorf("cannot undo the action %s", entry.Action)
+	}
+
+	if err = l.store.AddIssue(entry.Issue); err != nil {
+		return err
+	}
+	if err = l.insertReference(userID, entry.Issue.ID, entry.ListID, entry.ForeignUserID, entry.ForeignIssueID, entry.Position); err != nil {
+		return err
+	}
+	l.indexIssue(userID, entry.Issue, entry.ForeignUserID)
+
+	if entry.ForeignIssue == nil {
+		return nil
+	}
+
+	if err = l.store.AddIssue(entry.ForeignIssue); err != nil {
+		l.api.LogError("cannot restore foreigner issue after undo, Err=", err.Error())
+		return nil
+	}
+	if err = l.insertReference(entry.ForeignUserID, entry.ForeignIssue.ID, entry.ForeignListID, userID, entry.Issue.ID, entry.ForeignPosition); err != nil {
+		l.api.LogError("cannot restore foreigner list after undo, Err=", err.Error())
+	}
+	l.indexIssue(entry.ForeignUserID, entry.ForeignIssue, userID)
+
+	return nil
+}
+
+func (l *listManager) MoveIssue(userID, listID, issueID string, position int) error {
+	return l.store.MoveReference(userID, issueID, listID, position-1)
+}
+
+func (l *listManager) SearchIssues(userID, query string) ([]*SearchResult, error) {
+	issueIDs, ok, err := l.index.Search(userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if err = l.rebuildIndex(userID); err != nil {
+			return nil, err
+		}
+		if issueIDs, _, err = l.index.Search(userID, query); err != nil {
+			return nil, err
+		}
+	}
+
+	results := []*SearchResult{}
+	for _, issueID := range issueIDs {
+		list, ir, n := l.store.GetIssueListAndReference(userID, issueID)
+		if ir == nil {
+			// The index is only updated on a best effort basis, so clean up entries of issues that are gone
+			l.unindexIssue(userID, issueID)
+			continue
+		}
+
+		issue, err := l.store.GetIssue(issueID)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, &SearchResult{
+			ExtendedIssue: *l.extendIssueInfo(issue, ir),
+			UserList:      listKeyToName(list),
+			UserPosition:  n + 1,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].UserList != results[j].UserList {
+			return listOrder(results[i].UserList) < listOrder(results[j].UserList)
+		}
+		return results[i].UserPosition < results[j].UserPosition
+	})
+
+	return results, nil
+}
+
+// rebuildIndex indexes all the issues in the lists of userID, for users that have issues from before the index existed
+func (l *listManager) rebuildIndex(userID string) error {
+	issues := []indexedIssue{}
+	for _, listID := range []string{MyListKey, InListKey, OutListKey} {
+		irs, err := l.store.GetList(userID, listID)
+		if err != nil {
+			return err
+		}
+
+		for _, ir := range irs {
+			issue, err := l.store.GetIssue(ir.IssueID)
+			if err != nil {
+				continue
+			}
+
+			foreignUserName := ""
+			if ir.ForeignUserID != "" {
+				foreignUserName = l.GetUserName(ir.ForeignUserID)
+			}
+			issues = append(issues, indexedIssue{issue: issue, foreignUserName: foreignUserName})
+		}
+	}
+
+	return l.index.Rebuild(userID, issues)
+}
+
+// archiveIssue moves the completed issueID to the done list of userID, and returns the issue. If it cannot be archived,
+// the issue is removed.
+func (l *listManager) archiveIssue(userID, issueID, foreignUserID string) *Issue {
+	l.unindexIssue(userID, issueID)
+
+	issue, err := l.store.ModifyIssue(issueID, func(issue *Issue) error {
+		issue.CompleteAt = model.GetMillis()
+		return nil
+	})
+	if err != nil {
+		l.api.LogError("cannot get issue to archive, Err=", err.Error())
+		return nil
+	}
+
+	err = l.store.AddReference(userID, issueID, DoneListKey, foreignUserID, "")
+	if err == nil {
+		err = l.store.BumpReference(userID, issueID, DoneListKey)
+	}
+	if err != nil {
+		l.api.LogError("cannot archive issue, Err=", err.Error())
+		if err = l.store.RemoveIssue(issueID); err != nil {
+			l.api.LogError("cannot remove issue, Err=", err.Error())
+		}
+	}
+
+	return issue
+}
+
+// insertReference adds the reference to the 0-based position of listID for userID, or to the end if the list is shorter
+func (l *listManager) insertReference(userID, issueID, listID, foreignUserID, foreignIssueID string, position int) error {
+	if err := l.store.AddReference(userID, issueID, listID, foreignUserID, foreignIssueID); err != nil {
+		return err
+	}
+
+	irs, err := l.store.GetList(userID, listID)
+	if err != nil {
+		return err
+	}
+	if position >= len(irs)-1 {
+		return nil
+	}
+
+	return l.store.MoveReference(userID, issueID, listID, position)
+}
+
+func (l *listManager) recordAction(userID string, entry *JournalEntry) {
+	if entry.Issue == nil && len(entry.Entries) == 0 {
+		return
+	}
+
+	if err := l.store.PushJournalEntry(userID, entry); err != nil {
+		l.api.LogError("cannot record action in journal, Err=", err.Error())
+	}
+}
+
+func newJournalEntry(action string, issue *Issue, listID string, position int, ir *IssueRef) *JournalEntry {
+	return &JournalEntry{
+		Action:         action,
+		Issue:          issue,
+		ListID:         listID,
+		Position:       position,
+		ForeignUserID:  ir.ForeignUserID,
+		ForeignIssueID: ir.ForeignIssueID,
+	}
+}
+
+func (l *listManager) indexIssue(userID string, issue *Issue, foreignUserID string) {
+	foreignUserName := ""
+	if foreignUserID != "" {
+		foreignUserName = l.GetUserName(foreignUserID)
+	}
+
+	if err := l.index.IndexIssue(userID, issue, foreignUserName); err != nil {
+		l.api.LogError("cannot index issue, Err=", err.Error())
+	}
+}
+
+func (l *listManager) unindexIssue(userID, issueID string) {
+	if err := l.index.RemoveIssue(userID, issueID); err != nil {
+		l.api.LogError("cannot remove issue from index, Err=", err.Error())
+	}
+}
+
+func (l *listManager) GetUserName(userID string) string {
+	getUser := l.api.GetUser
+	if l.users != nil {
+		getUser = l.users.GetUser
+	}
+
+	user, err := getUser(userID)
+	if err != nil {
+		return "Someone"
+	}
+	return user.Username
+}
+
+func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue {
+	if issue == nil || ir == nil {
+		return nil
+	}
+
+	feIssue := &ExtendedIssue{
+		Issue: *issue,
+	}
+
+	if ir == nil || ir.ForeignUserID == "" {
+		return feIssue
+	}
+
+	userName := l.GetUserName(ir.ForeignUserID)
+
+	feIssue.ForeignUser = userName
+	feIssue.ForeignUserID = ir.ForeignUserID
+
+	if isDeclined(issue) || issue.CompleteAt != 0 {
+		return feIssue
+	}
 
 	list, _, n := l.store.GetIssueListAndReference(ir.ForeignUserID, ir.ForeignIssueID)
 
@@ -331,11 +1434,45 @@ func (l *listManager) extendIssueInfo(issue *Issue, ir *IssueRef) *ExtendedIssue
 		listName = "out"
 	}
 
-	userName := l.GetUserName(ir.ForeignUserID)
-
-	feIssue.ForeignUser = userName
 	feIssue.ForeignList = listName
 	feIssue.ForeignPosition = n
 
 	return feIssue
 }
+
+func isDeclined(issue *Issue) bool {
+	return issue != nil && issue.Status == IssueStatusDeclined
+}
+
+// listKeyToName returns the name used in commands and the API for the list listID
+func listKeyToName(listID string) string {
+	switch listID {
+	case InListKey:
+		return "in"
+	case OutListKey:
+		return "out"
+	case DoneListKey:
+		return "done"
+	}
+	return "my"
+}
+
+func (l *listManager) observeListSize(listID string, size int) {
+	name := listKeyToName(listID)
+	if listID == ChannelListKey {
+		name = "channel"
+	}
+	l.metrics.observe(metricListSize, float64(size), "list", name)
+}
+
+func listOrder(listName string) int {
+	switch listName {
+	case "my":
+		return 0
+	case "in":
+		return 1
+	case "out":
+		return 2
+	}
+	return 3
+}
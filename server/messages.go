@@ -0,0 +1,813 @@
+package main
+
+// The messages shown to users, with their English text. See message for how they are translated.
+var (
+	msgHelp = newMessage("command.help", `Available Commands:
+
+add [message]
+	Adds a Todo. A due date can be given at the end of the message after "by", or with the --due flag. Without a
+	message, a dialog asks for the message, the due date, the priority and optionally someone to send the Todo to.
+
+	example: /todo add Don't forget to be awesome
+	example: /todo add Pay invoice by tomorrow 5pm
+	example: /todo add Prepare the demo --due "next friday"
+
+	A Todo starting with the URL of a GitHub issue or pull request shows its title and state, kept up to date.
+
+	example: /todo add https://github.com/mattermost/mattermost-server/issues/42
+
+	Files already uploaded to Mattermost can be attached with the --file flag, followed by the link of the file.
+
+	example: /todo add Review the contract --file https://example.com/files/kq8ruc1nbtgz7xyjpucwhge4gh/public
+
+list
+	Lists your Todo issues.
+
+list [listName] [page]
+	List your issues in certain list, {{.PageSize}} at a time
+
+	example: /todo list in
+	example: /todo list out
+	example: /todo list done
+	example (same as /todo list): /todo list my
+	example: /todo list my 2
+
+list [listName] --in-progress
+	Lists only the Todo issues in progress of a list, with their position in the whole list.
+
+	example: /todo list out --in-progress
+
+list @[user] [my|in]
+	Shows the Todo list and the received Todos of a user that shared them with you, without changing them.
+	System admins can see the lists of every user.
+
+	example: /todo list @awesomePerson in
+
+share [user]
+	Lets a user, like your manager, see your Todo list and received Todos with /todo list @you.
+	Without a user, shows who you shared them with.
+
+	example: /todo share @awesomeManager
+
+unshare [user]
+	Stops sharing your Todo list with a user.
+
+	example: /todo unshare @awesomeManager
+
+search [query]
+	Finds your Todo issues in any list by their message, the user that sent or received them and their #tags.
+
+	example: /todo search #release notes
+
+pop
+	Removes the Todo issue at the top of the list.
+
+start [number]
+	Marks the Todo issue at the given position of your list, the first one by default, as in progress. If someone
+	sent it to you, they are told you started on it.
+
+	example: /todo start 2
+
+done [numbers]
+	Completes the Todo issues at the given positions of your list, moving them to your completed list.
+	Several numbers and ranges can be given at once.
+
+	example: /todo done 2
+	example: /todo done 1-3,5
+
+restore [number]
+	Moves the Todo issue at the given position of your completed list back to your list.
+
+	example: /todo restore 1
+
+move [from] [to]
+	Moves the Todo issue at position from of your list to position to.
+
+	example: /todo move 4 1
+
+edit [listName] [number] [message]
+	Changes the message of the Todo issue at the given position of a list. The list defaults to your own list.
+
+	example: /todo edit 2 Don't forget to be really awesome
+
+attach [listName] [number] [post link]
+	Attaches the Todo issue at the given position of a list to the thread the command is run in, or to the post of
+	the link. Completing it replies in the thread, and the list links to it. The list defaults to your own list.
+
+	example: /todo attach 2
+	example: /todo attach out 1 https://example.com/myteam/pl/kq8ruc1nbtgz7xyjpucwhge4gh
+
+rm [listName] [numbers]
+	Removes the Todo issues at the given positions of a list. The list defaults to your own list.
+	Several numbers and ranges can be given at once.
+
+	example: /todo rm 3
+	example: /todo rm out 1,4-6
+
+note [listName] [number] [text]
+	Adds a note to the Todo issue at the given position of a list. The list defaults to your own list. Notes on
+	a Todo issue you sent are shown to the receiver, who is notified.
+
+	example: /todo note out 1 The meeting moved to Thursday
+
+sub add [number] [message]
+	Adds a checklist item to the Todo issue at the given position of your list.
+
+	example: /todo sub add 2 Write tests
+
+sub [check|uncheck|rm] [number.item]
+	Checks, unchecks or removes a checklist item, numbered after its Todo issue as shown in the list.
+
+	example: /todo sub check 2.1
+
+send [user] [message]
+	Sends some user a Todo
+
+	example: /todo send @awesomePerson Don't forget to be awesome
+
+send [user] --at [time] [message]
+	Sends some user a Todo later, at a time like "monday 9am" in their timezone. Quote times of more than one word.
+
+	example: /todo send @awesomePerson --at "monday 9am" Review the weekly numbers
+
+scheduled [list|cancel] [number]
+	Lists the Todos you scheduled to send later, or cancels the one at the given position.
+
+	example: /todo scheduled cancel 1
+
+send ~[channel] [message]
+	Sends a Todo to every member of a channel. Each of them gets their own Todo, shown in your sent list.
+
+	example: /todo send ~town-square Fill out the survey
+
+channel add [message]
+	Adds a Todo to the shared list of the current channel, that any member of the channel can claim.
+
+	example: /todo channel add Update the onboarding docs by friday
+
+channel list
+	Lists the Todo issues of the shared list of the current channel.
+
+channel claim [number]
+	Moves the Todo issue at the given position of the shared list of the current channel to your list.
+
+	example: /todo channel claim 1
+
+accept [number]
+	Accepts the Todo issue at the given position of your received list, moving it to your list.
+
+	example: /todo accept 1
+
+accept [number] --message [message] --due [date]
+	Accepts the Todo issue at the given position of your received list, proposing a new message or due date. The
+	sender is asked to approve the change.
+
+	example: /todo accept 1 --message "Review the first draft" --due friday
+
+approve [number]
+	Applies the change proposed by the receiver of the Todo issue at the given position of your sent list.
+
+reject [number]
+	Keeps the Todo issue at the given position of your sent list as it is, dropping the change its receiver proposed.
+
+decline [number] [reason]
+	Declines the Todo issue at the given position of your received list, letting the sender know why.
+
+	example: /todo decline 1 I am on vacation that week
+
+forward [listName] [number] [user] [note]
+	Hands off a Todo issue you received to someone else, with an optional note. The number is a position of your
+	received list, or of your own list for the Todos you accepted. The sender is told who has it now.
+
+	example: /todo forward 1 @teammate Can you take this one? I am out next week
+
+nudge [number]
+	Reminds the receiver of the Todo issue at the given position of your sent list, the first one by default, that
+	it is still open. Each Todo can be nudged once a day.
+
+	example: /todo nudge 2
+
+watch [number]
+	Watches the Todo issue at the given position of your sent list, so you get a message when its receiver accepts,
+	starts, edits or forwards it. Without a number, shows the Todos you watch. Every Todo you send is watched unless
+	you change it with /todo settings watch selected.
+
+	example: /todo watch 2
+
+unwatch [number]
+	Stops watching the Todo issue at the given position of your sent list.
+
+	example: /todo unwatch 2
+
+stats [week|month]
+	Shows how many Todos you added and completed over the last week or month, how long they took on average, and
+	how many are open now.
+
+	example: /todo stats month
+
+template save [--team] [name] [todos]
+	Saves a template with the given Todos, one per line or separated by ;. Items can end with a due date, which
+	is relative to when the template is applied. Team admins can share a template with the team with --team.
+
+	example: /todo template save onboarding Set up your laptop by tomorrow; Meet the team; Read the handbook
+
+template apply [name] [user]
+	Adds the Todos of a template to your list, or sends them to the given user. Your templates take precedence
+	over the team templates with the same name.
+
+	example: /todo template apply onboarding @newhire
+
+template list
+	Lists your templates and the templates of the current team.
+
+template rm [--team] [name]
+	Removes a template.
+
+undo
+	Reverses your last pop, done, rm or send. The last 10 actions can be undone, one at a time.
+
+digest [on|off] [time]
+	Shows or changes your daily digest, a direct message with your open and received Todo issues.
+	The time is in the HH:MM format in your timezone, and defaults to 09:00.
+
+	example: /todo digest on 08:30
+
+settings [setting] [value]
+	Shows or changes your settings:
+	* digest [on|off] [time]: your daily digest, like the digest command
+	* notifications [on|off]: whether you get a message when someone sends you a Todo
+	* list [my|in|out|done]: the list shown by /todo list
+	* reminder [lead time|off]: reminds you of your Todos the given time before they are due, like 30m, 2h or 1d
+	* report [on|off]: a weekly report of the Todos you completed, sent on Mondays
+	* watch [all|selected]: whether you get a message for every change to the Todos you sent, or only the watched ones
+	* emails [on|off]: whether you get an email for the Todos you receive or that become overdue while you are away
+
+	example: /todo settings reminder 1h
+
+calendar [off]
+	Sends you the URL of a calendar feed with your Todo issues that have a due date, to subscribe to from
+	Google Calendar, Outlook or any calendar application. Every time you run it, you get a new URL and the
+	previous one stops working. Use off to disable the feed.
+
+jira link [issue key] [number]
+	Links the Todo issue at the given position of your list to a Jira issue. Completing the Todo resolves the
+	Jira issue, and resolving the Jira issue completes the Todo.
+
+	example: /todo jira link PROJ-123 2
+
+jira unlink [number]
+	Removes the link between the Todo issue at the given position of your list and its Jira issue.
+
+token create [name] [scopes]
+	Creates a token that other systems can use on your behalf, with the incoming webhook URL or the REST API.
+	The scopes are read, write and send, separated by commas, and default to write,send.
+
+	example: /todo token create monitoring
+	example: /todo token create dashboard read
+
+token list
+	Lists your tokens and their scopes.
+
+token revoke [id]
+	Revokes a token, so its webhook URL and its REST API requests stop working.
+
+export [csv|json]
+	Sends you a file with all your Todo issues and their details, in the CSV format by default.
+
+	example: /todo export json
+
+import [post link] [--dry-run]
+	Adds the Todo issues in the file attached to a post to your list. The file can be an export of this plugin,
+	a Todoist CSV export, a Wunderlist backup or a CSV file with a message column.
+	With --dry-run, it only shows what would be imported.
+
+	example: /todo import https://example.com/myteam/pl/kq8ruc1nbtgz7xyjpucwhge4gh --dry-run
+
+purge
+	System admins only. Deletes the old completed Todos and the Todos of the deactivated users now, as set in the
+	plugin settings, instead of waiting for the daily purge.
+
+audit [user] [since]
+	System admins only. Shows what a user did with their Todos, and what others did with the Todos they share, since
+	a date like 2020-03-15 or a number of days like 30d. The last 7 days by default.
+
+	example: /todo audit @awesomePerson 30d
+
+migrate
+	System admins only. Copies every Todo from the plugin key value store to the SQL tables of the database, before
+	switching the Storage Engine setting. It can run again to copy the changes made since.
+
+help
+	Display usage.
+`)
+	msgCommandError        = newMessage("command.error", "__Error: {{.Error}}__\n\nRun `/todo help` for usage instructions.")
+	msgCommandUnknownError = newMessage("command.unknown_error", "An unknown error occurred. Please talk to your system administrator for help.")
+
+	msgNotifyReceived         = newMessage("notify.received", "You have received a new Todo from @{{.User}}")
+	msgNotifyReceivedDue      = newMessage("notify.received_due", "You have received a new Todo from @{{.User}}, due {{.Due}}")
+	msgNotifyAccepted         = newMessage("notify.accepted", "@{{.User}} accepted a Todo you sent: {{.Todo}}")
+	msgNotifyDeclined         = newMessage("notify.declined", "@{{.User}} declined a Todo you sent: {{.Todo}}")
+	msgNotifyDeclineReason    = newMessage("notify.decline_reason", "Reason: {{.Reason}}")
+	msgNotifyCompleted        = newMessage("notify.completed", "@{{.User}} completed a Todo you sent: {{.Todo}}")
+	msgNotifyRemoved          = newMessage("notify.removed", "@{{.User}} removed a Todo you received: {{.Todo}}")
+	msgNotifyPopped           = newMessage("notify.popped", "@{{.User}} popped a Todo you sent: {{.Todo}}")
+	msgNotifyReopened         = newMessage("notify.reopened", "@{{.User}} reopened a Todo they had completed: {{.Todo}}")
+	msgNotifyRestored         = newMessage("notify.restored", "@{{.User}} restored a Todo they had removed: {{.Todo}}")
+	msgNotifyTookBack         = newMessage("notify.took_back", "@{{.User}} took back a Todo they sent you: {{.Todo}}")
+	msgNotifyEditedSent       = newMessage("notify.edited_sent", "@{{.User}} changed a Todo you sent from \"{{.OldTodo}}\" to: {{.Todo}}")
+	msgNotifyEditedReceived   = newMessage("notify.edited_received", "@{{.User}} changed a Todo you received from \"{{.OldTodo}}\" to: {{.Todo}}")
+	msgNotifyBumped           = newMessage("notify.bumped", "@{{.User}} bumped a Todo you received.")
+	msgNotifyNote             = newMessage("notify.note", "@{{.User}} added a note to a Todo they sent you: {{.Todo}}\n> {{.Note}}")
+	msgNotifyBulkCompleted    = newMessage("notify.bulk_completed", "@{{.User}} completed {{.Count}} of your Todos:\n{{.Todos}}")
+	msgNotifyBulkRemoved      = newMessage("notify.bulk_removed", "@{{.User}} removed {{.Count}} of your Todos:\n{{.Todos}}")
+	msgNotifyBulkSent         = newMessage("notify.bulk_sent", "* {{.Todo}} (a Todo you sent)")
+	msgNotifyBulkReceived     = newMessage("notify.bulk_received", "* {{.Todo}} (a Todo you received)")
+	msgNotifyBulkDeclined     = newMessage("notify.bulk_declined", "* {{.Todo}} (a Todo you sent, declining it)")
+	msgNotifyJiraAssigned     = newMessage("notify.jira_assigned", "Jira issue {{.Issue}} was assigned to you, so it was added to your Todo list.")
+	msgNotifyJiraResolved     = newMessage("notify.jira_resolved", "Jira issue {{.Issue}} was resolved, so its Todo was completed:\n{{.Todo}}")
+	msgNotifyGitHubClosed     = newMessage("notify.github_closed", "GitHub {{.Kind}} [{{.Issue}}]({{.URL}}) was {{.State}}, so its Todo was completed:\n{{.Todo}}")
+	msgNotifyChannelSent      = newMessage("notify.channel_sent", "Your Todo was sent to {{.Count}} members of ~{{.Channel}}: {{.Todo}}")
+	msgNotifyChannelFailed    = newMessage("notify.channel_failed", "It could not be sent to:\n{{.Failures}}")
+	msgNotifyForwardedSent    = newMessage("notify.forwarded_sent", "@{{.User}} forwarded a Todo you sent to @{{.Receiver}}: {{.Todo}}{{if .Note}}\n> {{.Note}}{{end}}")
+	msgNotifyForwarded        = newMessage("notify.forwarded", "@{{.User}} forwarded you a Todo from @{{.Sender}}{{if .Note}} (\"{{.Note}}\"){{end}}")
+	msgNotifyProposed         = newMessage("notify.proposed", "@{{.User}} accepted a Todo you sent, proposing a change: {{.Todo}}\n> {{.Change}}\n\nType `/todo approve {{.Number}}` to apply it, or `/todo reject {{.Number}}` to keep the Todo as it is.")
+	msgNotifyProposalApproved = newMessage("notify.proposal_approved", "@{{.User}} approved your change to a Todo: {{.Todo}}")
+	msgNotifyStarted          = newMessage("notify.started", "@{{.User}} started working on a Todo you sent: {{.Todo}}")
+	msgNotifyNudge            = newMessage("notify.nudge", "@{{.User}} kindly reminds you of a Todo they sent you {{.Age}} ago: {{.Todo}}")
+	msgNotifyProposalRejected = newMessage("notify.proposal_rejected", "@{{.User}} rejected your change to a Todo, so it stays as it was: {{.Todo}}")
+	msgNotifyShared           = newMessage("notify.shared", "@{{.User}} shared their Todo list with you. Type `/todo list @{{.User}}` to see it.")
+	msgNotifyStalled          = newMessage("notify.stalled", "@{{.User}} has not accepted a Todo you sent {{.Age}} ago yet: {{.Todo}}")
+	msgNotifyScheduledFailed  = newMessage("notify.scheduled_failed", "Your Todo scheduled for @{{.User}} could not be sent, because {{.Reason}}: {{.Todo}}")
+
+	msgReminderDaily   = newMessage("reminder.daily", "Daily Reminder:")
+	msgReminderDueSoon = newMessage("reminder.due_soon", "Due soon:")
+	msgReminderStalled = newMessage("reminder.stalled", "These Todos you received are waiting for you to accept or decline them for more than {{.Days}} days:")
+	msgDigestTitle     = newMessage("digest.title", "Daily Digest:")
+	msgDigestOverdue   = newMessage("digest.overdue", "#### Overdue Todos")
+	msgDigestMyList    = newMessage("digest.my_list", "#### Your Todo list")
+	msgDigestInList    = newMessage("digest.in_list", "#### Received Todos waiting for you to accept")
+	msgWeeklyReport    = newMessage("report.weekly", "Weekly Report:\n\n* Todos completed in the last 7 days: {{.Completed}}\n* Longest streak of days with a completed Todo: {{.Streak}}{{if .Oldest}}\n* Oldest open Todo, added {{.Age}} ago: {{.Oldest}}{{end}}")
+
+	msgReplyAttached  = newMessage("reply.attached", "@{{.User}} attached a todo to this thread")
+	msgReplySent      = newMessage("reply.sent", "@{{.User}} sent @{{.Receiver}} a todo attached to this thread")
+	msgReplyCompleted = newMessage("reply.completed", "@{{.User}} completed a todo attached to this thread")
+	msgReplyRemoved   = newMessage("reply.removed", "@{{.User}} removed a todo attached to this thread")
+	msgReplyPopped    = newMessage("reply.popped", "@{{.User}} popped a todo attached to this thread")
+	msgReplyClaimed   = newMessage("reply.claimed", "@{{.User}} claimed a Todo from the channel list: {{.Todo}}")
+
+	msgEmailReceivedSubject = newMessage("email.received_subject", "New Todo from @{{.User}}")
+	msgEmailOverdueSubject  = newMessage("email.overdue_subject", "Your Todos are overdue")
+	msgEmailOverdue         = newMessage("email.overdue", "These Todos are now overdue:")
+
+	msgPurgeNotAllowed = newMessage("purge.not_allowed", "only system admins can purge Todos")
+	msgPurgeNothing    = newMessage("purge.nothing", "there is nothing to purge, set the retention of completed Todos or turn on purging deactivated users in the plugin settings first")
+	msgPurgeStarted    = newMessage("purge.started", "Purging Todos. You will get a message once it is done.")
+	msgPurgeFailed     = newMessage("purge.failed", "The purge failed, see the server logs.")
+	msgPurgeSummary    = newMessage("purge.summary", "Purge done, {{.Users}} users checked:\n\n* Completed Todos deleted: {{.CompletedIssues}}\n* Deactivated users deleted: {{.DeactivatedUsers}}, with {{.DeactivatedIssues}} Todos{{if .Failures}}\n* Users that could not be purged: {{.Failures}}, see the server logs{{end}}")
+
+	msgButtonAccept    = newMessage("button.accept", "Accept")
+	msgButtonDecline   = newMessage("button.decline", "Decline")
+	msgButtonComplete  = newMessage("button.complete", "Done")
+	msgActionAccepted  = newMessage("action.accepted", "You accepted this Todo.")
+	msgActionDeclined  = newMessage("action.declined", "You declined this Todo.")
+	msgActionCompleted = newMessage("action.completed", "You completed this Todo.")
+	msgActionFailed    = newMessage("action.failed", "Unable to update this Todo: {{.Error}}")
+
+	msgErrIssueNotFound         = newMessage("error.issue_not_found", "cannot find element")
+	msgErrIssueAlreadyCompleted = newMessage("error.issue_already_completed", "the todo is already completed")
+	msgErrIssueNotReceived      = newMessage("error.issue_not_received", "only received Todos can be forwarded")
+	msgErrIssueNotWaiting       = newMessage("error.issue_not_waiting", "only Todos waiting to be accepted can be escalated")
+	msgErrIssueNotSent          = newMessage("error.issue_not_sent", "only the Todos you sent that were not declined can be watched")
+	msgErrInvalidForward        = newMessage("error.invalid_forward", "a Todo cannot be forwarded to its sender or to yourself")
+	msgErrNoProposal            = newMessage("error.no_proposal", "no change was proposed for this Todo")
+	msgErrIssueAlreadyStarted   = newMessage("error.issue_already_started", "the Todo is already in progress")
+	msgErrInvalidStart          = newMessage("error.invalid_start", "only Todos on your list can be started, accept received Todos first")
+	msgErrNothingToUndo         = newMessage("error.nothing_to_undo", "there is nothing to undo")
+	msgErrUndoConflict          = newMessage("error.undo_conflict", "the todo changed since, so the action cannot be undone")
+	msgErrNoTodoNumber          = newMessage("error.no_todo_number", "there is no todo number {{.Number}} in the list")
+	msgErrInvalidProposal       = newMessage("error.invalid_proposal", "only accepted Todos sent by someone else can have a change proposed")
+	msgErrCompletedNotEditable  = newMessage("error.completed_not_editable", "completed todos cannot be edited")
+
+	msgErrMessageEmpty         = newMessage("error.message_empty", "message cannot be empty")
+	msgErrMessageTooLong       = newMessage("error.message_too_long", "message cannot be longer than {{.Max}} characters")
+	msgErrSendDisabled         = newMessage("error.send_disabled", "sending Todos is disabled by your system admin")
+	msgErrChannelListsDisabled = newMessage("error.channel_lists_disabled", "channel Todo lists are disabled by your system admin")
+	msgErrTooManyTodos         = newMessage("error.too_many_todos", "you cannot have more than {{.Max}} Todos, complete or remove some first")
+	msgErrNotTeamMember        = newMessage("error.not_team_member", "you can only send Todos to members of your teams, and @{{.User}} is not")
+	msgErrReceiverFull         = newMessage("error.receiver_full", "@{{.User}} cannot receive more Todos, their list is full")
+	msgErrAddingTooFast        = newMessage("error.adding_too_fast", "you are adding Todos too fast, slow down and try again in {{.Wait}}")
+	msgErrSendingTooFast       = newMessage("error.sending_too_fast", "you are sending Todos too fast, slow down and try again in {{.Wait}}")
+	msgTimeSecond              = newMessage("time.second", "1 second")
+	msgTimeSeconds             = newMessage("time.seconds", "{{.Count}} seconds")
+	msgTimeMinutes             = newMessage("time.minutes", "{{.Count}} minutes")
+	msgTimeLessThanHour        = newMessage("time.less_than_hour", "less than an hour")
+	msgTimeHour                = newMessage("time.hour", "1 hour")
+	msgTimeHours               = newMessage("time.hours", "{{.Count}} hours")
+	msgTimeDay                 = newMessage("time.day", "1 day")
+	msgTimeDays                = newMessage("time.days", "{{.Count}} days")
+	msgErrFileFlag             = newMessage("error.file_flag", "--file must be followed by the link to a file")
+	msgErrNotFileLink          = newMessage("error.not_file_link", "{{.Reference}} is not a link to a file")
+	msgErrFileNotFound         = newMessage("error.file_not_found", "cannot find the file {{.Reference}}")
+	msgErrTooManyFiles         = newMessage("error.too_many_files", "a Todo cannot have more than {{.Max}} files")
+	msgErrTokenNotFound        = newMessage("error.token_not_found", "token not found")
+	msgErrTooManyTokens        = newMessage("error.too_many_tokens", "you cannot have more than {{.Max}} tokens, revoke one first")
+	msgErrTooManyScheduled     = newMessage("error.too_many_scheduled", "you cannot have more than {{.Max}} scheduled Todos, cancel one first")
+	msgErrScheduledNotFound    = newMessage("error.scheduled_not_found", "scheduled Todo not found")
+	msgErrDeliveryInPast       = newMessage("error.delivery_in_past", "the delivery time must be in the future")
+	msgErrListNotShared        = newMessage("error.list_not_shared", "you can only see the lists of the users that shared them with you")
+	msgErrSubtaskNotFound      = newMessage("error.subtask_not_found", "cannot find checklist item")
+	msgErrTooManySubtasks      = newMessage("error.too_many_subtasks", "a todo cannot have more than {{.Max}} checklist items")
+	msgErrInvalidSubtaskNumber = newMessage("error.invalid_subtask_number", "{{.Value}} is not a valid checklist item number, use the number of the Todo and of the item, like 2.1")
+	msgErrTooManyNotes         = newMessage("error.too_many_notes", "a todo cannot have more than {{.Max}} notes")
+	msgErrTemplateNotFound     = newMessage("error.template_not_found", "template not found")
+	msgErrTooManyTemplates     = newMessage("error.too_many_templates", "you cannot have more than {{.Max}} templates, remove one first")
+	msgErrTemplateNameMissing  = newMessage("error.template_name_missing", "you must specify the name of the template")
+	msgErrNameTooLong          = newMessage("error.name_too_long", "the name cannot be longer than {{.Max}} characters")
+	msgErrTemplateItemsMissing = newMessage("error.template_items_missing", "you must specify the Todos of the template, one per line or separated by ;")
+	msgErrTooManyTemplateItems = newMessage("error.too_many_template_items", "a template cannot have more than {{.Max}} Todos")
+	msgErrInvalidRange         = newMessage("error.invalid_range", "{{.Value}} is not a valid Todo number or range")
+	msgErrTooManyBulk          = newMessage("error.too_many_bulk", "you cannot change more than {{.Max}} Todos at once")
+	msgErrDueDateEmpty         = newMessage("error.due_date_empty", "the due date cannot be empty")
+	msgErrInvalidDueDate       = newMessage("error.invalid_due_date", "{{.Value}} is not a date I understand")
+	msgErrInvalidDigestTime    = newMessage("error.invalid_digest_time", "{{.Value}} is not a valid time, use the HH:MM format")
+	msgErrInvalidPeriod        = newMessage("error.invalid_period", "{{.Value}} is not a valid period, use {{.Week}} or {{.Month}}")
+	msgErrInvalidFormat        = newMessage("error.invalid_format", "{{.Value}} is not a valid format, use csv or json")
+	msgErrInvalidLeadTime      = newMessage("error.invalid_lead_time", "{{.Value}} is not a valid time, use minutes, hours or days like 30m, 2h or 1d")
+	msgErrLeadTimeRange        = newMessage("error.lead_time_range", "the reminder must be between 1 minute and {{.MaxDays}} days before the due date")
+
+	msgListNothingToDo       = newMessage("list.nothing_to_do", "Nothing to do!")
+	msgListNothingInProgress = newMessage("list.nothing_in_progress", "Nothing in progress!")
+	msgListPage              = newMessage("list.page", "Page {{.Page}} of {{.Pages}}, {{.Total}} Todos in total.")
+	msgBucketOverdue         = newMessage("bucket.overdue", "Overdue")
+	msgBucketToday           = newMessage("bucket.today", "Due today")
+	msgBucketUpcoming        = newMessage("bucket.upcoming", "Upcoming")
+	msgBucketNone            = newMessage("bucket.none", "No due date")
+	msgIssueInProgress       = newMessage("issue.in_progress", "**[In progress]**")
+	msgIssueDue              = newMessage("issue.due", "Due {{.Date}}")
+	msgIssuePriorityHigh     = newMessage("issue.priority_high", "High priority")
+	msgIssuePriorityLow      = newMessage("issue.priority_low", "Low priority")
+	msgIssueGitHub           = newMessage("issue.github", "GitHub {{.Kind}} {{.State}}")
+	msgIssueGoToThread       = newMessage("issue.go_to_thread", "[Go to thread]({{.Link}})")
+	msgIssueAttachment       = newMessage("issue.attachment", "Attachment: {{.Link}}")
+	msgIssueAddedBy          = newMessage("issue.added_by", "Added by @{{.User}}")
+	msgIssueCompletedOn      = newMessage("issue.completed_on", "Completed on {{.Date}}")
+	msgIssueDeclinedBy       = newMessage("issue.declined_by", "Declined by @{{.User}}")
+	msgIssueChangeProposed   = newMessage("issue.change_proposed", "Change proposed: {{.Change}}")
+	msgIssueNoteBy           = newMessage("issue.note_by", "Note by @{{.User}} on {{.Date}}: {{.Note}}")
+	msgIssueNote             = newMessage("issue.note", "Note on {{.Date}}: {{.Note}}")
+	msgProposalDue           = newMessage("proposal.due", "due {{.Date}}")
+
+	msgListTitleMy               = newMessage("list.title_my", "Todo List:")
+	msgListTitleIn               = newMessage("list.title_in", "Received Todo list:")
+	msgListTitleOut              = newMessage("list.title_out", "Sent Todo list:")
+	msgListTitleDone             = newMessage("list.title_done", "Completed Todo list:")
+	msgListTitleChannel          = newMessage("list.title_channel", "Channel Todo list:")
+	msgUsageSend                 = newMessage("usage.send", "You must specify a user and a message.")
+	msgUsageInvalidUser          = newMessage("usage.invalid_user", "Please, provide a valid user.")
+	msgUsageInvalidChannel       = newMessage("usage.invalid_channel", "Please, provide a valid channel.")
+	msgUsageAdd                  = newMessage("usage.add", "Please add a task.")
+	msgUsageSearch               = newMessage("usage.search", "Please provide something to search for.")
+	msgUsageDone                 = newMessage("usage.done", "You must specify the number of the Todo to complete.")
+	msgUsageRestore              = newMessage("usage.restore", "You must specify the number of the Todo to restore.")
+	msgUsageMove                 = newMessage("usage.move", "You must specify the current and the new position of the Todo.")
+	msgUsageEdit                 = newMessage("usage.edit", "Please provide the new message of the Todo.")
+	msgUsageChannel              = newMessage("usage.channel", "You must specify add, list or claim.")
+	msgUsageClaim                = newMessage("usage.claim", "You must specify the number of the Todo to claim.")
+	msgUsageAccept               = newMessage("usage.accept", "You must specify the number of the received Todo to accept.")
+	msgUsageDecline              = newMessage("usage.decline", "You must specify the number of the received Todo to decline.")
+	msgUsageAttach               = newMessage("usage.attach", "Run this command in the thread to attach the Todo to, or give the link to a post.")
+	msgUsageResolveProposal      = newMessage("usage.resolve_proposal", "You must specify the number of the sent Todo.")
+	msgUsageForward              = newMessage("usage.forward", "You must specify the number of the received Todo and the user to forward it to.")
+	msgUsageJira                 = newMessage("usage.jira", "You must specify link or unlink.")
+	msgUsageJiraLink             = newMessage("usage.jira_link", "You must specify the Jira issue key and the number of the Todo.")
+	msgUsageTodoNumber           = newMessage("usage.todo_number", "You must specify the number of the Todo.")
+	msgUsageToken                = newMessage("usage.token", "You must specify create, list or revoke.")
+	msgUsageTokenRevoke          = newMessage("usage.token_revoke", "You must specify the id of the token to revoke.")
+	msgResponseScheduled         = newMessage("response.scheduled", "Todo scheduled for @{{.User}} on {{.Date}}.")
+	msgResponseSent              = newMessage("response.sent", "Todo sent to @{{.User}}.")
+	msgResponseSendingChannel    = newMessage("response.sending_channel", "Sending Todo to {{.Count}} members of ~{{.Channel}}. You will get a message once they all received it.")
+	msgResponseAdded             = newMessage("response.added", "Added Todo.")
+	msgResponsePopped            = newMessage("response.popped", "Removed top Todo.")
+	msgResponseCompleted         = newMessage("response.completed", "Completed Todo {{.Number}}.")
+	msgResponseRestored          = newMessage("response.restored", "Restored Todo {{.Number}}.")
+	msgResponseRemoved           = newMessage("response.removed", "Removed Todo {{.Number}}.")
+	msgResponseMoved             = newMessage("response.moved", "Moved Todo {{.From}} to position {{.To}}.")
+	msgResponseEdited            = newMessage("response.edited", "Edited Todo {{.Number}}.")
+	msgResponseChannelAdded      = newMessage("response.channel_added", "Added Todo to the channel list.")
+	msgResponseClaimed           = newMessage("response.claimed", "Claimed Todo {{.Number}}, it is now on your list.")
+	msgResponseAccepted          = newMessage("response.accepted", "Accepted Todo {{.Number}}.")
+	msgResponseProposalFailed    = newMessage("response.proposal_failed", "Your change could not be proposed, the Todo was accepted as it is.")
+	msgResponseProposalSent      = newMessage("response.proposal_sent", "The sender will be asked to approve your change.")
+	msgResponseAttached          = newMessage("response.attached", "Attached Todo {{.Number}} to the thread.")
+	msgResponseStarted           = newMessage("response.started", "Todo {{.Number}} is in progress.")
+	msgResponseProposalRejected  = newMessage("response.proposal_rejected", "Rejected the change to Todo {{.Number}}.")
+	msgResponseProposalApproved  = newMessage("response.proposal_approved", "Approved the change to Todo {{.Number}}.")
+	msgResponseForwarded         = newMessage("response.forwarded", "Forwarded Todo {{.Number}} to @{{.User}}.")
+	msgResponseNudged            = newMessage("response.nudged", "Nudged @{{.User}} about Todo {{.Number}}.")
+	msgResponseDeclined          = newMessage("response.declined", "Declined Todo {{.Number}}.")
+	msgResponseUndoComplete      = newMessage("response.undo_complete", "Reopened the Todo you completed: {{.Todo}}")
+	msgResponseUndoRemove        = newMessage("response.undo_remove", "Restored the Todo you removed: {{.Todo}}")
+	msgResponseUndoSend          = newMessage("response.undo_send", "Took back the Todo you sent: {{.Todo}}")
+	msgResponseUndoBulk          = newMessage("response.undo_bulk", "Restored the {{.Count}} Todos you changed at once.")
+	msgResponseCalendarDisabled  = newMessage("response.calendar_disabled", "Your calendar feed is disabled.")
+	msgResponseCalendar          = newMessage("response.calendar", "Subscribe to this URL from your calendar application to see your Todo issues with a due date:\n\n{{.URL}}\n\nKeep the URL secret, anyone with it can see your Todo issues. Any previous URL no longer works.")
+	msgResponseJiraLinked        = newMessage("response.jira_linked", "Linked Todo {{.Number}} to {{.Link}}. It will be completed when {{.Key}} is resolved.")
+	msgResponseJiraLinkedResolve = newMessage("response.jira_linked_resolve", "Linked Todo {{.Number}} to {{.Link}}. It will be completed when {{.Key}} is resolved, and completing it will resolve {{.Key}}.")
+	msgResponseJiraUnlinked      = newMessage("response.jira_unlinked", "Unlinked Todo {{.Number}} from {{.Key}}.")
+	msgResponseTokenCreated      = newMessage("response.token_created", "Created token `{{.ID}}` with the scopes {{.Scopes}}.")
+	msgResponseTokenHook         = newMessage("response.token_hook", "Post Todos with:\n\n```\ncurl -d '{\"message\": \"Check the backups\", \"due\": \"tomorrow\"}' {{.URL}}\n```\n\nAdd `\"send_to\": \"username\"` to send the Todo to someone else.")
+	msgResponseTokenAPI          = newMessage("response.token_api", "Use it with the REST API in the `{{.Header}}` header:\n\n```\ncurl -H '{{.Header}}: {{.Secret}}' {{.URL}}\n```\n\nKeep the token secret, it will not be shown again.")
+	msgResponseTokenRevoked      = newMessage("response.token_revoked", "Revoked token `{{.ID}}`.")
+	msgExportMessage             = newMessage("export.message", "Here is the export of your {{.Count}} Todo issues.")
+	msgResponseExportSent        = newMessage("response.export_sent", "The export was sent to you by the Todo bot in a direct message.")
+	msgErrScheduleSelf           = newMessage("error.schedule_self", "you can only schedule Todos sent to others")
+	msgErrScheduleChannel        = newMessage("error.schedule_channel", "you cannot schedule Todos sent to a channel")
+	msgErrChannelNoMembers       = newMessage("error.channel_no_members", "~{{.Channel}} has no other members to send the Todo to")
+	msgErrGitHubFetch            = newMessage("error.github_fetch", "unable to get the GitHub {{.Kind}}: {{.Error}}")
+	msgErrInvalidPage            = newMessage("error.invalid_page", "{{.Value}} is not a valid page")
+	msgErrNoPage                 = newMessage("error.no_page", "there is no page {{.Page}} in the list")
+	msgErrInvalidList            = newMessage("error.invalid_list", "{{.Value}} is not a valid list")
+	msgErrUnexpectedAfterNumbers = newMessage("error.unexpected_after_numbers", "unexpected arguments after the Todo numbers: {{.Args}}")
+	msgErrUnexpectedAfterNumber  = newMessage("error.unexpected_after_number", "unexpected arguments after the Todo number: {{.Args}}")
+	msgErrUnexpectedProposalArgs = newMessage("error.unexpected_proposal_args", "unexpected arguments after the Todo number: {{.Args}}, use --message or --due to propose a change")
+	msgErrInvalidTodoNumber      = newMessage("error.invalid_todo_number", "{{.Value}} is not a valid Todo number")
+	msgErrTodoNumberMissing      = newMessage("error.todo_number_missing", "you must specify the number of the Todo")
+	msgErrNotChannelMember       = newMessage("error.not_channel_member", "you must be a member of the channel to use its Todo list")
+	msgErrInvalidChannelOption   = newMessage("error.invalid_channel_option", "{{.Value}} is not a valid option, use add, list or claim")
+	msgErrClaimedFirst           = newMessage("error.claimed_first", "someone else claimed the Todo first")
+	msgErrPostNotFound           = newMessage("error.post_not_found", "cannot find the post {{.Post}}")
+	msgErrForwardInactive        = newMessage("error.forward_inactive", "todos can only be forwarded to active users")
+	msgErrNudgeNotSent           = newMessage("error.nudge_not_sent", "only sent Todos can be nudged")
+	msgErrNudgeDeclined          = newMessage("error.nudge_declined", "@{{.User}} declined this Todo")
+	msgErrAlreadyNudged          = newMessage("error.already_nudged", "@{{.User}} was already nudged about this Todo in the last {{.Hours}} hours")
+	msgErrInvalidDigestOption    = newMessage("error.invalid_digest_option", "{{.Value}} is not a valid option, use on or off")
+	msgErrInvalidCalendarOption  = newMessage("error.invalid_calendar_option", "{{.Value}} is not a valid option, use off to disable the calendar feed")
+	msgErrJiraNotConfigured      = newMessage("error.jira_not_configured", "the Jira integration is not configured, please talk to your system administrator")
+	msgErrInvalidJiraKey         = newMessage("error.invalid_jira_key", "{{.Value}} is not a valid Jira issue key")
+	msgErrNotJiraLinked          = newMessage("error.not_jira_linked", "todo {{.Number}} is not linked to a Jira issue")
+	msgErrInvalidJiraOption      = newMessage("error.invalid_jira_option", "{{.Value}} is not a valid option, use link or unlink")
+	msgErrHookTokenNotFound      = newMessage("error.hook_token_not_found", "you have no token with id {{.ID}}, use /todo token list to see them")
+	msgErrInvalidTokenOption     = newMessage("error.invalid_token_option", "{{.Value}} is not a valid option, use create, list or revoke")
+	msgErrInvalidOption          = newMessage("error.invalid_option", "{{.Value}} is not a valid option")
+	msgErrImportPostMissing      = newMessage("error.import_post_missing", "you must specify the link to a post with the file to import")
+	msgErrPostWithoutFile        = newMessage("error.post_without_file", "the post does not have any file attached")
+	msgErrFileTooLarge           = newMessage("error.file_too_large", "the file cannot be larger than {{.Max}} MB")
+
+	msgDueConfirmation             = newMessage("response.due", "Due {{.Date}}.")
+	msgSearchNoResults             = newMessage("search.no_results", "No Todos match \"{{.Query}}\".")
+	msgSearchTitle                 = newMessage("search.title", "Todos matching \"{{.Query}}\":")
+	msgSearchPosition              = newMessage("search.position", "Number {{.Number}} of your {{.List}} list ({{.Date}})")
+	msgSearchSentTo                = newMessage("search.sent_to", "Sent to @{{.User}}")
+	msgSearchReceivedFrom          = newMessage("search.received_from", "Received from @{{.User}}")
+	msgDigestOff                   = newMessage("digest.off", "The daily digest is off.")
+	msgDigestOn                    = newMessage("digest.on", "The daily digest is on and sent at {{.Time}} in your timezone.")
+	msgTokensNone                  = newMessage("tokens.none", "You have no tokens. Create one with `/todo token create [name] [scopes]`.")
+	msgTokensTitle                 = newMessage("tokens.title", "Tokens:")
+	msgTokensUnnamed               = newMessage("tokens.unnamed", "Unnamed")
+	msgTokensToken                 = newMessage("tokens.token", "**{{.Name}}** `{{.ID}}`, created on {{.Date}}, scopes: {{.Scopes}}")
+	msgStatsSummary                = newMessage("stats.summary", "Your Todo statistics for the last {{.Period}}:\n\n* Added: {{.Added}}\n* Completed: {{.Completed}}{{if .Average}}\n* Average time to complete: {{.Average}}{{end}}\n* Open now: {{.Open}} in your list, {{.Received}} received, {{.Sent}} sent")
+	msgImportSummary               = newMessage("import.summary", "{{if .DryRun}}Dry run: {{.Created}} Todos would be imported{{else}}Imported {{.Created}} Todos{{end}} and {{.Skipped}} skipped.")
+	msgImportSkipped               = newMessage("import.skipped", "#### Skipped")
+	msgImportSkip                  = newMessage("import.skip", "Item {{.Item}}, {{.Reason}}: {{.Message}}")
+	msgImportMoreSkips             = newMessage("import.more_skips", "And {{.Count}} more.")
+	msgMigrateSummary              = newMessage("migrate.summary", "Migration done, copied to the SQL tables:\n\n* Lists: {{.Lists}}\n* Todos: {{.Issues}}\n* Undo histories: {{.Journals}}{{if .MissingIssues}}\n* Todos missing from the lists they are on: {{.MissingIssues}}{{end}}{{if .Failures}}\n* Records that could not be copied: {{.Failures}}, see the server logs{{end}}\n\nSet the Storage Engine to SQL tables in the plugin settings, then disable and enable the plugin to use them.")
+	msgMigrateFailed               = newMessage("migrate.failed", "The migration failed, see the server logs.")
+	msgMigrateStarted              = newMessage("migrate.started", "Copying the Todos to the SQL tables. You will get a message once it is done.")
+	msgMigrateNotAllowed           = newMessage("migrate.not_allowed", "only system admins can migrate Todos")
+	msgMigrateAlreadyDone          = newMessage("migrate.already_done", "the Todos are already stored in the SQL tables")
+	msgResponseBulkCompleted       = newMessage("response.bulk_completed", "Completed Todos {{.Numbers}}.")
+	msgResponseBulkRemoved         = newMessage("response.bulk_removed", "Removed Todos {{.Numbers}}.")
+	msgResponseBulkPartial         = newMessage("response.bulk_partial", "The other Todos could not be changed. Type `/todo undo` to reverse the ones above.")
+	msgNotifyChannelFailure        = newMessage("notify.channel_failure", "@{{.User}}: {{.Error}}")
+	msgNotifyChannelUnknownFailure = newMessage("notify.channel_unknown_failure", "@{{.User}}: an unknown error occurred")
+
+	msgImportTooMany              = newMessage("import.too_many", "only {{.Max}} Todos can be imported at once")
+	msgImportListFull             = newMessage("import.list_full", "your Todo list is full")
+	msgImportNotSaved             = newMessage("import.not_saved", "could not be saved")
+	msgImportSent                 = newMessage("import.sent", "it was sent to someone else")
+	msgImportDone                 = newMessage("import.done", "it is already done")
+	msgErrImportEmpty             = newMessage("error.import_empty", "the file is empty")
+	msgErrImportInvalidExport     = newMessage("error.import_invalid_export", "the file is not a valid Todo export: {{.Error}}")
+	msgErrImportInvalidWunderlist = newMessage("error.import_invalid_wunderlist", "the file is not a valid Wunderlist backup: {{.Error}}")
+	msgErrImportInvalidCSV        = newMessage("error.import_invalid_csv", "the file is not a valid CSV file: {{.Error}}")
+	msgErrImportNoMessageColumn   = newMessage("error.import_no_message_column", "the CSV file must have a message, content, title or task column")
+
+	msgDialogTitle          = newMessage("dialog.title", "Add Todo")
+	msgDialogSubmit         = newMessage("dialog.submit", "Add")
+	msgDialogMessage        = newMessage("dialog.message", "Message")
+	msgDialogDue            = newMessage("dialog.due", "Due date")
+	msgDialogDueHelp        = newMessage("dialog.due_help", "Like friday, next week or 2020-03-15 14:00, in your timezone.")
+	msgDialogPriority       = newMessage("dialog.priority", "Priority")
+	msgDialogPriorityHigh   = newMessage("dialog.priority_high", "High")
+	msgDialogPriorityNormal = newMessage("dialog.priority_normal", "Normal")
+	msgDialogPriorityLow    = newMessage("dialog.priority_low", "Low")
+	msgDialogAssignee       = newMessage("dialog.assignee", "Assignee")
+	msgDialogAssigneeHelp   = newMessage("dialog.assignee_help", "Sends the Todo to someone else. Leave empty to add it to your list.")
+	msgDialogNoPostAccess   = newMessage("dialog.no_post_access", "You do not have access to the post.")
+	msgDialogAddFailed      = newMessage("dialog.add_failed", "Unable to add the Todo.")
+	msgDialogSendFailed     = newMessage("dialog.send_failed", "Unable to send the Todo.")
+	msgErrInvalidPriority   = newMessage("error.invalid_priority", "{{.Value}} is not a valid priority")
+	msgErrNoSendPermission  = newMessage("error.no_send_permission", "you do not have permission to send Todos")
+	msgErrInactiveReceiver  = newMessage("error.inactive_receiver", "Todos can only be sent to active users")
+
+	msgUsageNote                 = newMessage("usage.note", "Please provide the text of the note.")
+	msgResponseNoteAdded         = newMessage("response.note_added", "Added a note to Todo {{.Number}}.")
+	msgUsageSubtask              = newMessage("usage.subtask", "You must specify add, check, uncheck or rm and the item.")
+	msgResponseSubtaskAdded      = newMessage("response.subtask_added", "Added item {{.Number}}.")
+	msgResponseSubtaskChecked    = newMessage("response.subtask_checked", "Checked item {{.Number}}.")
+	msgResponseSubtaskUnchecked  = newMessage("response.subtask_unchecked", "Unchecked item {{.Number}}.")
+	msgResponseSubtaskRemoved    = newMessage("response.subtask_removed", "Removed item {{.Number}}.")
+	msgErrNoSubtask              = newMessage("error.no_subtask", "todo {{.Number}} has no checklist item {{.Item}}")
+	msgErrInvalidSubtaskOption   = newMessage("error.invalid_subtask_option", "{{.Value}} is not a valid option, use add, check, uncheck or rm")
+	msgUsageTemplate             = newMessage("usage.template", "You must specify save, apply, list or rm.")
+	msgUsageTemplateApply        = newMessage("usage.template_apply", "You must specify the template, and optionally the user to send it to.")
+	msgUsageTemplateRemove       = newMessage("usage.template_remove", "You must specify the name of the template to remove.")
+	msgErrInvalidTemplateOption  = newMessage("error.invalid_template_option", "{{.Value}} is not a valid option, use save, apply, list or rm")
+	msgErrTeamTemplateSave       = newMessage("error.team_template_save", "only team admins can save team templates")
+	msgErrTeamTemplateRemove     = newMessage("error.team_template_remove", "only team admins can remove team templates")
+	msgErrNoTemplateNamed        = newMessage("error.no_template_named", "there is no template named {{.Name}}, use /todo template list to see them")
+	msgResponseTemplateSaved     = newMessage("response.template_saved", "Saved template **{{.Name}}** with {{.Count}} Todos. Use it with `/todo template apply {{.Name}} [@user]`.")
+	msgResponseTemplateSent      = newMessage("response.template_sent", "Sent {{.Count}} Todos from template **{{.Name}}** to @{{.User}}.")
+	msgResponseTemplateApplied   = newMessage("response.template_applied", "Added {{.Count}} Todos from template **{{.Name}}**.")
+	msgResponseTemplateRemoved   = newMessage("response.template_removed", "Removed template **{{.Name}}**.")
+	msgTemplatesNone             = newMessage("templates.none", "There are no templates. Save one with `/todo template save [name] [todos]`.")
+	msgTemplatesMine             = newMessage("templates.mine", "Your templates:")
+	msgTemplatesTeam             = newMessage("templates.team", "Team templates:")
+	msgErrInvalidUser            = newMessage("error.invalid_user", "{{.Value}} is not a valid user")
+	msgErrNotSharedList          = newMessage("error.not_shared_list", "{{.Value}} is not a list you can see, use my or in")
+	msgErrShareSelf              = newMessage("error.share_self", "you can always see your own Todo list")
+	msgErrTooManyViewers         = newMessage("error.too_many_viewers", "you cannot share your Todo list with more than {{.Max}} users, unshare it from someone first")
+	msgErrUnshareUserMissing     = newMessage("error.unshare_user_missing", "you must specify the user to stop sharing your Todo list with")
+	msgListTitleUserMy           = newMessage("list.title_user_my", "Todo list of @{{.User}}:")
+	msgListTitleUserIn           = newMessage("list.title_user_in", "Received Todo list of @{{.User}}:")
+	msgResponseNotShared         = newMessage("response.not_shared", "You did not share your Todo list with anyone.")
+	msgResponseSharedWith        = newMessage("response.shared_with", "You shared your Todo list with {{.Users}}.")
+	msgResponseAlreadyShared     = newMessage("response.already_shared", "You already shared your Todo list with @{{.User}}.")
+	msgResponseShared            = newMessage("response.shared", "@{{.User}} can now see your Todo list and received Todos.")
+	msgResponseUnshared          = newMessage("response.unshared", "@{{.User}} can no longer see your Todo list.")
+	msgScheduledNone             = newMessage("scheduled.none", "You have no scheduled Todos. Schedule one with `/todo send @user --at \"monday 9am\" [message]`.")
+	msgScheduledTitle            = newMessage("scheduled.title", "Scheduled Todos:")
+	msgScheduledTo               = newMessage("scheduled.to", "To @{{.User}} on {{.Date}}")
+	msgErrInvalidScheduledOption = newMessage("error.invalid_scheduled_option", "{{.Value}} is not a valid option, use list or cancel")
+	msgErrScheduledNumberMissing = newMessage("error.scheduled_number_missing", "you must specify the number of the scheduled Todo to cancel")
+	msgErrInvalidScheduledNumber = newMessage("error.invalid_scheduled_number", "{{.Value}} is not the number of one of your {{.Count}} scheduled Todos")
+	msgErrAlreadySent            = newMessage("error.already_sent", "the Todo was already sent")
+	msgResponseScheduledCanceled = newMessage("response.scheduled_canceled", "Canceled the Todo scheduled for @{{.User}}: {{.Todo}}")
+	msgUsageSettings             = newMessage("usage.settings", "You must specify the setting and its value.")
+	msgErrInvalidOnOff           = newMessage("error.invalid_on_off", "{{.Value}} is not a valid option, use on or off")
+	msgErrInvalidDefaultList     = newMessage("error.invalid_default_list", "{{.Value}} is not a valid list, use my, in, out or done")
+	msgErrInvalidWatchOption     = newMessage("error.invalid_watch_option", "{{.Value}} is not a valid option, use {{.All}} or {{.Selected}}")
+	msgErrInvalidSetting         = newMessage("error.invalid_setting", "{{.Value}} is not a valid setting, use digest, notifications, list, reminder, report, watch or emails")
+	msgSettingsSummary           = newMessage("settings.summary", "Your settings:\n\n* **digest**: {{.Digest}}\n* **notifications**: messages when you receive a Todo are {{if .Notifications}}on{{else}}off{{end}}\n* **list**: `/todo list` shows the {{.List}} list\n* **reminder**: {{if .Reminder}}{{.Reminder}} before the due date{{else}}off{{end}}\n* **report**: the weekly report is {{if .Report}}on{{else}}off{{end}}\n* **watch**: messages when the receiver accepts, starts, edits or forwards {{if .WatchSelected}}only the Todos you sent and watch with `/todo watch`{{else}}every Todo you sent{{end}}\n* **emails**: emails when you are away are {{if .Emails}}on{{else}}off{{end}}")
+	msgWatchNone                 = newMessage("watch.none", "You are not watching any Todo you sent. Type `/todo watch [number]` to watch one.")
+	msgWatchTitle                = newMessage("watch.title", "You are watching these Todos you sent:")
+	msgWatchSentTo               = newMessage("watch.sent_to", "sent to @{{.User}}")
+	msgResponseWatchingAll       = newMessage("response.watching_all", "You are watching every Todo you sent. Type `/todo settings watch selected` to only watch the ones you choose.")
+	msgUsageUnwatch              = newMessage("usage.unwatch", "You must specify the number of the sent Todo to stop watching.")
+	msgResponseUnwatched         = newMessage("response.unwatched", "Stopped watching Todo {{.Number}}.")
+	msgResponseWatched           = newMessage("response.watched", "Watching Todo {{.Number}}. You will get a message when @{{.User}} accepts, starts, edits or forwards it.")
+	msgResponseWatchingUntil     = newMessage("response.watching_until", "You are watching every Todo you sent until you type `/todo settings watch selected`.")
+	msgErrInvalidAuditStart      = newMessage("error.invalid_audit_start", "{{.Value}} is not a valid start, use a date like 2020-03-15 or a number of days like 30d")
+	msgErrAuditNotAllowed        = newMessage("error.audit_not_allowed", "only system admins can see the audit log")
+	msgErrAuditUserMissing       = newMessage("error.audit_user_missing", "you must specify a user, and optionally a start like 2020-03-15 or 30d")
+	msgAuditNone                 = newMessage("audit.none", "No Todo actions of @{{.User}} since {{.Since}}.")
+	msgAuditTitle                = newMessage("audit.title", "Todo actions of @{{.User}} since {{.Since}}:")
+	msgAuditTitleLast            = newMessage("audit.title_last", "Last {{.Count}} of the {{.Total}} Todo actions of @{{.User}} since {{.Since}}. Use the audit endpoint of the REST API to get all of them.")
+	msgAuditWith                 = newMessage("audit.with", "with @{{.User}}")
+
+	msgErrGitHubNotFound   = newMessage("error.github_not_found", "{{.Reference}} does not exist or is not visible to the plugin")
+	msgErrReceiverInactive = newMessage("error.receiver_inactive", "the receiver is no longer active")
+	msgErrTodoNotStored    = newMessage("error.todo_not_stored", "the Todo could not be stored")
+
+	msgCommandDescription                = newMessage("command.description", "Interact with your Todo list.")
+	msgCommandAutocompleteDesc           = newMessage("command.autocomplete_desc", "Available commands: add, list, search, pop, done, restore, move, edit, rm, note, sub, send, channel, accept, decline, approve, reject, forward, template, undo, digest, settings, calendar, jira, token, export, import")
+	msgAutocompleteTodo                  = newMessage("autocomplete.todo", "Interact with your Todo list")
+	msgAutocompleteAdd                   = newMessage("autocomplete.add", "Adds a Todo")
+	msgAutocompleteList                  = newMessage("autocomplete.list", "Lists your Todo issues")
+	msgAutocompleteShare                 = newMessage("autocomplete.share", "Lets a user see your Todo list")
+	msgAutocompleteUnshare               = newMessage("autocomplete.unshare", "Stops sharing your Todo list with a user")
+	msgAutocompleteSearch                = newMessage("autocomplete.search", "Finds your Todo issues in any list")
+	msgAutocompletePop                   = newMessage("autocomplete.pop", "Completes the Todo issue at the top of your list")
+	msgAutocompleteStart                 = newMessage("autocomplete.start", "Marks a Todo issue of your list as in progress")
+	msgAutocompleteDone                  = newMessage("autocomplete.done", "Completes a Todo issue of your list")
+	msgAutocompleteRestore               = newMessage("autocomplete.restore", "Moves a completed Todo issue back to your list")
+	msgAutocompleteMove                  = newMessage("autocomplete.move", "Moves a Todo issue of your list to another position")
+	msgAutocompleteEdit                  = newMessage("autocomplete.edit", "Changes the message of a Todo issue")
+	msgAutocompleteAttach                = newMessage("autocomplete.attach", "Attaches a Todo issue to the current thread")
+	msgAutocompleteRemove                = newMessage("autocomplete.rm", "Removes a Todo issue")
+	msgAutocompleteNote                  = newMessage("autocomplete.note", "Adds a note to a Todo issue")
+	msgAutocompleteSub                   = newMessage("autocomplete.sub", "Manages the checklist items of a Todo issue")
+	msgAutocompleteSubAdd                = newMessage("autocomplete.sub_add", "Adds a checklist item to a Todo issue of your list")
+	msgAutocompleteSend                  = newMessage("autocomplete.send", "Sends some user a Todo")
+	msgAutocompleteScheduled             = newMessage("autocomplete.scheduled", "Lists or cancels the Todos you scheduled to send later")
+	msgAutocompleteScheduledList         = newMessage("autocomplete.scheduled_list", "Lists the Todos you scheduled to send later")
+	msgAutocompleteScheduledCancel       = newMessage("autocomplete.scheduled_cancel", "Cancels a scheduled Todo")
+	msgAutocompleteChannel               = newMessage("autocomplete.channel", "Uses the shared Todo list of the current channel")
+	msgAutocompleteChannelAdd            = newMessage("autocomplete.channel_add", "Adds a Todo to the channel list")
+	msgAutocompleteChannelList           = newMessage("autocomplete.channel_list", "Lists the Todo issues of the channel list")
+	msgAutocompleteChannelClaim          = newMessage("autocomplete.channel_claim", "Moves a Todo issue of the channel list to your list")
+	msgAutocompleteAccept                = newMessage("autocomplete.accept", "Accepts a Todo issue you received")
+	msgAutocompleteApprove               = newMessage("autocomplete.approve", "Applies the change proposed by the receiver of a Todo issue you sent")
+	msgAutocompleteReject                = newMessage("autocomplete.reject", "Drops the change proposed by the receiver of a Todo issue you sent")
+	msgAutocompleteDecline               = newMessage("autocomplete.decline", "Declines a Todo issue you received")
+	msgAutocompleteForward               = newMessage("autocomplete.forward", "Hands off a Todo issue you received to someone else")
+	msgAutocompleteNudge                 = newMessage("autocomplete.nudge", "Reminds the receiver of a Todo issue you sent that it is still open")
+	msgAutocompleteWatch                 = newMessage("autocomplete.watch", "Gets you a message for every change to a Todo issue you sent")
+	msgAutocompleteUnwatch               = newMessage("autocomplete.unwatch", "Stops the messages about a Todo issue you sent")
+	msgAutocompleteStats                 = newMessage("autocomplete.stats", "Shows how many Todos you added and completed")
+	msgAutocompleteTemplate              = newMessage("autocomplete.template", "Saves lists of Todos to add or send at once")
+	msgAutocompleteTemplateSave          = newMessage("autocomplete.template_save", "Saves a template, with one Todo per line or separated by ;")
+	msgAutocompleteTemplateApply         = newMessage("autocomplete.template_apply", "Adds the Todos of a template to your list, or sends them to a user")
+	msgAutocompleteTemplateList          = newMessage("autocomplete.template_list", "Lists your templates and the templates of the team")
+	msgAutocompleteTemplateRemove        = newMessage("autocomplete.template_rm", "Removes a template")
+	msgAutocompleteUndo                  = newMessage("autocomplete.undo", "Reverses your last pop, done, rm or send")
+	msgAutocompleteDigest                = newMessage("autocomplete.digest", "Shows or changes your daily digest")
+	msgAutocompleteSettings              = newMessage("autocomplete.settings", "Shows or changes your settings")
+	msgAutocompleteSettingsDigest        = newMessage("autocomplete.settings_digest", "Changes your daily digest")
+	msgAutocompleteSettingsNotifications = newMessage("autocomplete.settings_notifications", "Whether you get a message when someone sends you a Todo")
+	msgAutocompleteSettingsList          = newMessage("autocomplete.settings_list", "The list shown by /todo list")
+	msgAutocompleteSettingsReminder      = newMessage("autocomplete.settings_reminder", "Reminds you of your Todos before they are due")
+	msgAutocompleteSettingsReport        = newMessage("autocomplete.settings_report", "Sends you a weekly report of the Todos you completed")
+	msgAutocompleteSettingsEmails        = newMessage("autocomplete.settings_emails", "Whether you get emails about your Todos while you are away")
+	msgAutocompleteSettingsWatch         = newMessage("autocomplete.settings_watch", "Which Todos you sent you get a message about when they change")
+	msgAutocompleteCalendar              = newMessage("autocomplete.calendar", "Sends you a new URL of your calendar feed of due Todos")
+	msgAutocompleteJira                  = newMessage("autocomplete.jira", "Links your Todo issues to Jira issues")
+	msgAutocompleteJiraLink              = newMessage("autocomplete.jira_link", "Links a Todo issue of your list to a Jira issue")
+	msgAutocompleteJiraUnlink            = newMessage("autocomplete.jira_unlink", "Removes the link of a Todo issue of your list to its Jira issue")
+	msgAutocompleteToken                 = newMessage("autocomplete.token", "Manages the tokens of your incoming webhooks and the REST API")
+	msgAutocompleteTokenCreate           = newMessage("autocomplete.token_create", "Creates a token and shows how to use it")
+	msgAutocompleteTokenList             = newMessage("autocomplete.token_list", "Lists your tokens")
+	msgAutocompleteTokenRevoke           = newMessage("autocomplete.token_revoke", "Revokes a token")
+	msgAutocompleteExport                = newMessage("autocomplete.export", "Sends you a file with all your Todo issues")
+	msgAutocompleteImport                = newMessage("autocomplete.import", "Adds the Todo issues in the file attached to a post")
+	msgAutocompletePurge                 = newMessage("autocomplete.purge", "Deletes the old completed Todos and the Todos of the deactivated users now")
+	msgAutocompleteAudit                 = newMessage("autocomplete.audit", "Shows what a user did with their Todos")
+	msgAutocompleteMigrate               = newMessage("autocomplete.migrate", "Copies every Todo to the SQL tables of the database")
+	msgAutocompleteHelp                  = newMessage("autocomplete.help", "Display usage")
+	msgAutocompleteAddMessage            = newMessage("autocomplete.add_message", "The Todo, optionally ending with by and a due date, and --file with the link of a file")
+	msgAutocompleteListPage              = newMessage("autocomplete.list_page", "The page to show, or --in-progress for the Todos in progress only")
+	msgAutocompleteSearchQuery           = newMessage("autocomplete.search_query", "The words, users or #tags to find")
+	msgAutocompleteMoveTo                = newMessage("autocomplete.move_to", "The new position")
+	msgAutocompleteEditMessage           = newMessage("autocomplete.edit_message", "The new message")
+	msgAutocompleteAttachLink            = newMessage("autocomplete.attach_link", "The link to a post, when not run in a thread")
+	msgAutocompleteNoteText              = newMessage("autocomplete.note_text", "The note")
+	msgAutocompleteSubAddMessage         = newMessage("autocomplete.sub_add_message", "The checklist item")
+	msgAutocompleteSubItem               = newMessage("autocomplete.sub_item", "The number of the Todo and of the item")
+	msgAutocompleteSendMessage           = newMessage("autocomplete.send_message", "The Todo, optionally ending with by and a due date, or with --at and a time to send it later")
+	msgAutocompleteScheduledNumber       = newMessage("autocomplete.scheduled_number", "The number of the scheduled Todo, as listed")
+	msgAutocompleteChannelAddMessage     = newMessage("autocomplete.channel_add_message", "The Todo, optionally ending with by and a due date")
+	msgAutocompleteNumber                = newMessage("autocomplete.number", "The number of the Todo")
+	msgAutocompleteAcceptProposal        = newMessage("autocomplete.accept_proposal", "A new message or due date to propose to the sender, optional")
+	msgAutocompleteDeclineReason         = newMessage("autocomplete.decline_reason", "Why you decline the Todo, optional")
+	msgAutocompleteForwardNote           = newMessage("autocomplete.forward_note", "A note for the new receiver, optional")
+	msgAutocompleteTemplateSaveArgs      = newMessage("autocomplete.template_save_args", "The name of the template, then its Todos")
+	msgAutocompleteTemplateName          = newMessage("autocomplete.template_name", "The name of the template")
+	msgAutocompleteSettingsReminderLead  = newMessage("autocomplete.settings_reminder_lead", "How long before the due date, like 30m, 2h or 1d, or off")
+	msgAutocompleteJiraKey               = newMessage("autocomplete.jira_key", "The key of the Jira issue")
+	msgAutocompleteTokenCreateArgs       = newMessage("autocomplete.token_create_args", "A name to remember what the token is for, and the scopes read, write or send separated by commas, optional")
+	msgAutocompleteTokenID               = newMessage("autocomplete.token_id", "The id of the token")
+	msgAutocompleteImportLink            = newMessage("autocomplete.import_link", "The link to the post with the file, optionally followed by --dry-run")
+	msgAutocompleteAuditSince            = newMessage("autocomplete.audit_since", "The start, like 2020-03-15 or 30d, the last 7 days by default")
+	msgAutocompleteListName              = newMessage("autocomplete.list_name", "The list to show")
+	msgAutocompleteTodoList              = newMessage("autocomplete.todo_list", "The list of the Todo, your list by default")
+	msgAutocompleteReceivedList          = newMessage("autocomplete.received_list", "The list of the Todo, your received list by default")
+	msgAutocompleteStatsPeriod           = newMessage("autocomplete.stats_period", "The period of the statistics, the last week by default")
+	msgAutocompleteDigestToggle          = newMessage("autocomplete.digest_toggle", "Turns the digest on or off")
+	msgAutocompleteNotificationsToggle   = newMessage("autocomplete.notifications_toggle", "Turns the messages on or off")
+	msgAutocompleteSettingsListName      = newMessage("autocomplete.settings_list_name", "The list")
+	msgAutocompleteReportToggle          = newMessage("autocomplete.report_toggle", "Turns the weekly report on or off")
+	msgAutocompleteEmailsToggle          = newMessage("autocomplete.emails_toggle", "Turns the emails on or off")
+	msgAutocompleteWatchMode             = newMessage("autocomplete.watch_mode", "The Todos you watch")
+	msgAutocompleteCalendarOff           = newMessage("autocomplete.calendar_off", "Disables the calendar feed")
+	msgAutocompleteExportFormat          = newMessage("autocomplete.export_format", "The format of the file")
+	msgAutocompleteShareUser             = newMessage("autocomplete.share_user", "The user to share your list with, or none to see who you shared it with")
+	msgAutocompleteUnshareUser           = newMessage("autocomplete.unshare_user", "The user to stop sharing your list with")
+	msgAutocompleteNumberOrFirst         = newMessage("autocomplete.number_or_first", "The number of the Todo, the first one by default")
+	msgAutocompleteSendUser              = newMessage("autocomplete.send_user", "The user to send the Todo to")
+	msgAutocompleteForwardUser           = newMessage("autocomplete.forward_user", "The user to forward the Todo to")
+	msgAutocompleteWatchNumber           = newMessage("autocomplete.watch_number", "The number of the Todo, or nothing to see the ones you watch")
+	msgAutocompleteTemplateApplyUser     = newMessage("autocomplete.template_apply_user", "The user to send the Todos to, optional")
+	msgAutocompleteAuditUser             = newMessage("autocomplete.audit_user", "The user to audit")
+	msgAutocompleteListMy                = newMessage("autocomplete.list_my", "Your Todo list")
+	msgAutocompleteListIn                = newMessage("autocomplete.list_in", "The Todos you received")
+	msgAutocompleteListOut               = newMessage("autocomplete.list_out", "The Todos you sent")
+	msgAutocompleteListDone              = newMessage("autocomplete.list_done", "The Todos you completed")
+	msgAutocompleteStatsWeek             = newMessage("autocomplete.stats_week", "The last 7 days")
+	msgAutocompleteStatsMonth            = newMessage("autocomplete.stats_month", "The last month")
+	msgAutocompleteDigestOn              = newMessage("autocomplete.digest_on", "Sends you the digest every day")
+	msgAutocompleteDigestOff             = newMessage("autocomplete.digest_off", "Stops the digest")
+	msgAutocompleteNotificationsOn       = newMessage("autocomplete.notifications_on", "Sends you a message for every Todo you receive")
+	msgAutocompleteNotificationsOff      = newMessage("autocomplete.notifications_off", "Only adds the Todos you receive to your received list")
+	msgAutocompleteReportOn              = newMessage("autocomplete.report_on", "Sends you the report every Monday")
+	msgAutocompleteReportOff             = newMessage("autocomplete.report_off", "Stops the report")
+	msgAutocompleteEmailsOn              = newMessage("autocomplete.emails_on", "Emails you the Todos you receive or that become overdue while you are away")
+	msgAutocompleteEmailsOff             = newMessage("autocomplete.emails_off", "Only sends you messages in Mattermost")
+	msgAutocompleteWatchAll              = newMessage("autocomplete.watch_all", "Every Todo you send is watched")
+	msgAutocompleteWatchSelected         = newMessage("autocomplete.watch_selected", "Only the Todos you watch with /todo watch")
+	msgAutocompleteSubCheck              = newMessage("autocomplete.sub_check", "Marks a checklist item as done")
+	msgAutocompleteSubUncheck            = newMessage("autocomplete.sub_uncheck", "Marks a checklist item as not done")
+	msgAutocompleteSubRemove             = newMessage("autocomplete.sub_rm", "Removes a checklist item")
+	msgCalendarName                      = newMessage("calendar.name", "Todos of @{{.User}}")
+	msgCalendarMyList                    = newMessage("calendar.my_list", "On your Todo list")
+	msgCalendarReceived                  = newMessage("calendar.received", "Received from @{{.User}}, not accepted yet")
+)
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/pkg/errors"
+)
+
+// MetricsPath is the path of the metrics of the plugin, in the Prometheus text format
+const MetricsPath = "/metrics"
+
+const (
+	metricTypeCounter   = "counter"
+	metricTypeHistogram = "histogram"
+)
+
+// metricDesc describes a metric. Histograms also have the upper bounds of their buckets.
+type metricDesc struct {
+	name    string
+	help    string
+	kind    string
+	buckets []float64
+}
+
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var (
+	metricIssueEvents = &metricDesc{
+		name: "todo_issue_events_total",
+		help: "Number of todo lifecycle events, like created, sent or completed todos, by event.",
+		kind: metricTypeCounter,
+	}
+	metricCommandDuration = &metricDesc{
+		name:    "todo_command_duration_seconds",
+		help:    "Duration of the /todo commands, by command and result.",
+		kind:    metricTypeHistogram,
+		buckets: durationBuckets,
+	}
+	metricHTTPRequestDuration = &metricDesc{
+		name:    "todo_http_request_duration_seconds",
+		help:    "Duration of the HTTP requests to the plugin, by route and status code.",
+		kind:    metricTypeHistogram,
+		buckets: durationBuckets,
+	}
+	metricListSize = &metricDesc{
+		name:    "todo_list_size",
+		help:    "Number of todos in the lists when they are loaded, by list.",
+		kind:    metricTypeHistogram,
+		buckets: []float64{0, 1, 5, 10, 20, 50, 100, 200, 500},
+	}
+	metricKVErrors = &metricDesc{
+		name: "todo_kv_errors_total",
+		help: "Number of failed KV store operations, by operation.",
+		kind: metricTypeCounter,
+	}
+
+	allMetrics = []*metricDesc{metricIssueEvents, metricCommandDuration, metricHTTPRequestDuration, metricListSize, metricKVErrors}
+)
+
+// metricSeries is the value of a metric for a set of labels
+type metricSeries struct {
+	labels  string
+	value   float64
+	buckets []uint64
+	count   uint64
+}
+
+// metrics collects the metrics of the plugin in memory. The zero value is not usable, use newMetrics. All methods
+// can be called on a nil *metrics, and do nothing.
+type metrics struct {
+	lock   sync.Mutex
+	series map[*metricDesc]map[string]*metricSeries
+}
+
+func newMetrics() *metrics {
+	return &metrics{series: map[*metricDesc]map[string]*metricSeries{}}
+}
+
+// inc adds one to the counter desc with the labels, given as name and value pairs
+func (m *metrics) inc(desc *metricDesc, labels ...string) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.getSeries(desc, labels).value++
+}
+
+// observe records value in the histogram desc with the labels, given as name and value pairs
+func (m *metrics) observe(desc *metricDesc, value float64, labels ...string) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	s := m.getSeries(desc, labels)
+	for i, bound := range desc.buckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.value += value
+	s.count++
+}
+
+// getSeries returns the series of desc with the labels, creating it if needed. It must be called under lock.
+func (m *metrics) getSeries(desc *metricDesc, labels []string) *metricSeries {
+	key := formatLabels(labels)
+	if m.series[desc] == nil {
+		m.series[desc] = map[string]*metricSeries{}
+	}
+
+	s, ok := m.series[desc][key]
+	if !ok {
+		s = &metricSeries{labels: key, buckets: make([]uint64, len(desc.buckets))}
+		m.series[desc][key] = s
+	}
+	return s
+}
+
+// handleIssueEvent counts the todo lifecycle events dispatched by the list manager
+func (m *metrics) handleIssueEvent(event *IssueEvent) {
+	m.inc(metricIssueEvents, "event", event.Type)
+}
+
+// write writes every metric in the Prometheus text exposition format
+func (m *metrics) write(w io.Writer) error {
+	if m == nil {
+		return nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var b strings.Builder
+	for _, desc := range allMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", desc.name, desc.help, desc.name, desc.kind)
+
+		keys := []string{}
+		for key := range m.series[desc] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := m.series[desc][key]
+			if desc.kind == metricTypeCounter {
+				fmt.Fprintf(&b, "%s%s %s\n", desc.name, withLabel(s.labels, "", ""), formatFloat(s.value))
+				continue
+			}
+
+			for i, bound := range desc.buckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", desc.name, withLabel(s.labels, "le", formatFloat(bound)), s.buckets[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", desc.name, withLabel(s.labels, "le", "+Inf"), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", desc.name, withLabel(s.labels, "", ""), formatFloat(s.value))
+			fmt.Fprintf(&b, "%s_count%s %d\n", desc.name, withLabel(s.labels, "", ""), s.count)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels renders the name and value pairs of labels as name="value", separated by commas
+func formatLabels(labels []string) string {
+	pairs := []string{}
+	for i := 0; i+1 < len(labels); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1])
+		pairs = append(pairs, labels[i]+`="`+value+`"`)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// withLabel wraps the rendered labels in braces, adding the label name with value if name is not empty
+func withLabel(labels, name, value string) string {
+	if name != "" {
+		if labels != "" {
+			labels += ","
+		}
+		labels += name + `="` + value + `"`
+	}
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// httpRoute returns the route of path used as label of the HTTP metrics, so that ids in paths do not create a series
+// per request
+func httpRoute(path string) string {
+	switch {
+	case strings.HasPrefix(path, APIv2Prefix+"/"):
+		return APIv2Prefix
+	case strings.HasPrefix(path, CalendarPath+"/"):
+		return CalendarPath
+	case strings.HasPrefix(path, HooksPath+"/"):
+		return HooksPath
+	case strings.HasPrefix(path, ActionPath+"/"):
+		return ActionPath
+	case strings.HasPrefix(path, PluginAPIPrefix+"/"):
+		return PluginAPIPrefix
+	}
+
+	switch path {
+	case JiraWebhookPath, MetricsPath, AutocompleteIssuesPath, AutocompleteUsersPath, DialogPath, DialogSubmitPath,
+		"/add", "/list", "/remove", "/complete", "/accept", "/restore", "/decline", "/bump", "/edit", "/search", "/stats":
+		return path
+	}
+	return "other"
+}
+
+// statusRecorder remembers the status code written to the response, for the HTTP metrics
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// serveMetrics serves the metrics to system admins, who can scrape them with a personal access token
+func (p *Plugin) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		p.handleErrorWithCode(w, http.StatusUnauthorized, "Not authorized", errors.New("a session or personal access token is required"))
+		return
+	}
+
+	if !p.API.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM) {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errors.New("only system admins can see the metrics"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := p.metrics.write(w); err != nil {
+		p.API.LogError("Unable to write metrics err=" + err.Error())
+	}
+}
+
+// observeCommand records the duration of the /todo command, by whether it succeeded
+func (m *metrics) observeCommand(command string, start time.Time, isUserError bool, err error) {
+	result := "success"
+	if err != nil && isUserError {
+		result = "user_error"
+	} else if err != nil {
+		result = "error"
+	}
+	m.observe(metricCommandDuration, time.Since(start).Seconds(), "command", command, "result", result)
+}
+
+// metricsAPI counts the failed KV store operations of the plugin API it wraps
+type metricsAPI struct {
+	plugin.API
+	metrics *metrics
+}
+
+func (a *metricsAPI) countError(operation string, appErr *model.AppError) {
+	if appErr != nil {
+		a.metrics.inc(metricKVErrors, "operation", operation)
+	}
+}
+
+func (a *metricsAPI) KVGet(key string) ([]byte, *model.AppError) {
+	value, appErr := a.API.KVGet(key)
+	a.countError("get", appErr)
+	return value, appErr
+}
+
+func (a *metricsAPI) KVSet(key string, value []byte) *model.AppError {
+	appErr := a.API.KVSet(key, value)
+	a.countError("set", appErr)
+	return appErr
+}
+
+func (a *metricsAPI) KVSetWithOptions(key string, value []byte, options model.PluginKVSetOptions) (bool, *model.AppError) {
+	ok, appErr := a.API.KVSetWithOptions(key, value, options)
+	a.countError("set", appErr)
+	return ok, appErr
+}
+
+func (a *metricsAPI) KVCompareAndSet(key string, oldValue, newValue []byte) (bool, *model.AppError) {
+	ok, appErr := a.API.KVCompareAndSet(key, oldValue, newValue)
+	a.countError("compare_and_set", appErr)
+	return ok, appErr
+}
+
+func (a *metricsAPI) KVDelete(key string) *model.AppError {
+	appErr := a.API.KVDelete(key)
+	a.countError("delete", appErr)
+	return appErr
+}
+
+func (a *metricsAPI) KVList(page, perPage int) ([]string, *model.AppError) {
+	keys, appErr := a.API.KVList(page, perPage)
+	a.countError("list", appErr)
+	return keys, appErr
+}
+
+func (a *metricsAPI) KVCompareAndDelete(key string, oldValue []byte) (bool, *model.AppError) {
+	ok, appErr := a.API.KVCompareAndDelete(key, oldValue)
+	a.countError("compare_and_delete", appErr)
+	return ok, appErr
+}
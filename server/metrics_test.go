@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsWrite(t *testing.T) {
+	m := newMetrics()
+	m.handleIssueEvent(&IssueEvent{Type: IssueEventCreated})
+	m.handleIssueEvent(&IssueEvent{Type: IssueEventCreated})
+	m.handleIssueEvent(&IssueEvent{Type: IssueEventCompleted})
+	m.observe(metricListSize, 3, "list", "my")
+	m.observe(metricListSize, 30, "list", "my")
+	m.inc(metricKVErrors, "operation", `a"b`)
+
+	var b strings.Builder
+	require.NoError(t, m.write(&b))
+	out := b.String()
+
+	assert.Contains(t, out, "# TYPE todo_issue_events_total counter\n")
+	assert.Contains(t, out, `todo_issue_events_total{event="created"} 2`+"\n")
+	assert.Contains(t, out, `todo_issue_events_total{event="completed"} 1`+"\n")
+	assert.Contains(t, out, "# TYPE todo_list_size histogram\n")
+	assert.Contains(t, out, `todo_list_size_bucket{list="my",le="1"} 0`+"\n")
+	assert.Contains(t, out, `todo_list_size_bucket{list="my",le="5"} 1`+"\n")
+	assert.Contains(t, out, `todo_list_size_bucket{list="my",le="50"} 2`+"\n")
+	assert.Contains(t, out, `todo_list_size_bucket{list="my",le="+Inf"} 2`+"\n")
+	assert.Contains(t, out, `todo_list_size_sum{list="my"} 33`+"\n")
+	assert.Contains(t, out, `todo_list_size_count{list="my"} 2`+"\n")
+	assert.Contains(t, out, `todo_kv_errors_total{operation="a\"b"} 1`+"\n")
+
+	var nilMetrics *metrics
+	nilMetrics.inc(metricKVErrors, "operation", "get")
+	assert.NoError(t, nilMetrics.write(&b))
+}
+
+func TestHTTPRoute(t *testing.T) {
+	assert.Equal(t, APIv2Prefix, httpRoute(APIv2Prefix+"/todos/abc/complete"))
+	assert.Equal(t, HooksPath, httpRoute(HooksPath+"/secret"))
+	assert.Equal(t, ActionPath, httpRoute(ActionPath+"/accept"))
+	assert.Equal(t, "/add", httpRoute("/add"))
+	assert.Equal(t, "/stats", httpRoute("/stats"))
+	assert.Equal(t, MetricsPath, httpRoute(MetricsPath))
+	assert.Equal(t, "other", httpRoute("/unknown/abc"))
+}
+
+func TestServeMetrics(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("HasPermissionTo", "admin", model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	api.On("HasPermissionTo", "user", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+	p := &Plugin{metrics: newMetrics()}
+	p.SetAPI(api)
+
+	for userID, status := range map[string]int{"": http.StatusUnauthorized, "user": http.StatusForbidden, "admin": http.StatusOK} {
+		r := httptest.NewRequest(http.MethodGet, MetricsPath, nil)
+		if userID != "" {
+			r.Header.Set("Mattermost-User-ID", userID)
+		}
+		w := httptest.NewRecorder()
+		p.ServeHTTP(nil, w, r)
+		assert.Equal(t, status, w.Code, userID)
+	}
+
+	var b strings.Builder
+	require.NoError(t, p.metrics.write(&b))
+	assert.Contains(t, b.String(), `todo_http_request_duration_seconds_count{route="/metrics",code="403"} 1`)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// MigrationSummary counts what was copied from the KV store to the SQL tables
+type MigrationSummary struct {
+	Lists    int
+	Issues   int
+	Journals int
+	// MissingIssues counts the references to issues that are not in the KV store, copied as they are
+	MissingIssues int
+	Failures      int
+}
+
+// migrateToSQL copies every list, channel lists included, with their issues and the journals of their users from kv
+// to the SQL tables. The lists and issues already in the tables are replaced, so it can run again to catch up.
+func (p *Plugin) migrateToSQL(kv *listStore, target *sqlListStore) (*MigrationSummary, error) {
+	summary := &MigrationSummary{}
+	copied := map[string]bool{}
+	users := map[string]bool{}
+	err := kv.forEachList(func(ownerID, listID string) error {
+		list, err := kv.GetList(ownerID, listID)
+		if err != nil {
+			p.API.LogError("cannot get list to migrate, Err=", err.Error())
+			summary.Failures++
+			return nil
+		}
+
+		for _, ir := range list {
+			if copied[ir.IssueID] {
+				continue
+			}
+			copied[ir.IssueID] = true
+
+			issue, err := kv.GetIssue(ir.IssueID)
+			if err != nil {
+				summary.MissingIssues++
+				continue
+			}
+			if err := target.importIssue(issue); err != nil {
+				p.API.LogError("cannot migrate issue, Err=", err.Error())
+				summary.Failures++
+				continue
+			}
+			summary.Issues++
+		}
+
+		if err := target.importList(ownerID, listID, list); err != nil {
+			p.API.LogError("cannot migrate list, Err=", err.Error())
+			summary.Failures++
+			return nil
+		}
+		summary.Lists++
+
+		if listID != ChannelListKey {
+			users[ownerID] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for userID := range users {
+		journal, _, err := kv.getJournal(userID)
+		if err != nil {
+			p.API.LogError("cannot get journal to migrate, Err=", err.Error())
+			summary.Failures++
+			continue
+		}
+		if len(journal) == 0 {
+			continue
+		}
+
+		if err := target.importJournal(userID, journal); err != nil {
+			p.API.LogError("cannot migrate journal, Err=", err.Error())
+			summary.Failures++
+			continue
+		}
+		summary.Journals++
+	}
+
+	p.API.LogInfo("Todo migration to SQL done",
+		"lists", summary.Lists,
+		"issues", summary.Issues,
+		"journals", summary.Journals,
+		"missing_issues", summary.MissingIssues,
+		"failures", summary.Failures,
+	)
+	return summary, nil
+}
+
+// migrationSummaryToString renders the summary of a migration for the system admin that asked for it
+func migrationSummaryToString(T translateFunc, summary *MigrationSummary) string {
+	return T(msgMigrateSummary, map[string]interface{}{
+		"Lists":         summary.Lists,
+		"Issues":        summary.Issues,
+		"Journals":      summary.Journals,
+		"MissingIssues": summary.MissingIssues,
+		"Failures":      summary.Failures,
+	})
+}
+
+func (p *Plugin) runMigrateCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return nil, true, newLocalizedError(msgMigrateNotAllowed, nil)
+	}
+
+	if p.sqlStore != nil {
+		return nil, true, newLocalizedError(msgMigrateAlreadyDone, nil)
+	}
+
+	target, err := newSQLListStore(p.API)
+	if err != nil {
+		return nil, false, err
+	}
+
+	go func() {
+		defer target.Close()
+
+		summary, err := p.migrateToSQL(NewListStore(p.API), target)
+		T := p.translator(extra.UserId)
+		message := T(msgMigrateFailed, nil)
+		if err != nil {
+			p.API.LogError("cannot migrate Todos to SQL, Err=", err.Error())
+		} else {
+			message = migrationSummaryToString(T, summary)
+		}
+		if err := p.PostBotDM(extra.UserId, message); err != nil {
+			p.API.LogError("cannot post migration summary, Err=", err.Error())
+		}
+	}()
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.localize(extra.UserId, msgMigrateStarted, nil)), false, nil
+}
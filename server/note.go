@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// MaxNotes is the maximum number of notes of a todo
+const MaxNotes = 100
+
+// Note is a timestamped comment on a todo, by its owner or by whoever sent it
+type Note struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	Message  string `json:"message"`
+	CreateAt int64  `json:"create_at"`
+}
+
+type apiV2NoteRequest struct {
+	Message string `json:"message"`
+}
+
+func (l *listManager) AddNote(userID, issueID, message string) (*ExtendedIssue, *Note, bool, error) {
+	issueList, _, _ := l.store.GetIssueListAndReference(userID, issueID)
+
+	note := &Note{
+		ID:       model.NewId(),
+		UserID:   userID,
+		Message:  message,
+		CreateAt: model.GetMillis(),
+	}
+
+	issue, err := l.updateSharedIssue(userID, issueID, func(issue *Issue) error {
+		if len(issue.Notes) >= MaxNotes {
+			return newLocalizedError(msgErrTooManyNotes, map[string]interface{}{"Max": MaxNotes})
+		}
+		issue.Notes = append(issue.Notes, note)
+		return nil
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Notes = issue.Notes
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return issue, note, issueList == OutListKey, nil
+}
+
+// addNote adds the note to the todo issueID of userID, and lets the receiver know if the note comes from the sender
+func (p *Plugin) addNote(userID, issueID, message string) (*ExtendedIssue, *Note, error) {
+	issue, note, isSender, err := p.listManager.AddNote(userID, issueID, message)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.sendRefreshEvent(userID)
+	if issue.ForeignUserID != "" {
+		p.sendRefreshEvent(issue.ForeignUserID)
+	}
+	if isSender && !isDeclined(&issue.Issue) {
+		p.notifyNote(userID, issue.ForeignUserID, issue.Message, message)
+	}
+
+	return issue, note, nil
+}
+
+// notifyNote lets the receiver of a todo know that its sender added a note to it
+func (p *Plugin) notifyNote(userID, receiverID, todoMessage, note string) {
+	userName := p.listManager.GetUserName(userID)
+	message := p.localize(receiverID, msgNotifyNote, map[string]interface{}{"User": userName, "Todo": todoMessage, "Note": note})
+	if err := p.PostBotDM(receiverID, message); err != nil {
+		p.API.LogError("Unable to send note notification err=" + err.Error())
+	}
+}
+
+func (p *Plugin) runNoteCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	listID, position, rest, err := splitListAndPosition(args)
+	if err != nil {
+		return nil, true, err
+	}
+
+	message := strings.TrimSpace(strings.Join(rest, " "))
+	if message == "" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageNote, nil)), false, nil
+	}
+	if err = p.checkMessage(message); err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, listID, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if _, _, err = p.addNote(extra.UserId, target.ID, message); err != nil {
+		return nil, false, err
+	}
+
+	responseMessage := T(msgResponseNoteAdded, map[string]interface{}{"Number": position})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, listID, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, listID)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+// serveAPIv2Notes routes the requests to the notes of the todo issueID
+func (p *Plugin) serveAPIv2Notes(w http.ResponseWriter, r *http.Request, userID, issueID string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		issue, err := p.listManager.GetIssue(userID, issueID)
+		if err == errIssueNotFound {
+			p.handleErrorWithCode(w, http.StatusNotFound, "Todo not found", err)
+			return
+		}
+		if err != nil {
+			p.API.LogError("Unable to get issue err=" + err.Error())
+			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issue", err)
+			return
+		}
+
+		notes := issue.Notes
+		if notes == nil {
+			notes = []*Note{}
+		}
+		p.writeAPIResponse(w, http.StatusOK, notes)
+	case http.MethodPost:
+		var noteRequest *apiV2NoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&noteRequest); err != nil || noteRequest == nil {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+			return
+		}
+
+		message := strings.TrimSpace(noteRequest.Message)
+		if err := p.checkMessage(message); err != nil {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+			return
+		}
+
+		_, note, err := p.addNote(userID, issueID, message)
+		if err == errIssueNotFound {
+			p.handleErrorWithCode(w, http.StatusNotFound, "Todo not found", err)
+			return
+		}
+		if err != nil {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to add note", err)
+			return
+		}
+
+		p.writeAPIResponse(w, http.StatusCreated, note)
+	default:
+		p.handleErrorWithCode(w, http.StatusMethodNotAllowed, "Method not allowed", errors.Errorf("%s is not supported", r.Method))
+	}
+}
+
+// renderNotes renders the notes of the issue for the list output. Notes of the other side of a sent todo show who
+// wrote them.
+func renderNotes(T translateFunc, issue *ExtendedIssue, location *time.Location) string {
+	str := ""
+	for _, note := range issue.Notes {
+		createAt := fromMillis(note.CreateAt).In(location).Format("January 2, 2006 at 15:04")
+		if note.UserID == issue.ForeignUserID {
+			str += "  * " + T(msgIssueNoteBy, map[string]interface{}{"User": issue.ForeignUser, "Date": createAt, "Note": note.Message}) + "\n"
+			continue
+		}
+		str += "  * " + T(msgIssueNote, map[string]interface{}{"Date": createAt, "Note": note.Message}) + "\n"
+	}
+	return str
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderNotes(t *testing.T) {
+	createAt := time.Date(2020, time.May, 4, 10, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	issue := &ExtendedIssue{
+		Issue: Issue{
+			Message: "Prepare the demo",
+			Notes: []*Note{
+				{ID: "a", UserID: "owner", Message: "Slides done", CreateAt: createAt},
+				{ID: "b", UserID: "sender", Message: "Use the new logo", CreateAt: createAt},
+			},
+		},
+		ForeignUser:   "alice",
+		ForeignUserID: "sender",
+	}
+
+	assert.Equal(t, "  * Note on May 4, 2020 at 10:00: Slides done\n"+
+		"  * Note by @alice on May 4, 2020 at 10:00: Use the new logo\n", renderNotes(renderEnglish, issue, time.UTC))
+	assert.Equal(t, "", renderNotes(renderEnglish, &ExtendedIssue{}, time.UTC))
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"time"
+)
+
+// NudgeCooldown is how long the receiver of a sent todo cannot be nudged about it again
+const NudgeCooldown = 24 * time.Hour
+
+// notifyNudge reminds receiverID, the current holder of a todo userID sent, that it is still open
+func (p *Plugin) notifyNudge(userID, receiverID, todoMessage string, sentAt int64) error {
+	T := p.translator(receiverID)
+	message := T(msgNotifyNudge, map[string]interface{}{
+		"User": p.listManager.GetUserName(userID),
+		"Todo": todoMessage,
+		"Age":  formatAge(T, time.Since(fromMillis(sentAt))),
+	})
+	return p.PostBotDM(receiverID, message)
+}
+
+// formatAge formats how long ago something happened, rounded down to hours or days
+func formatAge(T translateFunc, age time.Duration) string {
+	switch {
+	case age < time.Hour:
+		return T(msgTimeLessThanHour, nil)
+	case age < 2*time.Hour:
+		return T(msgTimeHour, nil)
+	case age < 24*time.Hour:
+		return T(msgTimeHours, map[string]interface{}{"Count": int(age / time.Hour)})
+	case age < 48*time.Hour:
+		return T(msgTimeDay, nil)
+	}
+	return T(msgTimeDays, map[string]interface{}{"Count": int(age / (24 * time.Hour))})
+}
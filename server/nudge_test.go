@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "less than an hour", formatAge(renderEnglish, 10*time.Minute))
+	assert.Equal(t, "1 hour", formatAge(renderEnglish, 90*time.Minute))
+	assert.Equal(t, "23 hours", formatAge(renderEnglish, 23*time.Hour+59*time.Minute))
+	assert.Equal(t, "1 day", formatAge(renderEnglish, 30*time.Hour))
+	assert.Equal(t, "3 days", formatAge(renderEnglish, 80*time.Hour))
+}
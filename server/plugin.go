@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/mattermost/go-i18n/i18n/bundle"
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
 	"github.com/pkg/errors"
@@ -16,26 +19,112 @@ import (
 const (
 	// WSEventRefresh is the WebSocket event for refreshing the Todo list
 	WSEventRefresh = "refresh"
+	// WSEventRefreshChannel is the WebSocket event for refreshing the shared Todo list of a channel
+	WSEventRefreshChannel = "refresh_channel"
+
+	// DefaultPerPage is the page size of the list endpoints when only the page is given
+	DefaultPerPage = 60
+	// MaxPerPage is the maximum page size of the list endpoints
+	MaxPerPage = 200
 )
 
 // ListManager representes the logic on the lists
 type ListManager interface {
-	// AddIssue adds a todo to userID's myList with the message
-	AddIssue(userID, message, postID string) error
-	// SendIssue sends the todo with the message from senderID to receiverID and returns the receiver's issueID
-	SendIssue(senderID, receiverID, message, postID string) (string, error)
+	// AddIssue adds a todo to userID's myList with the message and the due date in milliseconds, if any, and returns the new issue
+	AddIssue(userID, message, postID string, dueAt int64) (*Issue, error)
+	// SendIssue sends the todo with the message and the due date from senderID to receiverID and returns the receiver's issueID
+	SendIssue(senderID, receiverID, message, postID string, dueAt int64) (string, error)
 	// GetIssueList gets the todos on listID for userID
 	GetIssueList(userID, listID string) ([]*ExtendedIssue, error)
-	// CompleteIssue completes the todo issueID for userID, and returns the extended issue
+	// GetIssueListPage gets the todos on the 0-based page of listID for userID, and the total number of todos in the list
+	GetIssueListPage(userID, listID string, page, perPage int) ([]*ExtendedIssue, int, error)
+	// GetDueIssues gets the todos on the lists listIDs of userID due after from and until the given time in milliseconds
+	GetDueIssues(userID string, listIDs []string, from, until int64) ([]*ExtendedIssue, error)
+	// AddChannelIssue adds a todo with the message and the due date to the shared list of channelID on behalf of userID
+	AddChannelIssue(channelID, userID, message, postID string, dueAt int64) (*Issue, error)
+	// GetChannelIssueList gets the todos on the shared list of channelID, with the user that added each one as the foreign user
+	GetChannelIssueList(channelID string) ([]*ExtendedIssue, error)
+	// ClaimChannelIssue moves the todo issueID from the shared list of channelID to userID's myList and returns it
+	ClaimChannelIssue(channelID, userID, issueID string) (*Issue, error)
+	// GetIssueByPosition gets the todo shown at the 1-based position on listID for userID
+	GetIssueByPosition(userID, listID string, position int) (*ExtendedIssue, error)
+	// CompleteIssue completes the todo issueID for userID, moving it to the done list, and returns the extended issue
 	CompleteIssue(userID, issueID string) (*ExtendedIssue, error)
 	// AcceptIssue moves one the todo issueID of userID from inbox to myList, and returns the message and the foreignUserID if any
 	AcceptIssue(userID, issueID string) (todoMessage string, foreignUserID string, err error)
+	// DeclineIssue removes the todo issueID from userID's inbox, marks the sender's copy as declined with the reason,
+	// and returns the message and the foreignUserID
+	DeclineIssue(userID, issueID, reason string) (todoMessage string, foreignUserID string, err error)
+	// ProposeChange records a change to the todo issueID that userID received and accepted, for the sender to approve,
+	// and returns the todo with the sender as the foreign user
+	ProposeChange(userID, issueID string, proposal *Proposal) (*ExtendedIssue, error)
+	// ResolveProposal applies the change proposed for the todo issueID that userID sent to both copies if approve is
+	// true, or drops it otherwise, and returns the todo with the receiver as the foreign user and the proposal
+	ResolveProposal(userID, issueID string, approve bool) (*ExtendedIssue, *Proposal, error)
+	// AttachIssue links the todo issueID of userID, and the copy of the foreign user if any, to the thread of postID,
+	// replacing the post it was attached to, and returns it
+	AttachIssue(userID, issueID, postID string) (*ExtendedIssue, error)
+	// AttachFiles adds the files to the todo issueID of userID, and to the copy of the foreign user if any, and
+	// returns it
+	AttachFiles(userID, issueID string, files []*Attachment) (*ExtendedIssue, error)
+	// SetIssuePriority changes the priority of the todo issueID of userID, and of the copy of the foreign user if any,
+	// and returns it
+	SetIssuePriority(userID, issueID, priority string) (*ExtendedIssue, error)
+	// EscalateIssue records that the todo issueID waiting in the received list of userID was escalated, on the copy of
+	// its sender too, and returns it with the sender as the foreign user
+	EscalateIssue(userID, issueID string) (*ExtendedIssue, error)
+	// WatchIssue makes userID watch the todo issueID they sent, or stop watching it, on the copy of its receiver too,
+	// and returns it with the receiver as the foreign user
+	WatchIssue(userID, issueID string, watch bool) (*ExtendedIssue, error)
+	// StartIssue marks the todo issueID on userID's myList in progress, on the copy of its sender too, and returns it
+	// with the sender as the foreign user if any
+	StartIssue(userID, issueID string) (*ExtendedIssue, error)
+	// ForwardIssue hands off the todo issueID received by userID to the inbox of receiverID with an optional note, so
+	// the sender's copy follows the new receiver, and returns the message and the original sender
+	ForwardIssue(userID, issueID, receiverID, note string) (todoMessage string, senderID string, err error)
+	// EditIssue changes the message of the todo issueID for userID, and returns the previous message, the foreignUserID if any
+	// and whether userID sent the todo to the foreign user
+	EditIssue(userID, issueID, message string) (oldMessage string, foreignUserID string, isSender bool, err error)
 	// RemoveIssue removes the todo issueID for userID and returns the extended issue, and whether the user sent the todo to someone else
 	RemoveIssue(userID, issueID string) (issue *ExtendedIssue, isSender bool, err error)
-	// PopIssue the first element of myList for userID and returns the extended issue
+	// BulkIssues completes or removes every todo in issueIDs of userID. Every todo is checked first, so if any of them
+	// cannot be found nothing changes. The changes are undone together, and the todos changed before an error are
+	// returned along with it.
+	BulkIssues(userID, action string, issueIDs []string) ([]*BulkResult, error)
+	// PopIssue completes the first element of myList for userID and returns the extended issue
 	PopIssue(userID string) (*ExtendedIssue, error)
 	// BumpIssue moves a issueID sent by userID to the top of its receiver inbox list
 	BumpIssue(userID string, issueID string) (todoMessage string, receiver string, foreignIssueID string, err error)
+	// GetIssue returns the todo issueID of any list of userID
+	GetIssue(userID, issueID string) (*Issue, error)
+	// SetIssueGitHubLink stores the GitHub issue the todo issueID of userID is about, and returns the updated todo
+	SetIssueGitHubLink(userID, issueID string, link *GitHubLink) (*Issue, error)
+	// AddSubtask adds a checklist item to the todo issueID of userID, and returns the updated todo and the new item
+	AddSubtask(userID, issueID, message string) (*ExtendedIssue, *Subtask, error)
+	// CheckSubtask marks the checklist item subtaskID of the todo issueID of userID as done or not done
+	CheckSubtask(userID, issueID, subtaskID string, done bool) (*ExtendedIssue, error)
+	// RemoveSubtask removes the checklist item subtaskID from the todo issueID of userID
+	RemoveSubtask(userID, issueID, subtaskID string) (*ExtendedIssue, error)
+	// AddNote appends a note by userID to the todo issueID, and returns the updated todo, the note, and whether the user
+	// sent the todo to someone else
+	AddNote(userID, issueID, message string) (issue *ExtendedIssue, note *Note, isSender bool, err error)
+	// RestoreIssue moves the completed todo issueID of userID from the done list back to myList, and returns it
+	RestoreIssue(userID, issueID string) (*Issue, error)
+	// UndoLastAction reverses the last pop, complete, remove, send or bulk action of userID, and returns the journal entry
+	// of the action. Only the todos of a bulk action that did not change since are restored, and returned in its entries.
+	UndoLastAction(userID string) (*JournalEntry, error)
+	// MoveIssue moves the todo issueID on listID for userID to the 1-based position of the list
+	MoveIssue(userID, listID, issueID string, position int) error
+	// PurgeCompleted deletes the todos of the done list of userID completed before the given time in milliseconds,
+	// and returns how many were deleted
+	PurgeCompleted(userID string, before int64) (int, error)
+	// PurgeUser deletes every todo of userID, leaving the copies of the users they sent todos to or received them from,
+	// and returns how many were deleted
+	PurgeUser(userID string) (int, error)
+	// GetUserIDs returns the users with at least one todo list
+	GetUserIDs() ([]string, error)
+	// SearchIssues finds the todos of userID in any list matching every term in the query
+	SearchIssues(userID, query string) ([]*SearchResult, error)
 	// GetUserName returns the readable username from userID
 	GetUserName(userID string) string
 }
@@ -54,6 +143,30 @@ type Plugin struct {
 	configuration *configuration
 
 	listManager ListManager
+
+	// scheduler runs the background jobs, like the daily digest
+	scheduler *scheduler
+
+	// webhookSender delivers the todo lifecycle events to the configured webhook URLs
+	webhookSender *webhookSender
+
+	// eventBatcher coalesces the websocket events of the bulk operations
+	eventBatcher *eventBatcher
+
+	// metrics collects the metrics served at MetricsPath
+	metrics *metrics
+
+	// userCache keeps the users recently looked up for their names and timezones
+	userCache *userCache
+
+	// rateLimiter counts the todos added and sent by each user
+	rateLimiter rateLimiter
+
+	// sqlStore keeps the todos in the Mattermost database when the SQL storage engine is set, nil otherwise
+	sqlStore *sqlListStore
+
+	// translations are the messages in other languages than English, nil if they could not be loaded
+	translations *bundle.Bundle
 }
 
 func (p *Plugin) OnActivate() error {
@@ -72,16 +185,129 @@ func (p *Plugin) OnActivate() error {
 	}
 	p.BotUserID = botID
 
-	p.listManager = NewListManager(p.API)
+	bundlePath, err := p.API.GetBundlePath()
+	if err == nil {
+		p.translations, err = loadTranslations(filepath.Join(bundlePath, I18nDir))
+	}
+	if err != nil {
+		p.API.LogWarn("Unable to load translations, messages will be in English", "err", err.Error())
+	}
+
+	p.sqlStore = nil
+	if config.useSQLStore() {
+		p.sqlStore, err = newSQLListStore(p.API)
+		if err != nil {
+			return errors.Wrap(err, "failed to open the SQL storage")
+		}
+	}
+
+	p.webhookSender = newWebhookSender(p.API)
+	p.webhookSender.Start()
+
+	p.eventBatcher = newEventBatcher(EventBatchWindow, p.publishRefreshEvent, p.publishListChange)
 
-	return p.API.RegisterCommand(getCommand())
+	if p.metrics == nil {
+		p.metrics = newMetrics()
+	}
+	if _, ok := p.API.(*metricsAPI); !ok {
+		p.API = &metricsAPI{API: p.API, metrics: p.metrics}
+	}
+
+	listManager := NewListManager(p.API, p.sendWebhooks, p.handleJiraEvents, p.metrics.handleIssueEvent, p.recordCompletion, p.recordReceiver, p.recordAudit, p.recordDueUser)
+	listManager.metrics = p.metrics
+	if p.sqlStore != nil {
+		listManager.setStore(p.sqlStore)
+	}
+
+	p.userCache = newUserCache(p.API, UserCacheTTL)
+	listManager.users = p.userCache
+	listManager.changes = p.queueListChange
+	p.listManager = listManager
+
+	p.scheduler = newScheduler(p.API, SchedulerInterval,
+		scheduledJob{name: "digest", run: p.runDigestJob},
+		scheduledJob{name: "github", run: p.runGitHubRefreshJob},
+		scheduledJob{name: "deferred", run: p.runDeferredNotificationsJob},
+		scheduledJob{name: "due", run: p.runDueReminderJob},
+		scheduledJob{name: "weekly_report", run: p.runWeeklyReportJob},
+		scheduledJob{name: "scheduled_sends", run: p.runScheduledSendsJob},
+		scheduledJob{name: "escalation", run: p.runEscalationJob},
+		scheduledJob{name: "retention", run: p.runRetentionJob},
+		scheduledJob{name: "overdue_emails", run: p.runOverdueEmailJob},
+	)
+	p.scheduler.Start()
+
+	return p.API.RegisterCommand(getCommand(p.localizeServer))
+}
+
+func (p *Plugin) OnDeactivate() error {
+	if p.scheduler != nil {
+		p.scheduler.Stop()
+	}
+	if p.webhookSender != nil {
+		p.webhookSender.Stop()
+	}
+	if p.eventBatcher != nil {
+		p.eventBatcher.Stop()
+	}
+	if p.sqlStore != nil {
+		if err := p.sqlStore.Close(); err != nil {
+			p.API.LogWarn("Unable to close the SQL storage", "err", err.Error())
+		}
+	}
+	return nil
 }
 
 // ServeHTTP demonstrates a plugin that handles HTTP requests by greeting the world.
 func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	// Other plugins can set any Mattermost-User-ID header, so their requests only reach the plugin API
+	if c != nil && c.SourcePluginId != "" {
+		p.servePluginAPI(recorder, r, c.SourcePluginId)
+	} else {
+		p.serveHTTP(recorder, r)
+	}
+
+	p.metrics.observe(metricHTTPRequestDuration, time.Since(start).Seconds(), "route", httpRoute(r.URL.Path), "code", strconv.Itoa(recorder.status))
+}
+
+func (p *Plugin) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, APIv2Prefix+"/") {
+		p.serveAPIv2(w, r)
+		return
+	}
+
+	if r.URL.Path == JiraWebhookPath {
+		p.serveJiraWebhook(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, CalendarPath+"/") {
+		p.serveCalendar(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, HooksPath+"/") {
+		p.serveHook(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, ActionPath+"/") {
+		p.serveAction(w, r)
+		return
+	}
+
 	switch r.URL.Path {
+	case MetricsPath:
+		p.serveMetrics(w, r)
 	case "/add":
 		p.handleAdd(w, r)
+	case DialogPath:
+		p.handleDialog(w, r)
+	case DialogSubmitPath:
+		p.handleDialogSubmit(w, r)
 	case "/list":
 		p.handleList(w, r)
 	case "/remove":
@@ -90,8 +316,22 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		p.handleComplete(w, r)
 	case "/accept":
 		p.handleAccept(w, r)
+	case "/restore":
+		p.handleRestore(w, r)
+	case "/decline":
+		p.handleDecline(w, r)
 	case "/bump":
 		p.handleBump(w, r)
+	case "/edit":
+		p.handleEdit(w, r)
+	case "/search":
+		p.handleSearch(w, r)
+	case "/stats":
+		p.handleStats(w, r)
+	case AutocompleteIssuesPath:
+		p.handleAutocompleteIssues(w, r)
+	case AutocompleteUsersPath:
+		p.handleAutocompleteUsers(w, r)
 	default:
 		http.NotFound(w, r)
 	}
@@ -101,6 +341,12 @@ type addAPIRequest struct {
 	Message string `json:"message"`
 	SendTo  string `json:"send_to"`
 	PostID  string `json:"post_id"`
+	Due     string `json:"due"`
+}
+
+type addAPIResponse struct {
+	DueAt int64  `json:"due_at,omitempty"`
+	Due   string `json:"due,omitempty"`
 }
 
 func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
@@ -119,53 +365,116 @@ func (p *Plugin) handleAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	location := p.getUserLocation(userID)
+	message, dueAt, err := extractRequestDueDate(addRequest.Message, addRequest.Due, time.Now().In(location))
+	if err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to parse due date", err)
+		return
+	}
+
+	if err = p.checkMessage(message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+		return
+	}
+
 	senderName := p.listManager.GetUserName(userID)
 
 	if addRequest.SendTo == "" {
-		err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.PostID)
+		if err = p.checkTodoLimit(userID, 1); err != nil {
+			p.handleLimitError(w, "Todo limit reached", err)
+			return
+		}
+
+		_, err = p.listManager.AddIssue(userID, message, addRequest.PostID, dueAt)
 		if err != nil {
+			p.refundRate(userID, rateActionAdd, 1)
 			p.API.LogError("Unable to add issue err=" + err.Error())
 			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
 			return
 		}
-		replyMessage := fmt.Sprintf("@%s attached a todo to this thread", senderName)
-		p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message)
+		replyMessage := p.localizeServer(msgReplyAttached, map[string]interface{}{"User": senderName})
+		p.postReplyIfNeeded(addRequest.PostID, replyMessage, message)
+		p.writeAddResponse(w, dueAt, location)
 		return
 	}
 
 	receiver, appErr := p.API.GetUserByUsername(addRequest.SendTo)
 	if appErr != nil {
 		p.API.LogError("username not valid, err=" + appErr.Error())
-		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to find user", err)
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to find user", appErr)
 		return
 	}
 
 	if receiver.Id == userID {
-		err = p.listManager.AddIssue(userID, addRequest.Message, addRequest.PostID)
+		if err = p.checkTodoLimit(userID, 1); err != nil {
+			p.handleLimitError(w, "Todo limit reached", err)
+			return
+		}
+
+		_, err = p.listManager.AddIssue(userID, message, addRequest.PostID, dueAt)
 		if err != nil {
+			p.refundRate(userID, rateActionAdd, 1)
 			p.API.LogError("Unable to add issue err=" + err.Error())
 			p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to add issue", err)
 			return
 		}
-		replyMessage := fmt.Sprintf("@%s attached a todo to this thread", senderName)
-		p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message)
+		replyMessage := p.localizeServer(msgReplyAttached, map[string]interface{}{"User": senderName})
+		p.postReplyIfNeeded(addRequest.PostID, replyMessage, message)
+		p.writeAddResponse(w, dueAt, location)
 		return
 	}
 
-	issueID, err := p.listManager.SendIssue(userID, receiver.Id, addRequest.Message, addRequest.PostID)
+	if err = p.checkSendAllowed(userID, receiver, 1); err != nil {
+		p.handleLimitError(w, "Not allowed", err)
+		return
+	}
+
+	issueID, err := p.listManager.SendIssue(userID, receiver.Id, message, addRequest.PostID, dueAt)
 
 	if err != nil {
+		p.refundRate(userID, rateActionSend, 1)
 		p.API.LogError("Unable to send issue err=" + err.Error())
 		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to send issue", err)
 		return
 	}
 
-	receiverMessage := fmt.Sprintf("You have received a new Todo from @%s", senderName)
-	p.sendRefreshEvent(receiver.Id)
-	p.PostBotCustomDM(receiver.Id, receiverMessage, addRequest.Message, issueID)
+	p.notifySend(userID, receiver.Id, message, issueID, dueAt)
+
+	replyMessage := p.localizeServer(msgReplySent, map[string]interface{}{"User": senderName, "Receiver": addRequest.SendTo})
+	p.postReplyIfNeeded(addRequest.PostID, replyMessage, message)
+	p.writeAddResponse(w, dueAt, location)
+}
+
+// writeAddResponse echoes the parsed due date of an added todo back to the client
+func (p *Plugin) writeAddResponse(w http.ResponseWriter, dueAt int64, location *time.Location) {
+	response := addAPIResponse{DueAt: dueAt}
+	if dueAt != 0 {
+		response.Due = formatDueDate(dueAt, location)
+	}
+
+	b, _ := json.Marshal(response)
+	_, _ = w.Write(b)
+}
+
+// notifySend lets receiverID know that senderID sent them a todo
+func (p *Plugin) notifySend(senderID, receiverID, todo, receiverIssueID string, dueAt int64) {
+	senderName := p.listManager.GetUserName(senderID)
+	receiverMessage := p.localize(receiverID, msgNotifyReceived, map[string]interface{}{"User": senderName})
+	if dueAt != 0 {
+		receiverMessage = p.localize(receiverID, msgNotifyReceivedDue, map[string]interface{}{
+			"User": senderName,
+			"Due":  formatDueDate(dueAt, p.getUserLocation(receiverID)),
+		})
+	}
+
+	p.sendRefreshEvent(receiverID)
+	if !p.wantsSendNotifications(receiverID) {
+		return
+	}
+	p.PostBotCustomDM(receiverID, receiverMessage, todo, receiverIssueID)
 
-	replyMessage := fmt.Sprintf("@%s sent @%s a todo attached to this thread", senderName, addRequest.SendTo)
-	p.postReplyIfNeeded(addRequest.PostID, replyMessage, addRequest.Message)
+	subject := p.localize(receiverID, msgEmailReceivedSubject, map[string]interface{}{"User": senderName})
+	p.emailIfAway(receiverID, subject, receiverMessage, []string{todo})
 }
 
 func (p *Plugin) postReplyIfNeeded(postID, message, todo string) {
@@ -191,16 +500,26 @@ func (p *Plugin) handleList(w http.ResponseWriter, r *http.Request) {
 		listID = OutListKey
 	case "in":
 		listID = InListKey
+	case "done":
+		listID = DoneListKey
 	}
 
-	issues, err := p.listManager.GetIssueList(userID, listID)
+	ownerID := p.getListOwnerForRequest(w, r, userID, listID)
+	if ownerID == "" {
+		return
+	}
+
+	issues, err := p.getIssueListForRequest(w, r, ownerID, listID)
 	if err != nil {
 		p.API.LogError("Unable to get issues for user err=" + err.Error())
 		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get issues for user", err)
 		return
 	}
+	if issues == nil {
+		return
+	}
 
-	if len(issues) > 0 && r.URL.Query().Get("reminder") == "true" {
+	if ownerID == userID && len(issues) > 0 && r.URL.Query().Get("reminder") == "true" {
 		var lastReminderAt int64
 		lastReminderAt, err = p.getLastReminderTimeForUser(userID)
 		if err != nil {
@@ -209,16 +528,15 @@ func (p *Plugin) handleList(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		var timezone *time.Location
-		offset, _ := strconv.Atoi(r.Header.Get("X-Timezone-Offset"))
-		timezone = time.FixedZone("local", -60*offset)
+		timezone := p.getUserLocation(userID)
 
 		// Post reminder message if it's the next day and been more than an hour since the last post
 		now := model.GetMillis()
 		nt := time.Unix(now/1000, 0).In(timezone)
 		lt := time.Unix(lastReminderAt/1000, 0).In(timezone)
 		if nt.Sub(lt).Hours() >= 1 && (nt.Day() != lt.Day() || nt.Month() != lt.Month() || nt.Year() != lt.Year()) {
-			p.PostBotDM(userID, "Daily Reminder:\n\n"+issuesListToString(issues))
+			T := p.translator(userID)
+			p.PostBotDM(userID, T(msgReminderDaily, nil)+"\n\n"+issuesListToString(T, issues, timezone))
 			p.saveLastReminderTimeForUser(userID)
 		}
 	}
@@ -233,6 +551,82 @@ func (p *Plugin) handleList(w http.ResponseWriter, r *http.Request) {
 	w.Write(issuesJSON)
 }
 
+// getIssueListForRequest gets the whole list, or a page of it if the page or per_page query parameters are given,
+// and sets the X-Total-Count header to the number of issues in the list. If the parameters are not valid, it writes
+// the error and returns no issues.
+func (p *Plugin) getIssueListForRequest(w http.ResponseWriter, r *http.Request, userID, listID string) ([]*ExtendedIssue, error) {
+	query := r.URL.Query()
+	if query.Get("page") == "" && query.Get("per_page") == "" {
+		issues, err := p.listManager.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(issues)))
+		setDueBuckets(issues, time.Now().In(p.getUserLocation(userID)))
+		sortByDueDate(issues)
+		p.setLinks(issues)
+		return issues, nil
+	}
+
+	page, perPage := 0, DefaultPerPage
+	var err error
+	if value := query.Get("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 0 {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid page", errors.New("page must be a number starting at 0"))
+			return nil, nil
+		}
+	}
+	if value := query.Get("per_page"); value != "" {
+		if perPage, err = strconv.Atoi(value); err != nil || perPage < 1 {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid per_page", errors.New("per_page must be a positive number"))
+			return nil, nil
+		}
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+
+	issues, total, err := p.listManager.GetIssueListPage(userID, listID, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	setDueBuckets(issues, time.Now().In(p.getUserLocation(userID)))
+	sortByDueDate(issues)
+	p.setLinks(issues)
+	return issues, nil
+}
+
+func (p *Plugin) handleSearch(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to search issues", errors.New("query cannot be empty"))
+		return
+	}
+
+	results, err := p.listManager.SearchIssues(userID, query)
+	if err != nil {
+		p.API.LogError("Unable to search issues err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to search issues", err)
+		return
+	}
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		p.API.LogError("Unable marhsal search results to json err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable marhsal search results to json", err)
+		return
+	}
+
+	w.Write(resultsJSON)
+}
+
 type acceptAPIRequest struct {
 	ID string `json:"id"`
 }
@@ -260,13 +654,155 @@ func (p *Plugin) handleAccept(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	p.notifyAccept(userID, sender, acceptRequest.ID, todoMessage)
+}
+
+// notifyAccept lets the sender of the todo issueID know that userID accepted it, if they watch it
+func (p *Plugin) notifyAccept(userID, sender, issueID, todoMessage string) {
+	userName := p.listManager.GetUserName(userID)
+
+	message := p.localize(sender, msgNotifyAccepted, map[string]interface{}{"User": userName, "Todo": todoMessage})
+	p.sendRefreshEvent(sender)
+	if p.notifiesSender(sender, userID, issueID) {
+		p.PostBotDM(sender, message)
+	}
+}
+
+type restoreAPIRequest struct {
+	ID string `json:"id"`
+}
+
+func (p *Plugin) handleRestore(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var restoreRequest *restoreAPIRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&restoreRequest); err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	if err := p.checkTodoLimit(userID, 1); err != nil {
+		p.handleLimitError(w, "Todo limit reached", err)
+		return
+	}
+
+	if _, err := p.listManager.RestoreIssue(userID, restoreRequest.ID); err != nil {
+		p.refundRate(userID, rateActionAdd, 1)
+		p.API.LogError("Unable to restore issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to restore issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+}
+
+type declineAPIRequest struct {
+	ID     string `json:"id"`
+	Reason string `json:"reason"`
+}
+
+func (p *Plugin) handleDecline(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var declineRequest *declineAPIRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&declineRequest); err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	todoMessage, sender, err := p.listManager.DeclineIssue(userID, declineRequest.ID, declineRequest.Reason)
+	if err != nil {
+		p.API.LogError("Unable to decline issue err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to decline issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.notifyDecline(userID, sender, todoMessage, declineRequest.Reason)
+}
+
+// notifyDecline lets the sender of a todo know that userID declined it
+func (p *Plugin) notifyDecline(userID, sender, todoMessage, reason string) {
 	userName := p.listManager.GetUserName(userID)
 
-	message := fmt.Sprintf("@%s accepted a Todo you sent: %s", userName, todoMessage)
+	message := p.localize(sender, msgNotifyDeclined, map[string]interface{}{"User": userName, "Todo": todoMessage})
+	if reason != "" {
+		message += "\n" + p.localize(sender, msgNotifyDeclineReason, map[string]interface{}{"Reason": reason})
+	}
+
 	p.sendRefreshEvent(sender)
 	p.PostBotDM(sender, message)
 }
 
+// notifyProposal asks the sender of the todo accepted by userID to approve the change userID proposed
+func (p *Plugin) notifyProposal(userID string, issue *ExtendedIssue, proposal *Proposal) {
+	sender := issue.ForeignUserID
+	p.sendRefreshEvent(sender)
+	T := p.translator(sender)
+	p.PostBotDM(sender, T(msgNotifyProposed, map[string]interface{}{
+		"User":   p.listManager.GetUserName(userID),
+		"Todo":   issue.Message,
+		"Change": proposalToString(T, proposal, p.getUserLocation(sender)),
+		"Number": issue.ForeignPosition + 1,
+	}))
+}
+
+// notifyForward lets the sender of a todo and its new receiver know that userID forwarded it
+func (p *Plugin) notifyForward(userID, sender, receiverID, issueID, todoMessage, note string) {
+	userName := p.listManager.GetUserName(userID)
+	senderName := p.listManager.GetUserName(sender)
+	receiverName := p.listManager.GetUserName(receiverID)
+
+	p.sendRefreshEvent(sender)
+	if p.notifiesSender(sender, receiverID, issueID) {
+		p.PostBotDM(sender, p.localize(sender, msgNotifyForwardedSent, map[string]interface{}{
+			"User":     userName,
+			"Receiver": receiverName,
+			"Todo":     todoMessage,
+			"Note":     note,
+		}))
+	}
+
+	p.sendRefreshEvent(receiverID)
+	if !p.wantsSendNotifications(receiverID) {
+		return
+	}
+	receiverMessage := p.localize(receiverID, msgNotifyForwarded, map[string]interface{}{
+		"User":   userName,
+		"Sender": senderName,
+		"Note":   note,
+	})
+	p.PostBotCustomDM(receiverID, receiverMessage, todoMessage, issueID)
+}
+
+// notifyStart lets the sender of a todo userID received know that they started working on it
+func (p *Plugin) notifyStart(userID string, issue *ExtendedIssue) {
+	if issue.ForeignUserID == "" {
+		return
+	}
+
+	p.sendRefreshEvent(issue.ForeignUserID)
+	if !p.notifiesSender(issue.ForeignUserID, userID, issue.ID) {
+		return
+	}
+	p.PostBotDM(issue.ForeignUserID, p.localize(issue.ForeignUserID, msgNotifyStarted, map[string]interface{}{
+		"User": p.listManager.GetUserName(userID),
+		"Todo": issue.Message,
+	}))
+}
+
 type completeAPIRequest struct {
 	ID string `json:"id"`
 }
@@ -293,17 +829,22 @@ func (p *Plugin) handleComplete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	p.notifyCompletion(userID, issue)
+}
+
+// notifyCompletion lets the thread the todo is attached to and the sender of the todo know that userID completed it
+func (p *Plugin) notifyCompletion(userID string, issue *ExtendedIssue) {
 	userName := p.listManager.GetUserName(userID)
-	replyMessage := fmt.Sprintf("@%s completed a todo attached to this thread", userName)
+	replyMessage := p.localizeServer(msgReplyCompleted, map[string]interface{}{"User": userName})
 	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
 
 	if issue.ForeignUser == "" {
 		return
 	}
 
-	message := fmt.Sprintf("@%s completed a Todo you sent: %s", userName, issue.Message)
-	p.sendRefreshEvent(issue.ForeignUser)
-	p.PostBotDM(issue.ForeignUser, message)
+	message := p.localize(issue.ForeignUserID, msgNotifyCompleted, map[string]interface{}{"User": userName, "Todo": issue.Message})
+	p.sendRefreshEvent(issue.ForeignUserID)
+	p.PostBotDM(issue.ForeignUserID, message)
 }
 
 type removeAPIRequest struct {
@@ -333,21 +874,118 @@ func (p *Plugin) handleRemove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	p.notifyRemoval(userID, issue, isSender)
+}
+
+// notifyRemoval lets the thread the todo is attached to and the other side of a sent todo know that userID removed it
+func (p *Plugin) notifyRemoval(userID string, issue *ExtendedIssue, isSender bool) {
 	userName := p.listManager.GetUserName(userID)
-	replyMessage := fmt.Sprintf("@%s removed a todo attached to this thread", userName)
+	replyMessage := p.localizeServer(msgReplyRemoved, map[string]interface{}{"User": userName})
 	p.postReplyIfNeeded(issue.PostID, replyMessage, issue.Message)
 
 	if issue.ForeignUser == "" {
 		return
 	}
 
-	message := fmt.Sprintf("@%s removed a Todo you received: %s", userName, issue.Message)
+	msg := msgNotifyRemoved
+	if isSender {
+		msg = msgNotifyDeclined
+	}
+	message := p.localize(issue.ForeignUserID, msg, map[string]interface{}{"User": userName, "Todo": issue.Message})
+
+	p.sendRefreshEvent(issue.ForeignUserID)
+	p.PostBotDM(issue.ForeignUserID, message)
+}
+
+// notifyUndo lets the foreign user of the undone action know that their lists changed
+func (p *Plugin) notifyUndo(userID string, entry *JournalEntry) {
+	for _, bulkEntry := range entry.Entries {
+		p.notifyUndo(userID, bulkEntry)
+	}
+
+	if entry.ForeignUserID == "" || (entry.Action != JournalActionSend && entry.ForeignIssue == nil) {
+		return
+	}
+
+	userName := p.listManager.GetUserName(userID)
+
+	var msg *message
+	switch entry.Action {
+	case JournalActionComplete:
+		msg = msgNotifyReopened
+	case JournalActionRemove:
+		msg = msgNotifyRestored
+	case JournalActionSend:
+		msg = msgNotifyTookBack
+	default:
+		return
+	}
+
+	p.sendRefreshEvent(entry.ForeignUserID)
+	p.PostBotDM(entry.ForeignUserID, p.localize(entry.ForeignUserID, msg, map[string]interface{}{"User": userName, "Todo": entry.Issue.Message}))
+}
+
+type editAPIRequest struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (p *Plugin) handleEdit(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	var editRequest *editAPIRequest
+	decoder := json.NewDecoder(r.Body)
+	err := decoder.Decode(&editRequest)
+	if err != nil {
+		p.API.LogError("Unable to decode JSON err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", err)
+		return
+	}
+
+	if editRequest.Message == "" {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to edit issue", errors.New("message cannot be empty"))
+		return
+	}
+
+	if err = p.checkMessage(editRequest.Message); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to edit issue", err)
+		return
+	}
+
+	oldMessage, foreignUserID, isSender, err := p.listManager.EditIssue(userID, editRequest.ID, editRequest.Message)
+	if err != nil {
+		p.API.LogError("Unable to edit issue, err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to edit issue", err)
+		return
+	}
+
+	p.sendRefreshEvent(userID)
+	p.notifyEdit(userID, foreignUserID, editRequest.ID, isSender, oldMessage, editRequest.Message)
+}
+
+// notifyEdit lets the other side of a sent todo know that userID changed the message of their copy issueID
+func (p *Plugin) notifyEdit(userID, foreignUserID, issueID string, isSender bool, oldMessage, newMessage string) {
+	if foreignUserID == "" {
+		return
+	}
+
+	userName := p.listManager.GetUserName(userID)
+
+	msg := msgNotifyEditedSent
 	if isSender {
-		message = fmt.Sprintf("@%s declined a Todo you sent: %s", userName, issue.Message)
+		msg = msgNotifyEditedReceived
 	}
+	message := p.localize(foreignUserID, msg, map[string]interface{}{"User": userName, "OldTodo": oldMessage, "Todo": newMessage})
 
-	p.sendRefreshEvent(issue.ForeignUser)
-	p.PostBotDM(issue.ForeignUser, message)
+	p.sendRefreshEvent(foreignUserID)
+	if !isSender && !p.notifiesSender(foreignUserID, userID, issueID) {
+		return
+	}
+	p.PostBotDM(foreignUserID, message)
 }
 
 type bumpAPIRequest struct {
@@ -383,13 +1021,28 @@ func (p *Plugin) handleBump(w http.ResponseWriter, r *http.Request) {
 
 	userName := p.listManager.GetUserName(userID)
 
-	message := fmt.Sprintf("@%s bumped a Todo you received.", userName)
+	message := p.localize(foreignUser, msgNotifyBumped, map[string]interface{}{"User": userName})
 
 	p.sendRefreshEvent(foreignUser)
 	p.PostBotCustomDM(foreignUser, message, todoMessage, foreignIssueID)
 }
 
+// sendRefreshEvent tells the clients of userID to fetch their lists again, if LegacyRefreshEvents is enabled. Otherwise,
+// the list changes keep them up to date.
 func (p *Plugin) sendRefreshEvent(userID string) {
+	if !p.getConfiguration().LegacyRefreshEvents {
+		return
+	}
+
+	p.eventBatcher.AddRefresh(userID)
+}
+
+// queueListChange publishes a change of a list along with the other changes of its user in EventBatchWindow
+func (p *Plugin) queueListChange(change *ListChange) {
+	p.eventBatcher.AddChange(change)
+}
+
+func (p *Plugin) publishRefreshEvent(userID string) {
 	p.API.PublishWebSocketEvent(
 		WSEventRefresh,
 		nil,
@@ -397,6 +1050,81 @@ func (p *Plugin) sendRefreshEvent(userID string) {
 	)
 }
 
+// publishListChange sends a change of a list to the clients of its user, with the issue as JSON
+func (p *Plugin) publishListChange(change *ListChange) {
+	payload := map[string]interface{}{
+		"list":     listKeyToName(change.ListID),
+		"issue_id": change.IssueID,
+	}
+
+	if change.Issue != nil {
+		p.setLinks([]*ExtendedIssue{change.Issue})
+		issueJSON, err := json.Marshal(change.Issue)
+		if err != nil {
+			p.API.LogError("Unable to marshal issue err=" + err.Error())
+			return
+		}
+		payload["issue"] = string(issueJSON)
+	}
+
+	if change.Order != nil {
+		payload["order"] = strings.Join(change.Order, ",")
+	}
+
+	p.API.PublishWebSocketEvent(change.Type, payload, &model.WebsocketBroadcast{UserId: change.UserID})
+}
+
+func (p *Plugin) sendChannelRefreshEvent(channelID string) {
+	p.API.PublishWebSocketEvent(
+		WSEventRefreshChannel,
+		map[string]interface{}{"channel_id": channelID},
+		&model.WebsocketBroadcast{ChannelId: channelID},
+	)
+}
+
+// notifyClaim lets the members of channelID know that userID claimed the issue from the shared list
+func (p *Plugin) notifyClaim(channelID, userID string, issue *Issue) {
+	userName := p.listManager.GetUserName(userID)
+	_, appErr := p.API.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   p.localizeServer(msgReplyClaimed, map[string]interface{}{"User": userName, "Todo": issue.Message}),
+	})
+	if appErr != nil {
+		p.API.LogError("Unable to post claim message err=" + appErr.Error())
+	}
+}
+
+// isChannelMember checks whether userID is a member of channelID, and so can use its shared list
+func (p *Plugin) isChannelMember(channelID, userID string) bool {
+	member, appErr := p.API.GetChannelMember(channelID, userID)
+	return appErr == nil && member != nil
+}
+
+// setLinks sets the permalink of the post of every issue attached to one, and the download links of their files
+func (p *Plugin) setLinks(issues []*ExtendedIssue) {
+	siteURL := p.siteURL()
+	for _, issue := range issues {
+		if issue.PostID != "" {
+			issue.Permalink = fmt.Sprintf("%s/_redirect/pl/%s", siteURL, issue.PostID)
+		}
+		setFileURLs(siteURL, issue.Files)
+	}
+}
+
+// siteURL returns the site URL of the server, without a trailing slash
+func (p *Plugin) siteURL() string {
+	if config := p.API.GetConfig(); config != nil && config.ServiceSettings.SiteURL != nil {
+		return strings.TrimSuffix(*config.ServiceSettings.SiteURL, "/")
+	}
+	return ""
+}
+
+// pluginURL returns the absolute URL of path in the plugin, using the site URL of the server
+func (p *Plugin) pluginURL(path string) string {
+	return fmt.Sprintf("%s/plugins/%s%s", p.siteURL(), manifest.Id, path)
+}
+
 func (p *Plugin) handleErrorWithCode(w http.ResponseWriter, code int, errTitle string, err error) {
 	w.WriteHeader(code)
 	b, _ := json.Marshal(struct {
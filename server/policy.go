@@ -0,0 +1,132 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SendPolicyEveryone lets users send todos to anyone
+	SendPolicyEveryone = "everyone"
+	// SendPolicyTeam only lets users send todos to the members of their teams
+	SendPolicyTeam = "team"
+	// SendPolicyDisabled does not let users send todos
+	SendPolicyDisabled = "disabled"
+)
+
+var errSendDisabled = newLocalizedError(msgErrSendDisabled, nil)
+
+var errChannelListsDisabled = newLocalizedError(msgErrChannelListsDisabled, nil)
+
+// checkMessage validates the message of a todo, including the maximum length set by the system admin
+func (p *Plugin) checkMessage(message string) error {
+	if err := validateMessage(message); err != nil {
+		return err
+	}
+
+	if maxLength := p.getConfiguration().MaxMessageLength; maxLength > 0 && utf8.RuneCountInString(message) > maxLength {
+		return newLocalizedError(msgErrMessageTooLong, map[string]interface{}{"Max": maxLength})
+	}
+
+	return nil
+}
+
+// todoRoom returns how many more todos userID can have on their list and received list, or -1 if there is no limit
+func (p *Plugin) todoRoom(userID string) (int, error) {
+	maxTodos := p.getConfiguration().MaxTodosPerUser
+	if maxTodos <= 0 {
+		return -1, nil
+	}
+
+	total := 0
+	for _, listID := range []string{MyListKey, InListKey} {
+		issues, err := p.listManager.GetIssueList(userID, listID)
+		if err != nil {
+			return 0, err
+		}
+		total += len(issues)
+	}
+
+	if total >= maxTodos {
+		return 0, nil
+	}
+	return maxTodos - total, nil
+}
+
+// checkTodoLimit checks that count more todos fit on the lists of userID, and that userID is not adding them too fast
+func (p *Plugin) checkTodoLimit(userID string, count int) error {
+	room, err := p.todoRoom(userID)
+	if err != nil {
+		return err
+	}
+
+	if room >= 0 && count > room {
+		return newLocalizedError(msgErrTooManyTodos, map[string]interface{}{"Max": p.getConfiguration().MaxTodosPerUser})
+	}
+
+	return p.checkRate(userID, rateActionAdd, count)
+}
+
+// checkSendAllowed checks that senderID may send count todos to receiver, as set by the system admin, and is not
+// sending them too fast
+func (p *Plugin) checkSendAllowed(senderID string, receiver *model.User, count int) error {
+	if err := p.checkSendPolicy(senderID, receiver, count); err != nil {
+		return err
+	}
+
+	return p.checkRate(senderID, rateActionSend, count)
+}
+
+// checkSendPolicy checks that senderID may send count todos to receiver, as set by the system admin
+func (p *Plugin) checkSendPolicy(senderID string, receiver *model.User, count int) error {
+	switch p.getConfiguration().SendPolicy {
+	case SendPolicyDisabled:
+		return errSendDisabled
+	case SendPolicyTeam:
+		shared, err := p.shareTeam(senderID, receiver.Id)
+		if err != nil {
+			return err
+		}
+		if !shared {
+			return newLocalizedError(msgErrNotTeamMember, map[string]interface{}{"User": receiver.Username})
+		}
+	}
+
+	room, err := p.todoRoom(receiver.Id)
+	if err != nil {
+		return err
+	}
+
+	if room >= 0 && count > room {
+		return newLocalizedError(msgErrReceiverFull, map[string]interface{}{"User": receiver.Username})
+	}
+
+	return nil
+}
+
+// shareTeam checks whether both users are members of a team in common
+func (p *Plugin) shareTeam(userID, otherUserID string) (bool, error) {
+	teams, appErr := p.API.GetTeamsForUser(userID)
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+
+	otherTeams, appErr := p.API.GetTeamsForUser(otherUserID)
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+
+	teamIDs := map[string]bool{}
+	for _, team := range teams {
+		teamIDs[team.Id] = true
+	}
+	for _, team := range otherTeams {
+		if teamIDs[team.Id] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurationLimits(t *testing.T) {
+	config := &configuration{MaxTodosPerUser: 100, MaxMessageLength: 500, SendPolicy: SendPolicyTeam}
+	assert.NoError(t, config.IsValid())
+
+	assert.Error(t, (&configuration{MaxTodosPerUser: -1}).IsValid())
+	assert.Error(t, (&configuration{MaxMessageLength: MaxMessageLength + 1}).IsValid())
+	assert.Error(t, (&configuration{SendPolicy: "friends"}).IsValid())
+}
+
+func TestCheckMessage(t *testing.T) {
+	p := &Plugin{}
+	assert.NoError(t, p.checkMessage(strings.Repeat("a", 20)))
+	assert.Error(t, p.checkMessage(" "))
+
+	p.setConfiguration(&configuration{MaxMessageLength: 10})
+	assert.NoError(t, p.checkMessage("short"))
+	assert.Error(t, p.checkMessage(strings.Repeat("a", 11)))
+}
+
+func TestCheckSendAllowed(t *testing.T) {
+	receiver := &model.User{Id: "receiver", Username: "bob"}
+
+	p := &Plugin{}
+	p.setConfiguration(&configuration{SendPolicy: SendPolicyDisabled})
+	assert.Equal(t, errSendDisabled, p.checkSendAllowed("sender", receiver, 1))
+
+	api := &plugintest.API{}
+	api.On("GetTeamsForUser", "sender").Return([]*model.Team{{Id: "team1"}, {Id: "team2"}}, nil)
+	api.On("GetTeamsForUser", "receiver").Return([]*model.Team{{Id: "team3"}}, nil)
+	api.On("GetTeamsForUser", "teammate").Return([]*model.Team{{Id: "team2"}}, nil)
+	p = &Plugin{}
+	p.SetAPI(api)
+	p.setConfiguration(&configuration{SendPolicy: SendPolicyTeam})
+
+	assert.Error(t, p.checkSendAllowed("sender", receiver, 1))
+	assert.NoError(t, p.checkSendAllowed("sender", &model.User{Id: "teammate", Username: "alice"}, 1))
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	rateActionAdd  = "add"
+	rateActionSend = "send"
+)
+
+// rateLimitError is returned when a user adds or sends todos faster than the system admin allows
+type rateLimitError struct {
+	action string
+	wait   time.Duration
+}
+
+func (e *rateLimitError) localize(T translateFunc) string {
+	msg := msgErrAddingTooFast
+	if e.action == rateActionSend {
+		msg = msgErrSendingTooFast
+	}
+	return T(msg, map[string]interface{}{"Wait": formatWait(T, e.wait)})
+}
+
+func (e *rateLimitError) Error() string {
+	return e.localize(renderEnglish)
+}
+
+type rateWindow struct {
+	end   time.Time
+	count int
+}
+
+// rateLimiter counts the actions of each user in fixed windows of time. Counts are kept in memory, so in a cluster
+// they apply to each server. The zero value is ready to use.
+type rateLimiter struct {
+	lock    sync.Mutex
+	windows map[string]*rateWindow
+}
+
+// take counts n actions of userID, and returns how long to wait if more than limit actions would happen within
+// period. A batch larger than the limit is let through when nothing else was done in the period, so that applying a
+// template always works eventually.
+func (r *rateLimiter) take(userID, action string, n, limit int, period time.Duration, now time.Time) time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.windows == nil {
+		r.windows = map[string]*rateWindow{}
+	}
+
+	key := action + "_" + userID
+	window, ok := r.windows[key]
+	if !ok || !now.Before(window.end) {
+		r.removeExpired(now)
+		window = &rateWindow{end: now.Add(period)}
+		r.windows[key] = window
+	}
+
+	if window.count > 0 && window.count+n > limit {
+		return window.end.Sub(now)
+	}
+
+	window.count += n
+	return 0
+}
+
+// giveBack uncounts n actions of userID taken in the current window, for actions that failed after being counted
+func (r *rateLimiter) giveBack(userID, action string, n int, now time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	window, ok := r.windows[action+"_"+userID]
+	if !ok || !now.Before(window.end) {
+		return
+	}
+
+	window.count -= n
+	if window.count < 0 {
+		window.count = 0
+	}
+}
+
+// removeExpired forgets the windows that ended, so that users who stopped adding todos are not kept in memory
+func (r *rateLimiter) removeExpired(now time.Time) {
+	for key, window := range r.windows {
+		if !now.Before(window.end) {
+			delete(r.windows, key)
+		}
+	}
+}
+
+// checkRate counts n todos added or sent by userID, and returns a rateLimitError if they go over the limit set by
+// the system admin
+func (p *Plugin) checkRate(userID, action string, n int) error {
+	limit, period := p.getConfiguration().MaxAddsPerMinute, time.Minute
+	if action == rateActionSend {
+		limit, period = p.getConfiguration().MaxSendsPerHour, time.Hour
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	if wait := p.rateLimiter.take(userID, action, n, limit, period, time.Now()); wait > 0 {
+		return &rateLimitError{action: action, wait: wait}
+	}
+	return nil
+}
+
+// refundRate gives back n todos counted by checkRate for userID, when adding or sending them failed, so that only
+// the todos actually added or sent count against the limit
+func (p *Plugin) refundRate(userID, action string, n int) {
+	p.rateLimiter.giveBack(userID, action, n, time.Now())
+}
+
+// formatWait formats how long to wait before trying again, rounded up to seconds or minutes
+func formatWait(T translateFunc, wait time.Duration) string {
+	if wait <= time.Minute {
+		seconds := int((wait + time.Second - 1) / time.Second)
+		if seconds == 1 {
+			return T(msgTimeSecond, nil)
+		}
+		return T(msgTimeSeconds, map[string]interface{}{"Count": seconds})
+	}
+
+	minutes := int((wait + time.Minute - 1) / time.Minute)
+	return T(msgTimeMinutes, map[string]interface{}{"Count": minutes})
+}
+
+// handleLimitError writes the error of checkTodoLimit or checkSendAllowed, which is a 429 if the user is going too fast
+func (p *Plugin) handleLimitError(w http.ResponseWriter, errTitle string, err error) {
+	if _, ok := err.(*rateLimitError); ok {
+		p.handleErrorWithCode(w, http.StatusTooManyRequests, "Slow down", err)
+		return
+	}
+	p.handleErrorWithCode(w, http.StatusForbidden, errTitle, err)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	now := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+	r := &rateLimiter{}
+
+	assert.Zero(t, r.take("user1", rateActionAdd, 1, 2, time.Minute, now))
+	assert.Zero(t, r.take("user1", rateActionAdd, 1, 2, time.Minute, now.Add(10*time.Second)))
+	assert.Equal(t, 40*time.Second, r.take("user1", rateActionAdd, 1, 2, time.Minute, now.Add(20*time.Second)))
+
+	// Other users and actions are counted apart
+	assert.Zero(t, r.take("user2", rateActionAdd, 1, 2, time.Minute, now.Add(20*time.Second)))
+	assert.Zero(t, r.take("user1", rateActionSend, 1, 2, time.Hour, now.Add(20*time.Second)))
+
+	// The window starts over once the period ends, forgetting the windows that ended
+	assert.Zero(t, r.take("user1", rateActionAdd, 1, 2, time.Minute, now.Add(time.Minute)))
+	assert.Len(t, r.windows, 3)
+	assert.Zero(t, r.take("user2", rateActionAdd, 1, 2, time.Minute, now.Add(2*time.Minute)))
+	assert.Len(t, r.windows, 2)
+
+	// A batch larger than the limit goes through only if nothing else was done in the period
+	assert.Zero(t, r.take("user3", rateActionAdd, 5, 2, time.Minute, now))
+	assert.NotZero(t, r.take("user3", rateActionAdd, 1, 2, time.Minute, now))
+
+	// Actions given back do not count anymore, unless their window ended
+	r.giveBack("user3", rateActionAdd, 4, now)
+	assert.Zero(t, r.take("user3", rateActionAdd, 1, 2, time.Minute, now))
+	r.giveBack("user3", rateActionAdd, 2, now.Add(time.Minute))
+	assert.Zero(t, r.take("user3", rateActionAdd, 2, 2, time.Minute, now.Add(time.Minute)))
+}
+
+func TestCheckRate(t *testing.T) {
+	p := &Plugin{}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.checkRate("user1", rateActionSend, 1))
+	}
+
+	p.setConfiguration(&configuration{MaxSendsPerHour: 1})
+	assert.NoError(t, p.checkRate("user1", rateActionSend, 1))
+
+	err := p.checkRate("user1", rateActionSend, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "you are sending Todos too fast")
+	assert.NoError(t, p.checkRate("user1", rateActionAdd, 1))
+
+	// A send that failed after being counted is given back
+	p.refundRate("user1", rateActionSend, 1)
+	assert.NoError(t, p.checkRate("user1", rateActionSend, 1))
+
+	w := httptest.NewRecorder()
+	p.handleLimitError(w, "Not allowed", err)
+	assert.Equal(t, 429, w.Code)
+}
+
+func TestFormatWait(t *testing.T) {
+	assert.Equal(t, "1 second", formatWait(renderEnglish, 300*time.Millisecond))
+	assert.Equal(t, "40 seconds", formatWait(renderEnglish, 40*time.Second))
+	assert.Equal(t, "60 seconds", formatWait(renderEnglish, time.Minute))
+	assert.Equal(t, "2 minutes", formatWait(renderEnglish, 61*time.Second))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// WeeklyReportDay is the day of the week the weekly report is sent on, at DefaultDigestHour in the user's timezone
+	WeeklyReportDay = time.Monday
+	// CompletionHistoryDays is how many days of completions are kept for the streaks of the weekly report
+	CompletionHistoryDays = 365
+
+	dayLayout = "2006-01-02"
+)
+
+// recordCompletion counts the todos completed by each user per day in their timezone, for the weekly report
+func (p *Plugin) recordCompletion(event *IssueEvent) {
+	if event.Type != IssueEventCompleted {
+		return
+	}
+
+	localNow := fromMillis(event.CreateAt).In(p.getUserLocation(event.UserID))
+	oldestDay := localNow.AddDate(0, 0, -CompletionHistoryDays).Format(dayLayout)
+	if err := p.addCompletion(event.UserID, localNow.Format(dayLayout), oldestDay); err != nil {
+		p.API.LogError("cannot record completion, Err=", err.Error())
+	}
+}
+
+// runWeeklyReportJob sends the weekly report to every subscribed user whose report time has passed this week in
+// their timezone
+func (p *Plugin) runWeeklyReportJob(now time.Time) {
+	userIDs, _, err := p.getUserSet(StoreWeeklyReportUsersKey)
+	if err != nil {
+		p.API.LogError("cannot get weekly report users, Err=", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		settings, err := p.getUserSettings(userID)
+		if err != nil {
+			p.API.LogError("cannot get user settings, Err=", err.Error())
+			continue
+		}
+
+		localNow := now.In(p.getUserLocation(userID))
+		if !settings.WeeklyReport || !isWeeklyReportDue(settings.WeeklyReportSentAt, localNow) {
+			continue
+		}
+
+		if err := p.sendWeeklyReport(userID, localNow); err != nil {
+			p.API.LogError("cannot send weekly report, Err=", err.Error())
+			continue
+		}
+
+		settings.WeeklyReportSentAt = toMillis(now)
+		if err := p.saveUserSettings(userID, settings); err != nil {
+			p.API.LogError("cannot save user settings, Err=", err.Error())
+		}
+	}
+}
+
+// weeklyReportTime returns the last time the weekly report was scheduled at or before localNow
+func weeklyReportTime(localNow time.Time) time.Time {
+	daysSince := (int(localNow.Weekday()) - int(WeeklyReportDay) + 7) % 7
+	scheduled := time.Date(localNow.Year(), localNow.Month(), localNow.Day()-daysSince, DefaultDigestHour, 0, 0, 0, localNow.Location())
+	if scheduled.After(localNow) {
+		scheduled = scheduled.AddDate(0, 0, -7)
+	}
+	return scheduled
+}
+
+// isWeeklyReportDue checks whether the report time of this week has passed in localNow and the report was not sent since
+func isWeeklyReportDue(lastSentAt int64, localNow time.Time) bool {
+	return fromMillis(lastSentAt).Before(weeklyReportTime(localNow))
+}
+
+// sendWeeklyReport sends userID the report of the 7 days before localNow, which must be in the user's timezone
+func (p *Plugin) sendWeeklyReport(userID string, localNow time.Time) error {
+	completions, _, err := p.getCompletions(userID)
+	if err != nil {
+		return err
+	}
+
+	openIssues := []*ExtendedIssue{}
+	for _, listID := range []string{MyListKey, InListKey} {
+		issues, err := p.listManager.GetIssueList(userID, listID)
+		if err != nil {
+			return err
+		}
+		openIssues = append(openIssues, issues...)
+	}
+
+	T := p.translator(userID)
+	data := map[string]interface{}{
+		"Completed": completedInWeek(completions, localNow),
+		"Streak":    longestStreak(completions),
+	}
+	if oldest := oldestIssue(openIssues); oldest != nil {
+		data["Oldest"] = oldest.Message
+		data["Age"] = formatAge(T, localNow.Sub(fromMillis(oldest.CreateAt)))
+	}
+
+	return p.PostBotDM(userID, T(msgWeeklyReport, data))
+}
+
+// completedInWeek counts the todos completed during the 7 days before the day of localNow
+func completedInWeek(completions map[string]int, localNow time.Time) int {
+	count := 0
+	for i := 1; i <= 7; i++ {
+		count += completions[localNow.AddDate(0, 0, -i).Format(dayLayout)]
+	}
+	return count
+}
+
+// longestStreak returns the most consecutive days with at least one completed todo
+func longestStreak(completions map[string]int) int {
+	days := []time.Time{}
+	for day, count := range completions {
+		t, err := time.Parse(dayLayout, day)
+		if err != nil || count == 0 {
+			continue
+		}
+		days = append(days, t)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	longest, current := 0, 0
+	for i, day := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// oldestIssue returns the issue created first, or nil if there are none
+func oldestIssue(issues []*ExtendedIssue) *ExtendedIssue {
+	var oldest *ExtendedIssue
+	for _, issue := range issues {
+		if oldest == nil || issue.CreateAt < oldest.CreateAt {
+			oldest = issue
+		}
+	}
+	return oldest
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeeklyReportTime(t *testing.T) {
+	// June 1st, 2020 is a Monday
+	monday := time.Date(2020, 6, 1, DefaultDigestHour, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, monday, weeklyReportTime(monday))
+	assert.Equal(t, monday, weeklyReportTime(monday.Add(50*time.Hour)))
+	assert.Equal(t, monday.AddDate(0, 0, -7), weeklyReportTime(monday.Add(-time.Minute)))
+
+	assert.True(t, isWeeklyReportDue(toMillis(monday.Add(-time.Hour)), monday.Add(time.Minute)))
+	assert.False(t, isWeeklyReportDue(toMillis(monday.Add(time.Minute)), monday.Add(time.Hour)))
+	assert.False(t, isWeeklyReportDue(toMillis(monday.Add(-time.Hour)), monday.Add(-time.Minute)))
+}
+
+func TestCompletedInWeek(t *testing.T) {
+	completions := map[string]int{
+		"2020-05-24": 5,
+		"2020-05-25": 1,
+		"2020-05-31": 2,
+		"2020-06-01": 3,
+	}
+	assert.Equal(t, 3, completedInWeek(completions, time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestLongestStreak(t *testing.T) {
+	assert.Equal(t, 0, longestStreak(map[string]int{}))
+	assert.Equal(t, 3, longestStreak(map[string]int{
+		"2020-02-28": 1,
+		"2020-02-29": 2,
+		"2020-03-01": 1,
+		"2020-03-03": 4,
+		"2020-03-04": 1,
+		"2020-03-05": 0,
+	}))
+}
+
+func TestOldestIssue(t *testing.T) {
+	assert.Nil(t, oldestIssue(nil))
+
+	issues := []*ExtendedIssue{
+		{Issue: Issue{ID: "new", CreateAt: 300}},
+		{Issue: Issue{ID: "old", CreateAt: 100}},
+		{Issue: Issue{ID: "middle", CreateAt: 200}},
+	}
+	assert.Equal(t, "old", oldestIssue(issues).ID)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// RetentionInterval is how often the retention purge runs on its own
+	RetentionInterval = 24 * time.Hour
+	// TodoUsersPerPage is the number of keys read at once when looking for the users with todos
+	TodoUsersPerPage = 1000
+)
+
+// PurgeSummary counts what a retention purge deleted, for the audit log
+type PurgeSummary struct {
+	Users            int
+	CompletedIssues  int
+	DeactivatedUsers int
+	// DeactivatedIssues counts the todos of the deactivated users, completed ones included
+	DeactivatedIssues int
+	Failures          int
+}
+
+// runRetentionJob purges the old completed todos and the data of the deactivated users once per RetentionInterval
+func (p *Plugin) runRetentionJob(now time.Time) {
+	if !p.getConfiguration().retentionEnabled() {
+		return
+	}
+
+	lastPurge, err := p.getLastPurge()
+	if err != nil {
+		p.API.LogError("cannot get last retention purge, Err=", err.Error())
+		return
+	}
+	if now.Sub(fromMillis(lastPurge)) < RetentionInterval {
+		return
+	}
+
+	if _, err := p.purge(now, "scheduled", ""); err != nil {
+		p.API.LogError("cannot run retention purge, Err=", err.Error())
+		return
+	}
+
+	if err := p.saveLastPurge(toMillis(now)); err != nil {
+		p.API.LogError("cannot save last retention purge, Err=", err.Error())
+	}
+}
+
+// purge deletes the todos completed more than RetentionDays before now and everything kept for deactivated users,
+// as set by the system admin, and logs a summary with what triggered it and the user that asked for it, if any
+func (p *Plugin) purge(now time.Time, trigger, userID string) (*PurgeSummary, error) {
+	config := p.getConfiguration()
+	userIDs, err := p.listManager.GetUserIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PurgeSummary{Users: len(userIDs)}
+	before := toMillis(now.AddDate(0, 0, -config.RetentionDays))
+	for _, userID := range userIDs {
+		if config.PurgeDeactivatedUsers {
+			user, appErr := p.API.GetUser(userID)
+			if appErr == nil && user.DeleteAt != 0 {
+				purged, err := p.purgeUser(userID)
+				summary.DeactivatedIssues += purged
+				if err != nil {
+					p.API.LogError("cannot purge deactivated user, Err=", err.Error())
+					summary.Failures++
+					continue
+				}
+				summary.DeactivatedUsers++
+				continue
+			}
+		}
+
+		if config.RetentionDays > 0 {
+			purged, err := p.listManager.PurgeCompleted(userID, before)
+			summary.CompletedIssues += purged
+			if err != nil {
+				p.API.LogError("cannot purge completed issues, Err=", err.Error())
+				summary.Failures++
+			}
+		}
+	}
+
+	p.API.LogInfo("Todo retention purge done",
+		"trigger", trigger,
+		"user_id", userID,
+		"users", summary.Users,
+		"completed_todos", summary.CompletedIssues,
+		"deactivated_users", summary.DeactivatedUsers,
+		"deactivated_user_todos", summary.DeactivatedIssues,
+		"failures", summary.Failures,
+	)
+	return summary, nil
+}
+
+// purgeUser deletes the todos of userID and everything else kept for them, and returns how many todos were deleted
+func (p *Plugin) purgeUser(userID string) (int, error) {
+	purged, err := p.listManager.PurgeUser(userID)
+	if err != nil {
+		return purged, err
+	}
+
+	return purged, p.purgeUserData(userID)
+}
+
+// purgeSummaryToString renders the summary of a purge in the language of the system admin that asked for it
+func (p *Plugin) purgeSummaryToString(userID string, summary *PurgeSummary) string {
+	return p.localize(userID, msgPurgeSummary, map[string]interface{}{
+		"Users":             summary.Users,
+		"CompletedIssues":   summary.CompletedIssues,
+		"DeactivatedUsers":  summary.DeactivatedUsers,
+		"DeactivatedIssues": summary.DeactivatedIssues,
+		"Failures":          summary.Failures,
+	})
+}
+
+func (p *Plugin) runPurgeCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if !p.API.HasPermissionTo(extra.UserId, model.PERMISSION_MANAGE_SYSTEM) {
+		return nil, true, newLocalizedError(msgPurgeNotAllowed, nil)
+	}
+
+	if !p.getConfiguration().retentionEnabled() {
+		return nil, true, newLocalizedError(msgPurgeNothing, nil)
+	}
+
+	go func() {
+		message := p.localize(extra.UserId, msgPurgeFailed, nil)
+		summary, err := p.purge(time.Now(), "command", extra.UserId)
+		if err != nil {
+			p.API.LogError("cannot run retention purge, Err=", err.Error())
+		} else {
+			message = p.purgeSummaryToString(extra.UserId, summary)
+		}
+		if err := p.PostBotDM(extra.UserId, message); err != nil {
+			p.API.LogError("cannot post purge summary, Err=", err.Error())
+		}
+	}()
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.localize(extra.UserId, msgPurgeStarted, nil)), false, nil
+}
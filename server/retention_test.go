@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListKey(t *testing.T) {
+	userID := "ewiwmmwm6fbitxby3nibmyc4ir"
+
+	for name, tc := range map[string]struct {
+		key     string
+		ownerID string
+		listID  string
+		ok      bool
+	}{
+		"my list":       {listKey(userID, MyListKey), userID, MyListKey, true},
+		"received list": {listKey(userID, InListKey), userID, InListKey, true},
+		"sent list":     {listKey(userID, OutListKey), userID, OutListKey, true},
+		"done list":     {listKey(userID, DoneListKey), userID, DoneListKey, true},
+		"channel list":  {listKey(userID, ChannelListKey), userID, ChannelListKey, true},
+		"list index":    {listIndexKey(userID, InListKey), userID, InListKey, true},
+		"issue":         {issueKey(userID), "", "", false},
+		"settings":      {settingsKey(userID), "", "", false},
+		"too short":     {StoreListKey + "_abc", "", "", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ownerID, listID, ok := parseListKey(tc.key)
+			assert.Equal(t, tc.ownerID, ownerID)
+			assert.Equal(t, tc.listID, listID)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestRetentionEnabled(t *testing.T) {
+	assert.False(t, (&configuration{}).retentionEnabled())
+	assert.True(t, (&configuration{RetentionDays: 30}).retentionEnabled())
+	assert.True(t, (&configuration{PurgeDeactivatedUsers: true}).retentionEnabled())
+}
+
+func TestPurgeSummaryToString(t *testing.T) {
+	p := &Plugin{}
+	summary := &PurgeSummary{Users: 3, CompletedIssues: 5, DeactivatedUsers: 1, DeactivatedIssues: 2}
+	str := p.purgeSummaryToString("user1", summary)
+	assert.Contains(t, str, "Purge done, 3 users checked:")
+	assert.Contains(t, str, "* Deactivated users deleted: 1, with 2 Todos")
+	assert.NotContains(t, str, "could not be purged")
+
+	summary.Failures = 1
+	assert.Contains(t, p.purgeSummaryToString("user1", summary), "* Users that could not be purged: 1, see the server logs")
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// MaxScheduledSends is the maximum number of todos a user can schedule to send later
+const MaxScheduledSends = 20
+
+// atFlagRegexp matches the --at flag of a scheduled send, with the time either quoted or as a single word
+var atFlagRegexp = regexp.MustCompile(`\s*--at\s+(?:"([^"]*)"|'([^']*)'|(\S+))`)
+
+var errTooManyScheduledSends = newLocalizedError(msgErrTooManyScheduled, map[string]interface{}{"Max": MaxScheduledSends})
+
+var errScheduledSendNotFound = newLocalizedError(msgErrScheduledNotFound, nil)
+
+// ScheduledSend is a todo sent to ReceiverID at DeliverAt, and kept by its sender until then
+type ScheduledSend struct {
+	ID         string `json:"id"`
+	ReceiverID string `json:"receiver_id"`
+	Message    string `json:"message"`
+	DueAt      int64  `json:"due_at,omitempty"`
+	DeliverAt  int64  `json:"deliver_at"`
+	CreateAt   int64  `json:"create_at"`
+}
+
+// extractAtFlag removes the --at flag from message, and returns the rest of the message and the time of the flag.
+// It returns false if there is no --at flag.
+func extractAtFlag(message string) (string, string, bool) {
+	match := atFlagRegexp.FindStringSubmatchIndex(message)
+	if match == nil {
+		return message, "", false
+	}
+
+	phrase := ""
+	for i := 2; i < len(match); i += 2 {
+		if match[i] >= 0 {
+			phrase = message[match[i]:match[i+1]]
+			break
+		}
+	}
+
+	rest := strings.TrimSpace(strings.TrimSpace(message[:match[0]]) + " " + strings.TrimSpace(message[match[1]:]))
+	return rest, phrase, true
+}
+
+// runScheduledSendsJob delivers the scheduled sends whose time has come
+func (p *Plugin) runScheduledSendsJob(now time.Time) {
+	userIDs, _, err := p.getUserSet(StoreScheduledUsersKey)
+	if err != nil {
+		p.API.LogError("cannot get users with scheduled sends, Err=", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		sends, _, err := p.getScheduledSends(userID)
+		if err != nil {
+			p.API.LogError("cannot get scheduled sends, Err=", err.Error())
+			continue
+		}
+		if len(sends) > 0 && sends[0].DeliverAt > toMillis(now) {
+			continue
+		}
+
+		due, err := p.takeDueScheduledSends(userID, toMillis(now))
+		if err != nil {
+			p.API.LogError("cannot take scheduled sends, Err=", err.Error())
+		}
+
+		for _, send := range due {
+			if err := p.deliverScheduledSend(userID, send); err != nil {
+				p.notifyScheduledSendFailed(userID, send, err)
+			}
+		}
+	}
+}
+
+// deliverScheduledSend sends the todo senderID scheduled, as if they sent it now
+func (p *Plugin) deliverScheduledSend(senderID string, send *ScheduledSend) error {
+	receiver, appErr := p.API.GetUser(send.ReceiverID)
+	if appErr != nil || receiver.DeleteAt != 0 {
+		return newLocalizedError(msgErrReceiverInactive, nil)
+	}
+
+	if err := p.checkSendPolicy(senderID, receiver, 1); err != nil {
+		return err
+	}
+
+	receiverIssueID, err := p.listManager.SendIssue(senderID, receiver.Id, send.Message, "", send.DueAt)
+	if err != nil {
+		p.API.LogError("cannot deliver scheduled send, Err=", err.Error())
+		return newLocalizedError(msgErrTodoNotStored, nil)
+	}
+
+	p.sendRefreshEvent(senderID)
+	p.notifySend(senderID, receiver.Id, send.Message, receiverIssueID, send.DueAt)
+	return nil
+}
+
+// notifyScheduledSendFailed lets senderID know that send could not be delivered
+func (p *Plugin) notifyScheduledSendFailed(senderID string, send *ScheduledSend, reason error) {
+	message := p.localize(senderID, msgNotifyScheduledFailed, map[string]interface{}{
+		"User":   p.listManager.GetUserName(send.ReceiverID),
+		"Todo":   send.Message,
+		"Reason": p.localizeError(senderID, reason),
+	})
+	if err := p.PostBotDM(senderID, message); err != nil {
+		p.API.LogError("cannot notify failed scheduled send, Err=", err.Error())
+	}
+}
+
+// scheduleSend queues the todo for receiver at the time phrase, which is in the receiver's timezone, and returns it
+func (p *Plugin) scheduleSend(senderID string, receiver *model.User, message, phrase string, dueAt int64) (*ScheduledSend, error) {
+	now := time.Now().In(p.getUserLocation(receiver.Id))
+	deliverAt, err := parseDueDate(phrase, now)
+	if err != nil {
+		return nil, err
+	}
+	if !deliverAt.After(now) {
+		return nil, newLocalizedError(msgErrDeliveryInPast, nil)
+	}
+
+	send := &ScheduledSend{
+		ID:         model.NewId(),
+		ReceiverID: receiver.Id,
+		Message:    message,
+		DueAt:      dueAt,
+		DeliverAt:  toMillis(deliverAt),
+		CreateAt:   model.GetMillis(),
+	}
+	if err := p.addScheduledSend(senderID, send); err != nil {
+		return nil, err
+	}
+
+	return send, nil
+}
+
+// scheduledSendsToString renders the scheduled sends with their receivers, and the times in location
+func (p *Plugin) scheduledSendsToString(T translateFunc, sends []*ScheduledSend, location *time.Location) string {
+	if len(sends) == 0 {
+		return T(msgScheduledNone, nil)
+	}
+
+	str := T(msgScheduledTitle, nil) + "\n\n"
+	for i, send := range sends {
+		to := T(msgScheduledTo, map[string]interface{}{"User": p.listManager.GetUserName(send.ReceiverID), "Date": formatDueDate(send.DeliverAt, location)})
+		str += fmt.Sprintf("%d. %s\n  * %s\n", i+1, send.Message, to)
+		if send.DueAt != 0 {
+			str += "  * " + T(msgIssueDue, map[string]interface{}{"Date": formatDueDate(send.DueAt, location)}) + "\n"
+		}
+	}
+	return str
+}
+
+func (p *Plugin) runScheduledCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	sends, _, err := p.getScheduledSends(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	if len(args) == 0 || args[0] == "list" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.scheduledSendsToString(T, sends, location)), false, nil
+	}
+
+	if args[0] != "cancel" {
+		return nil, true, newLocalizedError(msgErrInvalidScheduledOption, map[string]interface{}{"Value": args[0]})
+	}
+
+	if len(args) != 2 {
+		return nil, true, newLocalizedError(msgErrScheduledNumberMissing, nil)
+	}
+	number, err := strconv.Atoi(args[1])
+	if err != nil || number < 1 || number > len(sends) {
+		return nil, true, newLocalizedError(msgErrInvalidScheduledNumber, map[string]interface{}{"Value": args[1], "Count": len(sends)})
+	}
+
+	send, err := p.removeScheduledSend(extra.UserId, sends[number-1].ID)
+	if err == errScheduledSendNotFound {
+		return nil, true, newLocalizedError(msgErrAlreadySent, nil)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseScheduledCanceled, map[string]interface{}{"User": p.listManager.GetUserName(send.ReceiverID), "Todo": send.Message})), false, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractAtFlag(t *testing.T) {
+	for name, tc := range map[string]struct {
+		message string
+		rest    string
+		phrase  string
+		found   bool
+	}{
+		"no flag":         {"Review the numbers", "Review the numbers", "", false},
+		"quoted":          {`--at "monday 9am" Review the numbers`, "Review the numbers", "monday 9am", true},
+		"single quoted":   {`Review the numbers --at 'in 2 hours'`, "Review the numbers", "in 2 hours", true},
+		"single word":     {"Review --at tomorrow the numbers", "Review the numbers", "tomorrow", true},
+		"with a due date": {`--at monday Review the numbers --due friday`, "Review the numbers --due friday", "monday", true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rest, phrase, found := extractAtFlag(tc.message)
+			assert.Equal(t, tc.rest, rest)
+			assert.Equal(t, tc.phrase, phrase)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/pkg/errors"
+)
+
+// SchedulerInterval is how often the scheduler runs its jobs
+const SchedulerInterval = time.Minute
+
+// JobLockTimeout is how long a job stays locked when the server running it stops before unlocking it
+const JobLockTimeout = 15 * time.Minute
+
+// scheduledJob is a task run on every tick of the scheduler. Jobs are responsible of deciding
+// whether there is something due at the given time.
+type scheduledJob struct {
+	name string
+	run  func(now time.Time)
+}
+
+type scheduler struct {
+	api      plugin.API
+	interval time.Duration
+	jobs     []scheduledJob
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// newScheduler creates a scheduler running jobs every interval
+func newScheduler(api plugin.API, interval time.Duration, jobs ...scheduledJob) *scheduler {
+	return &scheduler{
+		api:      api,
+		interval: interval,
+		jobs:     jobs,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the jobs in the background until Stop is called
+func (s *scheduler) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case now := <-ticker.C:
+				s.runJobs(now)
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler and waits for the running jobs to finish
+func (s *scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *scheduler) runJobs(now time.Time) {
+	for _, job := range s.jobs {
+		s.runJob(job, now)
+	}
+}
+
+// runJob runs the job if no other server of the cluster ran it in the same interval and none is still running it.
+// Every server runs a scheduler, so the KV store is used to make sure a job runs once per interval across the cluster.
+func (s *scheduler) runJob(job scheduledJob, now time.Time) {
+	claimed, err := s.claimRun(job.name, now)
+	if err != nil {
+		s.api.LogError("cannot claim scheduled job", "job", job.name, "err", err.Error())
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	locked, err := s.lock(job.name, now)
+	if err != nil {
+		s.api.LogError("cannot lock scheduled job", "job", job.name, "err", err.Error())
+		return
+	}
+	if !locked {
+		return
+	}
+	defer s.unlock(job.name)
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.api.LogError("scheduled job failed", "job", job.name, "err", fmt.Sprint(r))
+		}
+	}()
+
+	job.run(now)
+}
+
+// claimRun claims the run of the job for the interval now falls in. Only the first server claiming it gets true.
+func (s *scheduler) claimRun(jobName string, now time.Time) (bool, error) {
+	slot := now.Truncate(s.interval).Unix()
+	expire := int64((2 * s.interval) / time.Second)
+	if expire < 1 {
+		expire = 1
+	}
+
+	ok, appErr := s.api.KVSetWithOptions(jobRunKey(jobName, slot), []byte(strconv.FormatInt(slot, 10)), model.PluginKVSetOptions{
+		Atomic:          true,
+		OldValue:        nil,
+		ExpireInSeconds: expire,
+	})
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+	return ok, nil
+}
+
+// lock locks the job while it runs, so that a run taking longer than the interval does not overlap with the next one
+// on another server
+func (s *scheduler) lock(jobName string, now time.Time) (bool, error) {
+	ok, appErr := s.api.KVSetWithOptions(jobLockKey(jobName), []byte(strconv.FormatInt(now.Unix(), 10)), model.PluginKVSetOptions{
+		Atomic:          true,
+		OldValue:        nil,
+		ExpireInSeconds: int64(JobLockTimeout / time.Second),
+	})
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+	return ok, nil
+}
+
+func (s *scheduler) unlock(jobName string) {
+	if appErr := s.api.KVDelete(jobLockKey(jobName)); appErr != nil {
+		s.api.LogError("cannot unlock scheduled job", "job", jobName, "err", appErr.Error())
+	}
+}
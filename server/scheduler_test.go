@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSchedulerRunJobOncePerInterval(t *testing.T) {
+	now := time.Date(2020, 6, 1, 9, 0, 30, 0, time.UTC)
+	slot := now.Truncate(time.Minute).Unix()
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", jobRunKey("test", slot), mock.Anything, mock.Anything).Return(true, nil).Once()
+	api.On("KVSetWithOptions", jobRunKey("test", slot), mock.Anything, mock.Anything).Return(false, nil)
+	api.On("KVSetWithOptions", jobLockKey("test"), mock.Anything, mock.Anything).Return(true, nil)
+	api.On("KVDelete", jobLockKey("test")).Return(nil)
+
+	runs := 0
+	s := newScheduler(api, time.Minute, scheduledJob{name: "test", run: func(time.Time) { runs++ }})
+
+	// A second server ticking later in the same interval does not run the job again
+	s.runJobs(now)
+	s.runJobs(now.Add(20 * time.Second))
+	assert.Equal(t, 1, runs)
+	api.AssertNumberOfCalls(t, "KVDelete", 1)
+}
+
+func TestSchedulerSkipsLockedJob(t *testing.T) {
+	now := time.Date(2020, 6, 1, 9, 0, 30, 0, time.UTC)
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", jobRunKey("test", now.Truncate(time.Minute).Unix()), mock.Anything, mock.Anything).Return(true, nil)
+	api.On("KVSetWithOptions", jobLockKey("test"), mock.Anything, mock.Anything).Return(false, nil)
+
+	runs := 0
+	s := newScheduler(api, time.Minute, scheduledJob{name: "test", run: func(time.Time) { runs++ }})
+
+	s.runJobs(now)
+	assert.Equal(t, 0, runs)
+	api.AssertNotCalled(t, "KVDelete", mock.Anything)
+}
+
+func TestSchedulerUnlocksPanickingJob(t *testing.T) {
+	now := time.Date(2020, 6, 1, 9, 0, 30, 0, time.UTC)
+
+	api := &plugintest.API{}
+	api.On("KVSetWithOptions", mock.Anything, mock.Anything, mock.MatchedBy(func(options model.PluginKVSetOptions) bool {
+		return options.Atomic && options.OldValue == nil && options.ExpireInSeconds > 0
+	})).Return(true, nil)
+	api.On("KVDelete", jobLockKey("test")).Return(nil)
+	api.On("LogError", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+	s := newScheduler(api, time.Minute, scheduledJob{name: "test", run: func(time.Time) { panic("boom") }})
+
+	assert.NotPanics(t, func() { s.runJobs(now) })
+	api.AssertCalled(t, "KVDelete", jobLockKey("test"))
+}
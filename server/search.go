@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/pkg/errors"
+)
+
+// SearchResult is an issue matching a search, along with the list of the user it is in and its position
+type SearchResult struct {
+	ExtendedIssue
+	UserList     string `json:"user_list"`
+	UserPosition int    `json:"user_position"`
+}
+
+// searchIndexData is the inverted index of the issues of a user, keeping both the issues for each token
+// and the tokens for each issue so issues can be removed without scanning the whole index
+type searchIndexData struct {
+	Tokens map[string][]string `json:"tokens"`
+	Issues map[string][]string `json:"issues"`
+}
+
+func searchIndexKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreSearchIndexKey, userID)
+}
+
+// searchIndex maintains a per user inverted index in the KV store, so searches only load the matching issues
+type searchIndex struct {
+	api plugin.API
+}
+
+func newSearchIndex(api plugin.API) *searchIndex {
+	return &searchIndex{
+		api: api,
+	}
+}
+
+// IndexIssue adds or replaces the issue in the index of userID. foreignUserName is the name of the user that sent
+// or received the issue, if any, so issues can be found by it.
+func (s *searchIndex) IndexIssue(userID string, issue *Issue, foreignUserName string) error {
+	tokens := tokenize(issue.Message + " " + foreignUserName)
+	return s.update(userID, func(index *searchIndexData) {
+		removeFromIndex(index, issue.ID)
+		index.Issues[issue.ID] = tokens
+		for _, token := range tokens {
+			index.Tokens[token] = append(index.Tokens[token], issue.ID)
+		}
+	})
+}
+
+// indexedIssue is an issue to index along with the name of the user that sent or received it, if any
+type indexedIssue struct {
+	issue           *Issue
+	foreignUserName string
+}
+
+// Rebuild replaces the index of userID with one containing only the given issues
+func (s *searchIndex) Rebuild(userID string, issues []indexedIssue) error {
+	index := &searchIndexData{
+		Tokens: map[string][]string{},
+		Issues: map[string][]string{},
+	}
+
+	for _, ii := range issues {
+		tokens := tokenize(ii.issue.Message + " " + ii.foreignUserName)
+		index.Issues[ii.issue.ID] = tokens
+		for _, token := range tokens {
+			index.Tokens[token] = append(index.Tokens[token], ii.issue.ID)
+		}
+	}
+
+	jsonIndex, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	if appErr := s.api.KVSet(searchIndexKey(userID), jsonIndex); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return nil
+}
+
+// RemoveIssue removes the issue from the index of userID
+func (s *searchIndex) RemoveIssue(userID, issueID string) error {
+	return s.update(userID, func(index *searchIndexData) {
+		removeFromIndex(index, issueID)
+	})
+}
+
+// Search returns the ids of the issues of userID that have, for every term in the query, a token starting with it.
+// The returned bool is false when the user has no index yet.
+func (s *searchIndex) Search(userID, query string) ([]string, bool, error) {
+	index, _, err := s.get(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if index == nil {
+		return nil, false, nil
+	}
+
+	var matches map[string]bool
+	for _, term := range tokenize(query) {
+		termMatches := map[string]bool{}
+		for token, issueIDs := range index.Tokens {
+			if !strings.HasPrefix(token, term) {
+				continue
+			}
+			for _, issueID := range issueIDs {
+				if matches == nil || matches[issueID] {
+					termMatches[issueID] = true
+				}
+			}
+		}
+		matches = termMatches
+	}
+
+	issueIDs := []string{}
+	for issueID := range matches {
+		issueIDs = append(issueIDs, issueID)
+	}
+	sort.Strings(issueIDs)
+
+	return issueIDs, true, nil
+}
+
+func (s *searchIndex) get(userID string) (*searchIndexData, []byte, error) {
+	originalJSONIndex, appErr := s.api.KVGet(searchIndexKey(userID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONIndex == nil {
+		return nil, nil, nil
+	}
+
+	var index *searchIndexData
+	if err := json.Unmarshal(originalJSONIndex, &index); err != nil {
+		return nil, nil, err
+	}
+
+	return index, originalJSONIndex, nil
+}
+
+func (s *searchIndex) update(userID string, modify func(index *searchIndexData)) error {
+	for i := 0; i < StoreRetries; i++ {
+		index, originalJSONIndex, err := s.get(userID)
+		if err != nil {
+			return err
+		}
+
+		// Users without an index get it built from their lists on their first search
+		if index == nil {
+			return nil
+		}
+		if index.Tokens == nil {
+			index.Tokens = map[string][]string{}
+		}
+		if index.Issues == nil {
+			index.Issues = map[string][]string{}
+		}
+
+		modify(index)
+
+		newJSONIndex, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := s.api.KVCompareAndSet(searchIndexKey(userID), originalJSONIndex, newJSONIndex)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the index between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store search index")
+}
+
+func removeFromIndex(index *searchIndexData, issueID string) {
+	for _, token := range index.Issues[issueID] {
+		issueIDs := index.Tokens[token]
+		for i, id := range issueIDs {
+			if id == issueID {
+				issueIDs = append(issueIDs[:i], issueIDs[i+1:]...)
+				break
+			}
+		}
+
+		if len(issueIDs) == 0 {
+			delete(index.Tokens, token)
+		} else {
+			index.Tokens[token] = issueIDs
+		}
+	}
+	delete(index.Issues, issueID)
+}
+
+// tokenize splits text into unique lowercase words. Hashtags are kept both with and without the #,
+// so "#urgent" finds tags only while "urgent" finds both.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '#' && r != '_'
+	})
+
+	seen := map[string]bool{}
+	tokens := []string{}
+	add := func(token string) {
+		if token != "" && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, word := range words {
+		if strings.HasPrefix(word, "#") {
+			add(word)
+		}
+		add(strings.Trim(word, "#"))
+	}
+
+	return tokens
+}
+
+func searchResultsToString(T translateFunc, query string, results []*SearchResult, location *time.Location) string {
+	if len(results) == 0 {
+		return T(msgSearchNoResults, map[string]interface{}{"Query": query})
+	}
+
+	str := T(msgSearchTitle, map[string]interface{}{"Query": query}) + "\n\n"
+	for _, result := range results {
+		createAt := time.Unix(result.CreateAt/1000, 0).In(location)
+		position := T(msgSearchPosition, map[string]interface{}{"Number": result.UserPosition, "List": result.UserList, "Date": createAt.Format("January 2, 2006 at 15:04")})
+		str += fmt.Sprintf("* %s\n  * %s\n", result.Message, position)
+		if result.ForeignUser != "" {
+			switch result.UserList {
+			case "out":
+				str += "  * " + T(msgSearchSentTo, map[string]interface{}{"User": result.ForeignUser}) + "\n"
+			default:
+				str += "  * " + T(msgSearchReceivedFrom, map[string]interface{}{"User": result.ForeignUser}) + "\n"
+			}
+		}
+	}
+
+	return str
+}
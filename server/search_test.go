@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"review", "the", "#release", "release", "notes"}, tokenize("Review the #release notes, the #release!"))
+	assert.Equal(t, []string{}, tokenize(" ,. "))
+}
+
+func TestRemoveFromIndex(t *testing.T) {
+	index := &searchIndexData{
+		Tokens: map[string][]string{
+			"pay":     {"issue1", "issue2"},
+			"invoice": {"issue1"},
+		},
+		Issues: map[string][]string{
+			"issue1": {"pay", "invoice"},
+			"issue2": {"pay"},
+		},
+	}
+
+	removeFromIndex(index, "issue1")
+
+	assert.Equal(t, map[string][]string{"pay": {"issue2"}}, index.Tokens)
+	assert.Equal(t, map[string][]string{"issue2": {"pay"}}, index.Issues)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// MaxReminderLeadTime is the longest time before its due date a todo can be reminded of
+const MaxReminderLeadTime = 7 * 24 * time.Hour
+
+// defaultListID returns the list shown by /todo list when no list is given, as chosen in the settings of userID
+func (p *Plugin) defaultListID(userID string) string {
+	settings, err := p.getUserSettings(userID)
+	if err != nil {
+		p.API.LogError("cannot get user settings, Err=", err.Error())
+		return MyListKey
+	}
+
+	if listID, ok := parseListName(settings.DefaultList); ok {
+		return listID
+	}
+	return MyListKey
+}
+
+// wantsSendNotifications checks whether userID wants a message when someone sends them a todo
+func (p *Plugin) wantsSendNotifications(userID string) bool {
+	settings, err := p.getUserSettings(userID)
+	if err != nil {
+		p.API.LogError("cannot get user settings, Err=", err.Error())
+		return true
+	}
+	return !settings.MuteSendNotifications
+}
+
+// wantsEmails checks whether userID did not turn off the emails sent when they are away
+func (p *Plugin) wantsEmails(userID string) bool {
+	settings, err := p.getUserSettings(userID)
+	if err != nil {
+		p.API.LogError("cannot get user settings, Err=", err.Error())
+		return false
+	}
+	return !settings.MuteEmails
+}
+
+// runDueReminderJob reminds the users that chose a reminder lead time of the todos that become due within it
+func (p *Plugin) runDueReminderJob(now time.Time) {
+	userIDs, _, err := p.getUserSet(StoreDueReminderUsersKey)
+	if err != nil {
+		p.API.LogError("cannot get users with due reminders, Err=", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		settings, err := p.getUserSettings(userID)
+		if err != nil {
+			p.API.LogError("cannot get user settings, Err=", err.Error())
+			continue
+		}
+		if settings.ReminderLeadMinutes <= 0 {
+			continue
+		}
+
+		// Todos due before the last run were already reminded of, and the ones already overdue are in the digest
+		from := settings.RemindedUntil
+		if from < toMillis(now) {
+			from = toMillis(now)
+		}
+		until := toMillis(now.Add(time.Duration(settings.ReminderLeadMinutes) * time.Minute))
+		if until <= from {
+			continue
+		}
+
+		issues, err := p.listManager.GetDueIssues(userID, []string{MyListKey, InListKey}, from, until)
+		if err != nil {
+			p.API.LogError("cannot get issues for due reminder, Err=", err.Error())
+			continue
+		}
+
+		if len(issues) > 0 {
+			T := p.translator(userID)
+			location := p.getUserLocation(userID)
+			if err := p.PostBotDM(userID, T(msgReminderDueSoon, nil)+"\n\n"+issuesListToString(T, issues, location)); err != nil {
+				p.API.LogError("cannot send due reminder, Err=", err.Error())
+				continue
+			}
+		}
+
+		settings.RemindedUntil = until
+		if err := p.saveUserSettings(userID, settings); err != nil {
+			p.API.LogError("cannot save user settings, Err=", err.Error())
+		}
+	}
+}
+
+// dueSoonIssues returns the issues due after from and until the given time, both in milliseconds
+func dueSoonIssues(issues []*ExtendedIssue, from, until int64) []*ExtendedIssue {
+	dueSoon := []*ExtendedIssue{}
+	for _, issue := range issues {
+		if issue.DueAt > from && issue.DueAt <= until {
+			dueSoon = append(dueSoon, issue)
+		}
+	}
+	return dueSoon
+}
+
+// parseLeadTime parses a reminder lead time, like 30m, 2h or 1d
+func parseLeadTime(value string) (time.Duration, error) {
+	var lead time.Duration
+	var err error
+	if strings.HasSuffix(value, "d") {
+		var days int
+		days, err = strconv.Atoi(strings.TrimSuffix(value, "d"))
+		lead = time.Duration(days) * 24 * time.Hour
+	} else {
+		lead, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return 0, newLocalizedError(msgErrInvalidLeadTime, map[string]interface{}{"Value": value})
+	}
+
+	if lead < time.Minute || lead > MaxReminderLeadTime {
+		return 0, newLocalizedError(msgErrLeadTimeRange, map[string]interface{}{"MaxDays": int(MaxReminderLeadTime / (24 * time.Hour))})
+	}
+
+	return lead.Truncate(time.Minute), nil
+}
+
+// formatLeadTime formats a lead time in minutes with the largest unit it is a whole number of
+func formatLeadTime(minutes int) string {
+	switch {
+	case minutes%(24*60) == 0:
+		return fmt.Sprintf("%dd", minutes/(24*60))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func (p *Plugin) runSettingsCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) > 0 && args[0] == "digest" {
+		return p.runDigestCommand(args[1:], extra)
+	}
+
+	settings, err := p.getUserSettings(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(args) > 0 {
+		if len(args) != 2 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageSettings, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+		}
+
+		switch args[0] {
+		case "notifications":
+			if args[1] != "on" && args[1] != "off" {
+				return nil, true, newLocalizedError(msgErrInvalidOnOff, map[string]interface{}{"Value": args[1]})
+			}
+			settings.MuteSendNotifications = args[1] == "off"
+		case "list":
+			if _, ok := parseListName(args[1]); !ok {
+				return nil, true, newLocalizedError(msgErrInvalidDefaultList, map[string]interface{}{"Value": args[1]})
+			}
+			settings.DefaultList = args[1]
+		case "reminder":
+			settings.ReminderLeadMinutes = 0
+			if args[1] != "off" {
+				lead, err := parseLeadTime(args[1])
+				if err != nil {
+					return nil, true, err
+				}
+				settings.ReminderLeadMinutes = int(lead / time.Minute)
+			}
+		case "report":
+			if args[1] != "on" && args[1] != "off" {
+				return nil, true, newLocalizedError(msgErrInvalidOnOff, map[string]interface{}{"Value": args[1]})
+			}
+			if args[1] == "on" && !settings.WeeklyReport {
+				// The first report is the one of next week, not the one of the week that already started
+				settings.WeeklyReportSentAt = model.GetMillis()
+			}
+			settings.WeeklyReport = args[1] == "on"
+		case "watch":
+			if args[1] != WatchAll && args[1] != WatchSelected {
+				return nil, true, newLocalizedError(msgErrInvalidWatchOption, map[string]interface{}{"Value": args[1], "All": WatchAll, "Selected": WatchSelected})
+			}
+			settings.Watch = args[1]
+		case "emails":
+			if args[1] != "on" && args[1] != "off" {
+				return nil, true, newLocalizedError(msgErrInvalidOnOff, map[string]interface{}{"Value": args[1]})
+			}
+			settings.MuteEmails = args[1] == "off"
+		default:
+			return nil, true, newLocalizedError(msgErrInvalidSetting, map[string]interface{}{"Value": args[0]})
+		}
+
+		if err = p.saveUserSettings(extra.UserId, settings); err != nil {
+			return nil, false, err
+		}
+	}
+
+	digestSettings, err := p.getDigestSettings(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, userSettingsToString(T, settings, digestSettings)), false, nil
+}
+
+func userSettingsToString(T translateFunc, settings *UserSettings, digestSettings *DigestSettings) string {
+	defaultList := settings.DefaultList
+	if defaultList == "" {
+		defaultList = "my"
+	}
+
+	reminder := ""
+	if settings.ReminderLeadMinutes > 0 {
+		reminder = formatLeadTime(settings.ReminderLeadMinutes)
+	}
+
+	return T(msgSettingsSummary, map[string]interface{}{
+		"Digest":        digestSettingsToString(T, digestSettings),
+		"Notifications": !settings.MuteSendNotifications,
+		"List":          defaultList,
+		"Reminder":      reminder,
+		"Report":        settings.WeeklyReport,
+		"WatchSelected": settings.Watch == WatchSelected,
+		"Emails":        !settings.MuteEmails,
+	}) + "\n"
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLeadTime(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"30m": 30 * time.Minute,
+		"2h":  2 * time.Hour,
+		"1d":  24 * time.Hour,
+		"90s": time.Minute,
+	} {
+		lead, err := parseLeadTime(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, lead, value)
+	}
+
+	for _, value := range []string{"", "soon", "d", "30", "0m", "30s", "8d", "-1h"} {
+		_, err := parseLeadTime(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFormatLeadTime(t *testing.T) {
+	assert.Equal(t, "30m", formatLeadTime(30))
+	assert.Equal(t, "2h", formatLeadTime(120))
+	assert.Equal(t, "90m", formatLeadTime(90))
+	assert.Equal(t, "1d", formatLeadTime(24*60))
+}
+
+func TestDueSoonIssues(t *testing.T) {
+	issues := []*ExtendedIssue{
+		{Issue: Issue{ID: "none"}},
+		{Issue: Issue{ID: "before", DueAt: 100}},
+		{Issue: Issue{ID: "from", DueAt: 200}},
+		{Issue: Issue{ID: "within", DueAt: 250}},
+		{Issue: Issue{ID: "until", DueAt: 300}},
+		{Issue: Issue{ID: "after", DueAt: 301}},
+	}
+
+	ids := []string{}
+	for _, issue := range dueSoonIssues(issues, 200, 300) {
+		ids = append(ids, issue.ID)
+	}
+	assert.Equal(t, []string{"within", "until"}, ids)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// MaxListViewers is the maximum number of users a user can share their list with
+const MaxListViewers = 20
+
+// errListNotShared is returned when viewing the list of a user that did not share it with the viewer
+var errListNotShared = newLocalizedError(msgErrListNotShared, nil)
+
+// isSharedList checks whether listID is one of the lists the viewers of a user can see
+func isSharedList(listID string) bool {
+	return listID == MyListKey || listID == InListKey
+}
+
+// canViewList checks whether viewerID can see the lists of ownerID, because it is their own, they are a system admin
+// or ownerID shared their lists with them
+func (p *Plugin) canViewList(viewerID, ownerID string) (bool, error) {
+	if viewerID == ownerID || p.API.HasPermissionTo(viewerID, model.PERMISSION_MANAGE_SYSTEM) {
+		return true, nil
+	}
+
+	viewers, _, err := p.getUserSet(viewersKey(ownerID))
+	if err != nil {
+		return false, err
+	}
+	for _, viewer := range viewers {
+		if viewer == viewerID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getListOwnerForRequest returns the user in the user_id query parameter, or userID if there is none, after checking
+// that userID can see listID of that user. Otherwise, it writes the error and returns an empty string.
+func (p *Plugin) getListOwnerForRequest(w http.ResponseWriter, r *http.Request, userID, listID string) string {
+	ownerID := r.URL.Query().Get("user_id")
+	if ownerID == "" || ownerID == userID {
+		return userID
+	}
+
+	if !model.IsValidId(ownerID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid user", errors.New("user_id must be the ID of a user"))
+		return ""
+	}
+
+	if !isSharedList(listID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid list", errors.New("only the my and in lists of other users can be seen"))
+		return ""
+	}
+
+	allowed, err := p.canViewList(userID, ownerID)
+	if err != nil {
+		p.API.LogError("Unable to check list viewers err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to check list viewers", err)
+		return ""
+	}
+	if !allowed {
+		p.handleErrorWithCode(w, http.StatusForbidden, "Not allowed", errListNotShared)
+		return ""
+	}
+
+	return ownerID
+}
+
+// runListUserCommand shows the my and in lists of the user in args[0], or only the list in args[1], read-only
+func (p *Plugin) runListUserCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	owner, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[0], "@"))
+	if appErr != nil {
+		return nil, true, newLocalizedError(msgErrInvalidUser, map[string]interface{}{"Value": args[0]})
+	}
+
+	listIDs := []string{MyListKey, InListKey}
+	if len(args) > 1 {
+		listID, ok := parseListName(args[1])
+		if !ok || !isSharedList(listID) {
+			return nil, true, newLocalizedError(msgErrNotSharedList, map[string]interface{}{"Value": args[1]})
+		}
+		listIDs = []string{listID}
+	}
+
+	allowed, err := p.canViewList(extra.UserId, owner.Id)
+	if err != nil {
+		return nil, false, err
+	}
+	if !allowed {
+		return nil, true, errListNotShared
+	}
+
+	location := p.getUserLocation(extra.UserId)
+	responseMessage := ""
+	for _, listID := range listIDs {
+		issues, err := p.listManager.GetIssueList(owner.Id, listID)
+		if err != nil {
+			return nil, false, err
+		}
+		p.setLinks(issues)
+
+		title := msgListTitleUserMy
+		if listID == InListKey {
+			title = msgListTitleUserIn
+		}
+		responseMessage += T(title, map[string]interface{}{"User": owner.Username}) + issuesListToString(T, issues, location) + "\n\n"
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, strings.TrimSpace(responseMessage)), false, nil
+}
+
+func (p *Plugin) runShareCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	viewers, _, err := p.getUserSet(viewersKey(extra.UserId))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(args) == 0 {
+		if len(viewers) == 0 {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseNotShared, nil)), false, nil
+		}
+
+		names := []string{}
+		for _, viewer := range viewers {
+			names = append(names, "@"+p.listManager.GetUserName(viewer))
+		}
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseSharedWith, map[string]interface{}{"Users": strings.Join(names, ", ")})), false, nil
+	}
+
+	viewer, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[0], "@"))
+	if appErr != nil || viewer.IsBot || viewer.DeleteAt != 0 {
+		return nil, true, newLocalizedError(msgErrInvalidUser, map[string]interface{}{"Value": args[0]})
+	}
+	if viewer.Id == extra.UserId {
+		return nil, true, newLocalizedError(msgErrShareSelf, nil)
+	}
+
+	for _, id := range viewers {
+		if id == viewer.Id {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseAlreadyShared, map[string]interface{}{"User": viewer.Username})), false, nil
+		}
+	}
+	if len(viewers) >= MaxListViewers {
+		return nil, true, newLocalizedError(msgErrTooManyViewers, map[string]interface{}{"Max": MaxListViewers})
+	}
+
+	if err = p.updateUserSet(viewersKey(extra.UserId), viewer.Id, true); err != nil {
+		return nil, false, err
+	}
+
+	message := p.localize(viewer.Id, msgNotifyShared, map[string]interface{}{"User": p.listManager.GetUserName(extra.UserId)})
+	if err = p.PostBotDM(viewer.Id, message); err != nil {
+		p.API.LogError("cannot notify shared list, Err=", err.Error())
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseShared, map[string]interface{}{"User": viewer.Username})), false, nil
+}
+
+func (p *Plugin) runUnshareCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 {
+		return nil, true, newLocalizedError(msgErrUnshareUserMissing, nil)
+	}
+
+	viewer, appErr := p.API.GetUserByUsername(strings.TrimPrefix(args[0], "@"))
+	if appErr != nil {
+		return nil, true, newLocalizedError(msgErrInvalidUser, map[string]interface{}{"Value": args[0]})
+	}
+
+	if err := p.updateUserSet(viewersKey(extra.UserId), viewer.Id, false); err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseUnshared, map[string]interface{}{"User": viewer.Username})), false, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanViewList(t *testing.T) {
+	viewers, _ := json.Marshal([]string{"manager"})
+
+	api := &plugintest.API{}
+	api.On("HasPermissionTo", "admin", model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	api.On("HasPermissionTo", "manager", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+	api.On("HasPermissionTo", "peer", model.PERMISSION_MANAGE_SYSTEM).Return(false)
+	api.On("KVGet", viewersKey("owner")).Return(viewers, nil)
+
+	p := &Plugin{}
+	p.SetAPI(api)
+
+	for viewerID, expected := range map[string]bool{
+		"owner":   true,
+		"admin":   true,
+		"manager": true,
+		"peer":    false,
+	} {
+		allowed, err := p.canViewList(viewerID, "owner")
+		require.NoError(t, err)
+		assert.Equal(t, expected, allowed, viewerID)
+	}
+}
@@ -0,0 +1,652 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	// The drivers of the databases supported by the Mattermost server
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+
+	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StorageEngineKV stores the todos in the plugin KV store
+	StorageEngineKV = "kv"
+	// StorageEngineSQL stores the todos in tables of the Mattermost database
+	StorageEngineSQL = "sql"
+
+	driverPostgres = "postgres"
+	driverMySQL    = "mysql"
+)
+
+var postgresSchema = []string{
+	`CREATE TABLE IF NOT EXISTS todo_issues (
+		id VARCHAR(26) PRIMARY KEY,
+		message TEXT NOT NULL,
+		create_at BIGINT NOT NULL,
+		due_at BIGINT NOT NULL DEFAULT 0,
+		complete_at BIGINT NOT NULL DEFAULT 0,
+		data TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_todo_issues_due_at ON todo_issues (due_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_todo_issues_complete_at ON todo_issues (complete_at)`,
+	`CREATE TABLE IF NOT EXISTS todo_refs (
+		user_id VARCHAR(26) NOT NULL,
+		list_id VARCHAR(16) NOT NULL,
+		issue_id VARCHAR(26) NOT NULL,
+		foreign_user_id VARCHAR(26) NOT NULL DEFAULT '',
+		foreign_issue_id VARCHAR(26) NOT NULL DEFAULT '',
+		sort_order BIGINT NOT NULL,
+		PRIMARY KEY (user_id, list_id, issue_id)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_todo_refs_order ON todo_refs (user_id, list_id, sort_order)`,
+	`CREATE INDEX IF NOT EXISTS idx_todo_refs_issue ON todo_refs (user_id, issue_id)`,
+	`CREATE TABLE IF NOT EXISTS todo_journal (
+		user_id VARCHAR(26) PRIMARY KEY,
+		data TEXT NOT NULL
+	)`,
+}
+
+var mysqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS todo_issues (
+		id VARCHAR(26) PRIMARY KEY,
+		message TEXT NOT NULL,
+		create_at BIGINT NOT NULL,
+		due_at BIGINT NOT NULL DEFAULT 0,
+		complete_at BIGINT NOT NULL DEFAULT 0,
+		data MEDIUMTEXT NOT NULL,
+		INDEX idx_todo_issues_due_at (due_at),
+		INDEX idx_todo_issues_complete_at (complete_at)
+	) DEFAULT CHARACTER SET utf8mb4`,
+	`CREATE TABLE IF NOT EXISTS todo_refs (
+		user_id VARCHAR(26) NOT NULL,
+		list_id VARCHAR(16) NOT NULL,
+		issue_id VARCHAR(26) NOT NULL,
+		foreign_user_id VARCHAR(26) NOT NULL DEFAULT '',
+		foreign_issue_id VARCHAR(26) NOT NULL DEFAULT '',
+		sort_order BIGINT NOT NULL,
+		PRIMARY KEY (user_id, list_id, issue_id),
+		INDEX idx_todo_refs_order (user_id, list_id, sort_order),
+		INDEX idx_todo_refs_issue (user_id, issue_id)
+	) DEFAULT CHARACTER SET utf8mb4`,
+	`CREATE TABLE IF NOT EXISTS todo_journal (
+		user_id VARCHAR(26) PRIMARY KEY,
+		data MEDIUMTEXT NOT NULL
+	) DEFAULT CHARACTER SET utf8mb4`,
+}
+
+// sqlListStore is a ListStore keeping the todos in tables of the Mattermost database, so they can be queried without
+// reading every list. The issues are stored as JSON, with the columns used by the queries next to them.
+type sqlListStore struct {
+	db     *sql.DB
+	driver string
+}
+
+// newSQLListStore connects to the database of the Mattermost server and creates the tables of the todos if needed
+func newSQLListStore(api plugin.API) (*sqlListStore, error) {
+	settings := api.GetUnsanitizedConfig().SqlSettings
+	if settings.DriverName == nil || settings.DataSource == nil {
+		return nil, errors.New("the database settings of the server are not set")
+	}
+
+	driver := *settings.DriverName
+	if driver != driverPostgres && driver != driverMySQL {
+		return nil, errors.Errorf("the %s database is not supported", driver)
+	}
+
+	db, err := sql.Open(driver, *settings.DataSource)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open the database")
+	}
+
+	s := &sqlListStore{db: db, driver: driver}
+	if err := s.createSchema(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Close closes the connections to the database
+func (s *sqlListStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqlListStore) createSchema() error {
+	schema := postgresSchema
+	if s.driver == driverMySQL {
+		schema = mysqlSchema
+	}
+
+	for _, statement := range schema {
+		if _, err := s.db.Exec(statement); err != nil {
+			return errors.Wrap(err, "cannot create the todo tables")
+		}
+	}
+
+	return nil
+}
+
+// rebind replaces the ? placeholders of query with the placeholders of driver
+func rebind(driver, query string) string {
+	if driver != driverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// sqlQuerier is what both the database and its transactions can run
+type sqlQuerier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func (s *sqlListStore) exec(q sqlQuerier, query string, args ...interface{}) (sql.Result, error) {
+	return q.Exec(rebind(s.driver, query), args...)
+}
+
+func (s *sqlListStore) query(q sqlQuerier, query string, args ...interface{}) (*sql.Rows, error) {
+	return q.Query(rebind(s.driver, query), args...)
+}
+
+func (s *sqlListStore) queryRow(q sqlQuerier, query string, args ...interface{}) *sql.Row {
+	return q.QueryRow(rebind(s.driver, query), args...)
+}
+
+// inTransaction runs f in a transaction, committed if f succeeds and rolled back otherwise
+func (s *sqlListStore) inTransaction(f func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Wrap(err, rollbackErr.Error())
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlListStore) AddIssue(issue *Issue) error {
+	return s.insertIssue(s.db, issue)
+}
+
+func (s *sqlListStore) insertIssue(q sqlQuerier, issue *Issue) error {
+	data, err := json.Marshal(issue)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.exec(q, "INSERT INTO todo_issues (id, message, create_at, due_at, complete_at, data) VALUES (?, ?, ?, ?, ?, ?)",
+		issue.ID, issue.Message, issue.CreateAt, issue.DueAt, issue.CompleteAt, string(data))
+	return err
+}
+
+func (s *sqlListStore) ModifyIssue(issueID string, modify func(*Issue) error) (*Issue, error) {
+	var issue *Issue
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		var err error
+		issue, err = s.getIssue(tx, issueID, true)
+		if err != nil {
+			return err
+		}
+
+		if err = modify(issue); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(issue)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.exec(tx, "UPDATE todo_issues SET message = ?, due_at = ?, complete_at = ?, data = ? WHERE id = ?",
+			issue.Message, issue.DueAt, issue.CompleteAt, string(data), issueID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+func (s *sqlListStore) GetIssue(issueID string) (*Issue, error) {
+	return s.getIssue(s.db, issueID, false)
+}
+
+func (s *sqlListStore) getIssue(q sqlQuerier, issueID string, forUpdate bool) (*Issue, error) {
+	query := "SELECT data FROM todo_issues WHERE id = ?"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var data string
+	if err := s.queryRow(q, query, issueID).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("cannot find issue")
+		}
+		return nil, err
+	}
+
+	var issue *Issue
+	if err := json.Unmarshal([]byte(data), &issue); err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+func (s *sqlListStore) RemoveIssue(issueID string) error {
+	_, err := s.exec(s.db, "DELETE FROM todo_issues WHERE id = ?", issueID)
+	return err
+}
+
+func (s *sqlListStore) GetAndRemoveIssue(issueID string) (*Issue, error) {
+	issue, err := s.GetIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.RemoveIssue(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	return issue, nil
+}
+
+func (s *sqlListStore) GetIssueReference(userID, issueID, listID string) (*IssueRef, int, error) {
+	list, err := s.GetList(userID, listID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i, ir := range list {
+		if ir.IssueID == issueID {
+			return ir, i, nil
+		}
+	}
+	return nil, 0, errors.New("cannot find issue")
+}
+
+func (s *sqlListStore) GetIssueListAndReference(userID, issueID string) (string, *IssueRef, int) {
+	var listID string
+	err := s.queryRow(s.db, "SELECT list_id FROM todo_refs WHERE user_id = ? AND issue_id = ? AND list_id IN (?, ?, ?, ?)",
+		userID, issueID, MyListKey, OutListKey, InListKey, DoneListKey).Scan(&listID)
+	if err != nil {
+		return "", nil, 0
+	}
+
+	ir, n, err := s.GetIssueReference(userID, issueID, listID)
+	if err != nil {
+		return "", nil, 0
+	}
+
+	return listID, ir, n
+}
+
+func (s *sqlListStore) AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error {
+	return s.inTransaction(func(tx *sql.Tx) error {
+		list, err := s.getList(tx, userID, listID, true)
+		if err != nil {
+			return err
+		}
+
+		for _, ir := range list {
+			if ir.IssueID == issueID {
+				return errors.New("issue id already exists in list")
+			}
+		}
+
+		return s.insertReference(tx, userID, listID, &IssueRef{
+			IssueID:        issueID,
+			ForeignIssueID: foreignIssueID,
+			ForeignUserID:  foreignUserID,
+		}, nextSortOrder(list))
+	})
+}
+
+func (s *sqlListStore) insertReference(q sqlQuerier, userID, listID string, ir *IssueRef, sortOrder int64) error {
+	_, err := s.exec(q, "INSERT INTO todo_refs (user_id, list_id, issue_id, foreign_user_id, foreign_issue_id, sort_order) VALUES (?, ?, ?, ?, ?, ?)",
+		userID, listID, ir.IssueID, ir.ForeignUserID, ir.ForeignIssueID, sortOrder)
+	return err
+}
+
+func (s *sqlListStore) RemoveReference(userID, issueID, listID string) error {
+	result, err := s.exec(s.db, "DELETE FROM todo_refs WHERE user_id = ? AND list_id = ? AND issue_id = ?", userID, listID, issueID)
+	if err != nil {
+		return err
+	}
+
+	if removed, err := result.RowsAffected(); err == nil && removed == 0 {
+		return errors.New("cannot find issue")
+	}
+
+	return nil
+}
+
+func (s *sqlListStore) PopReference(userID, listID string) (*IssueRef, error) {
+	var ir *IssueRef
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		list, err := s.getList(tx, userID, listID, true)
+		if err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			return errors.New("cannot find issue")
+		}
+
+		ir = list[0].IssueRef
+		_, err = s.exec(tx, "DELETE FROM todo_refs WHERE user_id = ? AND list_id = ? AND issue_id = ?", userID, listID, ir.IssueID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ir, nil
+}
+
+func (s *sqlListStore) BumpReference(userID, issueID, listID string) error {
+	return s.MoveReference(userID, issueID, listID, 0)
+}
+
+func (s *sqlListStore) MoveReference(userID, issueID, listID string, position int) error {
+	return s.inTransaction(func(tx *sql.Tx) error {
+		list, err := s.getList(tx, userID, listID, true)
+		if err != nil {
+			return err
+		}
+
+		index := -1
+		for i, ir := range list {
+			if issueID == ir.IssueID {
+				index = i
+				break
+			}
+		}
+
+		if index == -1 {
+			return errors.New("cannot find issue")
+		}
+
+		if position < 0 || position >= len(list) {
+			return errors.New("position out of range")
+		}
+
+		ir := list[index]
+		newList := make([]*sortedIssueRef, 0, len(list))
+		newList = append(newList, list[:index]...)
+		newList = append(newList, list[index+1:]...)
+		newList = append(newList[:position], append([]*sortedIssueRef{ir}, newList[position:]...)...)
+
+		for i, ir := range newList {
+			_, err := s.exec(tx, "UPDATE todo_refs SET sort_order = ? WHERE user_id = ? AND list_id = ? AND issue_id = ?",
+				i, userID, listID, ir.IssueID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *sqlListStore) GetList(userID, listID string) ([]*IssueRef, error) {
+	list, err := s.getList(s.db, userID, listID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	irs := make([]*IssueRef, 0, len(list))
+	for _, ir := range list {
+		irs = append(irs, ir.IssueRef)
+	}
+	return irs, nil
+}
+
+// sortedIssueRef is an IssueRef with its sort order in the table
+type sortedIssueRef struct {
+	*IssueRef
+	sortOrder int64
+}
+
+// nextSortOrder returns the sort order to add a reference at the end of list
+func nextSortOrder(list []*sortedIssueRef) int64 {
+	if len(list) == 0 {
+		return 0
+	}
+	return list[len(list)-1].sortOrder + 1
+}
+
+// getList returns the references of the list in order, locking their rows until the end of the transaction if
+// forUpdate is true
+func (s *sqlListStore) getList(q sqlQuerier, userID, listID string, forUpdate bool) ([]*sortedIssueRef, error) {
+	query := "SELECT issue_id, foreign_user_id, foreign_issue_id, sort_order FROM todo_refs WHERE user_id = ? AND list_id = ? ORDER BY sort_order"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := s.query(q, query, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*sortedIssueRef{}
+	for rows.Next() {
+		ir := &sortedIssueRef{IssueRef: &IssueRef{}}
+		if err := rows.Scan(&ir.IssueID, &ir.ForeignUserID, &ir.ForeignIssueID, &ir.sortOrder); err != nil {
+			return nil, err
+		}
+		list = append(list, ir)
+	}
+
+	return list, rows.Err()
+}
+
+func (s *sqlListStore) GetUserIDs() ([]string, error) {
+	rows, err := s.query(s.db, "SELECT DISTINCT user_id FROM todo_refs WHERE list_id <> ?", ChannelListKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+func (s *sqlListStore) GetDueIssues(userID string, listIDs []string, from, until int64) ([]*IssueRef, []*Issue, error) {
+	if len(listIDs) == 0 {
+		return []*IssueRef{}, []*Issue{}, nil
+	}
+
+	args := []interface{}{userID}
+	for _, listID := range listIDs {
+		args = append(args, listID)
+	}
+	args = append(args, from, until)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(listIDs)), ", ")
+	rows, err := s.query(s.db, "SELECT r.issue_id, r.foreign_user_id, r.foreign_issue_id, i.data FROM todo_refs r "+
+		"JOIN todo_issues i ON i.id = r.issue_id WHERE r.user_id = ? AND r.list_id IN ("+placeholders+") "+
+		"AND i.due_at > ? AND i.due_at <= ? ORDER BY i.due_at, r.list_id, r.sort_order", args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	irs := []*IssueRef{}
+	issues := []*Issue{}
+	for rows.Next() {
+		ir := &IssueRef{}
+		var data string
+		if err := rows.Scan(&ir.IssueID, &ir.ForeignUserID, &ir.ForeignIssueID, &data); err != nil {
+			return nil, nil, err
+		}
+
+		var issue *Issue
+		if err := json.Unmarshal([]byte(data), &issue); err != nil {
+			return nil, nil, err
+		}
+		irs = append(irs, ir)
+		issues = append(issues, issue)
+	}
+
+	return irs, issues, rows.Err()
+}
+
+func (s *sqlListStore) GetCompletedIssueIDs(userID string, before int64) ([]string, error) {
+	rows, err := s.query(s.db, "SELECT r.issue_id FROM todo_refs r LEFT JOIN todo_issues i ON i.id = r.issue_id "+
+		"WHERE r.user_id = ? AND r.list_id = ? AND (i.id IS NULL OR (i.complete_at <> 0 AND i.complete_at < ?) "+
+		"OR (i.complete_at = 0 AND i.create_at < ?)) ORDER BY r.sort_order", userID, DoneListKey, before, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issueIDs := []string{}
+	for rows.Next() {
+		var issueID string
+		if err := rows.Scan(&issueID); err != nil {
+			return nil, err
+		}
+		issueIDs = append(issueIDs, issueID)
+	}
+
+	return issueIDs, rows.Err()
+}
+
+func (s *sqlListStore) PushJournalEntry(userID string, entry *JournalEntry) error {
+	return s.inTransaction(func(tx *sql.Tx) error {
+		journal, err := s.getJournal(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		journal = append(journal, entry)
+		if len(journal) > JournalSize {
+			journal = journal[len(journal)-JournalSize:]
+		}
+
+		return s.saveJournal(tx, userID, journal)
+	})
+}
+
+func (s *sqlListStore) PopJournalEntry(userID string) (*JournalEntry, error) {
+	var entry *JournalEntry
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		journal, err := s.getJournal(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if len(journal) == 0 {
+			return nil
+		}
+
+		entry = journal[len(journal)-1]
+		return s.saveJournal(tx, userID, journal[:len(journal)-1])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// getJournal returns the journal of userID, locking it until the end of the transaction
+func (s *sqlListStore) getJournal(tx *sql.Tx, userID string) ([]*JournalEntry, error) {
+	var data string
+	err := s.queryRow(tx, "SELECT data FROM todo_journal WHERE user_id = ? FOR UPDATE", userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var journal []*JournalEntry
+	if err := json.Unmarshal([]byte(data), &journal); err != nil {
+		return nil, err
+	}
+
+	return journal, nil
+}
+
+func (s *sqlListStore) saveJournal(q sqlQuerier, userID string, journal []*JournalEntry) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.exec(q, "DELETE FROM todo_journal WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+
+	_, err = s.exec(q, "INSERT INTO todo_journal (user_id, data) VALUES (?, ?)", userID, string(data))
+	return err
+}
+
+// importIssue stores issue, replacing the issue with the same ID if there is one
+func (s *sqlListStore) importIssue(issue *Issue) error {
+	return s.inTransaction(func(tx *sql.Tx) error {
+		if _, err := s.exec(tx, "DELETE FROM todo_issues WHERE id = ?", issue.ID); err != nil {
+			return err
+		}
+		return s.insertIssue(tx, issue)
+	})
+}
+
+// importList replaces the list listID of ownerID, a user or a channel, with the references of list
+func (s *sqlListStore) importList(ownerID, listID string, list []*IssueRef) error {
+	return s.inTransaction(func(tx *sql.Tx) error {
+		if _, err := s.exec(tx, "DELETE FROM todo_refs WHERE user_id = ? AND list_id = ?", ownerID, listID); err != nil {
+			return err
+		}
+
+		for i, ir := range list {
+			if err := s.insertReference(tx, ownerID, listID, ir, int64(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// importJournal replaces the journal of userID
+func (s *sqlListStore) importJournal(userID string, journal []*JournalEntry) error {
+	return s.inTransaction(func(tx *sql.Tx) error {
+		return s.saveJournal(tx, userID, journal)
+	})
+}
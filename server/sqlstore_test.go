@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebind(t *testing.T) {
+	query := "UPDATE todo_refs SET sort_order = ? WHERE user_id = ? AND list_id = ?"
+
+	assert.Equal(t, "UPDATE todo_refs SET sort_order = $1 WHERE user_id = $2 AND list_id = $3", rebind(driverPostgres, query))
+	assert.Equal(t, query, rebind(driverMySQL, query))
+}
+
+func TestNextSortOrder(t *testing.T) {
+	assert.Equal(t, int64(0), nextSortOrder(nil))
+	assert.Equal(t, int64(8), nextSortOrder([]*sortedIssueRef{
+		{IssueRef: &IssueRef{IssueID: "a"}, sortOrder: 2},
+		{IssueRef: &IssueRef{IssueID: "b"}, sortOrder: 7},
+	}))
+}
+
+func newTestSQLListStore(t *testing.T) (*sqlListStore, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	return &sqlListStore{db: db, driver: driverMySQL}, mock
+}
+
+const (
+	selectListForUpdate = "SELECT issue_id, foreign_user_id, foreign_issue_id, sort_order FROM todo_refs WHERE user_id = ? AND list_id = ? ORDER BY sort_order FOR UPDATE"
+	insertReference     = "INSERT INTO todo_refs (user_id, list_id, issue_id, foreign_user_id, foreign_issue_id, sort_order) VALUES (?, ?, ?, ?, ?, ?)"
+	deleteReference     = "DELETE FROM todo_refs WHERE user_id = ? AND list_id = ? AND issue_id = ?"
+	selectJournal       = "SELECT data FROM todo_journal WHERE user_id = ? FOR UPDATE"
+	deleteJournal       = "DELETE FROM todo_journal WHERE user_id = ?"
+	insertJournal       = "INSERT INTO todo_journal (user_id, data) VALUES (?, ?)"
+)
+
+func listRows(issueIDs ...string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"issue_id", "foreign_user_id", "foreign_issue_id", "sort_order"})
+	for i, issueID := range issueIDs {
+		rows.AddRow(issueID, "", "", int64(i*2))
+	}
+	return rows
+}
+
+func TestSQLListStoreAddReference(t *testing.T) {
+	t.Run("added at the end", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", MyListKey).WillReturnRows(listRows("issue1", "issue2"))
+		mock.ExpectExec(insertReference).WithArgs("user1", MyListKey, "issue3", "user2", "issue4", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.AddReference("user1", "issue3", MyListKey, "user2", "issue4"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("already in the list", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", MyListKey).WillReturnRows(listRows("issue1"))
+		mock.ExpectRollback()
+
+		assert.Error(t, s.AddReference("user1", "issue1", MyListKey, "", ""))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLListStoreRemoveReference(t *testing.T) {
+	s, mock := newTestSQLListStore(t)
+	defer s.Close()
+	mock.ExpectExec(deleteReference).WithArgs("user1", MyListKey, "issue1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(deleteReference).WithArgs("user1", MyListKey, "issue2").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, s.RemoveReference("user1", "issue1", MyListKey))
+	assert.Error(t, s.RemoveReference("user1", "issue2", MyListKey))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLListStorePopReference(t *testing.T) {
+	t.Run("first reference", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", InListKey).WillReturnRows(listRows("issue1", "issue2"))
+		mock.ExpectExec(deleteReference).WithArgs("user1", InListKey, "issue1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ir, err := s.PopReference("user1", InListKey)
+		require.NoError(t, err)
+		assert.Equal(t, "issue1", ir.IssueID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", InListKey).WillReturnRows(listRows())
+		mock.ExpectRollback()
+
+		_, err := s.PopReference("user1", InListKey)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLListStoreMoveReference(t *testing.T) {
+	update := "UPDATE todo_refs SET sort_order = ? WHERE user_id = ? AND list_id = ? AND issue_id = ?"
+
+	t.Run("moved", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", MyListKey).WillReturnRows(listRows("issue1", "issue2", "issue3"))
+		for i, issueID := range []string{"issue3", "issue1", "issue2"} {
+			mock.ExpectExec(update).WithArgs(i, "user1", MyListKey, issueID).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		require.NoError(t, s.MoveReference("user1", "issue3", MyListKey, 0))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", MyListKey).WillReturnRows(listRows("issue1", "issue2"))
+		mock.ExpectRollback()
+
+		assert.Error(t, s.MoveReference("user1", "issue1", MyListKey, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not in the list", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectListForUpdate).WithArgs("user1", MyListKey).WillReturnRows(listRows("issue1"))
+		mock.ExpectRollback()
+
+		assert.Error(t, s.MoveReference("user1", "issue2", MyListKey, 0))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLListStoreJournal(t *testing.T) {
+	first := &JournalEntry{Action: "remove", Issue: &Issue{ID: "issue1"}, ListID: MyListKey}
+	second := &JournalEntry{Action: "complete", Issue: &Issue{ID: "issue2"}, ListID: MyListKey, Position: 1}
+	journalJSON := func(journal ...*JournalEntry) string {
+		data, err := json.Marshal(journal)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("push", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectJournal).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(journalJSON(first)))
+		mock.ExpectExec(deleteJournal).WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertJournal).WithArgs("user1", journalJSON(first, second)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.PushJournalEntry("user1", second))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("push to a full journal", func(t *testing.T) {
+		full := []*JournalEntry{}
+		for i := 0; i < JournalSize; i++ {
+			full = append(full, first)
+		}
+
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectJournal).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(journalJSON(full...)))
+		mock.ExpectExec(deleteJournal).WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertJournal).WithArgs("user1", journalJSON(append(full[1:], second)...)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.PushJournalEntry("user1", second))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("pop", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectJournal).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"data"}).AddRow(journalJSON(first, second)))
+		mock.ExpectExec(deleteJournal).WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertJournal).WithArgs("user1", journalJSON(first)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		entry, err := s.PopJournalEntry("user1")
+		require.NoError(t, err)
+		assert.Equal(t, second, entry)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("pop an empty journal", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectJournal).WithArgs("user1").WillReturnRows(sqlmock.NewRows([]string{"data"}))
+		mock.ExpectCommit()
+
+		entry, err := s.PopJournalEntry("user1")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLListStoreImport(t *testing.T) {
+	t.Run("issue", func(t *testing.T) {
+		issue := &Issue{ID: "issue1", Message: "todo", CreateAt: 1, DueAt: 2, CompleteAt: 3}
+		data, err := json.Marshal(issue)
+		require.NoError(t, err)
+
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM todo_issues WHERE id = ?").WithArgs("issue1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO todo_issues (id, message, create_at, due_at, complete_at, data) VALUES (?, ?, ?, ?, ?, ?)").
+			WithArgs("issue1", "todo", int64(1), int64(2), int64(3), string(data)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.importIssue(issue))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("list", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM todo_refs WHERE user_id = ? AND list_id = ?").WithArgs("channel1", ChannelListKey).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(insertReference).WithArgs("channel1", ChannelListKey, "issue1", "user1", "", int64(0)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertReference).WithArgs("channel1", ChannelListKey, "issue2", "user2", "", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.importList("channel1", ChannelListKey, []*IssueRef{
+			{IssueID: "issue1", ForeignUserID: "user1"},
+			{IssueID: "issue2", ForeignUserID: "user2"},
+		}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed list", func(t *testing.T) {
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM todo_refs WHERE user_id = ? AND list_id = ?").WithArgs("user1", MyListKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertReference).WithArgs("user1", MyListKey, "issue1", "", "", int64(0)).WillReturnError(errors.New("duplicate key"))
+		mock.ExpectRollback()
+
+		assert.Error(t, s.importList("user1", MyListKey, []*IssueRef{{IssueID: "issue1"}}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		journal := []*JournalEntry{{Action: "remove", Issue: &Issue{ID: "issue1"}, ListID: MyListKey}}
+		data, err := json.Marshal(journal)
+		require.NoError(t, err)
+
+		s, mock := newTestSQLListStore(t)
+		defer s.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteJournal).WithArgs("user1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertJournal).WithArgs("user1", string(data)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, s.importJournal("user1", journal))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSQLListStoreGetDueIssues(t *testing.T) {
+	issue := &Issue{ID: "issue1", Message: "todo", DueAt: 200}
+	data, err := json.Marshal(issue)
+	require.NoError(t, err)
+
+	s, mock := newTestSQLListStore(t)
+	defer s.Close()
+	mock.ExpectQuery("SELECT r.issue_id, r.foreign_user_id, r.foreign_issue_id, i.data FROM todo_refs r "+
+		"JOIN todo_issues i ON i.id = r.issue_id WHERE r.user_id = ? AND r.list_id IN (?, ?) "+
+		"AND i.due_at > ? AND i.due_at <= ? ORDER BY i.due_at, r.list_id, r.sort_order").
+		WithArgs("user1", MyListKey, InListKey, int64(100), int64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"issue_id", "foreign_user_id", "foreign_issue_id", "data"}).AddRow("issue1", "user2", "issue2", string(data)))
+
+	irs, issues, err := s.GetDueIssues("user1", []string{MyListKey, InListKey}, 100, 300)
+	require.NoError(t, err)
+	assert.Equal(t, []*IssueRef{{IssueID: "issue1", ForeignUserID: "user2", ForeignIssueID: "issue2"}}, irs)
+	assert.Equal(t, []*Issue{issue}, issues)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSQLListStoreGetCompletedIssueIDs(t *testing.T) {
+	s, mock := newTestSQLListStore(t)
+	defer s.Close()
+	mock.ExpectQuery("SELECT r.issue_id FROM todo_refs r LEFT JOIN todo_issues i ON i.id = r.issue_id "+
+		"WHERE r.user_id = ? AND r.list_id = ? AND (i.id IS NULL OR (i.complete_at <> 0 AND i.complete_at < ?) "+
+		"OR (i.complete_at = 0 AND i.create_at < ?)) ORDER BY r.sort_order").
+		WithArgs("user1", DoneListKey, int64(100), int64(100)).
+		WillReturnRows(sqlmock.NewRows([]string{"issue_id"}).AddRow("issue1").AddRow("issue2"))
+
+	issueIDs, err := s.GetCompletedIssueIDs("user1", 100)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"issue1", "issue2"}, issueIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListManagerUsesIssueQueries(t *testing.T) {
+	l := NewListManager(&plugintest.API{})
+	assert.Nil(t, l.queries)
+
+	s, _ := newTestSQLListStore(t)
+	defer s.Close()
+	l.setStore(s)
+	assert.Equal(t, s, l.queries)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// StatsPeriodWeek covers the statistics of the last 7 days
+	StatsPeriodWeek = "week"
+	// StatsPeriodMonth covers the statistics of the last month
+	StatsPeriodMonth = "month"
+)
+
+// Stats are the productivity statistics of a user over a period, and the number of todos open at the moment
+type Stats struct {
+	Period string `json:"period"`
+	Since  int64  `json:"since"`
+	// Added counts the todos of the user, own or received, created since the start of the period
+	Added int `json:"added"`
+	// Completed counts the todos the user completed since the start of the period
+	Completed int `json:"completed"`
+	// AverageTimeToComplete is the average time in milliseconds it took to complete the todos of Completed
+	AverageTimeToComplete int64 `json:"average_time_to_complete"`
+	Open                  int   `json:"open"`
+	Received              int   `json:"received"`
+	Sent                  int   `json:"sent"`
+}
+
+// statsPeriodStart returns when the period ending at now started
+func statsPeriodStart(period string, now time.Time) (time.Time, error) {
+	switch period {
+	case StatsPeriodWeek:
+		return now.AddDate(0, 0, -7), nil
+	case StatsPeriodMonth:
+		return now.AddDate(0, -1, 0), nil
+	}
+	return time.Time{}, newLocalizedError(msgErrInvalidPeriod, map[string]interface{}{"Value": period, "Week": StatsPeriodWeek, "Month": StatsPeriodMonth})
+}
+
+// computeStats aggregates the lists of a user since the start of the period, in milliseconds
+func computeStats(myList, inList, outList, doneList []*ExtendedIssue, since int64) *Stats {
+	stats := &Stats{
+		Since:    since,
+		Open:     len(myList),
+		Received: len(inList),
+		Sent:     len(outList),
+	}
+
+	for _, list := range [][]*ExtendedIssue{myList, inList, doneList} {
+		for _, issue := range list {
+			if issue.CreateAt >= since {
+				stats.Added++
+			}
+		}
+	}
+
+	var total int64
+	for _, issue := range doneList {
+		if issue.CompleteAt < since {
+			continue
+		}
+		stats.Completed++
+		total += issue.CompleteAt - issue.CreateAt
+	}
+	if stats.Completed > 0 {
+		stats.AverageTimeToComplete = total / int64(stats.Completed)
+	}
+
+	return stats
+}
+
+// getStats computes the statistics of userID over the period ending at now
+func (p *Plugin) getStats(userID, period string, now time.Time) (*Stats, error) {
+	start, err := statsPeriodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	lists := map[string][]*ExtendedIssue{}
+	for _, listID := range []string{MyListKey, InListKey, OutListKey, DoneListKey} {
+		issues, err := p.listManager.GetIssueList(userID, listID)
+		if err != nil {
+			return nil, err
+		}
+		lists[listID] = issues
+	}
+
+	stats := computeStats(lists[MyListKey], lists[InListKey], lists[OutListKey], lists[DoneListKey], model.GetMillisForTime(start))
+	stats.Period = period
+	return stats, nil
+}
+
+// statsToString renders the statistics for the /todo stats command
+func statsToString(T translateFunc, stats *Stats) string {
+	average := ""
+	if stats.Completed > 0 {
+		average = formatAge(T, time.Duration(stats.AverageTimeToComplete)*time.Millisecond)
+	}
+	return T(msgStatsSummary, map[string]interface{}{
+		"Period":    stats.Period,
+		"Added":     stats.Added,
+		"Completed": stats.Completed,
+		"Average":   average,
+		"Open":      stats.Open,
+		"Received":  stats.Received,
+		"Sent":      stats.Sent,
+	}) + "\n"
+}
+
+func (p *Plugin) runStatsCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	period := StatsPeriodWeek
+	if len(args) > 0 {
+		period = args[0]
+	}
+
+	if _, err := statsPeriodStart(period, time.Now()); err != nil {
+		return nil, true, err
+	}
+
+	stats, err := p.getStats(extra.UserId, period, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, statsToString(p.translator(extra.UserId), stats)), false, nil
+}
+
+func (p *Plugin) handleStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-ID")
+	if userID == "" {
+		http.Error(w, "Not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = StatsPeriodWeek
+	}
+
+	if _, err := statsPeriodStart(period, time.Now()); err != nil {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid period", err)
+		return
+	}
+
+	stats, err := p.getStats(userID, period, time.Now())
+	if err != nil {
+		p.API.LogError("Unable to get stats err=" + err.Error())
+		p.handleErrorWithCode(w, http.StatusInternalServerError, "Unable to get stats", err)
+		return
+	}
+
+	p.writeAPIResponse(w, http.StatusOK, stats)
+}
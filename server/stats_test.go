@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStats(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	since := 100 * hour
+	issue := func(createAt, completeAt int64) *ExtendedIssue {
+		return &ExtendedIssue{Issue: Issue{CreateAt: createAt, CompleteAt: completeAt}}
+	}
+
+	myList := []*ExtendedIssue{issue(since-hour, 0), issue(since+hour, 0)}
+	inList := []*ExtendedIssue{issue(since+2*hour, 0)}
+	outList := []*ExtendedIssue{issue(since+hour, 0), issue(since+hour, 0)}
+	doneList := []*ExtendedIssue{issue(since+hour, since+3*hour), issue(since-10*hour, since+2*hour), issue(since-10*hour, since-hour)}
+
+	stats := computeStats(myList, inList, outList, doneList, since)
+	assert.Equal(t, 3, stats.Added)
+	assert.Equal(t, 2, stats.Completed)
+	assert.Equal(t, 7*hour, stats.AverageTimeToComplete)
+	assert.Equal(t, 2, stats.Open)
+	assert.Equal(t, 1, stats.Received)
+	assert.Equal(t, 2, stats.Sent)
+
+	stats = computeStats(nil, nil, nil, nil, since)
+	assert.Zero(t, stats.Completed)
+	assert.Zero(t, stats.AverageTimeToComplete)
+}
+
+func TestStatsPeriodStart(t *testing.T) {
+	now := time.Date(2020, 3, 31, 9, 0, 0, 0, time.UTC)
+
+	start, err := statsPeriodStart(StatsPeriodWeek, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 3, 24, 9, 0, 0, 0, time.UTC), start)
+
+	start, err = statsPeriodStart(StatsPeriodMonth, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC), start)
+
+	_, err = statsPeriodStart("year", now)
+	assert.Error(t, err)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
@@ -13,12 +18,82 @@ import (
 const (
 	// StoreRetries is the number of retries to use when storing lists fails on a race
 	StoreRetries = 3
-	// StoreListKey is the key used to store lists in the plugin KV store. Still "order" for backwards compatibility.
+	// StoreListKey is the key the lists were stored under as a whole, before their index and references were stored
+	// apart. Such lists are migrated the first time they are loaded.
 	StoreListKey = "order"
+	// StoreListIndexKey is the key used to store the IDs of the issues of a list in order
+	StoreListIndexKey = "index"
+	// StoreReferenceKey is the key used to store the reference to an issue on a list, with its foreign user and issue
+	StoreReferenceKey = "ref"
 	// StoreIssueKey is the key used to store issues in the plugin KV store. Still "item" for backwards compatibilty.
 	StoreIssueKey = "item"
 	// StoreReminderKey is the key used to store the last time a user was reminded
 	StoreReminderKey = "reminder"
+	// StoreDigestKey is the key used to store the digest settings of a user
+	StoreDigestKey = "digest"
+	// StoreDigestUsersKey is the key used to store the list of users subscribed to the digest
+	StoreDigestUsersKey = "digest_users"
+	// StoreSearchIndexKey is the key used to store the search index of a user
+	StoreSearchIndexKey = "search"
+	// StoreJournalKey is the key used to store the last destructive actions of a user
+	StoreJournalKey = "journal"
+	// StoreHookTokenKey is the key used to store an incoming webhook token, by the hash of the token
+	StoreHookTokenKey = "hook_token"
+	// StoreHookTokensKey is the key used to store the incoming webhook tokens of a user
+	StoreHookTokensKey = "hook_tokens"
+	// StoreJiraLinksKey is the key used to store the todos linked to a Jira issue
+	StoreJiraLinksKey = "jira_links"
+	// StoreJiraKey is the key used to store the Jira issue a todo is linked to
+	StoreJiraKey = "jira_key"
+	// StoreGitHubLinksKey is the key used to store the todos whose GitHub issues are refreshed
+	StoreGitHubLinksKey = "github_links"
+	// StoreCalendarTokenKey is the key used to store the owner of a calendar feed token, by the hash of the token
+	StoreCalendarTokenKey = "calendar_token"
+	// StoreCalendarKey is the key used to store the hash of the calendar feed token of a user
+	StoreCalendarKey = "calendar"
+	// StoreDeferredKey is the key used to store the notifications of a user queued during Do Not Disturb
+	StoreDeferredKey = "deferred"
+	// StoreDeferredUsersKey is the key used to store the list of users with queued notifications
+	StoreDeferredUsersKey = "deferred_users"
+	// StoreScheduledSendsKey is the key used to store the todos a user scheduled to send later
+	StoreScheduledSendsKey = "scheduled_sends"
+	// StoreScheduledUsersKey is the key used to store the list of users with scheduled sends
+	StoreScheduledUsersKey = "scheduled_users"
+	// StoreEscalationUsersKey is the key the users with received todos that may be escalated were stored under before
+	// StoreEscalationDueKey. They are moved to it the first time it changes.
+	StoreEscalationUsersKey = "escalation_users"
+	// StoreEscalationDueKey is the key used to store since when the oldest received todo of each user that was not
+	// escalated is waiting
+	StoreEscalationDueKey = "escalation_due"
+	// StoreOverdueUsersKey is the key used to store the list of users with todos that may become overdue
+	StoreOverdueUsersKey = "overdue_users"
+	// StoreRetentionKey is the key used to store the last time the retention purge ran
+	StoreRetentionKey = "retention"
+	// StoreAuditKey is the key used to store the audit log of a user, split in segments of AuditSegmentSize entries
+	StoreAuditKey = "audit"
+	// StoreViewersKey is the key used to store the users a user shared their lists with
+	StoreViewersKey = "viewers"
+	// StoreTemplatesKey is the key used to store the todo templates of a user
+	StoreTemplatesKey = "templates"
+	// StoreTeamTemplatesKey is the key used to store the todo templates shared with a team
+	StoreTeamTemplatesKey = "team_templates"
+	// StoreSettingsKey is the key used to store the personal settings of a user
+	StoreSettingsKey = "settings"
+	// StoreDueReminderUsersKey is the key used to store the list of users reminded of their todos before they are due
+	StoreDueReminderUsersKey = "due_reminder_users"
+	// StoreJobRunKey is the key used to claim the run of a scheduled job for an interval across a cluster
+	StoreJobRunKey = "job_run"
+	// StoreJobLockKey is the key used to lock a scheduled job while it runs on a server of a cluster
+	StoreJobLockKey = "job_lock"
+	// StoreWeeklyReportUsersKey is the key used to store the list of users subscribed to the weekly report
+	StoreWeeklyReportUsersKey = "weekly_report_users"
+	// StoreCompletionsKey is the key used to store how many todos a user completed on each day
+	StoreCompletionsKey = "completions"
+	// StoreNudgeKey is the key used to record that the receiver of a sent issue was nudged, until the cooldown ends
+	StoreNudgeKey = "nudge"
+
+	// JournalSize is the number of actions kept in the journal of a user
+	JournalSize = 10
 )
 
 // IssueRef denotes every element in any of the lists. Contains the issue that refers to,
@@ -26,8 +101,57 @@ const (
 // and the issue on that user system.
 type IssueRef struct {
 	IssueID        string `json:"issue_id"`
-	ForeignIssueID string `json:"foreign_issue_id"`
-	ForeignUserID  string `json:"foreign_user_id"`
+	ForeignIssueID string `json:"foreign_issue_id,omitempty"`
+	ForeignUserID  string `json:"foreign_user_id,omitempty"`
+}
+
+// JournalEntry records a destructive action on a todo with enough information to undo it. The issues are the copies
+// of the user and the foreign user as they were right before the action, and the positions are 0-based. A bulk
+// action holds the entries of every todo it changed, in the order they changed, so they are undone together.
+type JournalEntry struct {
+	Action          string `json:"action"`
+	Issue           *Issue `json:"issue"`
+	ListID          string `json:"list_id"`
+	Position        int    `json:"position"`
+	ForeignUserID   string `json:"foreign_user_id,omitempty"`
+	ForeignIssueID  string `json:"foreign_issue_id,omitempty"`
+	ForeignIssue    *Issue `json:"foreign_issue,omitempty"`
+	ForeignListID   string `json:"foreign_list_id,omitempty"`
+	ForeignPosition int    `json:"foreign_position,omitempty"`
+
+	Entries []*JournalEntry `json:"entries,omitempty"`
+}
+
+// DigestSettings are the preferences of a user for the daily digest
+type DigestSettings struct {
+	Enabled    bool  `json:"enabled"`
+	Hour       int   `json:"hour"`
+	Minute     int   `json:"minute"`
+	LastSentAt int64 `json:"last_sent_at"`
+}
+
+// UserSettings are the personal preferences of a user, other than the digest
+type UserSettings struct {
+	MuteSendNotifications bool   `json:"mute_send_notifications,omitempty"`
+	DefaultList           string `json:"default_list,omitempty"`
+	ReminderLeadMinutes   int    `json:"reminder_lead_minutes,omitempty"`
+	RemindedUntil         int64  `json:"reminded_until,omitempty"`
+	WeeklyReport          bool   `json:"weekly_report,omitempty"`
+	WeeklyReportSentAt    int64  `json:"weekly_report_sent_at,omitempty"`
+	MuteEmails            bool   `json:"mute_emails,omitempty"`
+	OverdueCheckedAt      int64  `json:"overdue_checked_at,omitempty"`
+	Watch                 string `json:"watch,omitempty"`
+}
+
+// setForeignIssue records the foreign copy of the issue and where it was, if it still existed
+func (e *JournalEntry) setForeignIssue(issue *Issue, listID string, position int) {
+	if issue == nil {
+		return
+	}
+
+	e.ForeignIssue = issue
+	e.ForeignListID = listID
+	e.ForeignPosition = position
 }
 
 func listKey(userID string, listID string) string {
@@ -38,10 +162,101 @@ func issueKey(issueID string) string {
 	return fmt.Sprintf("%s_%s", StoreIssueKey, issueID)
 }
 
+func listIndexKey(ownerID string, listID string) string {
+	return fmt.Sprintf("%s_%s%s", StoreListIndexKey, ownerID, listID)
+}
+
+// referenceKey is hashed, as the IDs of the owner, the list and the issue together are longer than a KV store key
+func referenceKey(ownerID, listID, issueID string) string {
+	hash := sha256.Sum256([]byte(ownerID + listID + "/" + issueID))
+	return fmt.Sprintf("%s_%s", StoreReferenceKey, base64.RawURLEncoding.EncodeToString(hash[:]))
+}
+
 func reminderKey(userID string) string {
 	return fmt.Sprintf("%s_%s", StoreReminderKey, userID)
 }
 
+func digestKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreDigestKey, userID)
+}
+
+func journalKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreJournalKey, userID)
+}
+
+func hookTokenKey(hash string) string {
+	return fmt.Sprintf("%s_%s", StoreHookTokenKey, hash)
+}
+
+func hookTokensKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreHookTokensKey, userID)
+}
+
+func jiraLinksKey(jiraIssueKey string) string {
+	return fmt.Sprintf("%s_%s", StoreJiraLinksKey, jiraIssueKey)
+}
+
+func jiraKey(issueID string) string {
+	return fmt.Sprintf("%s_%s", StoreJiraKey, issueID)
+}
+
+func calendarTokenKey(hash string) string {
+	return fmt.Sprintf("%s_%s", StoreCalendarTokenKey, hash)
+}
+
+func calendarKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreCalendarKey, userID)
+}
+
+func deferredKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreDeferredKey, userID)
+}
+
+func scheduledSendsKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreScheduledSendsKey, userID)
+}
+
+// auditKey is the key of the number of the last audit log segment of userID
+func auditKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreAuditKey, userID)
+}
+
+func auditSegmentKey(userID string, segment int) string {
+	return fmt.Sprintf("%s_%s_%d", StoreAuditKey, userID, segment)
+}
+
+func viewersKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreViewersKey, userID)
+}
+
+func settingsKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreSettingsKey, userID)
+}
+
+func templatesKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreTemplatesKey, userID)
+}
+
+func teamTemplatesKey(teamID string) string {
+	return fmt.Sprintf("%s_%s", StoreTeamTemplatesKey, teamID)
+}
+
+func jobRunKey(jobName string, slot int64) string {
+	return fmt.Sprintf("%s_%s_%d", StoreJobRunKey, jobName, slot)
+}
+
+func jobLockKey(jobName string) string {
+	return fmt.Sprintf("%s_%s", StoreJobLockKey, jobName)
+}
+
+func completionsKey(userID string) string {
+	return fmt.Sprintf("%s_%s", StoreCompletionsKey, userID)
+}
+
+func nudgeKey(issueID string) string {
+	return fmt.Sprintf("%s_%s", StoreNudgeKey, issueID)
+}
+
 type listStore struct {
 	api plugin.API
 }
@@ -67,6 +282,46 @@ func (l *listStore) AddIssue(issue *Issue) error {
 	return nil
 }
 
+func (l *listStore) ModifyIssue(issueID string, modify func(*Issue) error) (*Issue, error) {
+	for i := 0; i < StoreRetries; i++ {
+		originalJSONIssue, appErr := l.api.KVGet(issueKey(issueID))
+		if appErr != nil {
+			return nil, errors.New(appErr.Error())
+		}
+
+		if originalJSONIssue == nil {
+			return nil, errors.New("cannot find issue")
+		}
+
+		var issue *Issue
+		if err := json.Unmarshal(originalJSONIssue, &issue); err != nil {
+			return nil, err
+		}
+
+		if err := modify(issue); err != nil {
+			return nil, err
+		}
+
+		newJSONIssue, err := json.Marshal(issue)
+		if err != nil {
+			return nil, err
+		}
+
+		ok, appErr := l.api.KVCompareAndSet(issueKey(issueID), originalJSONIssue, newJSONIssue)
+		if appErr != nil {
+			return nil, errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the issue between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return issue, nil
+		}
+	}
+
+	return nil, errors.New("unable to store issue")
+}
+
 func (l *listStore) GetIssue(issueID string) (*Issue, error) {
 	originalJSONIssue, appErr := l.api.KVGet(issueKey(issueID))
 	if appErr != nil {
@@ -110,26 +365,17 @@ func (l *listStore) GetAndRemoveIssue(issueID string) (*Issue, error) {
 }
 
 func (l *listStore) GetIssueReference(userID, issueID, listID string) (*IssueRef, int, error) {
-	originalJSONList, err := l.api.KVGet(listKey(userID, listID))
+	index, _, err := l.getIndex(userID, listID)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	if originalJSONList == nil {
-		return nil, 0, errors.New("cannot load list")
-	}
-
-	var list []*IssueRef
-	jsonErr := json.Unmarshal(originalJSONList, &list)
-	if jsonErr != nil {
-		list, _, jsonErr = l.legacyIssueRef(userID, listID)
-		if list == nil {
-			return nil, 0, jsonErr
-		}
-	}
-
-	for i, ir := range list {
-		if ir.IssueID == issueID {
+	for i, id := range index {
+		if id == issueID {
+			ir, err := l.getReference(userID, listID, issueID)
+			if err != nil {
+				return nil, 0, err
+			}
 			return ir, i, nil
 		}
 	}
@@ -137,85 +383,87 @@ func (l *listStore) GetIssueReference(userID, issueID, listID string) (*IssueRef
 }
 
 func (l *listStore) GetIssueListAndReference(userID, issueID string) (string, *IssueRef, int) {
-	ir, n, _ := l.GetIssueReference(userID, issueID, MyListKey)
-	if ir != nil {
-		return MyListKey, ir, n
-	}
-
-	ir, n, _ = l.GetIssueReference(userID, issueID, OutListKey)
-	if ir != nil {
-		return OutListKey, ir, n
-	}
-
-	ir, n, _ = l.GetIssueReference(userID, issueID, InListKey)
-	if ir != nil {
-		return InListKey, ir, n
+	for _, listID := range []string{MyListKey, OutListKey, InListKey, DoneListKey} {
+		if ir, n, _ := l.GetIssueReference(userID, issueID, listID); ir != nil {
+			return listID, ir, n
+		}
 	}
 
 	return "", nil, 0
 }
 
 func (l *listStore) AddReference(userID, issueID, listID, foreignUserID, foreignIssueID string) error {
+	ir := &IssueRef{
+		IssueID:        issueID,
+		ForeignIssueID: foreignIssueID,
+		ForeignUserID:  foreignUserID,
+	}
+
+	// The reference is stored before the issue is added to the index, so every issue of an index has its reference
+	stored := false
 	for i := 0; i < StoreRetries; i++ {
-		list, originalJSONList, err := l.getList(userID, listID)
+		index, originalJSONIndex, err := l.getIndex(userID, listID)
 		if err != nil {
 			return err
 		}
 
-		for _, ir := range list {
-			if ir.IssueID == issueID {
+		for _, id := range index {
+			if id == issueID {
 				return errors.New("issue id already exists in list")
 			}
 		}
 
-		list = append(list, &IssueRef{
-			IssueID:        issueID,
-			ForeignIssueID: foreignIssueID,
-			ForeignUserID:  foreignUserID,
-		})
+		if !stored {
+			if err = l.setReference(userID, listID, ir); err != nil {
+				return err
+			}
+			stored = true
+		}
 
-		ok, err := l.saveList(userID, listID, list, originalJSONList)
+		ok, err := l.saveIndex(userID, listID, append(index, issueID), originalJSONIndex)
 		if err != nil {
+			l.deleteReference(userID, listID, issueID)
 			return err
 		}
 
-		// If err is nil but ok is false, then something else updated the installs between the get and set above
+		// If err is nil but ok is false, then something else updated the index between the get and set above
 		// so we need to try again, otherwise we can return
 		if ok {
 			return nil
 		}
 	}
 
-	return errors.New("unable to store installation")
+	l.deleteReference(userID, listID, issueID)
+	return errors.New("unable to store list")
 }
 
 func (l *listStore) RemoveReference(userID, issueID, listID string) error {
 	for i := 0; i < StoreRetries; i++ {
-		list, originalJSONList, err := l.getList(userID, listID)
+		index, originalJSONIndex, err := l.getIndex(userID, listID)
 		if err != nil {
 			return err
 		}
 
-		found := false
-		for i, ir := range list {
-			if ir.IssueID == issueID {
-				list = append(list[:i], list[i+1:]...)
-				found = true
+		newIndex := make([]string, 0, len(index))
+		for _, id := range index {
+			if id != issueID {
+				newIndex = append(newIndex, id)
 			}
 		}
 
-		if !found {
+		if len(newIndex) == len(index) {
 			return errors.New("cannot find issue")
 		}
 
-		ok, err := l.saveList(userID, listID, list, originalJSONList)
+		ok, err := l.saveIndex(userID, listID, newIndex, originalJSONIndex)
 		if err != nil {
 			return err
 		}
 
-		// If err is nil but ok is false, then something else updated the installs between the get and set above
+		// If err is nil but ok is false, then something else updated the index between the get and set above
 		// so we need to try again, otherwise we can return
 		if ok {
+			l.deleteReference(userID, listID, issueID)
 			return nil
 		}
 	}
@@ -225,26 +473,29 @@ func (l *listStore) RemoveReference(userID, issueID, listID string) error {
 
 func (l *listStore) PopReference(userID, listID string) (*IssueRef, error) {
 	for i := 0; i < StoreRetries; i++ {
-		list, originalJSONList, err := l.getList(userID, listID)
+		index, originalJSONIndex, err := l.getIndex(userID, listID)
 		if err != nil {
 			return nil, err
 		}
 
-		if len(list) == 0 {
+		if len(index) == 0 {
 			return nil, errors.New("cannot find issue")
 		}
 
-		ir := list[0]
-		list = list[1:]
+		ir, err := l.getReference(userID, listID, index[0])
+		if err != nil {
+			return nil, err
+		}
 
-		ok, err := l.saveList(userID, listID, list, originalJSONList)
+		ok, err := l.saveIndex(userID, listID, index[1:], originalJSONIndex)
 		if err != nil {
 			return nil, err
 		}
 
-		// If err is nil but ok is false, then something else updated the installs between the get and set above
+		// If err is nil but ok is false, then something else updated the index between the get and set above
 		// so we need to try again, otherwise we can return
 		if ok {
+			l.deleteReference(userID, listID, ir.IssueID)
 			return ir, nil
 		}
 	}
@@ -253,34 +504,43 @@ func (l *listStore) PopReference(userID, listID string) (*IssueRef, error) {
 }
 
 func (l *listStore) BumpReference(userID, issueID, listID string) error {
+	return l.MoveReference(userID, issueID, listID, 0)
+}
+
+func (l *listStore) MoveReference(userID, issueID, listID string, position int) error {
 	for i := 0; i < StoreRetries; i++ {
-		list, originalJSONList, err := l.getList(userID, listID)
+		index, originalJSONIndex, err := l.getIndex(userID, listID)
 		if err != nil {
 			return err
 		}
 
-		var i int
-		var ir *IssueRef
-
-		for i, ir = range list {
-			if issueID == ir.IssueID {
+		from := -1
+		for i, id := range index {
+			if id == issueID {
+				from = i
 				break
 			}
 		}
 
-		if i == len(list) {
+		if from == -1 {
 			return errors.New("cannot find issue")
 		}
 
-		newList := append([]*IssueRef{ir}, list[:i]...)
-		newList = append(newList, list[i+1:]...)
+		if position < 0 || position >= len(index) {
+			return errors.New("position out of range")
+		}
+
+		newIndex := make([]string, 0, len(index))
+		newIndex = append(newIndex, index[:from]...)
+		newIndex = append(newIndex, index[from+1:]...)
+		newIndex = append(newIndex[:position], append([]string{issueID}, newIndex[position:]...)...)
 
-		ok, err := l.saveList(userID, listID, newList, originalJSONList)
+		ok, err := l.saveIndex(userID, listID, newIndex, originalJSONIndex)
 		if err != nil {
 			return err
 		}
 
-		// If err is nil but ok is false, then something else updated the installs between the get and set above
+		// If err is nil but ok is false, then something else updated the index between the get and set above
 		// so we need to try again, otherwise we can return
 		if ok {
 			return nil
@@ -291,36 +551,60 @@ func (l *listStore) BumpReference(userID, issueID, listID string) error {
 }
 
 func (l *listStore) GetList(userID, listID string) ([]*IssueRef, error) {
-	irs, _, err := l.getList(userID, listID)
-	return irs, err
-}
-
-func (l *listStore) getList(userID, listID string) ([]*IssueRef, []byte, error) {
-	originalJSONList, err := l.api.KVGet(listKey(userID, listID))
+	index, _, err := l.getIndex(userID, listID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	if originalJSONList == nil {
-		return []*IssueRef{}, originalJSONList, nil
+	list := make([]*IssueRef, 0, len(index))
+	for _, issueID := range index {
+		ir, err := l.getReference(userID, listID, issueID)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, ir)
 	}
 
-	var list []*IssueRef
-	jsonErr := json.Unmarshal(originalJSONList, &list)
-	if jsonErr != nil {
-		return l.legacyIssueRef(userID, listID)
+	return list, nil
+}
+
+// getIndex returns the IDs of the issues on listID of ownerID in order, and the stored index to compare against when
+// saving it. A list still stored as a whole under StoreListKey is migrated first.
+func (l *listStore) getIndex(ownerID, listID string) ([]string, []byte, error) {
+	for i := 0; i < StoreRetries; i++ {
+		originalJSONIndex, appErr := l.api.KVGet(listIndexKey(ownerID, listID))
+		if appErr != nil {
+			return nil, nil, errors.New(appErr.Error())
+		}
+
+		if originalJSONIndex != nil {
+			var index []string
+			if err := json.Unmarshal(originalJSONIndex, &index); err != nil {
+				return nil, nil, err
+			}
+			return index, originalJSONIndex, nil
+		}
+
+		migrated, err := l.migrateList(ownerID, listID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !migrated {
+			return []string{}, nil, nil
+		}
 	}
 
-	return list, originalJSONList, nil
+	return nil, nil, errors.New("unable to migrate list")
 }
 
-func (l *listStore) saveList(userID, listID string, list []*IssueRef, originalJSONList []byte) (bool, error) {
-	newJSONList, jsonErr := json.Marshal(list)
-	if jsonErr != nil {
-		return false, jsonErr
+func (l *listStore) saveIndex(ownerID, listID string, index []string, originalJSONIndex []byte) (bool, error) {
+	newJSONIndex, err := json.Marshal(index)
+	if err != nil {
+		return false, err
 	}
 
-	ok, appErr := l.api.KVCompareAndSet(listKey(userID, listID), originalJSONList, newJSONList)
+	ok, appErr := l.api.KVCompareAndSet(listIndexKey(ownerID, listID), originalJSONIndex, newJSONIndex)
 	if appErr != nil {
 		return false, errors.New(appErr.Error())
 	}
@@ -328,28 +612,199 @@ func (l *listStore) saveList(userID, listID string, list []*IssueRef, originalJS
 	return ok, nil
 }
 
-func (l *listStore) legacyIssueRef(userID, listID string) ([]*IssueRef, []byte, error) {
-	originalJSONList, err := l.api.KVGet(listKey(userID, listID))
+// getReference returns the reference to issueID on listID of ownerID. An issue of the index without a stored
+// reference, removed by another request meanwhile, has no foreign user or issue.
+func (l *listStore) getReference(ownerID, listID, issueID string) (*IssueRef, error) {
+	jsonRef, appErr := l.api.KVGet(referenceKey(ownerID, listID, issueID))
+	if appErr != nil {
+		return nil, errors.New(appErr.Error())
+	}
+
+	if jsonRef == nil {
+		return &IssueRef{IssueID: issueID}, nil
+	}
+
+	var ir *IssueRef
+	if err := json.Unmarshal(jsonRef, &ir); err != nil {
+		return nil, err
+	}
+
+	return ir, nil
+}
+
+func (l *listStore) setReference(ownerID, listID string, ir *IssueRef) error {
+	jsonRef, err := json.Marshal(ir)
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+
+	if appErr := l.api.KVSet(referenceKey(ownerID, listID, ir.IssueID), jsonRef); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return nil
+}
+
+// deleteReference deletes the reference to issueID on listID of ownerID once it left the index. A reference left
+// behind is never read, so failing to delete it is logged but not returned.
+func (l *listStore) deleteReference(ownerID, listID, issueID string) {
+	if appErr := l.api.KVDelete(referenceKey(ownerID, listID, issueID)); appErr != nil {
+		l.api.LogError("cannot delete issue reference, Err=", appErr.Error())
+	}
+}
+
+// migrateList stores the list of ownerID kept as a whole under StoreListKey as an index and a reference per issue,
+// then deletes it. It returns false if there is no such list. If another request migrates the list at the same time,
+// the index stored first is kept, and the references it does not overwrite are the same.
+func (l *listStore) migrateList(ownerID, listID string) (bool, error) {
+	list, originalJSONList, err := l.getLegacyList(ownerID, listID)
+	if err != nil {
+		return false, err
 	}
 
 	if originalJSONList == nil {
-		return []*IssueRef{}, originalJSONList, nil
+		return false, nil
 	}
 
-	var list []string
-	jsonErr := json.Unmarshal(originalJSONList, &list)
-	if jsonErr != nil {
-		return nil, nil, jsonErr
+	index := make([]string, 0, len(list))
+	for _, ir := range list {
+		jsonRef, err := json.Marshal(ir)
+		if err != nil {
+			return false, err
+		}
+
+		// The reference is only stored if there is none, so a reference changed since the index was stored stays
+		if _, appErr := l.api.KVCompareAndSet(referenceKey(ownerID, listID, ir.IssueID), nil, jsonRef); appErr != nil {
+			return false, errors.New(appErr.Error())
+		}
+		index = append(index, ir.IssueID)
+	}
+
+	if _, err := l.saveIndex(ownerID, listID, index, nil); err != nil {
+		return false, err
+	}
+
+	if appErr := l.api.KVDelete(listKey(ownerID, listID)); appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+
+	return true, nil
+}
+
+// getLegacyList returns a list stored as a whole under StoreListKey, either as references or, in the oldest format,
+// as issue IDs
+func (l *listStore) getLegacyList(ownerID, listID string) ([]*IssueRef, []byte, error) {
+	originalJSONList, appErr := l.api.KVGet(listKey(ownerID, listID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONList == nil {
+		return []*IssueRef{}, nil, nil
+	}
+
+	var list []*IssueRef
+	if err := json.Unmarshal(originalJSONList, &list); err == nil {
+		return list, originalJSONList, nil
+	}
+
+	var ids []string
+	if err := json.Unmarshal(originalJSONList, &ids); err != nil {
+		return nil, nil, err
+	}
+
+	list = []*IssueRef{}
+	for _, id := range ids {
+		list = append(list, &IssueRef{IssueID: id})
+	}
+
+	return list, originalJSONList, nil
+}
+
+func (l *listStore) PushJournalEntry(userID string, entry *JournalEntry) error {
+	for i := 0; i < StoreRetries; i++ {
+		journal, originalJSONJournal, err := l.getJournal(userID)
+		if err != nil {
+			return err
+		}
+
+		journal = append(journal, entry)
+		if len(journal) > JournalSize {
+			journal = journal[len(journal)-JournalSize:]
+		}
+
+		ok, err := l.saveJournal(userID, journal, originalJSONJournal)
+		if err != nil {
+			return err
+		}
+
+		// If err is nil but ok is false, then something else updated the journal between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store journal")
+}
+
+func (l *listStore) PopJournalEntry(userID string) (*JournalEntry, error) {
+	for i := 0; i < StoreRetries; i++ {
+		journal, originalJSONJournal, err := l.getJournal(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(journal) == 0 {
+			return nil, nil
+		}
+
+		entry := journal[len(journal)-1]
+		ok, err := l.saveJournal(userID, journal[:len(journal)-1], originalJSONJournal)
+		if err != nil {
+			return nil, err
+		}
+
+		// If err is nil but ok is false, then something else updated the journal between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return entry, nil
+		}
+	}
+
+	return nil, errors.New("unable to store journal")
+}
+
+func (l *listStore) getJournal(userID string) ([]*JournalEntry, []byte, error) {
+	originalJSONJournal, appErr := l.api.KVGet(journalKey(userID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONJournal == nil {
+		return []*JournalEntry{}, nil, nil
+	}
+
+	var journal []*JournalEntry
+	if err := json.Unmarshal(originalJSONJournal, &journal); err != nil {
+		return nil, nil, err
+	}
+
+	return journal, originalJSONJournal, nil
+}
+
+func (l *listStore) saveJournal(userID string, journal []*JournalEntry, originalJSONJournal []byte) (bool, error) {
+	newJSONJournal, err := json.Marshal(journal)
+	if err != nil {
+		return false, err
 	}
 
-	newList := []*IssueRef{}
-	for _, v := range list {
-		newList = append(newList, &IssueRef{IssueID: v})
+	ok, appErr := l.api.KVCompareAndSet(journalKey(userID), originalJSONJournal, newJSONJournal)
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
 	}
 
-	return newList, originalJSONList, nil
+	return ok, nil
 }
 
 func (p *Plugin) saveLastReminderTimeForUser(userID string) error {
@@ -378,3 +833,1182 @@ func (p *Plugin) getLastReminderTimeForUser(userID string) (int64, error) {
 
 	return reminderAt, nil
 }
+
+// claimNudge records that the receiver of the sent issue issueID is nudged, for cooldown. It returns false if they
+// were already nudged about it during the cooldown.
+func (p *Plugin) claimNudge(issueID string, cooldown time.Duration) (bool, error) {
+	expire := int64(cooldown / time.Second)
+	if expire < 1 {
+		expire = 1
+	}
+
+	ok, appErr := p.API.KVSetWithOptions(nudgeKey(issueID), []byte(strconv.FormatInt(model.GetMillis(), 10)), model.PluginKVSetOptions{
+		Atomic:          true,
+		OldValue:        nil,
+		ExpireInSeconds: expire,
+	})
+	if appErr != nil {
+		return false, errors.New(appErr.Error())
+	}
+	return ok, nil
+}
+
+func (p *Plugin) getDigestSettings(userID string) (*DigestSettings, error) {
+	settingsBytes, appErr := p.API.KVGet(digestKey(userID))
+	if appErr != nil {
+		return nil, errors.New(appErr.Error())
+	}
+
+	settings := &DigestSettings{Hour: DefaultDigestHour}
+	if settingsBytes == nil {
+		return settings, nil
+	}
+
+	if err := json.Unmarshal(settingsBytes, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (p *Plugin) saveDigestSettings(userID string, settings *DigestSettings) error {
+	settingsBytes, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	if appErr := p.API.KVSet(digestKey(userID), settingsBytes); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return p.updateDigestUsers(userID, settings.Enabled)
+}
+
+func (p *Plugin) getUserSettings(userID string) (*UserSettings, error) {
+	settingsBytes, appErr := p.API.KVGet(settingsKey(userID))
+	if appErr != nil {
+		return nil, errors.New(appErr.Error())
+	}
+
+	settings := &UserSettings{}
+	if settingsBytes == nil {
+		return settings, nil
+	}
+
+	if err := json.Unmarshal(settingsBytes, settings); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (p *Plugin) saveUserSettings(userID string, settings *UserSettings) error {
+	settingsBytes, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	if appErr := p.API.KVSet(settingsKey(userID), settingsBytes); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	if err = p.updateUserSet(StoreDueReminderUsersKey, userID, settings.ReminderLeadMinutes > 0); err != nil {
+		return err
+	}
+	return p.updateUserSet(StoreWeeklyReportUsersKey, userID, settings.WeeklyReport)
+}
+
+func (p *Plugin) getDigestUsers() ([]string, []byte, error) {
+	return p.getUserSet(StoreDigestUsersKey)
+}
+
+// updateDigestUsers adds or removes userID from the list of users subscribed to the digest
+func (p *Plugin) updateDigestUsers(userID string, subscribed bool) error {
+	return p.updateUserSet(StoreDigestUsersKey, userID, subscribed)
+}
+
+// getUserSet returns the users stored under key
+func (p *Plugin) getUserSet(key string) ([]string, []byte, error) {
+	originalJSONUsers, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONUsers == nil {
+		return []string{}, nil, nil
+	}
+
+	var userIDs []string
+	if err := json.Unmarshal(originalJSONUsers, &userIDs); err != nil {
+		return nil, nil, err
+	}
+
+	return userIDs, originalJSONUsers, nil
+}
+
+// updateUserSet adds or removes userID from the users stored under key
+func (p *Plugin) updateUserSet(key, userID string, member bool) error {
+	for i := 0; i < StoreRetries; i++ {
+		userIDs, originalJSONUsers, err := p.getUserSet(key)
+		if err != nil {
+			return err
+		}
+
+		newUserIDs := []string{}
+		for _, id := range userIDs {
+			if id != userID {
+				newUserIDs = append(newUserIDs, id)
+			}
+		}
+		if member {
+			newUserIDs = append(newUserIDs, userID)
+		}
+
+		newJSONUsers, err := json.Marshal(newUserIDs)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(key, originalJSONUsers, newJSONUsers)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the list between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.Errorf("unable to store %s", key)
+}
+
+// getEscalationDue returns since when, in milliseconds, the oldest received todo of each user that was not escalated
+// is waiting. The users recorded under StoreEscalationUsersKey are waiting since 0, so they are checked on the next run.
+func (p *Plugin) getEscalationDue() (map[string]int64, []byte, error) {
+	originalJSONDue, appErr := p.API.KVGet(StoreEscalationDueKey)
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONDue == nil {
+		userIDs, _, err := p.getUserSet(StoreEscalationUsersKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		due := map[string]int64{}
+		for _, userID := range userIDs {
+			due[userID] = 0
+		}
+		return due, nil, nil
+	}
+
+	due := map[string]int64{}
+	if err := json.Unmarshal(originalJSONDue, &due); err != nil {
+		return nil, nil, err
+	}
+
+	return due, originalJSONDue, nil
+}
+
+// modifyEscalationDue applies modify to the escalation due times, and stores them if it returns true
+func (p *Plugin) modifyEscalationDue(modify func(due map[string]int64) bool) error {
+	for i := 0; i < StoreRetries; i++ {
+		due, originalJSONDue, err := p.getEscalationDue()
+		if err != nil {
+			return err
+		}
+
+		if !modify(due) {
+			return nil
+		}
+
+		newJSONDue, err := json.Marshal(due)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(StoreEscalationDueKey, originalJSONDue, newJSONDue)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the due times between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			if originalJSONDue == nil {
+				if appErr := p.API.KVDelete(StoreEscalationUsersKey); appErr != nil {
+					return errors.New(appErr.Error())
+				}
+			}
+			return nil
+		}
+	}
+
+	return errors.New("unable to store escalation due times")
+}
+
+// recordEscalationDue records that userID has a received todo waiting since the given time, unless an older one is
+// already recorded
+func (p *Plugin) recordEscalationDue(userID string, since int64) error {
+	return p.modifyEscalationDue(func(due map[string]int64) bool {
+		if current, ok := due[userID]; ok && current <= since {
+			return false
+		}
+		due[userID] = since
+		return true
+	})
+}
+
+// forgetEscalationDue forgets the received todos of userID waiting to be escalated
+func (p *Plugin) forgetEscalationDue(userID string) error {
+	return p.modifyEscalationDue(func(due map[string]int64) bool {
+		if _, ok := due[userID]; !ok {
+			return false
+		}
+		delete(due, userID)
+		return true
+	})
+}
+
+// getCompletions returns how many todos userID completed on each day, by date in the YYYY-MM-DD format
+func (p *Plugin) getCompletions(userID string) (map[string]int, []byte, error) {
+	originalJSONCompletions, appErr := p.API.KVGet(completionsKey(userID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	completions := map[string]int{}
+	if originalJSONCompletions == nil {
+		return completions, nil, nil
+	}
+
+	if err := json.Unmarshal(originalJSONCompletions, &completions); err != nil {
+		return nil, nil, err
+	}
+
+	return completions, originalJSONCompletions, nil
+}
+
+// addCompletion counts a todo completed by userID on day, forgetting the days before oldestDay
+func (p *Plugin) addCompletion(userID, day, oldestDay string) error {
+	for i := 0; i < StoreRetries; i++ {
+		completions, originalJSONCompletions, err := p.getCompletions(userID)
+		if err != nil {
+			return err
+		}
+
+		completions[day]++
+		for d := range completions {
+			if d < oldestDay {
+				delete(completions, d)
+			}
+		}
+
+		newJSONCompletions, err := json.Marshal(completions)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(completionsKey(userID), originalJSONCompletions, newJSONCompletions)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store completions")
+}
+
+func (p *Plugin) getHookToken(hash string) (*HookToken, error) {
+	tokenBytes, appErr := p.API.KVGet(hookTokenKey(hash))
+	if appErr != nil {
+		return nil, errors.New(appErr.Error())
+	}
+
+	if tokenBytes == nil {
+		return nil, nil
+	}
+
+	var token *HookToken
+	if err := json.Unmarshal(tokenBytes, &token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (p *Plugin) getHookTokens(userID string) ([]*HookToken, []byte, error) {
+	originalJSONTokens, appErr := p.API.KVGet(hookTokensKey(userID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONTokens == nil {
+		return []*HookToken{}, nil, nil
+	}
+
+	var tokens []*HookToken
+	if err := json.Unmarshal(originalJSONTokens, &tokens); err != nil {
+		return nil, nil, err
+	}
+
+	return tokens, originalJSONTokens, nil
+}
+
+// addHookToken stores token, failing if its owner already has MaxHookTokens tokens
+func (p *Plugin) addHookToken(token *HookToken) error {
+	tokenBytes, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < StoreRetries; i++ {
+		tokens, originalJSONTokens, err := p.getHookTokens(token.UserID)
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) >= MaxHookTokens {
+			return errTooManyHookTokens
+		}
+
+		newJSONTokens, err := json.Marshal(append(tokens, token))
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(hookTokensKey(token.UserID), originalJSONTokens, newJSONTokens)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the tokens between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			if appErr := p.API.KVSet(hookTokenKey(token.Hash), tokenBytes); appErr != nil {
+				return errors.New(appErr.Error())
+			}
+			return nil
+		}
+	}
+
+	return errors.New("unable to store hook token")
+}
+
+// removeHookToken deletes the token of userID with the given ID
+func (p *Plugin) removeHookToken(userID, tokenID string) (*HookToken, error) {
+	for i := 0; i < StoreRetries; i++ {
+		tokens, originalJSONTokens, err := p.getHookTokens(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		var removed *HookToken
+		newTokens := []*HookToken{}
+		for _, token := range tokens {
+			if token.ID == tokenID {
+				removed = token
+				continue
+			}
+			newTokens = append(newTokens, token)
+		}
+		if removed == nil {
+			return nil, errHookTokenNotFound
+		}
+
+		newJSONTokens, err := json.Marshal(newTokens)
+		if err != nil {
+			return nil, err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(hookTokensKey(userID), originalJSONTokens, newJSONTokens)
+		if appErr != nil {
+			return nil, errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the tokens between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			if appErr := p.API.KVDelete(hookTokenKey(removed.Hash)); appErr != nil {
+				return nil, errors.New(appErr.Error())
+			}
+			return removed, nil
+		}
+	}
+
+	return nil, errors.New("unable to remove hook token")
+}
+
+func (p *Plugin) getJiraLinks(jiraIssueKey string) ([]*JiraLink, []byte, error) {
+	originalJSONLinks, appErr := p.API.KVGet(jiraLinksKey(jiraIssueKey))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONLinks == nil {
+		return []*JiraLink{}, nil, nil
+	}
+
+	var links []*JiraLink
+	if err := json.Unmarshal(originalJSONLinks, &links); err != nil {
+		return nil, nil, err
+	}
+
+	return links, originalJSONLinks, nil
+}
+
+// getJiraKey returns the key of the Jira issue the todo is linked to, or an empty string if it is not linked
+func (p *Plugin) getJiraKey(issueID string) (string, error) {
+	keyBytes, appErr := p.API.KVGet(jiraKey(issueID))
+	if appErr != nil {
+		return "", errors.New(appErr.Error())
+	}
+
+	return string(keyBytes), nil
+}
+
+// addJiraLink links a todo to a Jira issue, replacing the Jira issue it was linked to before
+func (p *Plugin) addJiraLink(jiraIssueKey string, link *JiraLink) error {
+	oldKey, err := p.getJiraKey(link.IssueID)
+	if err != nil {
+		return err
+	}
+	if oldKey != "" && oldKey != jiraIssueKey {
+		if err = p.removeJiraLink(oldKey, link.IssueID); err != nil {
+			return err
+		}
+	}
+
+	if err = p.updateJiraLinks(jiraIssueKey, link.IssueID, link); err != nil {
+		return err
+	}
+
+	if appErr := p.API.KVSet(jiraKey(link.IssueID), []byte(jiraIssueKey)); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return nil
+}
+
+// removeJiraLink unlinks a todo from a Jira issue
+func (p *Plugin) removeJiraLink(jiraIssueKey, issueID string) error {
+	if err := p.updateJiraLinks(jiraIssueKey, issueID, nil); err != nil {
+		return err
+	}
+
+	if appErr := p.API.KVDelete(jiraKey(issueID)); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return nil
+}
+
+// updateJiraLinks replaces the link of issueID to the Jira issue with newLink, removing it if newLink is nil
+func (p *Plugin) updateJiraLinks(jiraIssueKey, issueID string, newLink *JiraLink) error {
+	for i := 0; i < StoreRetries; i++ {
+		links, originalJSONLinks, err := p.getJiraLinks(jiraIssueKey)
+		if err != nil {
+			return err
+		}
+
+		newLinks := []*JiraLink{}
+		for _, link := range links {
+			if link.IssueID != issueID {
+				newLinks = append(newLinks, link)
+			}
+		}
+		if newLink != nil {
+			newLinks = append(newLinks, newLink)
+		}
+
+		newJSONLinks, err := json.Marshal(newLinks)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(jiraLinksKey(jiraIssueKey), originalJSONLinks, newJSONLinks)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the links between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store Jira links")
+}
+
+func (p *Plugin) getGitHubLinkRefs() ([]*GitHubLinkRef, []byte, error) {
+	originalJSONRefs, appErr := p.API.KVGet(StoreGitHubLinksKey)
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONRefs == nil {
+		return []*GitHubLinkRef{}, nil, nil
+	}
+
+	var refs []*GitHubLinkRef
+	if err := json.Unmarshal(originalJSONRefs, &refs); err != nil {
+		return nil, nil, err
+	}
+
+	return refs, originalJSONRefs, nil
+}
+
+// updateGitHubLinkRefs adds or removes ref from the todos whose GitHub issues are refreshed
+func (p *Plugin) updateGitHubLinkRefs(ref *GitHubLinkRef, refreshed bool) error {
+	for i := 0; i < StoreRetries; i++ {
+		refs, originalJSONRefs, err := p.getGitHubLinkRefs()
+		if err != nil {
+			return err
+		}
+
+		newRefs := []*GitHubLinkRef{}
+		for _, r := range refs {
+			if r.IssueID != ref.IssueID {
+				newRefs = append(newRefs, r)
+			}
+		}
+		if refreshed {
+			newRefs = append(newRefs, ref)
+		}
+
+		newJSONRefs, err := json.Marshal(newRefs)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(StoreGitHubLinksKey, originalJSONRefs, newJSONRefs)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the list between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store GitHub links")
+}
+
+// getCalendarTokenOwner returns the user whose calendar feed token has the hash, or an empty string if there is none
+func (p *Plugin) getCalendarTokenOwner(hash string) (string, error) {
+	userID, appErr := p.API.KVGet(calendarTokenKey(hash))
+	if appErr != nil {
+		return "", errors.New(appErr.Error())
+	}
+
+	return string(userID), nil
+}
+
+// saveCalendarToken replaces the calendar feed token of userID with the one with the hash, or disables the feed if
+// the hash is empty
+func (p *Plugin) saveCalendarToken(userID, hash string) error {
+	oldHash, appErr := p.API.KVGet(calendarKey(userID))
+	if appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	if oldHash != nil {
+		if appErr = p.API.KVDelete(calendarTokenKey(string(oldHash))); appErr != nil {
+			return errors.New(appErr.Error())
+		}
+	}
+
+	if hash == "" {
+		if appErr = p.API.KVDelete(calendarKey(userID)); appErr != nil {
+			return errors.New(appErr.Error())
+		}
+		return nil
+	}
+
+	if appErr = p.API.KVSet(calendarTokenKey(hash), []byte(userID)); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	if appErr = p.API.KVSet(calendarKey(userID), []byte(hash)); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return nil
+}
+
+func (p *Plugin) getDeferredNotifications(userID string) ([]*DeferredNotification, []byte, error) {
+	originalJSONNotifications, appErr := p.API.KVGet(deferredKey(userID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONNotifications == nil {
+		return []*DeferredNotification{}, nil, nil
+	}
+
+	var notifications []*DeferredNotification
+	if err := json.Unmarshal(originalJSONNotifications, &notifications); err != nil {
+		return nil, nil, err
+	}
+
+	return notifications, originalJSONNotifications, nil
+}
+
+// addDeferredNotification queues notification for userID, dropping the oldest ones beyond MaxDeferredNotifications
+func (p *Plugin) addDeferredNotification(userID string, notification *DeferredNotification) error {
+	for i := 0; i < StoreRetries; i++ {
+		notifications, originalJSONNotifications, err := p.getDeferredNotifications(userID)
+		if err != nil {
+			return err
+		}
+
+		notifications = append(notifications, notification)
+		if len(notifications) > MaxDeferredNotifications {
+			notifications = notifications[len(notifications)-MaxDeferredNotifications:]
+		}
+
+		newJSONNotifications, err := json.Marshal(notifications)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(deferredKey(userID), originalJSONNotifications, newJSONNotifications)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the queue between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return p.updateUserSet(StoreDeferredUsersKey, userID, true)
+		}
+	}
+
+	return errors.New("unable to store deferred notification")
+}
+
+// takeDeferredNotifications empties the queue of userID and returns the notifications that were in it
+func (p *Plugin) takeDeferredNotifications(userID string) ([]*DeferredNotification, error) {
+	// The user is removed first, so a notification queued meanwhile adds them back
+	if err := p.updateUserSet(StoreDeferredUsersKey, userID, false); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < StoreRetries; i++ {
+		notifications, originalJSONNotifications, err := p.getDeferredNotifications(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if originalJSONNotifications != nil {
+			ok, appErr := p.API.KVCompareAndSet(deferredKey(userID), originalJSONNotifications, []byte("[]"))
+			if appErr != nil {
+				return nil, errors.New(appErr.Error())
+			}
+
+			// If err is nil but ok is false, then something else updated the queue between the get and set above
+			// so we need to try again, otherwise we can return
+			if !ok {
+				continue
+			}
+		}
+
+		return notifications, nil
+	}
+
+	return nil, errors.New("unable to take deferred notifications")
+}
+
+// getTemplates returns the templates stored under key, sorted by name
+func (p *Plugin) getTemplates(key string) ([]*Template, []byte, error) {
+	originalJSONTemplates, appErr := p.API.KVGet(key)
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONTemplates == nil {
+		return []*Template{}, nil, nil
+	}
+
+	var templates []*Template
+	if err := json.Unmarshal(originalJSONTemplates, &templates); err != nil {
+		return nil, nil, err
+	}
+
+	return templates, originalJSONTemplates, nil
+}
+
+// saveTemplate stores template under key, replacing the template with the same name
+func (p *Plugin) saveTemplate(key string, template *Template) error {
+	for i := 0; i < StoreRetries; i++ {
+		templates, originalJSONTemplates, err := p.getTemplates(key)
+		if err != nil {
+			return err
+		}
+
+		newTemplates := []*Template{}
+		for _, t := range templates {
+			if !strings.EqualFold(t.Name, template.Name) {
+				newTemplates = append(newTemplates, t)
+			}
+		}
+		if len(newTemplates) >= MaxTemplates {
+			return errTooManyTemplates
+		}
+		newTemplates = append(newTemplates, template)
+		sort.Slice(newTemplates, func(i, j int) bool {
+			return strings.ToLower(newTemplates[i].Name) < strings.ToLower(newTemplates[j].Name)
+		})
+
+		newJSONTemplates, err := json.Marshal(newTemplates)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(key, originalJSONTemplates, newJSONTemplates)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the templates between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store template")
+}
+
+// removeTemplate deletes the template with the given name stored under key
+func (p *Plugin) removeTemplate(key, name string) error {
+	for i := 0; i < StoreRetries; i++ {
+		templates, originalJSONTemplates, err := p.getTemplates(key)
+		if err != nil {
+			return err
+		}
+
+		newTemplates := []*Template{}
+		for _, t := range templates {
+			if !strings.EqualFold(t.Name, name) {
+				newTemplates = append(newTemplates, t)
+			}
+		}
+		if len(newTemplates) == len(templates) {
+			return errTemplateNotFound
+		}
+
+		newJSONTemplates, err := json.Marshal(newTemplates)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(key, originalJSONTemplates, newJSONTemplates)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the templates between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to remove template")
+}
+
+// getScheduledSends returns the sends userID scheduled, the first to deliver first
+func (p *Plugin) getScheduledSends(userID string) ([]*ScheduledSend, []byte, error) {
+	originalJSONSends, appErr := p.API.KVGet(scheduledSendsKey(userID))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONSends == nil {
+		return []*ScheduledSend{}, nil, nil
+	}
+
+	var sends []*ScheduledSend
+	if err := json.Unmarshal(originalJSONSends, &sends); err != nil {
+		return nil, nil, err
+	}
+
+	return sends, originalJSONSends, nil
+}
+
+// modifyScheduledSends replaces the scheduled sends of userID with the ones modify returns, retrying if something
+// else changes them at the same time
+func (p *Plugin) modifyScheduledSends(userID string, modify func(sends []*ScheduledSend) ([]*ScheduledSend, error)) error {
+	for i := 0; i < StoreRetries; i++ {
+		sends, originalJSONSends, err := p.getScheduledSends(userID)
+		if err != nil {
+			return err
+		}
+
+		newSends, err := modify(sends)
+		if err != nil {
+			return err
+		}
+
+		newJSONSends, err := json.Marshal(newSends)
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(scheduledSendsKey(userID), originalJSONSends, newJSONSends)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else updated the sends between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store scheduled sends")
+}
+
+// addScheduledSend queues send for userID, failing if they already have MaxScheduledSends
+func (p *Plugin) addScheduledSend(userID string, send *ScheduledSend) error {
+	err := p.modifyScheduledSends(userID, func(sends []*ScheduledSend) ([]*ScheduledSend, error) {
+		if len(sends) >= MaxScheduledSends {
+			return nil, errTooManyScheduledSends
+		}
+
+		sends = append(sends, send)
+		sort.SliceStable(sends, func(i, j int) bool { return sends[i].DeliverAt < sends[j].DeliverAt })
+		return sends, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.updateUserSet(StoreScheduledUsersKey, userID, true)
+}
+
+// removeScheduledSend cancels the scheduled send of userID with the given ID and returns it
+func (p *Plugin) removeScheduledSend(userID, sendID string) (*ScheduledSend, error) {
+	var removed *ScheduledSend
+	err := p.modifyScheduledSends(userID, func(sends []*ScheduledSend) ([]*ScheduledSend, error) {
+		removed = nil
+		newSends := []*ScheduledSend{}
+		for _, send := range sends {
+			if send.ID == sendID {
+				removed = send
+				continue
+			}
+			newSends = append(newSends, send)
+		}
+		if removed == nil {
+			return nil, errScheduledSendNotFound
+		}
+		return newSends, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
+
+// takeDueScheduledSends removes the sends of userID to deliver at or before now and returns them
+func (p *Plugin) takeDueScheduledSends(userID string, now int64) ([]*ScheduledSend, error) {
+	// The user is removed first, so a send scheduled meanwhile adds them back
+	if err := p.updateUserSet(StoreScheduledUsersKey, userID, false); err != nil {
+		return nil, err
+	}
+
+	var due, pending []*ScheduledSend
+	err := p.modifyScheduledSends(userID, func(sends []*ScheduledSend) ([]*ScheduledSend, error) {
+		due, pending = []*ScheduledSend{}, []*ScheduledSend{}
+		for _, send := range sends {
+			if send.DeliverAt <= now {
+				due = append(due, send)
+			} else {
+				pending = append(pending, send)
+			}
+		}
+		return pending, nil
+	})
+	if err != nil {
+		// The sends are still there, so the user must stay in the list
+		if setErr := p.updateUserSet(StoreScheduledUsersKey, userID, true); setErr != nil {
+			p.API.LogError("cannot restore scheduled send user, Err=", setErr.Error())
+		}
+		return nil, err
+	}
+
+	if len(pending) > 0 {
+		if err := p.updateUserSet(StoreScheduledUsersKey, userID, true); err != nil {
+			return due, err
+		}
+	}
+
+	return due, nil
+}
+
+// getLastPurge returns when the retention purge last ran in milliseconds, or 0 if it never did
+func (p *Plugin) getLastPurge() (int64, error) {
+	value, appErr := p.API.KVGet(StoreRetentionKey)
+	if appErr != nil {
+		return 0, errors.New(appErr.Error())
+	}
+
+	if value == nil {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+// saveLastPurge records when the retention purge ran, in milliseconds
+func (p *Plugin) saveLastPurge(at int64) error {
+	if appErr := p.API.KVSet(StoreRetentionKey, []byte(strconv.FormatInt(at, 10))); appErr != nil {
+		return errors.New(appErr.Error())
+	}
+
+	return nil
+}
+
+// GetUserIDs returns the users with a list in the KV store, found from the keys of their lists
+func (l *listStore) GetUserIDs() ([]string, error) {
+	seen := map[string]bool{}
+	userIDs := []string{}
+	err := l.forEachList(func(ownerID, listID string) error {
+		if listID != ChannelListKey && !seen[ownerID] {
+			seen[ownerID] = true
+			userIDs = append(userIDs, ownerID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+// forEachList calls f with the owner and the ID of every list in the KV store, channel lists included. The keys are
+// listed before f is called, as f may migrate a list and change the keys.
+func (l *listStore) forEachList(f func(ownerID, listID string) error) error {
+	type list struct{ ownerID, listID string }
+	seen := map[list]bool{}
+	lists := []list{}
+	for page := 0; ; page++ {
+		keys, appErr := l.api.KVList(page, TodoUsersPerPage)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		for _, key := range keys {
+			// A list is under both keys while another request migrates it
+			if ownerID, listID, ok := parseListKey(key); ok && !seen[list{ownerID, listID}] {
+				seen[list{ownerID, listID}] = true
+				lists = append(lists, list{ownerID, listID})
+			}
+		}
+
+		if len(keys) < TodoUsersPerPage {
+			break
+		}
+	}
+
+	for _, list := range lists {
+		if err := f(list.ownerID, list.listID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseListKey returns the owner, a user or a channel, and the list of a key made by listIndexKey or listKey, and
+// false if it is not the key of a list
+func parseListKey(key string) (string, string, bool) {
+	for _, prefix := range []string{StoreListIndexKey + "_", StoreListKey + "_"} {
+		if !strings.HasPrefix(key, prefix) || len(key) < len(prefix)+26 {
+			continue
+		}
+
+		ownerID := key[len(prefix) : len(prefix)+26]
+		listID := key[len(prefix)+26:]
+		switch listID {
+		case MyListKey, InListKey, OutListKey, DoneListKey, ChannelListKey:
+			if model.IsValidId(ownerID) {
+				return ownerID, listID, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// purgeUserData deletes the settings, tokens, templates and every other record kept for userID, other than the todos
+func (p *Plugin) purgeUserData(userID string) error {
+	tokens, _, err := p.getHookTokens(userID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if _, err := p.removeHookToken(userID, token.ID); err != nil && err != errHookTokenNotFound {
+			return err
+		}
+	}
+
+	if err := p.saveCalendarToken(userID, ""); err != nil {
+		return err
+	}
+
+	for _, key := range []string{
+		StoreDigestUsersKey,
+		StoreDueReminderUsersKey,
+		StoreWeeklyReportUsersKey,
+		StoreDeferredUsersKey,
+		StoreScheduledUsersKey,
+		StoreOverdueUsersKey,
+	} {
+		if err := p.updateUserSet(key, userID, false); err != nil {
+			return err
+		}
+	}
+
+	if err := p.forgetEscalationDue(userID); err != nil {
+		return err
+	}
+
+	for _, key := range []string{
+		reminderKey(userID),
+		digestKey(userID),
+		journalKey(userID),
+		hookTokensKey(userID),
+		deferredKey(userID),
+		scheduledSendsKey(userID),
+		settingsKey(userID),
+		templatesKey(userID),
+		completionsKey(userID),
+		searchIndexKey(userID),
+		viewersKey(userID),
+	} {
+		if appErr := p.API.KVDelete(key); appErr != nil {
+			return errors.New(appErr.Error())
+		}
+	}
+
+	return nil
+}
+
+// getAuditSegment returns the last audit log segment of userID, its number and its entries
+func (p *Plugin) getAuditSegment(userID string) (int, []byte, []*AuditEntry, []byte, error) {
+	originalSegment, appErr := p.API.KVGet(auditKey(userID))
+	if appErr != nil {
+		return 0, nil, nil, nil, errors.New(appErr.Error())
+	}
+
+	segment := 0
+	if originalSegment != nil {
+		var err error
+		if segment, err = strconv.Atoi(string(originalSegment)); err != nil {
+			return 0, nil, nil, nil, err
+		}
+	}
+
+	entries, originalJSONEntries, err := p.getAuditEntriesOfSegment(userID, segment)
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+
+	return segment, originalSegment, entries, originalJSONEntries, nil
+}
+
+func (p *Plugin) getAuditEntriesOfSegment(userID string, segment int) ([]*AuditEntry, []byte, error) {
+	originalJSONEntries, appErr := p.API.KVGet(auditSegmentKey(userID, segment))
+	if appErr != nil {
+		return nil, nil, errors.New(appErr.Error())
+	}
+
+	if originalJSONEntries == nil {
+		return []*AuditEntry{}, nil, nil
+	}
+
+	var entries []*AuditEntry
+	if err := json.Unmarshal(originalJSONEntries, &entries); err != nil {
+		return nil, nil, err
+	}
+
+	return entries, originalJSONEntries, nil
+}
+
+// appendAuditEntry adds entry at the end of the audit log of userID, starting a new segment when the last one is full.
+// Entries are never changed or removed once stored.
+func (p *Plugin) appendAuditEntry(userID string, entry *AuditEntry) error {
+	for i := 0; i < StoreRetries; i++ {
+		segment, originalSegment, entries, originalJSONEntries, err := p.getAuditSegment(userID)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) >= AuditSegmentSize {
+			// If something else started the next segment first, ok is false and the entry goes to that one
+			newSegment := []byte(strconv.Itoa(segment + 1))
+			if _, appErr := p.API.KVCompareAndSet(auditKey(userID), originalSegment, newSegment); appErr != nil {
+				return errors.New(appErr.Error())
+			}
+			continue
+		}
+
+		newJSONEntries, err := json.Marshal(append(entries, entry))
+		if err != nil {
+			return err
+		}
+
+		ok, appErr := p.API.KVCompareAndSet(auditSegmentKey(userID, segment), originalJSONEntries, newJSONEntries)
+		if appErr != nil {
+			return errors.New(appErr.Error())
+		}
+
+		// If err is nil but ok is false, then something else added an entry between the get and set above
+		// so we need to try again, otherwise we can return
+		if ok {
+			return nil
+		}
+	}
+
+	return errors.New("unable to store audit entry")
+}
+
+// getAuditEntries returns the audit entries of userID created at or after since in milliseconds, oldest first
+func (p *Plugin) getAuditEntries(userID string, since int64) ([]*AuditEntry, error) {
+	segment, _, last, _, err := p.getAuditSegment(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := [][]*AuditEntry{last}
+	for n := segment - 1; n >= 0 && (len(segments[0]) == 0 || segments[0][0].CreateAt >= since); n-- {
+		entries, _, err := p.getAuditEntriesOfSegment(userID, n)
+		if err != nil {
+			return nil, err
+		}
+		segments = append([][]*AuditEntry{entries}, segments...)
+	}
+
+	result := []*AuditEntry{}
+	for _, entries := range segments {
+		for _, entry := range entries {
+			if entry.CreateAt >= since {
+				result = append(result, entry)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStoreModifyIssueRetries(t *testing.T) {
+	first, _ := json.Marshal(&Issue{ID: "issue1", Message: "first"})
+	second, _ := json.Marshal(&Issue{ID: "issue1", Message: "second"})
+
+	api := &plugintest.API{}
+	api.On("KVGet", issueKey("issue1")).Return(first, nil).Once()
+	api.On("KVGet", issueKey("issue1")).Return(second, nil).Once()
+	api.On("KVCompareAndSet", issueKey("issue1"), first, mock.Anything).Return(false, nil).Once()
+	api.On("KVCompareAndSet", issueKey("issue1"), second, mock.Anything).Return(true, nil).Once()
+
+	seen := []string{}
+	issue, err := NewListStore(api).ModifyIssue("issue1", func(issue *Issue) error {
+		seen = append(seen, issue.Message)
+		issue.Message += " edited"
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "second edited", issue.Message)
+	assert.Equal(t, []string{"first", "second"}, seen)
+	api.AssertExpectations(t)
+}
+
+// memoryKV is a plugin API keeping the KV store in memory, safe for concurrent use
+type memoryKV struct {
+	plugin.API
+	mutex sync.Mutex
+	data  map[string][]byte
+}
+
+func newMemoryKV() *memoryKV {
+	return &memoryKV{API: &plugintest.API{}, data: map[string][]byte{}}
+}
+
+func (kv *memoryKV) KVGet(key string) ([]byte, *model.AppError) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	return kv.data[key], nil
+}
+
+func (kv *memoryKV) KVSet(key string, value []byte) *model.AppError {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	if value == nil {
+		delete(kv.data, key)
+	} else {
+		kv.data[key] = value
+	}
+	return nil
+}
+
+func (kv *memoryKV) KVDelete(key string) *model.AppError {
+	return kv.KVSet(key, nil)
+}
+
+func (kv *memoryKV) KVCompareAndSet(key string, oldValue, newValue []byte) (bool, *model.AppError) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	if current, ok := kv.data[key]; ok != (oldValue != nil) || !bytes.Equal(current, oldValue) {
+		return false, nil
+	}
+	kv.data[key] = newValue
+	return true, nil
+}
+
+func (kv *memoryKV) KVList(page, perPage int) ([]string, *model.AppError) {
+	kv.mutex.Lock()
+	defer kv.mutex.Unlock()
+	keys := []string{}
+	for key := range kv.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if page*perPage >= len(keys) {
+		return []string{}, nil
+	}
+	keys = keys[page*perPage:]
+	if len(keys) > perPage {
+		keys = keys[:perPage]
+	}
+	return keys, nil
+}
+
+func (kv *memoryKV) LogError(msg string, keyValuePairs ...interface{}) {}
+
+func TestListStoreReferences(t *testing.T) {
+	kv := newMemoryKV()
+	l := NewListStore(kv)
+
+	require.NoError(t, l.AddReference("user1", "issue1", InListKey, "user2", "foreign1"))
+	require.NoError(t, l.AddReference("user1", "issue2", InListKey, "", ""))
+	require.NoError(t, l.AddReference("user1", "issue3", InListKey, "", ""))
+	assert.Error(t, l.AddReference("user1", "issue1", InListKey, "", ""))
+
+	listID, ir, n := l.GetIssueListAndReference("user1", "issue1")
+	assert.Equal(t, InListKey, listID)
+	assert.Equal(t, &IssueRef{IssueID: "issue1", ForeignUserID: "user2", ForeignIssueID: "foreign1"}, ir)
+	assert.Equal(t, 0, n)
+
+	require.NoError(t, l.MoveReference("user1", "issue1", InListKey, 2))
+	require.NoError(t, l.BumpReference("user1", "issue3", InListKey))
+	list, err := l.GetList("user1", InListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []*IssueRef{{IssueID: "issue3"}, {IssueID: "issue2"}, {IssueID: "issue1", ForeignUserID: "user2", ForeignIssueID: "foreign1"}}, list)
+
+	ir, err = l.PopReference("user1", InListKey)
+	require.NoError(t, err)
+	assert.Equal(t, "issue3", ir.IssueID)
+	require.NoError(t, l.RemoveReference("user1", "issue1", InListKey))
+	assert.Error(t, l.RemoveReference("user1", "issue1", InListKey))
+
+	list, err = l.GetList("user1", InListKey)
+	require.NoError(t, err)
+	assert.Equal(t, []*IssueRef{{IssueID: "issue2"}}, list)
+	assert.Nil(t, kv.data[referenceKey("user1", InListKey, "issue1")])
+	assert.Nil(t, kv.data[referenceKey("user1", InListKey, "issue3")])
+}
+
+func TestListStoreMigratesList(t *testing.T) {
+	for name, legacy := range map[string]string{
+		"references": `[{"issue_id":"issue1","foreign_issue_id":"foreign1","foreign_user_id":"user2"},{"issue_id":"issue2"}]`,
+		"issue IDs":  `["issue1","issue2"]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			kv := newMemoryKV()
+			kv.data[listKey("user1", OutListKey)] = []byte(legacy)
+			l := NewListStore(kv)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					list, err := l.GetList("user1", OutListKey)
+					assert.NoError(t, err)
+					assert.Len(t, list, 2)
+				}()
+			}
+			wg.Wait()
+
+			assert.Nil(t, kv.data[listKey("user1", OutListKey)])
+			assert.Equal(t, `["issue1","issue2"]`, string(kv.data[listIndexKey("user1", OutListKey)]))
+
+			ir, n, err := l.GetIssueReference("user1", "issue2", OutListKey)
+			require.NoError(t, err)
+			assert.Equal(t, "issue2", ir.IssueID)
+			assert.Equal(t, 1, n)
+			if name == "references" {
+				ir, _, err = l.GetIssueReference("user1", "issue1", OutListKey)
+				require.NoError(t, err)
+				assert.Equal(t, "user2", ir.ForeignUserID)
+			}
+		})
+	}
+}
+
+func TestListStoreConcurrentAddRemove(t *testing.T) {
+	kv := newMemoryKV()
+	l := NewListStore(kv)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, l.AddReference("user1", fmt.Sprintf("old%d", i), MyListKey, "", ""))
+	}
+
+	// Every add or remove that succeeds must show in the list, whatever the others do at the same time
+	var mutex sync.Mutex
+	expected := map[string]bool{}
+	for i := 0; i < 10; i++ {
+		expected[fmt.Sprintf("old%d", i)] = true
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			issueID := fmt.Sprintf("new%d", i)
+			if l.AddReference("user1", issueID, MyListKey, "user2", "foreign"+issueID) == nil {
+				mutex.Lock()
+				expected[issueID] = true
+				mutex.Unlock()
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			issueID := fmt.Sprintf("old%d", i)
+			if l.RemoveReference("user1", issueID, MyListKey) == nil {
+				mutex.Lock()
+				delete(expected, issueID)
+				mutex.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	list, err := l.GetList("user1", MyListKey)
+	require.NoError(t, err)
+	actual := map[string]bool{}
+	for _, ir := range list {
+		actual[ir.IssueID] = true
+		if strings.HasPrefix(ir.IssueID, "new") {
+			assert.Equal(t, "foreign"+ir.IssueID, ir.ForeignIssueID)
+		}
+	}
+	assert.Equal(t, expected, actual)
+}
+
+func TestIssueRefOmitsEmptyForeignIDs(t *testing.T) {
+	b, err := json.Marshal(&IssueRef{IssueID: "issue1"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"issue_id":"issue1"}`, string(b))
+}
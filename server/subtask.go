@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// MaxSubtasks is the maximum number of checklist items of a todo
+const MaxSubtasks = 50
+
+// errSubtaskNotFound is returned when the checklist item cannot be found in the todo
+var errSubtaskNotFound = newLocalizedError(msgErrSubtaskNotFound, nil)
+
+// Subtask is a checklist item of a todo
+type Subtask struct {
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+	CreateAt   int64  `json:"create_at"`
+	CompleteAt int64  `json:"complete_at,omitempty"`
+}
+
+type apiV2SubtaskRequest struct {
+	Message string `json:"message"`
+}
+
+// subtaskProgress returns how many of the checklist items of the issue are done, and how many there are
+func subtaskProgress(issue *Issue) (int, int) {
+	done := 0
+	for _, subtask := range issue.Subtasks {
+		if subtask.CompleteAt != 0 {
+			done++
+		}
+	}
+	return done, len(issue.Subtasks)
+}
+
+func (l *listManager) AddSubtask(userID, issueID, message string) (*ExtendedIssue, *Subtask, error) {
+	subtask := &Subtask{
+		ID:       model.NewId(),
+		Message:  message,
+		CreateAt: model.GetMillis(),
+	}
+
+	issue, err := l.updateSubtasks(userID, issueID, func(subtasks []*Subtask) ([]*Subtask, error) {
+		if len(subtasks) >= MaxSubtasks {
+			return nil, newLocalizedError(msgErrTooManySubtasks, map[string]interface{}{"Max": MaxSubtasks})
+		}
+		copied := *subtask
+		return append(subtasks, &copied), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return issue, subtask, nil
+}
+
+func (l *listManager) CheckSubtask(userID, issueID, subtaskID string, done bool) (*ExtendedIssue, error) {
+	return l.updateSubtasks(userID, issueID, func(subtasks []*Subtask) ([]*Subtask, error) {
+		for _, subtask := range subtasks {
+			if subtask.ID != subtaskID {
+				continue
+			}
+			subtask.CompleteAt = 0
+			if done {
+				subtask.CompleteAt = model.GetMillis()
+			}
+			return subtasks, nil
+		}
+		return nil, errSubtaskNotFound
+	})
+}
+
+func (l *listManager) RemoveSubtask(userID, issueID, subtaskID string) (*ExtendedIssue, error) {
+	return l.updateSubtasks(userID, issueID, func(subtasks []*Subtask) ([]*Subtask, error) {
+		for i, subtask := range subtasks {
+			if subtask.ID == subtaskID {
+				return append(subtasks[:i], subtasks[i+1:]...), nil
+			}
+		}
+		return nil, errSubtaskNotFound
+	})
+}
+
+// updateSubtasks applies update to the checklist of the todo issueID of userID, and shares the result with the
+// foreign user of a sent todo
+func (l *listManager) updateSubtasks(userID, issueID string, update func([]*Subtask) ([]*Subtask, error)) (*ExtendedIssue, error) {
+	return l.updateSharedIssue(userID, issueID, func(issue *Issue) (err error) {
+		issue.Subtasks, err = update(issue.Subtasks)
+		return err
+	}, func(foreignIssue, issue *Issue) {
+		foreignIssue.Subtasks = issue.Subtasks
+	})
+}
+
+// parseSubtaskPosition parses the number of a checklist item, like 2.1 for the first item of the second todo
+func parseSubtaskPosition(arg string) (int, int, error) {
+	parts := strings.Split(arg, ".")
+	if len(parts) != 2 {
+		return 0, 0, newLocalizedError(msgErrInvalidSubtaskNumber, map[string]interface{}{"Value": arg})
+	}
+
+	position, err := parsePosition(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	subPosition, err := strconv.Atoi(parts[1])
+	if err != nil || subPosition < 1 {
+		return 0, 0, newLocalizedError(msgErrInvalidSubtaskNumber, map[string]interface{}{"Value": arg})
+	}
+
+	return position, subPosition, nil
+}
+
+func (p *Plugin) runSubtaskCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) < 2 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageSubtask, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	var issue *ExtendedIssue
+	var responseMessage string
+	switch args[0] {
+	case "add":
+		position, err := parsePosition(args[1])
+		if err != nil {
+			return nil, true, err
+		}
+
+		message := strings.TrimSpace(strings.Join(args[2:], " "))
+		if err = p.checkMessage(message); err != nil {
+			return nil, true, err
+		}
+
+		target, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, position)
+		if err != nil {
+			return nil, true, err
+		}
+
+		if issue, _, err = p.listManager.AddSubtask(extra.UserId, target.ID, message); err != nil {
+			return nil, true, err
+		}
+		responseMessage = T(msgResponseSubtaskAdded, map[string]interface{}{"Number": fmt.Sprintf("%d.%d", position, len(issue.Subtasks))})
+	case "check", "uncheck", "rm":
+		position, subPosition, err := parseSubtaskPosition(args[1])
+		if err != nil {
+			return nil, true, err
+		}
+
+		target, err := p.listManager.GetIssueByPosition(extra.UserId, MyListKey, position)
+		if err != nil {
+			return nil, true, err
+		}
+		if subPosition > len(target.Subtasks) {
+			return nil, true, newLocalizedError(msgErrNoSubtask, map[string]interface{}{"Number": position, "Item": subPosition})
+		}
+		subtaskID := target.Subtasks[subPosition-1].ID
+
+		switch args[0] {
+		case "check":
+			issue, err = p.listManager.CheckSubtask(extra.UserId, target.ID, subtaskID, true)
+			responseMessage = T(msgResponseSubtaskChecked, map[string]interface{}{"Number": args[1]})
+		case "uncheck":
+			issue, err = p.listManager.CheckSubtask(extra.UserId, target.ID, subtaskID, false)
+			responseMessage = T(msgResponseSubtaskUnchecked, map[string]interface{}{"Number": args[1]})
+		default:
+			issue, err = p.listManager.RemoveSubtask(extra.UserId, target.ID, subtaskID)
+			responseMessage = T(msgResponseSubtaskRemoved, map[string]interface{}{"Number": args[1]})
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	default:
+		return nil, true, newLocalizedError(msgErrInvalidSubtaskOption, map[string]interface{}{"Value": args[0]})
+	}
+
+	p.sendRefreshEvent(extra.UserId)
+	if issue.ForeignUserID != "" {
+		p.sendRefreshEvent(issue.ForeignUserID)
+	}
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+// serveAPIv2Subtasks routes the requests to the checklist of the todo issueID
+func (p *Plugin) serveAPIv2Subtasks(w http.ResponseWriter, r *http.Request, userID, issueID string, parts []string) {
+	if !model.IsValidId(issueID) {
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid todo id", errors.Errorf("%s is not a valid id", issueID))
+		return
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		issue, err := p.listManager.GetIssue(userID, issueID)
+		if err != nil {
+			p.handleSubtaskError(w, err)
+			return
+		}
+		subtasks := issue.Subtasks
+		if subtasks == nil {
+			subtasks = []*Subtask{}
+		}
+		p.writeAPIResponse(w, http.StatusOK, subtasks)
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var subtaskRequest *apiV2SubtaskRequest
+		if err := json.NewDecoder(r.Body).Decode(&subtaskRequest); err != nil || subtaskRequest == nil {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to decode JSON", errors.New("the body must be a JSON object"))
+			return
+		}
+
+		message := strings.TrimSpace(subtaskRequest.Message)
+		if err := p.checkMessage(message); err != nil {
+			p.handleErrorWithCode(w, http.StatusBadRequest, "Invalid message", err)
+			return
+		}
+
+		issue, subtask, err := p.listManager.AddSubtask(userID, issueID, message)
+		if err != nil {
+			p.handleSubtaskError(w, err)
+			return
+		}
+		p.sendSubtaskRefreshEvents(userID, issue)
+		p.writeAPIResponse(w, http.StatusCreated, subtask)
+	case len(parts) == 2 && (parts[1] == "complete" || parts[1] == "reopen") && r.Method == http.MethodPost:
+		issue, err := p.listManager.CheckSubtask(userID, issueID, parts[0], parts[1] == "complete")
+		if err != nil {
+			p.handleSubtaskError(w, err)
+			return
+		}
+		p.sendSubtaskRefreshEvents(userID, issue)
+		p.writeAPIResponse(w, http.StatusOK, issue.Subtasks)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		issue, err := p.listManager.RemoveSubtask(userID, issueID, parts[0])
+		if err != nil {
+			p.handleSubtaskError(w, err)
+			return
+		}
+		p.sendSubtaskRefreshEvents(userID, issue)
+		p.writeAPIResponse(w, http.StatusOK, issue.Subtasks)
+	default:
+		p.handleErrorWithCode(w, http.StatusNotFound, "Not found", errors.Errorf("%s %s is not part of the API", r.Method, r.URL.Path))
+	}
+}
+
+func (p *Plugin) handleSubtaskError(w http.ResponseWriter, err error) {
+	switch err {
+	case errIssueNotFound:
+		p.handleErrorWithCode(w, http.StatusNotFound, "Todo not found", err)
+	case errSubtaskNotFound:
+		p.handleErrorWithCode(w, http.StatusNotFound, "Checklist item not found", err)
+	default:
+		p.handleErrorWithCode(w, http.StatusBadRequest, "Unable to update the checklist", err)
+	}
+}
+
+func (p *Plugin) sendSubtaskRefreshEvents(userID string, issue *ExtendedIssue) {
+	p.sendRefreshEvent(userID)
+	if issue.ForeignUserID != "" {
+		p.sendRefreshEvent(issue.ForeignUserID)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubtaskPosition(t *testing.T) {
+	position, subPosition, err := parseSubtaskPosition("2.1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, position)
+	assert.Equal(t, 1, subPosition)
+
+	for _, arg := range []string{"", "2", "2.", ".1", "2.0", "0.1", "2.1.1", "a.b"} {
+		_, _, err = parseSubtaskPosition(arg)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestRenderIssuesSubtasks(t *testing.T) {
+	issue := &ExtendedIssue{Issue: Issue{
+		Message:  "Release",
+		CreateAt: time.Date(2020, time.May, 4, 10, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond),
+		Subtasks: []*Subtask{
+			{ID: "a", Message: "Write tests", CompleteAt: 1},
+			{ID: "b", Message: "Tag"},
+		},
+	}}
+
+	str := renderIssues(renderEnglish, []*ExtendedIssue{issue}, 3, time.UTC)
+	assert.Contains(t, str, "3. Release (1/2)\n")
+	assert.Contains(t, str, "  * [x] 3.1 Write tests\n")
+	assert.Contains(t, str, "  * [ ] 3.2 Tag\n")
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxTemplates is the maximum number of templates of a user, or of a team
+	MaxTemplates = 50
+	// MaxTemplateItems is the maximum number of todos in a template
+	MaxTemplateItems = 50
+	// MaxTemplateNameLength is the maximum length in characters of the name of a template
+	MaxTemplateNameLength = 64
+
+	templateTeamFlag = "--team"
+)
+
+var errTemplateNotFound = newLocalizedError(msgErrTemplateNotFound, nil)
+
+var errTooManyTemplates = newLocalizedError(msgErrTooManyTemplates, map[string]interface{}{"Max": MaxTemplates})
+
+// Template is a named list of todos that are added or sent all at once. Templates belong to a user, or are shared
+// with a team by its admins.
+type Template struct {
+	Name      string   `json:"name"`
+	Items     []string `json:"items"`
+	CreatorID string   `json:"creator_id"`
+	UpdateAt  int64    `json:"update_at"`
+}
+
+// parseTemplateText splits the text after save into the name of the template and its items, one per line or
+// separated by semicolons
+func parseTemplateText(text string) (string, []string, error) {
+	text = strings.TrimSpace(text)
+	name := text
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		name, text = text[:i], text[i:]
+	} else {
+		text = ""
+	}
+
+	if name == "" {
+		return "", nil, newLocalizedError(msgErrTemplateNameMissing, nil)
+	}
+	if utf8.RuneCountInString(name) > MaxTemplateNameLength {
+		return "", nil, newLocalizedError(msgErrNameTooLong, map[string]interface{}{"Max": MaxTemplateNameLength})
+	}
+
+	items := []string{}
+	for _, item := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ';' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return "", nil, newLocalizedError(msgErrTemplateItemsMissing, nil)
+	}
+	if len(items) > MaxTemplateItems {
+		return "", nil, newLocalizedError(msgErrTooManyTemplateItems, map[string]interface{}{"Max": MaxTemplateItems})
+	}
+
+	return name, items, nil
+}
+
+// findTemplate returns the template of userID with the given name, or else the template of the team
+func (p *Plugin) findTemplate(userID, teamID, name string) (*Template, error) {
+	keys := []string{templatesKey(userID)}
+	if teamID != "" {
+		keys = append(keys, teamTemplatesKey(teamID))
+	}
+
+	for _, key := range keys {
+		templates, _, err := p.getTemplates(key)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range templates {
+			if strings.EqualFold(template.Name, name) {
+				return template, nil
+			}
+		}
+	}
+
+	return nil, errTemplateNotFound
+}
+
+// canManageTeamTemplates checks whether userID may save and remove the templates of the team
+func (p *Plugin) canManageTeamTemplates(userID, teamID string) bool {
+	return teamID != "" && p.API.HasPermissionToTeam(userID, teamID, model.PERMISSION_MANAGE_TEAM)
+}
+
+// applyTemplate adds the todos of the template to the list of userID, or sends them to receiverID if it is someone
+// else. Due dates in the items are relative to the time the template is applied. It returns how many todos were
+// created, which may be less than the items of the template on error.
+func (p *Plugin) applyTemplate(userID, receiverID string, template *Template) (int, error) {
+	now := time.Now().In(p.getUserLocation(userID))
+
+	created := 0
+	for _, item := range template.Items {
+		message, dueAt, err := extractDueDate(item, now)
+		if err != nil {
+			return created, err
+		}
+		if err = p.checkMessage(message); err != nil {
+			return created, err
+		}
+
+		if receiverID == "" || receiverID == userID {
+			if _, err = p.listManager.AddIssue(userID, message, "", dueAt); err != nil {
+				return created, err
+			}
+		} else {
+			receiverIssueID, err := p.listManager.SendIssue(userID, receiverID, message, "", dueAt)
+			if err != nil {
+				return created, err
+			}
+			p.notifySend(userID, receiverID, message, receiverIssueID, dueAt)
+		}
+		created++
+	}
+
+	if created > 0 {
+		p.sendRefreshEvent(userID)
+	}
+
+	return created, nil
+}
+
+func (p *Plugin) runTemplateCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageTemplate, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+	}
+
+	switch args[0] {
+	case "save":
+		return p.runTemplateSaveCommand(args[1:], extra)
+	case "apply":
+		return p.runTemplateApplyCommand(args[1:], extra)
+	case "list":
+		return p.runTemplateListCommand(extra)
+	case "rm":
+		return p.runTemplateRemoveCommand(args[1:], extra)
+	}
+
+	return nil, true, newLocalizedError(msgErrInvalidTemplateOption, map[string]interface{}{"Value": args[0]})
+}
+
+func (p *Plugin) runTemplateSaveCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	key := templatesKey(extra.UserId)
+	if len(args) > 0 && args[0] == templateTeamFlag {
+		if !p.canManageTeamTemplates(extra.UserId, extra.TeamId) {
+			return nil, true, newLocalizedError(msgErrTeamTemplateSave, nil)
+		}
+		key = teamTemplatesKey(extra.TeamId)
+		args = args[1:]
+	}
+
+	name, items, err := parseTemplateText(strings.Join(args, " "))
+	if err != nil {
+		return nil, true, err
+	}
+
+	// Items are checked now, so a typo in a due date does not show up only when the template is applied
+	now := time.Now().In(p.getUserLocation(extra.UserId))
+	for _, item := range items {
+		message, _, err := extractDueDate(item, now)
+		if err != nil {
+			return nil, true, err
+		}
+		if err = p.checkMessage(message); err != nil {
+			return nil, true, err
+		}
+	}
+
+	err = p.saveTemplate(key, &Template{
+		Name:      name,
+		Items:     items,
+		CreatorID: extra.UserId,
+		UpdateAt:  model.GetMillis(),
+	})
+	if err == errTooManyTemplates {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	responseMessage := T(msgResponseTemplateSaved, map[string]interface{}{"Name": name, "Count": len(items)})
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runTemplateApplyCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) == 0 || len(args) > 2 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageTemplateApply, nil)), false, nil
+	}
+
+	template, err := p.findTemplate(extra.UserId, extra.TeamId, args[0])
+	if err == errTemplateNotFound {
+		return nil, true, newLocalizedError(msgErrNoTemplateNamed, map[string]interface{}{"Name": args[0]})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var receiver *model.User
+	if len(args) == 2 {
+		var appErr *model.AppError
+		receiver, appErr = p.API.GetUserByUsername(strings.TrimPrefix(args[1], "@"))
+		if appErr != nil {
+			return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageInvalidUser, nil)+"\n"+p.getHelp(extra.UserId)), false, nil
+		}
+		if receiver.DeleteAt != 0 || receiver.IsBot {
+			return nil, true, newLocalizedError(msgErrInactiveReceiver, nil)
+		}
+	}
+
+	receiverID, action := "", rateActionAdd
+	if receiver != nil && receiver.Id != extra.UserId {
+		receiverID, action = receiver.Id, rateActionSend
+		err = p.checkSendAllowed(extra.UserId, receiver, len(template.Items))
+	} else {
+		err = p.checkTodoLimit(extra.UserId, len(template.Items))
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	created, err := p.applyTemplate(extra.UserId, receiverID, template)
+	if err != nil {
+		p.refundRate(extra.UserId, action, len(template.Items)-created)
+		return nil, false, errors.Wrapf(err, "created %d of the %d Todos of the template", created, len(template.Items))
+	}
+
+	if receiverID != "" {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseTemplateSent, map[string]interface{}{"Count": created, "Name": template.Name, "User": receiver.Username})), false, nil
+	}
+
+	responseMessage := T(msgResponseTemplateApplied, map[string]interface{}{"Count": created, "Name": template.Name})
+
+	issues, total, err := p.listManager.GetIssueListPage(extra.UserId, MyListKey, 0, ListPageSize)
+	if err != nil {
+		p.API.LogError(err.Error())
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+	}
+
+	responseMessage += listTitle(T, MyListKey)
+	responseMessage += issuesPageToString(T, issues, 0, ListPageSize, total, p.getUserLocation(extra.UserId))
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
+
+func (p *Plugin) runTemplateListCommand(extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	templates, _, err := p.getTemplates(templatesKey(extra.UserId))
+	if err != nil {
+		return nil, false, err
+	}
+
+	teamTemplates := []*Template{}
+	if extra.TeamId != "" {
+		if teamTemplates, _, err = p.getTemplates(teamTemplatesKey(extra.TeamId)); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, templatesToString(p.translator(extra.UserId), templates, teamTemplates)), false, nil
+}
+
+func (p *Plugin) runTemplateRemoveCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	key := templatesKey(extra.UserId)
+	if len(args) > 0 && args[0] == templateTeamFlag {
+		if !p.canManageTeamTemplates(extra.UserId, extra.TeamId) {
+			return nil, true, newLocalizedError(msgErrTeamTemplateRemove, nil)
+		}
+		key = teamTemplatesKey(extra.TeamId)
+		args = args[1:]
+	}
+
+	if len(args) != 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgUsageTemplateRemove, nil)), false, nil
+	}
+
+	err := p.removeTemplate(key, args[0])
+	if err == errTemplateNotFound {
+		return nil, true, newLocalizedError(msgErrNoTemplateNamed, map[string]interface{}{"Name": args[0]})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseTemplateRemoved, map[string]interface{}{"Name": args[0]})), false, nil
+}
+
+func templatesToString(T translateFunc, templates, teamTemplates []*Template) string {
+	if len(templates) == 0 && len(teamTemplates) == 0 {
+		return T(msgTemplatesNone, nil)
+	}
+
+	str := ""
+	for _, section := range []struct {
+		title     *message
+		templates []*Template
+	}{
+		{msgTemplatesMine, templates},
+		{msgTemplatesTeam, teamTemplates},
+	} {
+		if len(section.templates) == 0 {
+			continue
+		}
+
+		str += T(section.title, nil) + "\n\n"
+		for _, template := range section.templates {
+			str += fmt.Sprintf("* **%s**: %s\n", template.Name, strings.Join(template.Items, "; "))
+		}
+		str += "\n"
+	}
+
+	return str
+}
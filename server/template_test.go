@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTemplateText(t *testing.T) {
+	name, items, err := parseTemplateText("onboarding Set up your laptop; Meet the team;; Read the handbook ")
+	require.NoError(t, err)
+	assert.Equal(t, "onboarding", name)
+	assert.Equal(t, []string{"Set up your laptop", "Meet the team", "Read the handbook"}, items)
+
+	name, items, err = parseTemplateText("release\nTag the release\nWrite the changelog")
+	require.NoError(t, err)
+	assert.Equal(t, "release", name)
+	assert.Equal(t, []string{"Tag the release", "Write the changelog"}, items)
+
+	for _, text := range []string{"", "onboarding", "onboarding ;", strings.Repeat("a", MaxTemplateNameLength+1) + " item", "big " + strings.Repeat("item;", MaxTemplateItems+1)} {
+		_, _, err = parseTemplateText(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestTemplatesToString(t *testing.T) {
+	assert.Contains(t, templatesToString(renderEnglish, nil, nil), "There are no templates")
+
+	str := templatesToString(renderEnglish, []*Template{{Name: "mine", Items: []string{"a", "b"}}}, []*Template{{Name: "shared", Items: []string{"c"}}})
+	assert.Equal(t, "Your templates:\n\n* **mine**: a; b\n\nTeam templates:\n\n* **shared**: c\n\n", str)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+)
+
+// UserCacheTTL is how long a user looked up from the server is reused before looking it up again
+const UserCacheTTL = 5 * time.Minute
+
+type cachedUser struct {
+	user     *model.User
+	expireAt time.Time
+}
+
+// userCache keeps the users recently looked up, so that their names and timezones are not fetched from the server
+// on every command, notification and rendered list. The users returned are shared and must not be modified.
+type userCache struct {
+	api   plugin.API
+	ttl   time.Duration
+	now   func() time.Time
+	lock  sync.Mutex
+	users map[string]cachedUser
+}
+
+// newUserCache creates a userCache keeping the users for ttl
+func newUserCache(api plugin.API, ttl time.Duration) *userCache {
+	return &userCache{
+		api:   api,
+		ttl:   ttl,
+		now:   time.Now,
+		users: map[string]cachedUser{},
+	}
+}
+
+// GetUser returns the user userID, looking it up from the server if it is not cached or it expired
+func (c *userCache) GetUser(userID string) (*model.User, *model.AppError) {
+	now := c.now()
+
+	c.lock.Lock()
+	cached, ok := c.users[userID]
+	c.lock.Unlock()
+	if ok && now.Before(cached.expireAt) {
+		return cached.user, nil
+	}
+
+	user, appErr := c.api.GetUser(userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	c.Set(user)
+	return user, nil
+}
+
+// Set caches user, replacing the cached copy if there is one
+func (c *userCache) Set(user *model.User) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.users[user.Id] = cachedUser{user: user, expireAt: c.now().Add(c.ttl)}
+}
+
+// getUser returns the user userID, from the cache if it was looked up recently
+func (p *Plugin) getUser(userID string) (*model.User, *model.AppError) {
+	if p.userCache == nil {
+		return p.API.GetUser(userID)
+	}
+	return p.userCache.GetUser(userID)
+}
+
+// UserHasLoggedIn refreshes the cached copy of the user, which may have changed its name or timezone. The server
+// has no hook for user updates, so other changes show up when the cached copy expires.
+func (p *Plugin) UserHasLoggedIn(c *plugin.Context, user *model.User) {
+	if p.userCache != nil {
+		p.userCache.Set(user)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCache(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1", Username: "alice"}, nil).Once()
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1", Username: "alice2"}, nil).Once()
+	api.On("GetUser", "user2").Return(nil, &model.AppError{Message: "not found"})
+
+	now := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+	c := newUserCache(api, time.Minute)
+	c.now = func() time.Time { return now }
+
+	user, appErr := c.GetUser("user1")
+	require.Nil(t, appErr)
+	assert.Equal(t, "alice", user.Username)
+
+	// Cached until it expires
+	now = now.Add(30 * time.Second)
+	user, _ = c.GetUser("user1")
+	assert.Equal(t, "alice", user.Username)
+	api.AssertNumberOfCalls(t, "GetUser", 1)
+
+	now = now.Add(time.Minute)
+	user, _ = c.GetUser("user1")
+	assert.Equal(t, "alice2", user.Username)
+
+	c.Set(&model.User{Id: "user1", Username: "alice3"})
+	user, _ = c.GetUser("user1")
+	assert.Equal(t, "alice3", user.Username)
+
+	_, appErr = c.GetUser("user2")
+	assert.NotNil(t, appErr)
+	_, appErr = c.GetUser("user2")
+	assert.NotNil(t, appErr)
+	api.AssertNumberOfCalls(t, "GetUser", 4)
+}
+
+func TestUserHasLoggedInRefreshesCache(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUser", "user1").Return(&model.User{Id: "user1", Username: "alice"}, nil).Once()
+
+	p := &Plugin{userCache: newUserCache(api, time.Hour)}
+	lm := &listManager{api: api, users: p.userCache}
+	assert.Equal(t, "alice", lm.GetUserName("user1"))
+
+	p.UserHasLoggedIn(nil, &model.User{Id: "user1", Username: "bob"})
+	assert.Equal(t, "bob", lm.GetUserName("user1"))
+	api.AssertNumberOfCalls(t, "GetUser", 1)
+}
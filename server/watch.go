@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+const (
+	// WatchAll tells senders about every change the receivers make to the todos they sent
+	WatchAll = "all"
+	// WatchSelected only tells senders about the changes to the sent todos they watch
+	WatchSelected = "selected"
+)
+
+// isWatching checks whether userID watches issue
+func isWatching(userID string, issue *Issue) bool {
+	for _, watcher := range issue.Watchers {
+		if watcher == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// notifiesSender checks whether senderID wants a message about a change the receiver made to the todo issueID, now
+// on a list of holderID: always if they watch every todo they sent, otherwise only if they watch this one
+func (p *Plugin) notifiesSender(senderID, holderID, issueID string) bool {
+	settings, err := p.getUserSettings(senderID)
+	if err != nil {
+		p.API.LogError("cannot get user settings, Err=", err.Error())
+		return true
+	}
+	if settings.Watch != WatchSelected {
+		return true
+	}
+
+	issue, err := p.listManager.GetIssue(holderID, issueID)
+	if err != nil {
+		p.API.LogError("cannot get watched issue, Err=", err.Error())
+		return true
+	}
+	return isWatching(senderID, issue)
+}
+
+// watchedIssuesToString renders the sent issues userID watches, with their position in the sent list
+func watchedIssuesToString(T translateFunc, userID string, issues []*ExtendedIssue) string {
+	str := ""
+	for i, issue := range issues {
+		if isWatching(userID, &issue.Issue) {
+			str += fmt.Sprintf("%d. %s (%s)\n", i+1, issue.Message, T(msgWatchSentTo, map[string]interface{}{"User": issue.ForeignUser}))
+		}
+	}
+
+	if str == "" {
+		return T(msgWatchNone, nil)
+	}
+	return T(msgWatchTitle, nil) + "\n\n" + str
+}
+
+func (p *Plugin) runWatchCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	if len(args) > 0 {
+		return p.runSetWatchingCommand(args, extra, true)
+	}
+
+	settings, err := p.getUserSettings(extra.UserId)
+	if err != nil {
+		return nil, false, err
+	}
+	if settings.Watch != WatchSelected {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, T(msgResponseWatchingAll, nil)), false, nil
+	}
+
+	issues, err := p.listManager.GetIssueList(extra.UserId, OutListKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, watchedIssuesToString(T, extra.UserId, issues)), false, nil
+}
+
+func (p *Plugin) runUnwatchCommand(args []string, extra *model.CommandArgs) (*model.CommandResponse, bool, error) {
+	if len(args) < 1 {
+		return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, p.localize(extra.UserId, msgUsageUnwatch, nil)), false, nil
+	}
+
+	return p.runSetWatchingCommand(args, extra, false)
+}
+
+// runSetWatchingCommand makes the user watch the sent todo at the position given in args, or stop watching it
+func (p *Plugin) runSetWatchingCommand(args []string, extra *model.CommandArgs, watch bool) (*model.CommandResponse, bool, error) {
+	T := p.translator(extra.UserId)
+
+	position, err := parsePosition(args[0])
+	if err != nil {
+		return nil, true, err
+	}
+
+	target, err := p.listManager.GetIssueByPosition(extra.UserId, OutListKey, position)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if _, err = p.listManager.WatchIssue(extra.UserId, target.ID, watch); err == errIssueNotSent {
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	responseMessage := T(msgResponseUnwatched, map[string]interface{}{"Number": position})
+	if watch {
+		responseMessage = T(msgResponseWatched, map[string]interface{}{"Number": position, "User": target.ForeignUser})
+	}
+
+	settings, err := p.getUserSettings(extra.UserId)
+	if err == nil && settings.Watch != WatchSelected {
+		responseMessage += " " + T(msgResponseWatchingUntil, nil)
+	}
+
+	return getCommandResponse(model.COMMAND_RESPONSE_TYPE_EPHEMERAL, responseMessage), false, nil
+}
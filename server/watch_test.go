@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// issueListManager is a ListManager that only finds the issues it holds
+type issueListManager struct {
+	ListManager
+	issues map[string]*Issue
+}
+
+func (m *issueListManager) GetIssue(userID, issueID string) (*Issue, error) {
+	if issue, ok := m.issues[issueID]; ok {
+		return issue, nil
+	}
+	return nil, errIssueNotFound
+}
+
+func TestWatchedIssuesToString(t *testing.T) {
+	issues := []*ExtendedIssue{
+		{Issue: Issue{Message: "Write docs", Watchers: []string{"sender"}}, ForeignUser: "alice"},
+		{Issue: Issue{Message: "Review PR"}, ForeignUser: "bob"},
+		{Issue: Issue{Message: "Ship it", Watchers: []string{"someone", "sender"}}, ForeignUser: "carol"},
+	}
+
+	assert.Equal(t, "You are watching these Todos you sent:\n\n1. Write docs (sent to @alice)\n3. Ship it (sent to @carol)\n", watchedIssuesToString(renderEnglish, "sender", issues))
+	assert.Contains(t, watchedIssuesToString(renderEnglish, "other", issues), "You are not watching any Todo you sent.")
+}
+
+func TestNotifiesSender(t *testing.T) {
+	selected, err := json.Marshal(&UserSettings{Watch: WatchSelected})
+	require.NoError(t, err)
+
+	api := &plugintest.API{}
+	api.On("KVGet", settingsKey("all")).Return(nil, nil)
+	api.On("KVGet", settingsKey("selected")).Return(selected, nil)
+
+	p := &Plugin{}
+	p.SetAPI(api)
+	p.listManager = &issueListManager{issues: map[string]*Issue{
+		"watched":   {ID: "watched", Watchers: []string{"selected"}},
+		"unwatched": {ID: "unwatched"},
+	}}
+
+	assert.True(t, p.notifiesSender("all", "receiver", "unwatched"))
+	assert.True(t, p.notifiesSender("selected", "receiver", "watched"))
+	assert.False(t, p.notifiesSender("selected", "receiver", "unwatched"))
+}
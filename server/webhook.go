@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin"
+)
+
+const (
+	// WebhookQueueSize is the number of deliveries that can wait to be sent before new ones are dropped
+	WebhookQueueSize = 1000
+	// WebhookWorkers is the number of deliveries sent at the same time
+	WebhookWorkers = 4
+	// WebhookRetries is the number of times a failed delivery is retried
+	WebhookRetries = 3
+	// WebhookRetryDelay is the delay before the first retry, doubled on every retry
+	WebhookRetryDelay = 2 * time.Second
+	// WebhookTimeout is the timeout of every delivery attempt
+	WebhookTimeout = 10 * time.Second
+
+	// WebhookEventHeader is the header with the type of event of a delivery
+	WebhookEventHeader = "X-Todo-Event"
+	// WebhookDeliveryHeader is the header with the unique ID of a delivery, the same on every retry
+	WebhookDeliveryHeader = "X-Todo-Delivery"
+	// WebhookSignatureHeader is the header with the HMAC-SHA256 signature of the payload, made with the webhook secret
+	WebhookSignatureHeader = "X-Todo-Signature"
+)
+
+// webhookPayload is the JSON body sent to the webhook URLs
+type webhookPayload struct {
+	Event         string `json:"event"`
+	Timestamp     int64  `json:"timestamp"`
+	UserID        string `json:"user_id"`
+	User          string `json:"user"`
+	ForeignUserID string `json:"foreign_user_id,omitempty"`
+	ForeignUser   string `json:"foreign_user,omitempty"`
+	ChannelID     string `json:"channel_id,omitempty"`
+	Issue         *Issue `json:"issue"`
+}
+
+type webhookDelivery struct {
+	id     string
+	url    string
+	event  string
+	body   []byte
+	secret string
+}
+
+// webhookSender sends the webhook deliveries in the background, retrying the failed ones with exponential backoff
+type webhookSender struct {
+	api        plugin.API
+	client     *http.Client
+	retryDelay time.Duration
+	deliveries chan *webhookDelivery
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func newWebhookSender(api plugin.API) *webhookSender {
+	return &webhookSender{
+		api:        api,
+		client:     &http.Client{Timeout: WebhookTimeout},
+		retryDelay: WebhookRetryDelay,
+		deliveries: make(chan *webhookDelivery, WebhookQueueSize),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start starts the workers that send the deliveries
+func (s *webhookSender) Start() {
+	for i := 0; i < WebhookWorkers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+}
+
+// Stop stops the workers, dropping the deliveries that were not sent yet
+func (s *webhookSender) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Enqueue queues the body to be sent to url, signed with secret if it is not empty
+func (s *webhookSender) Enqueue(url, event string, body []byte, secret string) {
+	delivery := &webhookDelivery{
+		id:     model.NewId(),
+		url:    url,
+		event:  event,
+		body:   body,
+		secret: secret,
+	}
+
+	select {
+	case s.deliveries <- delivery:
+	default:
+		s.api.LogWarn("Webhook queue is full, dropping delivery", "url", url, "event", event)
+	}
+}
+
+func (s *webhookSender) work() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case delivery := <-s.deliveries:
+			s.deliver(delivery)
+		}
+	}
+}
+
+// deliver sends the delivery, retrying on network errors and server errors until it runs out of retries
+func (s *webhookSender) deliver(delivery *webhookDelivery) {
+	delay := s.retryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := s.send(delivery)
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt == WebhookRetries {
+			s.api.LogWarn("Unable to deliver webhook", "url", delivery.url, "event", delivery.event, "err", err.Error())
+			return
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// send makes one delivery attempt, and returns whether it should be retried if it failed
+func (s *webhookSender) send(delivery *webhookDelivery) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.event)
+	req.Header.Set(WebhookDeliveryHeader, delivery.id)
+	if delivery.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, signWebhookPayload(delivery.secret, delivery.body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("received status code %d", resp.StatusCode)
+}
+
+// signWebhookPayload returns the signature of body with secret, in the sha256=<hex digest> format
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhooks queues the delivery of the event to every configured webhook URL
+func (p *Plugin) sendWebhooks(event *IssueEvent) {
+	config := p.getConfiguration()
+	urls := config.getWebhookURLs()
+	if len(urls) == 0 || p.webhookSender == nil {
+		return
+	}
+
+	payload := &webhookPayload{
+		Event:         event.Type,
+		Timestamp:     event.CreateAt,
+		UserID:        event.UserID,
+		User:          p.listManager.GetUserName(event.UserID),
+		ForeignUserID: event.ForeignUserID,
+		ChannelID:     event.ChannelID,
+		Issue:         event.Issue,
+	}
+	if event.ForeignUserID != "" {
+		payload.ForeignUser = p.listManager.GetUserName(event.ForeignUserID)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		p.API.LogError("Unable to marshal webhook payload err=" + err.Error())
+		return
+	}
+
+	for _, url := range urls {
+		p.webhookSender.Enqueue(url, event.Type, body, config.WebhookSecret)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	assert.Equal(t, "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8", signWebhookPayload("key", []byte("The quick brown fox jumps over the lazy dog")))
+	assert.NotEqual(t, signWebhookPayload("key", []byte("body")), signWebhookPayload("other", []byte("body")))
+}
+
+func TestConfigurationWebhookURLs(t *testing.T) {
+	config := &configuration{WebhookURLs: " https://example.com/hook, ,http://localhost:8080/todo "}
+	assert.Equal(t, []string{"https://example.com/hook", "http://localhost:8080/todo"}, config.getWebhookURLs())
+	assert.NoError(t, config.IsValid())
+
+	config.WebhookURLs = "https://example.com/hook, ftp://example.com"
+	assert.Error(t, config.IsValid())
+
+	config.WebhookURLs = "example.com"
+	assert.Error(t, config.IsValid())
+}
+
+func TestWebhookSenderDeliver(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"event":"created"}`, string(body))
+		assert.Equal(t, "created", r.Header.Get(WebhookEventHeader))
+		assert.NotEmpty(t, r.Header.Get(WebhookDeliveryHeader))
+		assert.Equal(t, signWebhookPayload("secret", body), r.Header.Get(WebhookSignatureHeader))
+
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender(&plugintest.API{})
+	sender.retryDelay = time.Millisecond
+
+	sender.deliver(&webhookDelivery{id: "id", url: server.URL, event: "created", body: []byte(`{"event":"created"}`), secret: "secret"})
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+func TestWebhookSenderSendNoRetryOnClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(WebhookSignatureHeader))
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sender := newWebhookSender(&plugintest.API{})
+	retry, err := sender.send(&webhookDelivery{id: "id", url: server.URL, event: "created", body: []byte("{}")})
+	assert.Error(t, err)
+	assert.False(t, retry)
+}
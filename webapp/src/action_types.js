@@ -6,3 +6,7 @@ export const GET_ISSUES = pluginId + '_get_issues';
 export const GET_OUT_ISSUES = pluginId + '_get_out_issues';
 export const GET_IN_ISSUES = pluginId + '_get_in_issues';
 export const RECEIVED_SHOW_RHS_ACTION = pluginId + '_show_rhs';
+export const ISSUE_ADDED = pluginId + '_issue_added';
+export const ISSUE_UPDATED = pluginId + '_issue_updated';
+export const ISSUE_REMOVED = pluginId + '_issue_removed';
+export const ISSUE_REORDERED = pluginId + '_issue_reordered';
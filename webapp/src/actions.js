@@ -1,9 +1,10 @@
 import {getConfig} from 'mattermost-redux/selectors/entities/general';
 import {Client4} from 'mattermost-redux/client';
 import * as UserActions from 'mattermost-redux/actions/users';
+import {IntegrationTypes} from 'mattermost-redux/action_types';
 
 import {id as pluginId} from './manifest';
-import {OPEN_ROOT_MODAL, CLOSE_ROOT_MODAL, RECEIVED_SHOW_RHS_ACTION, GET_ISSUES, GET_IN_ISSUES, GET_OUT_ISSUES} from './action_types';
+import {OPEN_ROOT_MODAL, CLOSE_ROOT_MODAL, RECEIVED_SHOW_RHS_ACTION, GET_ISSUES, GET_IN_ISSUES, GET_OUT_ISSUES, ISSUE_ADDED, ISSUE_UPDATED, ISSUE_REMOVED, ISSUE_REORDERED} from './action_types';
 
 export const openRootModal = (postID) => (dispatch) => {
     dispatch({
@@ -45,6 +46,28 @@ export const getPluginServerRoute = (state) => {
     return basePath + '/plugins/' + pluginId;
 };
 
+/**
+ * Opens the dialog to add a Todo from the post postID, with the fields the server defines.
+ */
+export const openAddDialog = (postID) => async (dispatch, getState) => {
+    let data;
+    try {
+        const resp = await fetch(getPluginServerRoute(getState()) + '/dialog?post_id=' + postID, Client4.getOptions({
+            method: 'get',
+        }));
+        data = await resp.json();
+    } catch (error) {
+        return {error};
+    }
+
+    dispatch({
+        type: IntegrationTypes.RECEIVED_DIALOG,
+        data,
+    });
+
+    return {data};
+};
+
 export const add = (message, sendTo, postID) => async (dispatch, getState) => {
     await fetch(getPluginServerRoute(getState()) + '/add', Client4.getOptions({
         method: 'post',
@@ -90,6 +113,26 @@ export const list = (reminder = false, listName = 'my') => async (dispatch, getS
     return {data};
 };
 
+const listChangeTypes = {
+    issue_added: ISSUE_ADDED,
+    issue_updated: ISSUE_UPDATED,
+    issue_removed: ISSUE_REMOVED,
+    issue_reordered: ISSUE_REORDERED,
+};
+
+/**
+ * Applies a change of a list published by the server, so the list is not fetched again.
+ */
+export const receivedListChange = (event, data) => (dispatch) => {
+    dispatch({
+        type: listChangeTypes[event],
+        list: data.list,
+        issueID: data.issue_id,
+        issue: data.issue ? JSON.parse(data.issue) : null,
+        order: data.order ? data.order.split(',') : [],
+    });
+};
+
 export const remove = (id) => async (dispatch, getState) => {
     await fetch(getPluginServerRoute(getState()) + '/remove', Client4.getOptions({
         method: 'post',
@@ -36,7 +36,10 @@ function ToDoIssues(props) {
         let createdMessage = 'Created ';
         let listPositionMessage = '';
         if (issue.user) {
-            if (issue.list === '') {
+            if (issue.status === 'declined') {
+                createdMessage = 'Sent to ' + issue.user;
+                listPositionMessage = issue.decline_reason ? 'Declined: ' + issue.decline_reason : 'Declined.';
+            } else if (issue.list === '') {
                 createdMessage = 'Sent to ' + issue.user;
                 listPositionMessage = 'Accepted. On position ' + (issue.position + 1) + '.';
             } else if (issue.list === 'in') {
@@ -48,6 +51,8 @@ function ToDoIssues(props) {
             }
         }
 
+        const dueMessage = issue.due_at ? 'Due ' + new Date(issue.due_at).toLocaleString() : '';
+
         const listDiv = (
             <div
                 className='light'
@@ -87,7 +92,7 @@ function ToDoIssues(props) {
         );
 
         const actionButtons = (<div className='action-buttons'>
-            {canRemove(props.list, issue.list) && removeButton}
+            {canRemove(props.list, issue.list, issue.status) && removeButton}
             {canAccept(props.list) && acceptButton}
             {canComplete(props.list) && completeButton}
             {canBump(props.list, issue.list) && bumpButton}
@@ -101,13 +106,21 @@ function ToDoIssues(props) {
                 <div style={style.message}>
                     {issueComponent}
                 </div>
-                {(canRemove(props.list, issue.list) || canComplete(props.list) || canAccept(props.list) || canBump(props.list, issue.list)) && actionButtons}
+                {(canRemove(props.list, issue.list, issue.status) || canComplete(props.list) || canAccept(props.list) || canBump(props.list, issue.list)) && actionButtons}
                 <div
                     className='light'
                     style={style.subtitle}
                 >
                     {createdMessage + ' on ' + formattedDate + ' at ' + formattedTime}
                 </div>
+                {dueMessage &&
+                    <div
+                        className='light'
+                        style={style.subtitle}
+                    >
+                        {dueMessage}
+                    </div>
+                }
                 {listPositionMessage && listDiv}
             </div>
         );
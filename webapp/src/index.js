@@ -5,7 +5,7 @@ import { id as pluginId } from './manifest';
 import Root from './components/root';
 import SidebarRight from './components/sidebar_right';
 
-import { openRootModal, list, setShowRHSAction } from './actions';
+import { openAddDialog, list, setShowRHSAction, receivedListChange } from './actions';
 import reducer from './reducer';
 import PostTypeTodo from './components/post_type_todo';
 
@@ -20,7 +20,7 @@ export default class Plugin {
 
         registry.registerPostDropdownMenuAction(
             'Add Todo',
-            (postID) => store.dispatch(openRootModal(postID)),
+            (postID) => store.dispatch(openAddDialog(postID)),
         );
 
         const { showRHSPlugin } = registry.registerRightHandSidebarComponent(SidebarRight, 'Todo List');
@@ -36,6 +36,10 @@ export default class Plugin {
 
         registry.registerWebSocketEventHandler(`custom_${pluginId}_refresh`, refresh);
 
+        ['issue_added', 'issue_updated', 'issue_removed', 'issue_reordered'].forEach((event) => {
+            registry.registerWebSocketEventHandler(`custom_${pluginId}_${event}`, (msg) => store.dispatch(receivedListChange(event, msg.data)));
+        });
+
         store.dispatch(list(true));
         store.dispatch(list(false, 'in'));
         store.dispatch(list(false, 'out'));
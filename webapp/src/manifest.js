@@ -21,7 +21,208 @@ const manifest = JSON.parse(`
     "settings_schema": {
         "header": "",
         "footer": "",
-        "settings": []
+        "settings": [
+            {
+                "key": "MaxTodosPerUser",
+                "display_name": "Maximum Todos per User:",
+                "type": "number",
+                "help_text": "The maximum number of Todos a user can have on their list and their received list together. Users cannot add, receive, restore or claim Todos beyond it. Use 0 for no limit.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "MaxMessageLength",
+                "display_name": "Maximum Message Length:",
+                "type": "number",
+                "help_text": "The maximum number of characters of a Todo message. Use 0 for the longest message Mattermost allows in a post.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "SendPolicy",
+                "display_name": "Sending Todos:",
+                "type": "dropdown",
+                "help_text": "Who users can send Todos to.",
+                "placeholder": "",
+                "default": "everyone",
+                "options": [
+                    {
+                        "display_name": "Everyone",
+                        "value": "everyone"
+                    },
+                    {
+                        "display_name": "Members of the same team",
+                        "value": "team"
+                    },
+                    {
+                        "display_name": "Nobody, sending is disabled",
+                        "value": "disabled"
+                    }
+                ]
+            },
+            {
+                "key": "DisableChannelLists",
+                "display_name": "Disable Channel Todo Lists:",
+                "type": "bool",
+                "help_text": "When true, the shared Todo lists of the channels cannot be used.",
+                "placeholder": "",
+                "default": false
+            },
+            {
+                "key": "MaxAddsPerMinute",
+                "display_name": "Maximum Todos Added per Minute:",
+                "type": "number",
+                "help_text": "How many Todos a user can add to their list, restore or claim in a minute. Use 0 for no limit.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "MaxSendsPerHour",
+                "display_name": "Maximum Todos Sent per Hour:",
+                "type": "number",
+                "help_text": "How many Todos a user can send to others in an hour. Use 0 for no limit.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "AllowedPlugins",
+                "display_name": "Plugins Allowed to Add Todos:",
+                "type": "text",
+                "help_text": "Comma separated IDs of the plugins that can add and send Todos on behalf of users, like com.github.manland.mattermost-plugin-gitlab. Leave empty to allow none.",
+                "placeholder": "",
+                "default": null
+            },
+            {
+                "key": "EscalationDays",
+                "display_name": "Days before Escalating Received Todos:",
+                "type": "number",
+                "help_text": "The number of days a received Todo can wait to be accepted before the receiver is reminded of it and its sender is told it is stalled. Each Todo is escalated once per receiver. Use 0 to turn escalations off.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "RetentionDays",
+                "display_name": "Days to Keep Completed Todos:",
+                "type": "number",
+                "help_text": "The number of days completed Todos are kept before they are deleted for good, checked once a day. System admins can also purge them at once with /todo purge. Use 0 to keep them forever.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "PurgeDeactivatedUsers",
+                "display_name": "Delete the Todos of Deactivated Users:",
+                "type": "bool",
+                "help_text": "When true, the Todos, settings and tokens of the deactivated users are deleted for good, checked once a day. The copies of the Todos they sent or received stay with the other users.",
+                "placeholder": "",
+                "default": false
+            },
+            {
+                "key": "EmailInactiveMinutes",
+                "display_name": "Minutes Away before Emailing Todos:",
+                "type": "number",
+                "help_text": "The number of minutes users must be inactive in Mattermost before they also get an email for the Todos they receive and the Todos that become overdue, using the email settings of the server. Users can turn the emails off with /todo settings emails off. Use 0 to turn emails off.",
+                "placeholder": "",
+                "default": 0
+            },
+            {
+                "key": "StorageEngine",
+                "display_name": "Storage Engine:",
+                "type": "dropdown",
+                "help_text": "Where the Todos are stored. The plugin key value store works everywhere. The SQL tables store them in the Mattermost database, PostgreSQL or MySQL, which is faster for large installations. Copy the existing Todos with /todo migrate before switching, then disable and enable the plugin.",
+                "placeholder": "",
+                "default": "kv",
+                "options": [
+                    {
+                        "display_name": "Plugin key value store",
+                        "value": "kv"
+                    },
+                    {
+                        "display_name": "SQL tables",
+                        "value": "sql"
+                    }
+                ]
+            },
+            {
+                "key": "LegacyRefreshEvents",
+                "display_name": "Send Legacy Refresh Events:",
+                "type": "bool",
+                "help_text": "When true, the refresh event is also sent to the clients after every change of a Todo list, so they fetch the whole list again. Enable it for clients that do not handle the issue_added, issue_updated, issue_removed and issue_reordered events.",
+                "placeholder": "",
+                "default": false
+            },
+            {
+                "key": "WebhookURLs",
+                "display_name": "Webhook URLs:",
+                "type": "text",
+                "help_text": "Comma separated URLs that receive a JSON payload when a Todo is created, sent, accepted, declined, completed or deleted.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "WebhookSecret",
+                "display_name": "Webhook Secret:",
+                "type": "generated",
+                "help_text": "The secret used to sign the webhook payloads. The HMAC-SHA256 signature of the payload is sent in the X-Todo-Signature header.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "JiraURL",
+                "display_name": "Jira URL:",
+                "type": "text",
+                "help_text": "The base URL of your Jira instance, like https://example.atlassian.net. Required for /todo jira.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "JiraWebhookSecret",
+                "display_name": "Jira Webhook Secret:",
+                "type": "generated",
+                "help_text": "Create a Jira webhook for the issue created and updated events pointing to https://\u003cyour Mattermost\u003e/plugins/com.mattermost.plugin-todo/jira/webhook?secret=\u003cthis secret\u003e. Issues assigned to Mattermost users are added to their Todo lists, and resolving a Jira issue completes its Todos.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "JiraUsername",
+                "display_name": "Jira Username:",
+                "type": "text",
+                "help_text": "The Jira user, usually an email address, that resolves the Jira issues when their Todos are completed. Leave empty to only sync from Jira.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "JiraAPIToken",
+                "display_name": "Jira API Token:",
+                "type": "text",
+                "help_text": "The API token (Jira Cloud) or password (Jira Server) of the Jira user.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "JiraDoneTransition",
+                "display_name": "Jira Done Transition:",
+                "type": "text",
+                "help_text": "The name of the Jira transition that resolves an issue. If it is not available, the first transition to a done status is used.",
+                "placeholder": "",
+                "default": "Done"
+            },
+            {
+                "key": "GitHubToken",
+                "display_name": "GitHub Token:",
+                "type": "text",
+                "help_text": "A GitHub personal access token used to read the issues and pull requests linked by /todo add. Required for private repositories, and recommended to avoid the low rate limit of anonymous requests.",
+                "placeholder": "",
+                "default": ""
+            },
+            {
+                "key": "GitHubCompleteClosed",
+                "display_name": "Complete Todos of Closed GitHub Issues:",
+                "type": "bool",
+                "help_text": "When true, the Todos linked to GitHub issues and pull requests are completed when those are closed or merged. The links are checked every 15 minutes.",
+                "placeholder": "",
+                "default": false
+            }
+        ]
     }
 }
 `);
@@ -1,6 +1,6 @@
 import {combineReducers} from 'redux';
 
-import {OPEN_ROOT_MODAL, CLOSE_ROOT_MODAL, GET_ISSUES, GET_IN_ISSUES, GET_OUT_ISSUES, RECEIVED_SHOW_RHS_ACTION} from './action_types';
+import {OPEN_ROOT_MODAL, CLOSE_ROOT_MODAL, GET_ISSUES, GET_IN_ISSUES, GET_OUT_ISSUES, RECEIVED_SHOW_RHS_ACTION, ISSUE_ADDED, ISSUE_UPDATED, ISSUE_REMOVED, ISSUE_REORDERED} from './action_types';
 
 const rootModalVisible = (state = false, action) => {
     switch (action.type) {
@@ -24,32 +24,47 @@ const postID = (state = '', action) => {
     }
 };
 
-const issues = (state = [], action) => {
+// listReducer keeps the issues of the list listName, fetched with getType and patched with the changes the server publishes
+const listReducer = (listName, getType) => (state = [], action) => {
     switch (action.type) {
-    case GET_ISSUES:
+    case getType:
         return action.data;
-    default:
-        return state;
+    case ISSUE_ADDED:
+        if (action.list !== listName) {
+            return state;
+        }
+        return [...state.filter((issue) => issue.id !== action.issueID), action.issue];
+    case ISSUE_UPDATED:
+        if (action.list !== listName) {
+            return state;
+        }
+        return state.map((issue) => (issue.id === action.issueID ? action.issue : issue));
+    case ISSUE_REMOVED:
+        if (action.list !== listName) {
+            return state;
+        }
+        return state.filter((issue) => issue.id !== action.issueID);
+    case ISSUE_REORDERED: {
+        if (action.list !== listName) {
+            return state;
+        }
+        const byID = {};
+        state.forEach((issue) => {
+            byID[issue.id] = issue;
+        });
+        const ordered = action.order.filter((id) => byID[id]).map((id) => byID[id]);
+        return [...ordered, ...state.filter((issue) => !action.order.includes(issue.id))];
     }
-};
-
-const inIssues = (state = [], action) => {
-    switch (action.type) {
-    case GET_IN_ISSUES:
-        return action.data;
     default:
         return state;
     }
 };
 
-const outIssues = (state = [], action) => {
-    switch (action.type) {
-    case GET_OUT_ISSUES:
-        return action.data;
-    default:
-        return state;
-    }
-};
+const issues = listReducer('my', GET_ISSUES);
+
+const inIssues = listReducer('in', GET_IN_ISSUES);
+
+const outIssues = listReducer('out', GET_OUT_ISSUES);
 
 function rhsPluginAction(state = null, action) {
     switch (action.type) {
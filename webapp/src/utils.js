@@ -1,7 +1,7 @@
 import Constants from './constants';
 
-export function canRemove(myList, foreignList) {
-    return myList === 'my' || myList === 'in' || foreignList === 'in';
+export function canRemove(myList, foreignList, status) {
+    return myList === 'my' || myList === 'in' || foreignList === 'in' || status === 'declined';
 }
 
 export function canComplete(myList) {